@@ -18,20 +18,54 @@ import (
 	"github.com/fekuna/omnipos-pkg/middleware"
 	"github.com/fekuna/omnipos-pkg/search"
 	"github.com/fekuna/omnipos-product-service/config"
+	"github.com/fekuna/omnipos-product-service/internal/apperror"
+	"github.com/fekuna/omnipos-product-service/internal/auth"
+	"github.com/fekuna/omnipos-product-service/internal/database"
+	"github.com/fekuna/omnipos-product-service/internal/event"
+	"github.com/fekuna/omnipos-product-service/internal/messaging"
+	"github.com/fekuna/omnipos-product-service/internal/outbox"
+	"github.com/fekuna/omnipos-product-service/internal/product"
 	productv1 "github.com/fekuna/omnipos-proto/gen/go/omnipos/product/v1"
 
 	catH "github.com/fekuna/omnipos-product-service/internal/category/handler"
 	catRepoPkg "github.com/fekuna/omnipos-product-service/internal/category/repository"
 	catUCPkg "github.com/fekuna/omnipos-product-service/internal/category/usecase"
 
+	dlqH "github.com/fekuna/omnipos-product-service/internal/deadletter/handler"
+	dlqRepoPkg "github.com/fekuna/omnipos-product-service/internal/deadletter/repository"
+	dlqUCPkg "github.com/fekuna/omnipos-product-service/internal/deadletter/usecase"
+
+	"github.com/fekuna/omnipos-product-service/internal/inventory"
 	invH "github.com/fekuna/omnipos-product-service/internal/inventory/handler"
 	invListenerPkg "github.com/fekuna/omnipos-product-service/internal/inventory/listener"
 	invRepoPkg "github.com/fekuna/omnipos-product-service/internal/inventory/repository"
 	invUCPkg "github.com/fekuna/omnipos-product-service/internal/inventory/usecase"
+	invWorkerPkg "github.com/fekuna/omnipos-product-service/internal/inventory/worker"
+
+	outboxRepoPkg "github.com/fekuna/omnipos-product-service/internal/outbox/repository"
+	outboxWorkerPkg "github.com/fekuna/omnipos-product-service/internal/outbox/worker"
 
 	prodH "github.com/fekuna/omnipos-product-service/internal/product/handler"
 	prodRepoPkg "github.com/fekuna/omnipos-product-service/internal/product/repository"
 	prodUCPkg "github.com/fekuna/omnipos-product-service/internal/product/usecase"
+	prodWorkerPkg "github.com/fekuna/omnipos-product-service/internal/product/worker"
+
+	purH "github.com/fekuna/omnipos-product-service/internal/purchase/handler"
+	purRepoPkg "github.com/fekuna/omnipos-product-service/internal/purchase/repository"
+	purUCPkg "github.com/fekuna/omnipos-product-service/internal/purchase/usecase"
+	purWorkerPkg "github.com/fekuna/omnipos-product-service/internal/purchase/worker"
+
+	searchAdminPkg "github.com/fekuna/omnipos-product-service/internal/searchindex/elastic"
+	searchH "github.com/fekuna/omnipos-product-service/internal/searchindex/handler"
+	searchUCPkg "github.com/fekuna/omnipos-product-service/internal/searchindex/usecase"
+
+	stkH "github.com/fekuna/omnipos-product-service/internal/stocktake/handler"
+	stkRepoPkg "github.com/fekuna/omnipos-product-service/internal/stocktake/repository"
+	stkUCPkg "github.com/fekuna/omnipos-product-service/internal/stocktake/usecase"
+
+	trfH "github.com/fekuna/omnipos-product-service/internal/transfer/handler"
+	trfRepoPkg "github.com/fekuna/omnipos-product-service/internal/transfer/repository"
+	trfUCPkg "github.com/fekuna/omnipos-product-service/internal/transfer/usecase"
 
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
@@ -53,6 +87,13 @@ func main() {
 	if err := i18n.Load("../omnipos-pkg/i18n/locales/active.id.json"); err != nil {
 		log.Printf("Failed to load id locales: %v", err)
 	}
+	// Messages of this service's errors, loaded after the shared ones so they take precedence.
+	if err := i18n.Load("locales/active.en.json"); err != nil {
+		log.Printf("Failed to load service en locales: %v", err)
+	}
+	if err := i18n.Load("locales/active.id.json"); err != nil {
+		log.Printf("Failed to load service id locales: %v", err)
+	}
 
 	// 2. Initialize Logger
 	logConfig := &logger.ZapLoggerConfig{
@@ -91,10 +132,43 @@ func main() {
 	defer db.Close()
 	appLogger.Info("Connected to PostgreSQL database", zap.String("db_name", cfg.Postgres.DBName))
 
+	// Merchant isolation relies on row-level security, which a superuser or BYPASSRLS role skips.
+	if err := database.CheckRowLevelSecurity(context.Background(), db); err != nil {
+		appLogger.Fatal("Refusing to start", zap.Error(err))
+	}
+
 	// 4. Initialize Repositories
 	catRepo := catRepoPkg.NewPGRepository(db)
 	prodRepo := prodRepoPkg.NewPGRepository(db)
 	invRepo := invRepoPkg.NewPGRepository(db)
+	trfRepo := trfRepoPkg.NewPGRepository(db)
+	purRepo := purRepoPkg.NewPGRepository(db)
+	stkRepo := stkRepoPkg.NewPGRepository(db)
+	dlqRepo := dlqRepoPkg.NewPGRepository(db)
+	outboxRepo := outboxRepoPkg.NewPGRepository(db)
+	txManager := database.NewTxManager(db)
+
+	// 4.5 Initialize Elasticsearch
+	esClient, err := search.NewClient(&search.Config{
+		Addresses: cfg.Elastic.Addresses,
+		Username:  cfg.Elastic.Username,
+		Password:  cfg.Elastic.Password,
+	})
+	if err != nil {
+		appLogger.Warn("Could not connect to Elasticsearch (Search features might be limited)", zap.Error(err))
+		// We don't fail fatal here to allow service to run even if ES is down (best practice for resilience)
+		esClient = nil
+	} else {
+		appLogger.Info("Connected to Elasticsearch", zap.Strings("addresses", cfg.Elastic.Addresses))
+	}
+
+	// Side effects of a change are written to the outbox with the change and relayed later.
+	// Search entries are left out without Elasticsearch, nothing could deliver them.
+	outboxWriter := outbox.NewWriter(outboxRepo, esClient != nil)
+
+	// Every stock write records StockChanged/LowStockReached and a stock watch wakeup in the
+	// same transaction.
+	stockRepo := invRepoPkg.NewPublishingRepository(invRepo, txManager, outboxWriter, outboxWriter)
 
 	// 5. Initialize Redis
 	redisClient, err := cache.NewRedisClient(&cache.Config{
@@ -108,46 +182,98 @@ func main() {
 	defer redisClient.Close()
 	appLogger.Info("Connected to Redis", zap.String("addr", cfg.Redis.Addr))
 
-	// 5.5 Initialize Kafka Consumer
-	kafkaConsumer := broker.NewConsumer(&broker.Config{
-		Brokers: cfg.Kafka.Brokers,
-		Topic:   cfg.Kafka.Topic,
-		GroupID: cfg.Kafka.GroupID,
-	})
-	defer kafkaConsumer.Close()
-	appLogger.Info("Connected to Kafka Consumer", zap.Strings("brokers", cfg.Kafka.Brokers), zap.String("topic", cfg.Kafka.Topic))
-
-	// 5.8 Initialize Elasticsearch
-	esClient, err := search.NewClient(&search.Config{
-		Addresses: cfg.Elastic.Addresses,
-		Username:  cfg.Elastic.Username,
-		Password:  cfg.Elastic.Password,
-	})
-	if err != nil {
-		appLogger.Warn("Could not connect to Elasticsearch (Search features might be limited)", zap.Error(err))
-		// We don't fail fatal here to allow service to run even if ES is down (best practice for resilience)
-		esClient = nil
+	// 5.5 Initialize Kafka Consumer and Producer
+	var eventReader messaging.Reader
+	var eventPublisher messaging.Publisher
+	if cfg.Kafka.Driver == "memory" {
+		fakeBroker := messaging.NewFakeBroker()
+		eventReader = fakeBroker.Reader(cfg.Kafka.Topic)
+		eventPublisher = fakeBroker
+		appLogger.Warn("Using the in-memory broker, events are not shared with other services")
 	} else {
-		appLogger.Info("Connected to Elasticsearch", zap.Strings("addresses", cfg.Elastic.Addresses))
+		kafkaConsumer := broker.NewConsumer(&broker.Config{
+			Brokers: cfg.Kafka.Brokers,
+			Topic:   cfg.Kafka.Topic,
+			GroupID: cfg.Kafka.GroupID,
+		})
+		defer kafkaConsumer.Close()
+		eventReader = messaging.NewKafkaReader(kafkaConsumer)
+		eventPublisher = messaging.NewKafkaPublisher(cfg.Kafka.Brokers)
+		appLogger.Info("Connected to Kafka Consumer", zap.Strings("brokers", cfg.Kafka.Brokers), zap.String("topic", cfg.Kafka.Topic))
 	}
+	defer eventPublisher.Close()
+	eventProducer := event.NewProducer(eventPublisher, cfg.Kafka.CatalogTopic, cfg.Kafka.InventoryTopic)
 
 	// 6. Initialize UseCases
-	catUC := catUCPkg.NewCategoryUseCase(catRepo, appLogger)
-	prodUC := prodUCPkg.NewProductUseCase(prodRepo, redisClient, esClient, appLogger) // Injection
-	invUC := invUCPkg.NewInventoryUseCase(invRepo, redisClient, appLogger)
+	catUC := catUCPkg.NewCategoryUseCase(catRepo, txManager, outboxWriter, appLogger)
+	prodUC := prodUCPkg.NewProductUseCase(prodRepo, redisClient, esClient, txManager, outboxWriter, appLogger) // Injection
+	stockFeed := invWorkerPkg.NewRedisStockFeed(redisClient, appLogger)
+	invUC := invUCPkg.NewInventoryUseCase(stockRepo, txManager, redisClient, stockFeed, inventory.InsufficientStockPolicy(cfg.Inventory.InsufficientStockPolicy), appLogger)
+	trfUC := trfUCPkg.NewTransferUseCase(trfRepo, stockRepo, txManager, appLogger)
+	purUC := purUCPkg.NewPurchaseUseCase(purRepo, stockRepo, prodRepo, txManager, outboxWriter, time.Duration(cfg.Reorder.DismissPeriod)*time.Hour, appLogger)
+	stkUC := stkUCPkg.NewStocktakeUseCase(stkRepo, stockRepo, txManager, appLogger)
+	dlqUC := dlqUCPkg.NewDeadLetterUseCase(dlqRepo, eventPublisher, cfg.Kafka.DeadLetterTopic, appLogger)
+	searchAdmin := searchAdminPkg.NewClient(cfg.Elastic.Addresses, cfg.Elastic.Username, cfg.Elastic.Password)
+	searchUC := searchUCPkg.NewSearchIndexUseCase(prodRepo, outboxRepo, searchAdmin, redisClient, appLogger)
 
 	// 6.5 Initialize Listeners
-	invListener := invListenerPkg.NewInventoryListener(kafkaConsumer, invUC, appLogger)
+	retryPolicy := invListenerPkg.RetryPolicy{
+		MaxAttempts:    cfg.EventRetry.MaxAttempts,
+		InitialBackoff: time.Duration(cfg.EventRetry.InitialBackoff) * time.Millisecond,
+		MaxBackoff:     time.Duration(cfg.EventRetry.MaxBackoff) * time.Millisecond,
+	}
+	invListener := invListenerPkg.NewInventoryListener(eventReader, invUC, prodUC, dlqUC, retryPolicy, appLogger)
+	reservationSweeper := prodWorkerPkg.NewReservationSweeper(prodUC, time.Duration(cfg.Reservation.SweepInterval)*time.Second, appLogger)
+	reorderJob := purWorkerPkg.NewReorderJob(purUC, time.Duration(cfg.Reorder.SuggestionInterval)*time.Second, appLogger)
+	outboxRelay := outboxWorkerPkg.NewRelay(outboxRepo, txManager, eventProducer, esClient, redisClient,
+		map[string]string{product.SearchIndex: product.SearchIndexMapping},
+		outboxWorkerPkg.RelayOptions{
+			Interval:    time.Duration(cfg.Outbox.PollInterval) * time.Millisecond,
+			BatchSize:   cfg.Outbox.BatchSize,
+			MaxBackoff:  time.Duration(cfg.Outbox.MaxBackoff) * time.Second,
+			MaxAttempts: cfg.Outbox.MaxAttempts,
+			Lease:       time.Duration(cfg.Outbox.Lease) * time.Second,
+			Retention:   time.Duration(cfg.Outbox.Retention) * time.Hour,
+		},
+		appLogger,
+	)
 
 	// Start Listener
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	go invListener.Start(ctx)
+	go reservationSweeper.Start(ctx)
+	go reorderJob.Start(ctx)
+	go outboxRelay.Start(ctx)
+	go stockFeed.Start(ctx)
 
 	// 6. Initialize Handlers
 	catHandler := catH.NewCategoryHandler(catUC, appLogger)
 	prodHandler := prodH.NewProductHandler(prodUC, appLogger)
 	invHandler := invH.NewInventoryHandler(invUC, appLogger)
+	trfHandler := trfH.NewTransferHandler(trfUC, appLogger)
+	purHandler := purH.NewPurchaseHandler(purUC, appLogger)
+	stkHandler := stkH.NewStocktakeHandler(stkUC, appLogger)
+	dlqHandler := dlqH.NewDeadLetterHandler(dlqUC, appLogger)
+	searchHandler := searchH.NewSearchIndexHandler(searchUC, appLogger)
+
+	// 6.8 Initialize Authentication
+	verifierConfig := auth.VerifierConfig{
+		SecretKey: cfg.JWT.SecretKey,
+		Issuer:    cfg.JWT.Issuer,
+		Audience:  cfg.JWT.Audience,
+	}
+	if cfg.JWT.JWKSFile != "" {
+		verifierConfig.RSAKeys, err = auth.LoadJWKS(cfg.JWT.JWKSFile)
+		if err != nil {
+			appLogger.Fatal("Could not load JWKS", zap.Error(err))
+		}
+	}
+	verifier, err := auth.NewVerifier(verifierConfig)
+	if err != nil {
+		appLogger.Fatal("Could not set up JWT verification", zap.Error(err))
+	}
+	authenticator := auth.NewAuthenticator(verifier, auth.DefaultPermissions())
 
 	// 7. Start gRPC Server
 	port := cfg.Server.GRPCPort
@@ -161,7 +287,15 @@ func main() {
 	}
 
 	grpcServer := grpc.NewServer(
-		grpc.UnaryInterceptor(middleware.ContextInterceptor()),
+		grpc.ChainUnaryInterceptor(
+			middleware.ContextInterceptor(),
+			apperror.UnaryInterceptor(appLogger),
+			authenticator.UnaryInterceptor(),
+		),
+		grpc.ChainStreamInterceptor(
+			apperror.StreamInterceptor(appLogger),
+			authenticator.StreamInterceptor(),
+		),
 	)
 
 	// Register Services
@@ -169,6 +303,11 @@ func main() {
 	productv1.RegisterProductServiceServer(grpcServer, prodHandler)
 	productv1.RegisterProductVariantServiceServer(grpcServer, prodHandler)
 	productv1.RegisterInventoryServiceServer(grpcServer, invHandler)
+	productv1.RegisterStockTransferServiceServer(grpcServer, trfHandler)
+	productv1.RegisterPurchaseServiceServer(grpcServer, purHandler)
+	productv1.RegisterStocktakeServiceServer(grpcServer, stkHandler)
+	productv1.RegisterDeadLetterServiceServer(grpcServer, dlqHandler)
+	productv1.RegisterSearchIndexServiceServer(grpcServer, searchHandler)
 
 	// Register Reflection
 	reflection.Register(grpcServer)
@@ -0,0 +1,98 @@
+// Command reindex rebuilds the products search index from Postgres and moves the products
+// alias to it.
+//
+//	go run ./cmd/reindex                      # every merchant
+//	go run ./cmd/reindex -merchant <id>       # one merchant, others are copied over
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/fekuna/omnipos-pkg/cache"
+	"github.com/fekuna/omnipos-pkg/database/postgres"
+	"github.com/fekuna/omnipos-pkg/logger"
+	"github.com/fekuna/omnipos-product-service/config"
+	"github.com/fekuna/omnipos-product-service/internal/database"
+	"github.com/fekuna/omnipos-product-service/internal/searchindex/dto"
+	"github.com/fekuna/omnipos-product-service/internal/searchindex/elastic"
+	"github.com/joho/godotenv"
+	"go.uber.org/zap"
+
+	outboxRepoPkg "github.com/fekuna/omnipos-product-service/internal/outbox/repository"
+	prodRepoPkg "github.com/fekuna/omnipos-product-service/internal/product/repository"
+	searchUCPkg "github.com/fekuna/omnipos-product-service/internal/searchindex/usecase"
+)
+
+func main() {
+	merchantID := flag.String("merchant", "", "rebuild only this merchant's products (default every merchant)")
+	batchSize := flag.Int("batch-size", 0, "products loaded per batch (default 500)")
+	flag.Parse()
+
+	_ = godotenv.Load()
+	cfg := config.LoadEnv()
+
+	appLogger := logger.NewZapLogger(&logger.ZapLoggerConfig{
+		IsDevelopment: cfg.Server.AppEnv == "development",
+		Encoding:      "console",
+		Level:         "info",
+	})
+	defer appLogger.Sync()
+
+	db, err := postgres.NewPostgres(&postgres.Config{
+		Host:            cfg.Postgres.Host,
+		Port:            cfg.Postgres.Port,
+		User:            cfg.Postgres.User,
+		Password:        cfg.Postgres.Password,
+		DBName:          cfg.Postgres.DBName,
+		SSLMode:         cfg.Postgres.SSLMode,
+		MaxOpenConns:    cfg.Postgres.MaxOpenConns,
+		MaxIdleConns:    cfg.Postgres.MaxIdleConns,
+		ConnMaxLifetime: time.Duration(cfg.Postgres.ConnMaxLifetime) * time.Second,
+		ConnMaxIdleTime: time.Duration(cfg.Postgres.ConnMaxIdleTime) * time.Second,
+	})
+	if err != nil {
+		appLogger.Fatal("Could not connect to database", zap.Error(err))
+	}
+	defer db.Close()
+
+	redisClient, err := cache.NewRedisClient(&cache.Config{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	if err != nil {
+		appLogger.Fatal("Could not connect to Redis", zap.Error(err))
+	}
+	defer redisClient.Close()
+
+	uc := searchUCPkg.NewSearchIndexUseCase(
+		prodRepoPkg.NewPGRepository(db),
+		outboxRepoPkg.NewPGRepository(db),
+		elastic.NewClient(cfg.Elastic.Addresses, cfg.Elastic.Username, cfg.Elastic.Password),
+		redisClient,
+		appLogger,
+	)
+
+	ctx, stop := signal.NotifyContext(database.AsSystem(context.Background()), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	report := func(p dto.ReindexProgress) {
+		appLogger.Info("Reindex progress",
+			zap.String("index", p.Index),
+			zap.String("phase", p.Phase),
+			zap.Int("indexed", p.Indexed),
+			zap.Int("total", p.Total),
+			zap.Int64("copied", p.Copied),
+			zap.Int("caught_up", p.CaughtUp),
+		)
+	}
+
+	if _, err := uc.ReindexProducts(ctx, &dto.ReindexInput{MerchantID: *merchantID, BatchSize: *batchSize}, report); err != nil {
+		appLogger.Fatal("Reindex failed", zap.Error(err))
+	}
+}
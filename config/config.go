@@ -7,13 +7,18 @@ import (
 )
 
 type Config struct {
-	Server   ServerConfig
-	Logger   LoggerConfig
-	Postgres PostgresConfig
-	JWT      JWTConfig
-	Redis    RedisConfig
-	Kafka    KafkaConfig
-	Elastic  ElasticsearchConfig
+	Server      ServerConfig
+	Logger      LoggerConfig
+	Postgres    PostgresConfig
+	JWT         JWTConfig
+	Redis       RedisConfig
+	Kafka       KafkaConfig
+	Elastic     ElasticsearchConfig
+	Inventory   InventoryConfig
+	Reservation ReservationConfig
+	Reorder     ReorderConfig
+	EventRetry  EventRetryConfig
+	Outbox      OutboxConfig
 }
 
 type ServerConfig struct {
@@ -42,7 +47,10 @@ type PostgresConfig struct {
 }
 
 type JWTConfig struct {
-	SecretKey string
+	SecretKey string // HS256 signing key, empty to accept only RS256
+	JWKSFile  string // Path of a JWKS file with the RS256 public keys, empty to accept only HS256
+	Issuer    string // Required iss claim, empty to skip the check
+	Audience  string // Required aud claim, empty to skip the check
 }
 
 type RedisConfig struct {
@@ -52,9 +60,13 @@ type RedisConfig struct {
 }
 
 type KafkaConfig struct {
-	Brokers []string
-	Topic   string
-	GroupID string
+	Driver          string // "kafka", or "memory" for the in-process fake broker
+	Brokers         []string
+	Topic           string
+	DeadLetterTopic string
+	CatalogTopic    string // Product, variant and category events we publish
+	InventoryTopic  string // Stock events we publish
+	GroupID         string
 }
 
 type ElasticsearchConfig struct {
@@ -63,6 +75,34 @@ type ElasticsearchConfig struct {
 	Password  string
 }
 
+type InventoryConfig struct {
+	InsufficientStockPolicy string // "reject" or "allow_negative"
+}
+
+type ReservationConfig struct {
+	SweepInterval int // seconds between expired reservation sweeps
+}
+
+type ReorderConfig struct {
+	SuggestionInterval int // seconds between reorder suggestion runs
+	DismissPeriod      int // hours a dismissed suggestion holds, 0 until its location changes
+}
+
+type EventRetryConfig struct {
+	MaxAttempts    int // attempts before a failing event is dead-lettered
+	InitialBackoff int // milliseconds before the first retry
+	MaxBackoff     int // milliseconds cap on the backoff between retries
+}
+
+type OutboxConfig struct {
+	PollInterval int // milliseconds between relay polls
+	BatchSize    int // entries claimed per batch
+	MaxBackoff   int // seconds cap on the backoff between delivery attempts
+	MaxAttempts  int // attempts before an entry is parked as failed, 0 for no limit
+	Lease        int // seconds a claimed batch has to be delivered before it is claimed again
+	Retention    int // hours delivered entries are kept
+}
+
 func LoadEnv() *Config {
 	// Basic config loading
 	// In a real scenario, use structured config loader like viper or koanf
@@ -90,7 +130,10 @@ func LoadEnv() *Config {
 			ConnMaxIdleTime: getEnvInt("POSTGRES_CONN_MAX_IDLE_TIME", 60),
 		},
 		JWT: JWTConfig{
-			SecretKey: getEnv("JWT_SECRET_KEY", "your-secret-key-change-this-in-prod"),
+			SecretKey: getEnv("JWT_SECRET_KEY", ""),
+			JWKSFile:  getEnv("JWT_JWKS_FILE", ""),
+			Issuer:    getEnv("JWT_ISSUER", ""),
+			Audience:  getEnv("JWT_AUDIENCE", ""),
 		},
 		Redis: RedisConfig{
 			Addr:     getEnv("REDIS_ADDR", "localhost:6379"),
@@ -98,15 +141,42 @@ func LoadEnv() *Config {
 			DB:       getEnvInt("REDIS_DB", 0),
 		},
 		Kafka: KafkaConfig{
-			Brokers: getEnvSlice("KAFKA_BROKERS", []string{"localhost:9092"}),
-			Topic:   getEnv("KAFKA_TOPIC_ORDERS", "orders.events"),
-			GroupID: getEnv("KAFKA_GROUP_INVENTORY", "inventory"),
+			Driver:          getEnv("BROKER_DRIVER", "kafka"),
+			Brokers:         getEnvSlice("KAFKA_BROKERS", []string{"localhost:9092"}),
+			Topic:           getEnv("KAFKA_TOPIC_ORDERS", "orders.events"),
+			DeadLetterTopic: getEnv("KAFKA_TOPIC_ORDERS_DLQ", "orders.events.dlq"),
+			CatalogTopic:    getEnv("KAFKA_TOPIC_CATALOG", "catalog.events"),
+			InventoryTopic:  getEnv("KAFKA_TOPIC_INVENTORY", "inventory.events"),
+			GroupID:         getEnv("KAFKA_GROUP_INVENTORY", "inventory"),
 		},
 		Elastic: ElasticsearchConfig{
 			Addresses: getEnvSlice("ELASTICSEARCH_ADDRESSES", []string{"http://localhost:9200"}),
 			Username:  getEnv("ELASTICSEARCH_USERNAME", ""),
 			Password:  getEnv("ELASTICSEARCH_PASSWORD", ""),
 		},
+		Inventory: InventoryConfig{
+			InsufficientStockPolicy: getEnv("INSUFFICIENT_STOCK_POLICY", "reject"),
+		},
+		Reservation: ReservationConfig{
+			SweepInterval: getEnvInt("RESERVATION_SWEEP_INTERVAL", 60),
+		},
+		Reorder: ReorderConfig{
+			SuggestionInterval: getEnvInt("REORDER_SUGGESTION_INTERVAL", 3600),
+			DismissPeriod:      getEnvInt("REORDER_DISMISS_HOURS", 168),
+		},
+		EventRetry: EventRetryConfig{
+			MaxAttempts:    getEnvInt("EVENT_RETRY_MAX_ATTEMPTS", 5),
+			InitialBackoff: getEnvInt("EVENT_RETRY_INITIAL_BACKOFF_MS", 200),
+			MaxBackoff:     getEnvInt("EVENT_RETRY_MAX_BACKOFF_MS", 10000),
+		},
+		Outbox: OutboxConfig{
+			PollInterval: getEnvInt("OUTBOX_POLL_INTERVAL_MS", 500),
+			BatchSize:    getEnvInt("OUTBOX_BATCH_SIZE", 100),
+			MaxBackoff:   getEnvInt("OUTBOX_MAX_BACKOFF_SECONDS", 300),
+			MaxAttempts:  getEnvInt("OUTBOX_MAX_ATTEMPTS", 50),
+			Lease:        getEnvInt("OUTBOX_LEASE_SECONDS", 60),
+			Retention:    getEnvInt("OUTBOX_RETENTION_HOURS", 168),
+		},
 	}
 }
 
@@ -0,0 +1,90 @@
+// Package apperror holds the typed errors the usecases return for problems the caller can
+// act on, and maps them to gRPC statuses with translated messages. Any other error is an
+// internal failure: it is logged, and the caller only learns that something went wrong.
+package apperror
+
+import (
+	"errors"
+	"strings"
+	"text/template"
+)
+
+// Kind says what went wrong from the caller's point of view.
+type Kind int
+
+const (
+	// InvalidArgument: the request is wrong whatever the state of the system.
+	InvalidArgument Kind = iota + 1
+	// NotFound: the resource does not exist, or belongs to another merchant.
+	NotFound
+	// AlreadyExists: the resource clashes with one that exists, such as a taken SKU.
+	AlreadyExists
+	// FailedPrecondition: the request is valid but the system is not in a state to run it,
+	// such as selling more than is in stock.
+	FailedPrecondition
+	// Aborted: the request ran into a concurrent one and may be retried.
+	Aborted
+)
+
+// Error is a domain error. ID names the message in the i18n locales, and is also sent to
+// clients as the reason of the error. Message is the English text, used when no locale
+// has the ID; both are templates over Data, in the go-i18n syntax.
+type Error struct {
+	Kind    Kind
+	ID      string
+	Message string
+	Field   string
+	Data    map[string]interface{}
+}
+
+func New(kind Kind, id, message string) *Error {
+	return &Error{Kind: kind, ID: id, Message: message}
+}
+
+// Invalid returns an InvalidArgument error about one field of the request.
+func Invalid(id, field, message string) *Error {
+	return &Error{Kind: InvalidArgument, ID: id, Field: field, Message: message}
+}
+
+// With returns a copy of e with a template value set, leaving the sentinel untouched.
+func (e *Error) With(key string, value interface{}) *Error {
+	c := *e
+	c.Data = make(map[string]interface{}, len(e.Data)+1)
+	for k, v := range e.Data {
+		c.Data[k] = v
+	}
+	c.Data[key] = value
+	return &c
+}
+
+func (e *Error) Error() string {
+	return render(e.Message, e.Data)
+}
+
+// Is matches errors by ID, so a sentinel matches the copies With makes of it.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.ID == e.ID
+}
+
+// As returns the domain error in err's chain, if any.
+func As(err error) (*Error, bool) {
+	var e *Error
+	ok := errors.As(err, &e)
+	return e, ok
+}
+
+func render(message string, data map[string]interface{}) string {
+	if !strings.Contains(message, "{{") {
+		return message
+	}
+	tmpl, err := template.New("").Option("missingkey=zero").Parse(message)
+	if err != nil {
+		return message
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return message
+	}
+	return b.String()
+}
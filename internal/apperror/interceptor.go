@@ -0,0 +1,41 @@
+package apperror
+
+import (
+	"context"
+
+	"github.com/fekuna/omnipos-pkg/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryInterceptor converts the errors handlers return with Status, logging the internal
+// ones since their cause is not sent to the caller.
+func UnaryInterceptor(log logger.ZapLogger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			err = convert(ctx, log, info.FullMethod, err)
+		}
+		return resp, err
+	}
+}
+
+func StreamInterceptor(log logger.ZapLogger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		err := handler(srv, ss)
+		if err != nil {
+			err = convert(ss.Context(), log, info.FullMethod, err)
+		}
+		return err
+	}
+}
+
+func convert(ctx context.Context, log logger.ZapLogger, method string, err error) error {
+	if _, ok := status.FromError(err); !ok {
+		if _, ok := As(err); !ok && ctx.Err() == nil {
+			log.Error("Request failed", zap.String("method", method), zap.Error(err))
+		}
+	}
+	return Status(ctx, err)
+}
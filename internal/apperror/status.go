@@ -0,0 +1,129 @@
+package apperror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fekuna/omnipos-pkg/i18n"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+const (
+	errorDomain     = "product.omnipos"
+	defaultLanguage = "en"
+	internalID      = "INTERNAL_ERROR"
+	internalMessage = "something went wrong, please try again later"
+)
+
+// supportedLanguages are the locales main.go loads.
+var supportedLanguages = map[string]bool{"en": true, "id": true}
+
+var kindCodes = map[Kind]codes.Code{
+	InvalidArgument:    codes.InvalidArgument,
+	NotFound:           codes.NotFound,
+	AlreadyExists:      codes.AlreadyExists,
+	FailedPrecondition: codes.FailedPrecondition,
+	Aborted:            codes.Aborted,
+}
+
+// Status converts err to a gRPC status error in the caller's language. Status errors pass
+// through unchanged; errors that are not domain errors become a bare Internal.
+func Status(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+
+	lang := Language(ctx)
+	e, ok := As(err)
+	if !ok {
+		return withDetails(status.New(codes.Internal, translate(lang, internalID, internalMessage, nil)),
+			&errdetails.ErrorInfo{Reason: internalID, Domain: errorDomain},
+		)
+	}
+
+	message := translate(lang, e.ID, e.Message, e.Data)
+	info := &errdetails.ErrorInfo{Reason: e.ID, Domain: errorDomain, Metadata: metadataOf(e.Data)}
+	localized := &errdetails.LocalizedMessage{Locale: lang, Message: message}
+	st := status.New(kindCodes[e.Kind], message)
+
+	switch e.Kind {
+	case InvalidArgument:
+		if e.Field != "" {
+			return withDetails(st, info, localized, &errdetails.BadRequest{
+				FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: e.Field, Description: message}},
+			})
+		}
+	case FailedPrecondition:
+		return withDetails(st, info, localized, &errdetails.PreconditionFailure{
+			Violations: []*errdetails.PreconditionFailure_Violation{{Type: e.ID, Description: message}},
+		})
+	}
+	return withDetails(st, info, localized)
+}
+
+// Message returns the caller's-language message of a domain error, for responses that
+// report failures in their body.
+func Message(ctx context.Context, e *Error) string {
+	return translate(Language(ctx), e.ID, e.Message, e.Data)
+}
+
+// Language returns the first supported language of the caller's accept-language metadata,
+// English when there is none.
+func Language(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return defaultLanguage
+	}
+	for _, header := range md.Get("accept-language") {
+		for _, part := range strings.Split(header, ",") {
+			tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+			base := strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+			if supportedLanguages[base] {
+				return base
+			}
+		}
+	}
+	return defaultLanguage
+}
+
+// translate looks id up in the loaded locales, falling back to the English default for
+// messages the locales do not have yet.
+func translate(lang, id, fallback string, data map[string]interface{}) string {
+	if msg := i18n.Translate(lang, id, data); msg != "" && msg != id {
+		return msg
+	}
+	return render(fallback, data)
+}
+
+func withDetails(st *status.Status, details ...protoadapt.MessageV1) error {
+	detailed, err := st.WithDetails(details...)
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// metadataOf turns template data into ErrorInfo metadata, so clients can build their own
+// messages from it.
+func metadataOf(data map[string]interface{}) map[string]string {
+	if len(data) == 0 {
+		return nil
+	}
+	md := make(map[string]string, len(data))
+	for k, v := range data {
+		md[k] = fmt.Sprint(v)
+	}
+	return md
+}
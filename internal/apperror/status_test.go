@@ -0,0 +1,228 @@
+package apperror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fekuna/omnipos-pkg/i18n"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func loadLocales(t *testing.T) {
+	t.Helper()
+	i18n.Init()
+	for _, path := range []string{"../../locales/active.en.json", "../../locales/active.id.json"} {
+		if err := i18n.Load(path); err != nil {
+			t.Fatalf("load %s: %v", path, err)
+		}
+	}
+}
+
+func TestStatus(t *testing.T) {
+	loadLocales(t)
+
+	sqlErr := errors.New(`pq: duplicate key value violates unique constraint "products_sku_key"`)
+	tests := []struct {
+		name          string
+		err           error
+		lang          string
+		wantCode      codes.Code
+		wantReason    string
+		wantMessage   string
+		wantMetadata  map[string]string
+		wantField     string // BadRequest field violation, if any
+		wantCondition bool   // PreconditionFailure violation of type wantReason
+	}{
+		{
+			name:        "not found",
+			err:         New(NotFound, "TRANSFER_NOT_FOUND", "transfer not found"),
+			lang:        "en",
+			wantCode:    codes.NotFound,
+			wantReason:  "TRANSFER_NOT_FOUND",
+			wantMessage: "transfer not found",
+		},
+		{
+			name:        "not found in Indonesian",
+			err:         New(NotFound, "TRANSFER_NOT_FOUND", "transfer not found"),
+			lang:        "id",
+			wantCode:    codes.NotFound,
+			wantReason:  "TRANSFER_NOT_FOUND",
+			wantMessage: "transfer tidak ditemukan",
+		},
+		{
+			name:         "already exists",
+			err:          New(AlreadyExists, "VARIANT_SKU_ALREADY_EXISTS", "variant SKU {{.SKU}} already exists").With("SKU", "TEE-XL"),
+			lang:         "en",
+			wantCode:     codes.AlreadyExists,
+			wantReason:   "VARIANT_SKU_ALREADY_EXISTS",
+			wantMessage:  "variant SKU TEE-XL already exists",
+			wantMetadata: map[string]string{"SKU": "TEE-XL"},
+		},
+		{
+			name:         "already exists in Indonesian",
+			err:          New(AlreadyExists, "VARIANT_SKU_ALREADY_EXISTS", "variant SKU {{.SKU}} already exists").With("SKU", "TEE-XL"),
+			lang:         "id",
+			wantCode:     codes.AlreadyExists,
+			wantReason:   "VARIANT_SKU_ALREADY_EXISTS",
+			wantMessage:  "SKU varian TEE-XL sudah digunakan",
+			wantMetadata: map[string]string{"SKU": "TEE-XL"},
+		},
+		{
+			name:          "failed precondition",
+			err:           New(FailedPrecondition, "TRANSFER_WRONG_STATUS", "transfer is {{.Status}}").With("Status", "closed"),
+			lang:          "en",
+			wantCode:      codes.FailedPrecondition,
+			wantReason:    "TRANSFER_WRONG_STATUS",
+			wantMessage:   "transfer is closed",
+			wantMetadata:  map[string]string{"Status": "closed"},
+			wantCondition: true,
+		},
+		{
+			name:          "failed precondition in Indonesian",
+			err:           New(FailedPrecondition, "TRANSFER_WRONG_STATUS", "transfer is {{.Status}}").With("Status", "closed"),
+			lang:          "id",
+			wantCode:      codes.FailedPrecondition,
+			wantReason:    "TRANSFER_WRONG_STATUS",
+			wantMessage:   "transfer berstatus closed",
+			wantMetadata:  map[string]string{"Status": "closed"},
+			wantCondition: true,
+		},
+		{
+			name:        "invalid argument wrapped",
+			err:         fmt.Errorf("receive: %w", Invalid("NEGATIVE_RECEIVED_QUANTITY", "items.quantity", "received quantity cannot be negative")),
+			lang:        "id",
+			wantCode:    codes.InvalidArgument,
+			wantReason:  "NEGATIVE_RECEIVED_QUANTITY",
+			wantMessage: "jumlah diterima tidak boleh negatif",
+			wantField:   "items.quantity",
+		},
+		{
+			name:         "message missing from the locales",
+			err:          New(NotFound, "NOT_IN_ANY_LOCALE", "widget {{.ID}} not found").With("ID", 7),
+			lang:         "id",
+			wantCode:     codes.NotFound,
+			wantReason:   "NOT_IN_ANY_LOCALE",
+			wantMessage:  "widget 7 not found",
+			wantMetadata: map[string]string{"ID": "7"},
+		},
+		// INTERNAL_ERROR comes with the shared locales of omnipos-pkg, so both languages get
+		// the English default here.
+		{
+			name:        "raw SQL error",
+			err:         sqlErr,
+			lang:        "en",
+			wantCode:    codes.Internal,
+			wantReason:  internalID,
+			wantMessage: internalMessage,
+		},
+		{
+			name:        "raw SQL error in Indonesian",
+			err:         fmt.Errorf("create product: %w", sqlErr),
+			lang:        "id",
+			wantCode:    codes.Internal,
+			wantReason:  internalID,
+			wantMessage: internalMessage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("accept-language", tt.lang+";q=0.9, fr"))
+			st, ok := status.FromError(Status(ctx, tt.err))
+			if !ok {
+				t.Fatalf("Status did not return a status error")
+			}
+			if st.Code() != tt.wantCode {
+				t.Errorf("code = %v, want %v", st.Code(), tt.wantCode)
+			}
+			if st.Message() != tt.wantMessage {
+				t.Errorf("message = %q, want %q", st.Message(), tt.wantMessage)
+			}
+
+			var info *errdetails.ErrorInfo
+			var localized *errdetails.LocalizedMessage
+			var badRequest *errdetails.BadRequest
+			var precondition *errdetails.PreconditionFailure
+			for _, d := range st.Details() {
+				switch d := d.(type) {
+				case *errdetails.ErrorInfo:
+					info = d
+				case *errdetails.LocalizedMessage:
+					localized = d
+				case *errdetails.BadRequest:
+					badRequest = d
+				case *errdetails.PreconditionFailure:
+					precondition = d
+				}
+			}
+
+			if info == nil || info.Reason != tt.wantReason || info.Domain != errorDomain {
+				t.Errorf("error info = %v, want reason %s", info, tt.wantReason)
+			} else if len(info.Metadata) != 0 || len(tt.wantMetadata) != 0 {
+				if !reflect.DeepEqual(info.Metadata, tt.wantMetadata) {
+					t.Errorf("error info metadata = %v, want %v", info.Metadata, tt.wantMetadata)
+				}
+			}
+
+			if tt.wantCode == codes.Internal {
+				if localized != nil || badRequest != nil || precondition != nil {
+					t.Errorf("internal error has details beyond ErrorInfo: %v", st.Details())
+				}
+				for _, leak := range []string{"pq:", "products_sku_key", "create product"} {
+					if strings.Contains(fmt.Sprint(st.Proto()), leak) {
+						t.Errorf("internal error leaks %q", leak)
+					}
+				}
+				return
+			}
+
+			if localized == nil || localized.Locale != tt.lang || localized.Message != tt.wantMessage {
+				t.Errorf("localized message = %v, want %s %q", localized, tt.lang, tt.wantMessage)
+			}
+			if tt.wantField != "" {
+				if badRequest == nil || len(badRequest.FieldViolations) != 1 || badRequest.FieldViolations[0].Field != tt.wantField {
+					t.Errorf("bad request = %v, want a violation of %s", badRequest, tt.wantField)
+				}
+			} else if badRequest != nil {
+				t.Errorf("bad request = %v, want none", badRequest)
+			}
+			if tt.wantCondition {
+				if precondition == nil || len(precondition.Violations) != 1 || precondition.Violations[0].Type != tt.wantReason {
+					t.Errorf("precondition failure = %v, want a violation of type %s", precondition, tt.wantReason)
+				}
+			} else if precondition != nil {
+				t.Errorf("precondition failure = %v, want none", precondition)
+			}
+		})
+	}
+}
+
+func TestLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", "en"},
+		{"id", "id"},
+		{"id-ID,id;q=0.9,en;q=0.8", "id"},
+		{"fr-FR, ID;q=0.5", "id"},
+		{"fr, de", "en"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.header != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("accept-language", tt.header))
+			}
+			if got := Language(ctx); got != tt.want {
+				t.Errorf("Language(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
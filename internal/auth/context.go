@@ -2,29 +2,40 @@ package auth
 
 import (
 	"context"
-
-	"google.golang.org/grpc/metadata"
 )
 
 type UserContext struct {
 	MerchantID string
 	UserID     string
-	Role       string
+	Role       Role
+}
+
+type userContextKey struct{}
+
+// WithUser returns a context carrying the caller verified by the interceptor.
+func WithUser(ctx context.Context, user *UserContext) context.Context {
+	return context.WithValue(ctx, userContextKey{}, user)
 }
 
-// ExtractMerchantID helper - simpler version assuming middleware populates metadata or context
+// GetUser returns the caller verified by the interceptor, nil outside an authenticated call.
+func GetUser(ctx context.Context) *UserContext {
+	user, _ := ctx.Value(userContextKey{}).(*UserContext)
+	return user
+}
+
+// GetMerchantID returns the merchant of the verified token. Caller-supplied headers are
+// never trusted, so an empty string means the call is not scoped to a merchant.
 func GetMerchantID(ctx context.Context) string {
-	// Check if added to context by interceptor
-	if val, ok := ctx.Value("merchant_id").(string); ok {
-		return val
+	if user := GetUser(ctx); user != nil {
+		return user.MerchantID
 	}
+	return ""
+}
 
-	// Fallback to metadata
-	md, ok := metadata.FromIncomingContext(ctx)
-	if ok {
-		if val := md.Get("x-merchant-id"); len(val) > 0 {
-			return val[0]
-		}
+// GetUserID returns the subject of the verified token.
+func GetUserID(ctx context.Context) string {
+	if user := GetUser(ctx); user != nil {
+		return user.UserID
 	}
 	return ""
 }
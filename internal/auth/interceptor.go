@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/fekuna/omnipos-product-service/internal/database"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Authenticator verifies the bearer token of every call and checks the caller's role
+// against the permission table before the handler runs.
+type Authenticator struct {
+	verifier    *Verifier
+	permissions Permissions
+}
+
+func NewAuthenticator(verifier *Verifier, permissions Permissions) *Authenticator {
+	return &Authenticator{
+		verifier:    verifier,
+		permissions: permissions,
+	}
+}
+
+func (a *Authenticator) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := a.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (a *Authenticator) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authorize(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authorize returns ctx carrying the verified caller.
+func (a *Authenticator) authorize(ctx context.Context, fullMethod string) (context.Context, error) {
+	required, ok := a.permissions.Required(fullMethod)
+	if !ok {
+		return nil, status.Error(codes.PermissionDenied, "method is not open to any role")
+	}
+	if required == RolePublic {
+		return ctx, nil
+	}
+
+	token, err := bearerToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := a.verifier.Verify(token)
+	if err != nil {
+		if errors.Is(err, ErrTokenExpired) {
+			return nil, status.Error(codes.Unauthenticated, "token expired")
+		}
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	// Merchant roles only make sense with a merchant: every query is scoped to it.
+	if claims.Role != RoleOperator && claims.MerchantID == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing merchant context")
+	}
+	if !Allows(required, claims.Role) {
+		return nil, status.Errorf(codes.PermissionDenied, "role %q may not call %s", claims.Role, fullMethod)
+	}
+
+	// Row-level security scopes every query of the call to the caller's merchant.
+	if claims.Role == RoleOperator {
+		ctx = database.AsSystem(ctx)
+	} else {
+		ctx = database.WithTenant(ctx, claims.MerchantID)
+	}
+
+	return WithUser(ctx, &UserContext{
+		MerchantID: claims.MerchantID,
+		UserID:     claims.Subject,
+		Role:       claims.Role,
+	}), nil
+}
+
+func bearerToken(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", status.Error(codes.Unauthenticated, "missing authorization")
+	}
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return "", status.Error(codes.Unauthenticated, "missing authorization")
+	}
+	scheme, token, found := strings.Cut(values[0], " ")
+	if !found || !strings.EqualFold(scheme, "bearer") || token == "" {
+		return "", status.Error(codes.Unauthenticated, "authorization must be a bearer token")
+	}
+	return token, nil
+}
+
+// authenticatedStream hands the context with the verified caller to stream handlers.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testSecret   = "test-only-hs256-secret-of-32-bytes!"
+	testIssuer   = "omnipos-auth"
+	testAudience = "omnipos-product"
+	testKid      = "key-1"
+)
+
+// signToken builds a JWT with the given header and claims. key is a []byte for HS256, an
+// *rsa.PrivateKey for RS256 and nil to leave the signature empty.
+func signToken(t *testing.T, header, claims map[string]interface{}, key interface{}) string {
+	t.Helper()
+	segment := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("marshal token segment: %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signed := segment(header) + "." + segment(claims)
+
+	var signature []byte
+	switch key := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		digest := sha256.Sum256([]byte(signed))
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatalf("sign token: %v", err)
+		}
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// validClaims returns claims every verifier of the tests accepts, with changes applied.
+func validClaims(changes map[string]interface{}) map[string]interface{} {
+	claims := map[string]interface{}{
+		"sub":         "user-1",
+		"merchant_id": "merchant-1",
+		"role":        "manager",
+		"iss":         testIssuer,
+		"aud":         testAudience,
+		"exp":         time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range changes {
+		if v == nil {
+			delete(claims, k)
+		} else {
+			claims[k] = v
+		}
+	}
+	return claims
+}
+
+func newTestKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate RSA key: %v", err)
+	}
+	return key
+}
+
+func TestVerifierVerify(t *testing.T) {
+	rsaKey := newTestKey(t)
+	otherKey := newTestKey(t)
+	publicDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+
+	both, err := NewVerifier(VerifierConfig{
+		SecretKey: testSecret,
+		RSAKeys:   map[string]*rsa.PublicKey{testKid: &rsaKey.PublicKey},
+		Issuer:    testIssuer,
+		Audience:  testAudience,
+	})
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	rsaOnly, err := NewVerifier(VerifierConfig{
+		RSAKeys:  map[string]*rsa.PublicKey{testKid: &rsaKey.PublicKey},
+		Issuer:   testIssuer,
+		Audience: testAudience,
+	})
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	unchecked, err := NewVerifier(VerifierConfig{SecretKey: testSecret})
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+
+	hs256 := map[string]interface{}{"alg": "HS256", "typ": "JWT"}
+	rs256 := map[string]interface{}{"alg": "RS256", "typ": "JWT", "kid": testKid}
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		verifier *Verifier
+		token    string
+		wantErr  error
+	}{
+		{"HS256", both, signToken(t, hs256, validClaims(nil), []byte(testSecret)), nil},
+		{"RS256", both, signToken(t, rs256, validClaims(nil), rsaKey), nil},
+		{"RS256 without a secret configured", rsaOnly, signToken(t, rs256, validClaims(nil), rsaKey), nil},
+		{"HS256 with another secret", both, signToken(t, hs256, validClaims(nil), []byte(testSecret+"x")), ErrInvalidToken},
+		{"RS256 with another key", both, signToken(t, rs256, validClaims(nil), otherKey), ErrInvalidToken},
+		{"alg none", both, signToken(t, map[string]interface{}{"alg": "none"}, validClaims(nil), nil), ErrInvalidToken},
+		{"alg HS384", both, signToken(t, map[string]interface{}{"alg": "HS384"}, validClaims(nil), []byte(testSecret)), ErrInvalidToken},
+		{"HS256 signed with the RSA public key", rsaOnly, signToken(t, hs256, validClaims(nil), publicDER), ErrInvalidToken},
+		{"HS256 signed with the RSA public key and a secret configured", both, signToken(t, hs256, validClaims(nil), publicDER), ErrInvalidToken},
+		{"unknown kid", both, signToken(t, map[string]interface{}{"alg": "RS256", "kid": "key-2"}, validClaims(nil), rsaKey), ErrInvalidToken},
+		{"missing kid", both, signToken(t, map[string]interface{}{"alg": "RS256"}, validClaims(nil), rsaKey), ErrInvalidToken},
+		{"expired", both, signToken(t, hs256, validClaims(map[string]interface{}{"exp": now.Add(-time.Minute).Unix()}), []byte(testSecret)), ErrTokenExpired},
+		{"expired within clock skew", both, signToken(t, hs256, validClaims(map[string]interface{}{"exp": now.Add(-clockSkew / 2).Unix()}), []byte(testSecret)), nil},
+		{"missing exp", both, signToken(t, hs256, validClaims(map[string]interface{}{"exp": nil}), []byte(testSecret)), ErrInvalidToken},
+		{"not valid yet", both, signToken(t, hs256, validClaims(map[string]interface{}{"nbf": now.Add(time.Minute).Unix()}), []byte(testSecret)), ErrInvalidToken},
+		{"not valid yet within clock skew", both, signToken(t, hs256, validClaims(map[string]interface{}{"nbf": now.Add(clockSkew / 2).Unix()}), []byte(testSecret)), nil},
+		{"other issuer", both, signToken(t, hs256, validClaims(map[string]interface{}{"iss": "someone-else"}), []byte(testSecret)), ErrInvalidToken},
+		{"missing issuer", both, signToken(t, hs256, validClaims(map[string]interface{}{"iss": nil}), []byte(testSecret)), ErrInvalidToken},
+		{"audience list", both, signToken(t, hs256, validClaims(map[string]interface{}{"aud": []string{"omnipos-order", testAudience}}), []byte(testSecret)), nil},
+		{"other audience", both, signToken(t, hs256, validClaims(map[string]interface{}{"aud": "omnipos-order"}), []byte(testSecret)), ErrInvalidToken},
+		{"audience list without ours", both, signToken(t, hs256, validClaims(map[string]interface{}{"aud": []string{"omnipos-order"}}), []byte(testSecret)), ErrInvalidToken},
+		{"missing audience", both, signToken(t, hs256, validClaims(map[string]interface{}{"aud": nil}), []byte(testSecret)), ErrInvalidToken},
+		{"iss and aud not configured", unchecked, signToken(t, hs256, validClaims(map[string]interface{}{"iss": nil, "aud": nil}), []byte(testSecret)), nil},
+		{"missing sub", both, signToken(t, hs256, validClaims(map[string]interface{}{"sub": nil}), []byte(testSecret)), ErrInvalidToken},
+		{"two segments", both, "eyJhbGciOiJIUzI1NiJ9.e30", ErrInvalidToken},
+		{"garbage", both, "not.a.token", ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := tt.verifier.Verify(tt.token)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if claims.Subject != "user-1" || claims.MerchantID != "merchant-1" || claims.Role != RoleManager {
+				t.Errorf("Verify() claims = %+v", claims)
+			}
+		})
+	}
+}
+
+func TestVerifierRejectsTamperedClaims(t *testing.T) {
+	v, err := NewVerifier(VerifierConfig{SecretKey: testSecret})
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	token := signToken(t, map[string]interface{}{"alg": "HS256"}, validClaims(nil), []byte(testSecret))
+
+	parts := strings.Split(token, ".")
+	owner, _ := json.Marshal(validClaims(map[string]interface{}{"role": "owner"}))
+	parts[1] = base64.RawURLEncoding.EncodeToString(owner)
+
+	if _, err := v.Verify(strings.Join(parts, ".")); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify() of a tampered token error = %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestNewVerifier(t *testing.T) {
+	key := newTestKey(t)
+
+	tests := []struct {
+		name    string
+		cfg     VerifierConfig
+		wantErr bool
+	}{
+		{"secret key", VerifierConfig{SecretKey: testSecret}, false},
+		{"JWKS only", VerifierConfig{RSAKeys: map[string]*rsa.PublicKey{testKid: &key.PublicKey}}, false},
+		{"nothing configured", VerifierConfig{}, true},
+		{"short secret key", VerifierConfig{SecretKey: "too-short"}, true},
+		{"placeholder secret key", VerifierConfig{SecretKey: "your-secret-key-change-this-in-prod"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewVerifier(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewVerifier() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Role string
+
+const (
+	RoleCashier Role = "cashier"
+	RoleManager Role = "manager"
+	RoleOwner   Role = "owner"
+
+	// RoleOperator is for the people running the service. Operator tokens carry no merchant
+	// and only reach the operator services, which work across merchants.
+	RoleOperator Role = "operator"
+
+	// RolePublic marks methods callable without a token.
+	RolePublic Role = "public"
+)
+
+// merchantRanks orders the merchant roles: each role may do everything the ones below it may.
+var merchantRanks = map[Role]int{
+	RoleCashier: 1,
+	RoleManager: 2,
+	RoleOwner:   3,
+}
+
+// Permissions maps a full gRPC method name, or a "/service/" prefix, to the role it needs.
+// Methods that are not listed are denied.
+type Permissions map[string]Role
+
+// Required returns the role a method needs.
+func (p Permissions) Required(fullMethod string) (Role, bool) {
+	if role, ok := p[fullMethod]; ok {
+		return role, true
+	}
+	if i := strings.LastIndex(fullMethod, "/"); i > 0 {
+		role, ok := p[fullMethod[:i+1]]
+		return role, ok
+	}
+	return "", false
+}
+
+// Allows reports whether a caller with the given role may call a method needing required.
+func Allows(required, role Role) bool {
+	switch required {
+	case RolePublic:
+		return true
+	case RoleOperator:
+		return role == RoleOperator
+	}
+	have, ok := merchantRanks[role]
+	return ok && have >= merchantRanks[required]
+}
+
+func method(service, name string) string {
+	return fmt.Sprintf("/omnipos.product.v1.%s/%s", service, name)
+}
+
+func service(name string) string {
+	return fmt.Sprintf("/omnipos.product.v1.%s/", name)
+}
+
+// DefaultPermissions is the permission table of the service. Cashiers read the catalog and
+// stock and reserve it for sales, managers run stock operations and change the catalog and
+// prices, and only owners delete or change merchant-wide settings.
+func DefaultPermissions() Permissions {
+	return Permissions{
+		// Catalog
+		method("CategoryService", "GetCategory"):             RoleCashier,
+		method("CategoryService", "ListCategories"):          RoleCashier,
+		method("CategoryService", "CreateCategory"):          RoleManager,
+		method("CategoryService", "UpdateCategory"):          RoleManager,
+		method("CategoryService", "DeleteCategory"):          RoleOwner,
+		method("ProductService", "GetProduct"):               RoleCashier,
+		method("ProductService", "ListProducts"):             RoleCashier,
+		method("ProductService", "SearchProducts"):           RoleCashier,
+		method("ProductService", "SyncCatalog"):              RoleCashier,
+		method("ProductService", "CreateProduct"):            RoleManager,
+		method("ProductService", "UpdateProduct"):            RoleManager,
+		method("ProductService", "DeleteProduct"):            RoleOwner,
+		method("ProductVariantService", "ListVariants"):      RoleCashier,
+		method("ProductVariantService", "AddVariant"):        RoleManager,
+		method("ProductVariantService", "UpdateVariant"):     RoleManager,
+		method("ProductVariantService", "GenerateVariants"):  RoleManager,
+		method("ProductVariantService", "DeactivateVariant"): RoleManager,
+
+		// Sales
+		method("ProductService", "ReserveStock"):       RoleCashier,
+		method("ProductService", "CommitReservation"):  RoleCashier,
+		method("ProductService", "ReleaseReservation"): RoleCashier,
+
+		// Stock
+		method("InventoryService", "GetProductInventory"):    RoleCashier,
+		method("InventoryService", "GetProductStock"):        RoleCashier,
+		method("InventoryService", "GetStockAvailability"):   RoleCashier,
+		method("InventoryService", "WatchStock"):             RoleCashier,
+		method("InventoryService", "ListLowStock"):           RoleCashier,
+		method("InventoryService", "ListInventoryMovements"): RoleManager,
+		method("InventoryService", "AdjustInventory"):        RoleManager,
+		method("InventoryService", "TransferInventory"):      RoleManager,
+		method("InventoryService", "ListStockShortages"):     RoleManager,
+		method("InventoryService", "ResolveStockShortage"):   RoleManager,
+		service("StockTransferService"):                      RoleManager,
+		method("StocktakeService", "GetStocktake"):           RoleCashier,
+		method("StocktakeService", "RecordStocktakeCounts"):  RoleCashier,
+		method("StocktakeService", "StartStocktake"):         RoleManager,
+		method("StocktakeService", "ListStocktakes"):         RoleManager,
+		method("StocktakeService", "GetStocktakeVariances"):  RoleManager,
+		method("StocktakeService", "ApproveStocktake"):       RoleManager,
+		method("StocktakeService", "CancelStocktake"):        RoleManager,
+
+		// Purchasing
+		service("PurchaseService"):                    RoleManager,
+		method("PurchaseService", "SetCostingMethod"): RoleOwner,
+
+		// Operations
+		service("DeadLetterService"):                 RoleOperator,
+		service("SearchIndexService"):                RoleOperator,
+		"/grpc.reflection.v1.ServerReflection/":      RolePublic,
+		"/grpc.reflection.v1alpha.ServerReflection/": RolePublic,
+	}
+}
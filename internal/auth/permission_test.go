@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestAllows(t *testing.T) {
+	roles := []Role{"", RoleCashier, RoleManager, RoleOwner, RoleOperator}
+
+	tests := []struct {
+		required Role
+		allowed  []Role
+	}{
+		{RolePublic, roles},
+		{RoleCashier, []Role{RoleCashier, RoleManager, RoleOwner}},
+		{RoleManager, []Role{RoleManager, RoleOwner}},
+		{RoleOwner, []Role{RoleOwner}},
+		{RoleOperator, []Role{RoleOperator}},
+	}
+	for _, tt := range tests {
+		for _, role := range roles {
+			want := false
+			for _, r := range tt.allowed {
+				want = want || r == role
+			}
+			if got := Allows(tt.required, role); got != want {
+				t.Errorf("Allows(%q, %q) = %v, want %v", tt.required, role, got, want)
+			}
+		}
+	}
+}
+
+func TestDefaultPermissionsRequired(t *testing.T) {
+	p := DefaultPermissions()
+
+	tests := []struct {
+		method string
+		want   Role
+		listed bool
+	}{
+		{"/omnipos.product.v1.ProductService/GetProduct", RoleCashier, true},
+		{"/omnipos.product.v1.ProductService/UpdateProduct", RoleManager, true},
+		{"/omnipos.product.v1.ProductService/DeleteProduct", RoleOwner, true},
+		{"/omnipos.product.v1.InventoryService/WatchStock", RoleCashier, true},
+		{"/omnipos.product.v1.StockTransferService/DispatchTransfer", RoleManager, true},
+		{"/omnipos.product.v1.PurchaseService/CreatePurchaseOrder", RoleManager, true},
+		{"/omnipos.product.v1.PurchaseService/SetCostingMethod", RoleOwner, true}, // method over service
+		{"/omnipos.product.v1.DeadLetterService/ReplayDeadLetter", RoleOperator, true},
+		{"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo", RolePublic, true},
+		{"/omnipos.product.v1.ProductService/DropEverything", "", false},
+		{"/omnipos.product.v1.UnknownService/Get", "", false},
+		{"/grpc.health.v1.Health/Check", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, listed := p.Required(tt.method)
+		if got != tt.want || listed != tt.listed {
+			t.Errorf("Required(%q) = %q, %v, want %q, %v", tt.method, got, listed, tt.want, tt.listed)
+		}
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	v, err := NewVerifier(VerifierConfig{SecretKey: testSecret})
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	a := NewAuthenticator(v, DefaultPermissions())
+
+	token := func(role Role, merchantID string) string {
+		claims := map[string]interface{}{"sub": "user-1", "role": role, "exp": time.Now().Add(time.Hour).Unix()}
+		if merchantID != "" {
+			claims["merchant_id"] = merchantID
+		}
+		return signToken(t, map[string]interface{}{"alg": "HS256"}, claims, []byte(testSecret))
+	}
+
+	const (
+		getProduct    = "/omnipos.product.v1.ProductService/GetProduct"
+		deleteProduct = "/omnipos.product.v1.ProductService/DeleteProduct"
+		replay        = "/omnipos.product.v1.DeadLetterService/ReplayDeadLetter"
+		reflection    = "/grpc.reflection.v1.ServerReflection/ServerReflectionInfo"
+		unlisted      = "/omnipos.product.v1.ProductService/DropEverything"
+	)
+
+	tests := []struct {
+		name   string
+		method string
+		token  string
+		want   codes.Code
+	}{
+		{"cashier reads", getProduct, token(RoleCashier, "m-1"), codes.OK},
+		{"cashier deletes", deleteProduct, token(RoleCashier, "m-1"), codes.PermissionDenied},
+		{"manager deletes", deleteProduct, token(RoleManager, "m-1"), codes.PermissionDenied},
+		{"owner deletes", deleteProduct, token(RoleOwner, "m-1"), codes.OK},
+		{"unknown role", getProduct, token("admin", "m-1"), codes.PermissionDenied},
+		{"merchant role without merchant", getProduct, token(RoleOwner, ""), codes.Unauthenticated},
+		{"operator on a merchant service", getProduct, token(RoleOperator, ""), codes.PermissionDenied},
+		{"operator on an operator service", replay, token(RoleOperator, ""), codes.OK},
+		{"owner on an operator service", replay, token(RoleOwner, "m-1"), codes.PermissionDenied},
+		{"unlisted method", unlisted, token(RoleOwner, "m-1"), codes.PermissionDenied},
+		{"unlisted method without a token", unlisted, "", codes.PermissionDenied},
+		{"public method without a token", reflection, "", codes.OK},
+		{"missing token", getProduct, "", codes.Unauthenticated},
+		{"bad token", getProduct, "not.a.token", codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.token != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+tt.token))
+			}
+			_, err := a.authorize(ctx, tt.method)
+			if got := status.Code(err); got != tt.want {
+				t.Errorf("authorize(%s) = %v (%v), want %v", tt.method, got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,6 @@
+package category
+
+import "github.com/fekuna/omnipos-product-service/internal/apperror"
+
+// ErrCategoryNotFound is also returned for a category of another merchant.
+var ErrCategoryNotFound = apperror.New(apperror.NotFound, "CATEGORY_NOT_FOUND", "category not found")
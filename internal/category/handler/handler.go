@@ -52,7 +52,7 @@ func (h *CategoryHandler) CreateCategory(ctx context.Context, req *pb.CreateCate
 	cat, err := h.uc.CreateCategory(ctx, input)
 	if err != nil {
 		h.logger.Error("failed to create category", zap.Error(err))
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, err
 	}
 
 	return &pb.CreateCategoryResponse{
@@ -61,19 +61,11 @@ func (h *CategoryHandler) CreateCategory(ctx context.Context, req *pb.CreateCate
 }
 
 func (h *CategoryHandler) GetCategory(ctx context.Context, req *pb.GetCategoryRequest) (*pb.GetCategoryResponse, error) {
-	cat, err := h.uc.GetCategory(ctx, req.Id)
-	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
-	}
-	if cat == nil {
-		return nil, status.Error(codes.NotFound, "category not found")
-	}
-
-	// Ensure merchant ownership check if needed (usually Repo filters by merchant, but GetCategory input has only ID)
-	// If IDs are UUIDs, collision unlikely, but strict multi-tenancy requires checking MerchantID.
 	merchantID := auth.GetMerchantID(ctx)
-	if cat.MerchantID != merchantID {
-		return nil, status.Error(codes.NotFound, "category not found")
+
+	cat, err := h.uc.GetCategory(ctx, merchantID, req.Id)
+	if err != nil {
+		return nil, err
 	}
 
 	return &pb.GetCategoryResponse{
@@ -104,7 +96,7 @@ func (h *CategoryHandler) ListCategories(ctx context.Context, req *pb.ListCatego
 
 	cats, count, err := h.uc.ListCategories(ctx, filters)
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, err
 	}
 
 	protoCats := make([]*pb.Category, len(cats))
@@ -136,7 +128,7 @@ func (h *CategoryHandler) UpdateCategory(ctx context.Context, req *pb.UpdateCate
 
 	cat, err := h.uc.UpdateCategory(ctx, input)
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, err
 	}
 
 	return &pb.UpdateCategoryResponse{
@@ -145,10 +137,11 @@ func (h *CategoryHandler) UpdateCategory(ctx context.Context, req *pb.UpdateCate
 }
 
 func (h *CategoryHandler) DeleteCategory(ctx context.Context, req *pb.DeleteCategoryRequest) (*emptypb.Empty, error) {
-	// TODO: Verify ownership
-	err := h.uc.DeleteCategory(ctx, req.Id)
+	merchantID := auth.GetMerchantID(ctx)
+
+	err := h.uc.DeleteCategory(ctx, merchantID, req.Id)
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, err
 	}
 	return &emptypb.Empty{}, nil
 }
@@ -9,8 +9,8 @@ import (
 
 type Repository interface {
 	Create(ctx context.Context, category *model.Category) error
-	FindByID(ctx context.Context, id string) (*model.Category, error)
+	FindByID(ctx context.Context, merchantID, id string) (*model.Category, error)
 	FindAll(ctx context.Context, filters *dto.CategoryFilters) ([]model.Category, int, error)
 	Update(ctx context.Context, category *model.Category) error
-	Delete(ctx context.Context, id string) error
+	Delete(ctx context.Context, merchantID, id string) error
 }
@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/fekuna/omnipos-product-service/internal/category"
 	"github.com/fekuna/omnipos-product-service/internal/category/dto"
+	"github.com/fekuna/omnipos-product-service/internal/database"
 	"github.com/fekuna/omnipos-product-service/internal/model"
 	"github.com/jmoiron/sqlx"
 )
@@ -20,19 +22,24 @@ func NewPGRepository(db *sqlx.DB) *PGRepository {
 	return &PGRepository{DB: db}
 }
 
+// conn returns the transaction started by the TxManager if ctx carries one.
+func (r *PGRepository) conn(ctx context.Context) database.DBTX {
+	return database.Conn(ctx, r.DB)
+}
+
 func (r *PGRepository) Create(ctx context.Context, c *model.Category) error {
 	query := `
         INSERT INTO categories (id, merchant_id, parent_id, name, description, image_url, sort_order, is_active, created_at, updated_at)
         VALUES (:id, :merchant_id, :parent_id, :name, :description, :image_url, :sort_order, :is_active, :created_at, :updated_at)
     `
-	_, err := r.DB.NamedExecContext(ctx, query, c)
+	_, err := r.conn(ctx).NamedExecContext(ctx, query, c)
 	return err
 }
 
-func (r *PGRepository) FindByID(ctx context.Context, id string) (*model.Category, error) {
+func (r *PGRepository) FindByID(ctx context.Context, merchantID, id string) (*model.Category, error) {
 	var category model.Category
-	query := `SELECT * FROM categories WHERE id = $1 LIMIT 1`
-	err := r.DB.GetContext(ctx, &category, query, id)
+	query := `SELECT * FROM categories WHERE id = $1 AND merchant_id = $2 LIMIT 1`
+	err := r.conn(ctx).GetContext(ctx, &category, query, id, merchantID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -46,13 +53,8 @@ func (r *PGRepository) FindAll(ctx context.Context, f *dto.CategoryFilters) ([]m
 	var categories []model.Category
 	var count int
 
-	conditions := []string{}
-	args := map[string]interface{}{}
-
-	if f.MerchantID != "" {
-		conditions = append(conditions, "merchant_id = :merchant_id")
-		args["merchant_id"] = f.MerchantID
-	}
+	conditions := []string{"merchant_id = :merchant_id"}
+	args := map[string]interface{}{"merchant_id": f.MerchantID}
 	// ParentID filtering logic
 	if f.ParentID != nil {
 		if *f.ParentID == "" {
@@ -76,18 +78,9 @@ func (r *PGRepository) FindAll(ctx context.Context, f *dto.CategoryFilters) ([]m
 	// Count query
 	countQuery := "SELECT count(*) FROM categories" + whereClause
 
-	// Check if we assume 'args' can be used with NamedQuery for count.
-	// sqlx.Named doesn't directly support returning scalar easily without struct scan.
-	// Simpler approach: Use NamedQuery and scan into struct or use rows.
-
-	rows, err := r.DB.NamedQueryContext(ctx, countQuery, args)
-	if err != nil {
+	if err := database.NamedGet(ctx, r.conn(ctx), &count, countQuery, args); err != nil {
 		return nil, 0, err
 	}
-	defer rows.Close()
-	if rows.Next() {
-		rows.Scan(&count)
-	}
 
 	// List query
 	query := "SELECT * FROM categories" + whereClause + " ORDER BY sort_order ASC, name ASC"
@@ -98,13 +91,7 @@ func (r *PGRepository) FindAll(ctx context.Context, f *dto.CategoryFilters) ([]m
 		query += fmt.Sprintf(" LIMIT %d OFFSET %d", f.PageSize, offset)
 	}
 
-	nstmt, err := r.DB.PrepareNamedContext(ctx, query)
-	if err != nil {
-		return nil, 0, err
-	}
-	defer nstmt.Close()
-
-	err = nstmt.SelectContext(ctx, &categories, args)
+	err := database.NamedSelect(ctx, r.conn(ctx), &categories, query, args)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -124,13 +111,32 @@ func (r *PGRepository) Update(ctx context.Context, c *model.Category) error {
             updated_at = :updated_at
         WHERE id = :id AND merchant_id = :merchant_id
     `
-	_, err := r.DB.NamedExecContext(ctx, query, c)
-	return err
+	res, err := r.conn(ctx).NamedExecContext(ctx, query, c)
+	if err != nil {
+		return err
+	}
+	return requireRow(res)
 }
 
-func (r *PGRepository) Delete(ctx context.Context, id string) error {
+func (r *PGRepository) Delete(ctx context.Context, merchantID, id string) error {
 	// Check if it has children? Database constraint (fk) is SET NULL, so children become root.
 	// Or we could enforce check here. Simple delete for now.
-	_, err := r.DB.ExecContext(ctx, "DELETE FROM categories WHERE id = $1", id)
-	return err
+	// A trigger leaves the category's tombstone in catalog_changes for catalog sync.
+	res, err := r.conn(ctx).ExecContext(ctx, "DELETE FROM categories WHERE id = $1 AND merchant_id = $2", id, merchantID)
+	if err != nil {
+		return err
+	}
+	return requireRow(res)
+}
+
+// requireRow reports a write that matched no row, e.g. a category of another merchant, as not found.
+func requireRow(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return category.ErrCategoryNotFound
+	}
+	return nil
 }
@@ -2,35 +2,37 @@ package usecase
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"time"
 
 	"github.com/fekuna/omnipos-pkg/logger"
 	"github.com/fekuna/omnipos-product-service/internal/category"
 	"github.com/fekuna/omnipos-product-service/internal/category/dto"
+	"github.com/fekuna/omnipos-product-service/internal/database"
+	"github.com/fekuna/omnipos-product-service/internal/event"
 	"github.com/fekuna/omnipos-product-service/internal/model"
 	"github.com/google/uuid"
 )
 
 type categoryUseCase struct {
 	repo   category.Repository
+	tx     database.TxManager
+	events event.Publisher // Writes to the outbox, in the transaction of the change
 	logger logger.ZapLogger
 }
 
-func NewCategoryUseCase(repo category.Repository, log logger.ZapLogger) category.UseCase {
+func NewCategoryUseCase(repo category.Repository, tx database.TxManager, events event.Publisher, log logger.ZapLogger) category.UseCase {
 	return &categoryUseCase{
 		repo:   repo,
+		tx:     tx,
+		events: events,
 		logger: log,
 	}
 }
 
 func (uc *categoryUseCase) CreateCategory(ctx context.Context, input *dto.CreateCategoryInput) (*model.Category, error) {
-	// Validate parent if needed
-	if input.ParentID != nil && *input.ParentID != "" {
-		_, err := uc.repo.FindByID(ctx, *input.ParentID)
-		if err != nil {
-			return nil, err // Handle not found specifically?
-		}
+	if err := uc.checkParent(ctx, input.MerchantID, input.ParentID); err != nil {
+		return nil, err
 	}
 
 	id := uuid.New().String()
@@ -51,7 +53,12 @@ func (uc *categoryUseCase) CreateCategory(ctx context.Context, input *dto.Create
 		IsActive:    true,
 	}
 
-	err := uc.repo.Create(ctx, cat)
+	err := uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.repo.Create(ctx, cat); err != nil {
+			return err
+		}
+		return uc.events.Publish(ctx, event.ForCategory(event.CategoryCreated, cat))
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -59,8 +66,15 @@ func (uc *categoryUseCase) CreateCategory(ctx context.Context, input *dto.Create
 	return cat, nil
 }
 
-func (uc *categoryUseCase) GetCategory(ctx context.Context, id string) (*model.Category, error) {
-	return uc.repo.FindByID(ctx, id)
+func (uc *categoryUseCase) GetCategory(ctx context.Context, merchantID, id string) (*model.Category, error) {
+	cat, err := uc.repo.FindByID(ctx, merchantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if cat == nil {
+		return nil, category.ErrCategoryNotFound
+	}
+	return cat, nil
 }
 
 func (uc *categoryUseCase) ListCategories(ctx context.Context, filters *dto.CategoryFilters) ([]model.Category, int, error) {
@@ -79,12 +93,12 @@ func (uc *categoryUseCase) ListCategories(ctx context.Context, filters *dto.Cate
 }
 
 func (uc *categoryUseCase) UpdateCategory(ctx context.Context, input *dto.UpdateCategoryInput) (*model.Category, error) {
-	cat, err := uc.repo.FindByID(ctx, input.ID)
+	cat, err := uc.GetCategory(ctx, input.MerchantID, input.ID)
 	if err != nil {
 		return nil, err
 	}
-	if cat == nil {
-		return nil, errors.New("category not found")
+	if err := uc.checkParent(ctx, input.MerchantID, input.ParentID); err != nil {
+		return nil, err
 	}
 
 	// Update fields
@@ -96,13 +110,45 @@ func (uc *categoryUseCase) UpdateCategory(ctx context.Context, input *dto.Update
 	cat.ParentID = input.ParentID // Handle carefully logic for self-parenting loop check
 	cat.UpdatedAt = time.Now()
 
-	err = uc.repo.Update(ctx, cat)
+	err = uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.repo.Update(ctx, cat); err != nil {
+			return err
+		}
+		return uc.events.Publish(ctx, event.ForCategory(event.CategoryUpdated, cat))
+	})
 	if err != nil {
 		return nil, err
 	}
+
 	return cat, nil
 }
 
-func (uc *categoryUseCase) DeleteCategory(ctx context.Context, id string) error {
-	return uc.repo.Delete(ctx, id)
+func (uc *categoryUseCase) DeleteCategory(ctx context.Context, merchantID, id string) error {
+	return uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Load it first, the event carries the last known state.
+		cat, err := uc.GetCategory(ctx, merchantID, id)
+		if err != nil {
+			return err
+		}
+
+		if err := uc.repo.Delete(ctx, merchantID, id); err != nil {
+			return err
+		}
+		return uc.events.Publish(ctx, event.ForCategory(event.CategoryDeleted, cat))
+	})
+}
+
+// checkParent verifies that a parent category, if any, belongs to the same merchant.
+func (uc *categoryUseCase) checkParent(ctx context.Context, merchantID string, parentID *string) error {
+	if parentID == nil || *parentID == "" {
+		return nil
+	}
+	parent, err := uc.repo.FindByID(ctx, merchantID, *parentID)
+	if err != nil {
+		return err
+	}
+	if parent == nil {
+		return fmt.Errorf("parent %w", category.ErrCategoryNotFound)
+	}
+	return nil
 }
@@ -0,0 +1,13 @@
+package database
+
+import "context"
+
+// ChangeToken returns the oldest transaction still running, for change feeds that stamp their
+// rows with the xid8 of the transaction that wrote them. Every change that a read started
+// afterwards misses was made by this transaction or a later one, so the read's caller can
+// resume from the token with change_xid >= token.
+func ChangeToken(ctx context.Context, conn DBTX) (string, error) {
+	var token string
+	err := conn.GetContext(ctx, &token, `SELECT pg_snapshot_xmin(pg_current_snapshot())::text`)
+	return token, err
+}
@@ -0,0 +1,140 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Row-level security policies on the merchant tables only let a transaction see the rows
+// of the merchant in its app.merchant_id setting, so a query that forgets its merchant_id
+// predicate returns nothing instead of another merchant's rows. Without a tenant in ctx,
+// no merchant rows are visible at all.
+
+type tenantKey struct{}
+
+type tenant struct {
+	merchantID string
+	system     bool
+}
+
+// WithTenant scopes the queries run with ctx to one merchant.
+func WithTenant(ctx context.Context, merchantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant{merchantID: merchantID})
+}
+
+// AsSystem lets the queries run with ctx see every merchant. It is meant for background
+// jobs and operator tools that work across merchants, never for merchant requests.
+func AsSystem(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant{system: true})
+}
+
+// CheckRowLevelSecurity returns an error when the role db connects as skips row-level
+// security, as superusers and BYPASSRLS roles do even on tables that force it.
+func CheckRowLevelSecurity(ctx context.Context, db *sqlx.DB) error {
+	var role struct {
+		Name   string `db:"rolname"`
+		Bypass bool   `db:"bypass"`
+	}
+	query := `SELECT rolname, rolsuper OR rolbypassrls AS bypass FROM pg_roles WHERE rolname = current_user`
+	if err := db.GetContext(ctx, &role, query); err != nil {
+		return fmt.Errorf("failed to read database role: %w", err)
+	}
+	if role.Bypass {
+		return fmt.Errorf("database role %s bypasses row-level security, connect as a role without SUPERUSER and BYPASSRLS such as omnipos_app", role.Name)
+	}
+	return nil
+}
+
+// applyTenant sets the tenant of ctx for the rest of the transaction.
+func applyTenant(ctx context.Context, tx *sqlx.Tx) error {
+	return setTenant(ctx, tx, true)
+}
+
+// setTenant sets both tenant settings from ctx, for the current transaction when local is
+// true and for the rest of the session otherwise.
+func setTenant(ctx context.Context, conn execer, local bool) error {
+	t, _ := ctx.Value(tenantKey{}).(tenant)
+	bypass := "off"
+	if t.system {
+		bypass = "on"
+	}
+	query := `SELECT set_config('app.merchant_id', $1, $3), set_config('app.bypass_rls', $2, $3)`
+	if _, err := conn.ExecContext(ctx, query, t.merchantID, bypass, local); err != nil {
+		return fmt.Errorf("failed to set tenant: %w", err)
+	}
+	return nil
+}
+
+// resetTenant clears both session settings, leaving the connection as if no tenant had
+// been set.
+func resetTenant(ctx context.Context, conn execer) error {
+	return setTenant(context.WithValue(ctx, tenantKey{}, tenant{}), conn, false)
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// tenantConn runs each statement on a pooled connection whose session is set to the tenant
+// of ctx first and reset afterwards: three round trips, where a transaction of its own would
+// take four. A connection that can't be reset is closed instead of going back to the pool,
+// so nothing else handed the connection, such as a migration or a health check, runs as the
+// last tenant.
+type tenantConn struct {
+	db *sqlx.DB
+}
+
+func (c *tenantConn) withConn(ctx context.Context, fn func(conn *sqlx.Conn) error) error {
+	conn, err := c.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Reset even when ctx is done, the statement may have run anyway.
+		if err := resetTenant(context.WithoutCancel(ctx), conn); err != nil {
+			_ = conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}()
+
+	if err := setTenant(ctx, conn, false); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func (c *tenantConn) ExecContext(ctx context.Context, query string, args ...interface{}) (res sql.Result, err error) {
+	err = c.withConn(ctx, func(conn *sqlx.Conn) error {
+		res, err = conn.ExecContext(ctx, query, args...)
+		return err
+	})
+	return res, err
+}
+
+func (c *tenantConn) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return c.withConn(ctx, func(conn *sqlx.Conn) error {
+		return conn.GetContext(ctx, dest, query, args...)
+	})
+}
+
+func (c *tenantConn) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return c.withConn(ctx, func(conn *sqlx.Conn) error {
+		return conn.SelectContext(ctx, dest, query, args...)
+	})
+}
+
+func (c *tenantConn) NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
+	query, args, err := c.db.BindNamed(query, arg)
+	if err != nil {
+		return nil, err
+	}
+	return c.ExecContext(ctx, query, args...)
+}
+
+func (c *tenantConn) Rebind(query string) string {
+	return c.db.Rebind(query)
+}
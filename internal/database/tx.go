@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// DBTX is the query surface shared by *sqlx.Tx and the tenant-scoped connection returned by
+// Conn, so repositories can run the same statements inside or outside a transaction. It
+// only has buffered calls: rows cannot outlive the statement's transaction.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error)
+	Rebind(query string) string
+}
+
+// TxManager runs a function inside a database transaction. Repositories called with the
+// ctx passed to fn take part in that transaction.
+type TxManager interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type txKey struct{}
+
+type sqlxTxManager struct {
+	db *sqlx.DB
+}
+
+func NewTxManager(db *sqlx.DB) TxManager {
+	return &sqlxTxManager{db: db}
+}
+
+func (m *sqlxTxManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return RunInTx(ctx, m.db, fn)
+}
+
+// RunInTx runs fn in a new transaction, or in the caller's transaction if ctx already carries one.
+// A new transaction is scoped to the tenant of ctx before fn runs.
+func RunInTx(ctx context.Context, db *sqlx.DB, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := applyTenant(ctx, tx); err != nil {
+		return err
+	}
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Conn returns the transaction carried by ctx. Without one, it returns a connection that
+// runs each statement on its own, scoped to the tenant of ctx.
+func Conn(ctx context.Context, db *sqlx.DB) DBTX {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return tx
+	}
+	return &tenantConn{db: db}
+}
+
+// NamedGet runs a query with :name parameters taken from arg and scans its single row into dest.
+func NamedGet(ctx context.Context, conn DBTX, dest interface{}, query string, arg interface{}) error {
+	query, args, err := sqlx.Named(query, arg)
+	if err != nil {
+		return err
+	}
+	return conn.GetContext(ctx, dest, conn.Rebind(query), args...)
+}
+
+// NamedSelect runs a query with :name parameters taken from arg and scans its rows into dest.
+func NamedSelect(ctx context.Context, conn DBTX, dest interface{}, query string, arg interface{}) error {
+	query, args, err := sqlx.Named(query, arg)
+	if err != nil {
+		return err
+	}
+	return conn.SelectContext(ctx, dest, conn.Rebind(query), args...)
+}
@@ -0,0 +1,8 @@
+package dto
+
+type DeadLetterFilters struct {
+	Topic    string
+	Status   string
+	Page     int
+	PageSize int
+}
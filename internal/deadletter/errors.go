@@ -0,0 +1,5 @@
+package deadletter
+
+import "github.com/fekuna/omnipos-product-service/internal/apperror"
+
+var ErrDeadLetterNotFound = apperror.New(apperror.NotFound, "DEAD_LETTER_NOT_FOUND", "dead letter not found")
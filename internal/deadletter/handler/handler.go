@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/fekuna/omnipos-pkg/logger"
+	"github.com/fekuna/omnipos-product-service/internal/deadletter"
+	"github.com/fekuna/omnipos-product-service/internal/deadletter/dto"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	productv1 "github.com/fekuna/omnipos-proto/gen/go/omnipos/product/v1"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var _ productv1.DeadLetterServiceServer = (*DeadLetterHandler)(nil)
+
+// DeadLetterHandler is an operator API; dead letters are not scoped to a merchant.
+type DeadLetterHandler struct {
+	productv1.UnimplementedDeadLetterServiceServer
+	uc     deadletter.UseCase
+	logger logger.ZapLogger
+}
+
+func NewDeadLetterHandler(uc deadletter.UseCase, log logger.ZapLogger) *DeadLetterHandler {
+	return &DeadLetterHandler{
+		uc:     uc,
+		logger: log,
+	}
+}
+
+func (h *DeadLetterHandler) ListDeadLetters(ctx context.Context, req *productv1.ListDeadLettersRequest) (*productv1.ListDeadLettersResponse, error) {
+	filters := &dto.DeadLetterFilters{
+		Topic:    req.Topic,
+		Status:   req.Status,
+		Page:     int(req.Page),
+		PageSize: int(req.PageSize),
+	}
+
+	deadLetters, count, err := h.uc.ListDeadLetters(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	protos := make([]*productv1.DeadLetter, len(deadLetters))
+	for i, dl := range deadLetters {
+		protos[i] = mapDeadLetterToProto(&dl)
+	}
+
+	return &productv1.ListDeadLettersResponse{
+		DeadLetters: protos,
+		Total:       int32(count),
+	}, nil
+}
+
+func (h *DeadLetterHandler) ReplayDeadLetter(ctx context.Context, req *productv1.ReplayDeadLetterRequest) (*productv1.DeadLetterResponse, error) {
+	dl, err := h.uc.ReplayDeadLetter(ctx, req.Id)
+	if err != nil {
+		h.logger.Error("failed to replay dead letter", zap.String("dead_letter_id", req.Id), zap.Error(err))
+		return nil, err
+	}
+
+	return &productv1.DeadLetterResponse{DeadLetter: mapDeadLetterToProto(dl)}, nil
+}
+
+func optionalTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func mapDeadLetterToProto(m *model.DeadLetter) *productv1.DeadLetter {
+	if m == nil {
+		return nil
+	}
+
+	return &productv1.DeadLetter{
+		Id:             m.ID,
+		Topic:          m.Topic,
+		Partition:      int32(m.Partition),
+		Offset:         m.Offset,
+		Key:            string(m.MessageKey),
+		Payload:        string(m.Payload),
+		Error:          m.Error,
+		Attempts:       int32(m.Attempts),
+		Status:         m.Status,
+		ReplayCount:    int32(m.ReplayCount),
+		LastReplayedAt: optionalTimestamp(m.LastReplayedAt),
+		CreatedAt:      timestamppb.New(m.CreatedAt),
+		UpdatedAt:      timestamppb.New(m.UpdatedAt),
+	}
+}
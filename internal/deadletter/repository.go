@@ -0,0 +1,15 @@
+package deadletter
+
+import (
+	"context"
+
+	"github.com/fekuna/omnipos-product-service/internal/deadletter/dto"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+)
+
+type Repository interface {
+	Create(ctx context.Context, dl *model.DeadLetter) error
+	FindByID(ctx context.Context, id string) (*model.DeadLetter, error)
+	FindAll(ctx context.Context, filters *dto.DeadLetterFilters) ([]model.DeadLetter, int, error)
+	MarkReplayed(ctx context.Context, dl *model.DeadLetter) error
+}
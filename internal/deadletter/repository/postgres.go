@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fekuna/omnipos-product-service/internal/database"
+	"github.com/fekuna/omnipos-product-service/internal/deadletter/dto"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type PGRepository struct {
+	DB *sqlx.DB
+}
+
+func NewPGRepository(db *sqlx.DB) *PGRepository {
+	return &PGRepository{DB: db}
+}
+
+// conn returns the transaction started by the TxManager if ctx carries one.
+func (r *PGRepository) conn(ctx context.Context) database.DBTX {
+	return database.Conn(ctx, r.DB)
+}
+
+func (r *PGRepository) Create(ctx context.Context, dl *model.DeadLetter) error {
+	query := `
+        INSERT INTO dead_letter_events (
+            id, topic, partition, "offset", message_key, payload, error, attempts,
+            status, replay_count, last_replayed_at, created_at, updated_at
+        )
+        VALUES (
+            :id, :topic, :partition, :offset, :message_key, :payload, :error, :attempts,
+            :status, :replay_count, :last_replayed_at, :created_at, :updated_at
+        )
+    `
+	if _, err := r.conn(ctx).NamedExecContext(ctx, query, dl); err != nil {
+		return fmt.Errorf("failed to store dead letter: %w", err)
+	}
+	return nil
+}
+
+func (r *PGRepository) FindByID(ctx context.Context, id string) (*model.DeadLetter, error) {
+	var dl model.DeadLetter
+	query := `SELECT * FROM dead_letter_events WHERE id = $1`
+	err := r.conn(ctx).GetContext(ctx, &dl, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &dl, nil
+}
+
+func (r *PGRepository) FindAll(ctx context.Context, f *dto.DeadLetterFilters) ([]model.DeadLetter, int, error) {
+	var deadLetters []model.DeadLetter
+	var count int
+
+	conditions := []string{}
+	args := map[string]interface{}{}
+
+	if f.Topic != "" {
+		conditions = append(conditions, "topic = :topic")
+		args["topic"] = f.Topic
+	}
+	if f.Status != "" {
+		conditions = append(conditions, "status = :status")
+		args["status"] = f.Status
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	countQuery := "SELECT count(*) FROM dead_letter_events" + whereClause
+	if err := database.NamedGet(ctx, r.conn(ctx), &count, countQuery, args); err != nil {
+		return nil, 0, err
+	}
+
+	query := "SELECT * FROM dead_letter_events" + whereClause + " ORDER BY created_at DESC"
+	if f.PageSize > 0 {
+		offset := (f.Page - 1) * f.PageSize
+		query += fmt.Sprintf(" LIMIT %d OFFSET %d", f.PageSize, offset)
+	}
+
+	err := database.NamedSelect(ctx, r.conn(ctx), &deadLetters, query, args)
+	return deadLetters, count, err
+}
+
+func (r *PGRepository) MarkReplayed(ctx context.Context, dl *model.DeadLetter) error {
+	query := `
+        UPDATE dead_letter_events
+        SET status = :status,
+            replay_count = :replay_count,
+            last_replayed_at = :last_replayed_at,
+            updated_at = :updated_at
+        WHERE id = :id
+    `
+	_, err := r.conn(ctx).NamedExecContext(ctx, query, dl)
+	return err
+}
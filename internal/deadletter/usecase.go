@@ -0,0 +1,17 @@
+package deadletter
+
+import (
+	"context"
+
+	"github.com/fekuna/omnipos-product-service/internal/deadletter/dto"
+	"github.com/fekuna/omnipos-product-service/internal/messaging"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+)
+
+type UseCase interface {
+	// Record stores a failed message and publishes it to the dead-letter topic.
+	Record(ctx context.Context, msg messaging.Message, cause error, attempts int) (*model.DeadLetter, error)
+	ListDeadLetters(ctx context.Context, filters *dto.DeadLetterFilters) ([]model.DeadLetter, int, error)
+	// ReplayDeadLetter publishes the original payload back to the topic it was consumed from.
+	ReplayDeadLetter(ctx context.Context, id string) (*model.DeadLetter, error)
+}
@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/fekuna/omnipos-pkg/logger"
+	"github.com/fekuna/omnipos-product-service/internal/deadletter"
+	"github.com/fekuna/omnipos-product-service/internal/deadletter/dto"
+	"github.com/fekuna/omnipos-product-service/internal/messaging"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Headers added to dead-lettered and replayed messages.
+const (
+	HeaderDeadLetterID      = "x-dead-letter-id"
+	HeaderError             = "x-error"
+	HeaderAttempts          = "x-attempts"
+	HeaderOriginalTopic     = "x-original-topic"
+	HeaderOriginalPartition = "x-original-partition"
+	HeaderOriginalOffset    = "x-original-offset"
+	HeaderFailedAt          = "x-failed-at"
+	HeaderReplayedFrom      = "x-replayed-from"
+)
+
+type deadLetterUseCase struct {
+	repo      deadletter.Repository
+	publisher messaging.Publisher
+	topic     string // dead-letter topic
+	logger    logger.ZapLogger
+}
+
+func NewDeadLetterUseCase(repo deadletter.Repository, publisher messaging.Publisher, topic string, log logger.ZapLogger) deadletter.UseCase {
+	return &deadLetterUseCase{
+		repo:      repo,
+		publisher: publisher,
+		topic:     topic,
+		logger:    log,
+	}
+}
+
+// Record stores the message before publishing it, so it can still be listed and replayed
+// when the dead-letter topic is unreachable.
+func (uc *deadLetterUseCase) Record(ctx context.Context, msg messaging.Message, cause error, attempts int) (*model.DeadLetter, error) {
+	now := time.Now()
+	dl := &model.DeadLetter{
+		BaseModel:  model.BaseModel{ID: uuid.New().String(), CreatedAt: now, UpdatedAt: now},
+		Topic:      msg.Topic,
+		Partition:  msg.Partition,
+		Offset:     msg.Offset,
+		MessageKey: msg.Key,
+		Payload:    msg.Value,
+		Error:      cause.Error(),
+		Attempts:   attempts,
+		Status:     model.DeadLetterStatusPending,
+	}
+	if err := uc.repo.Create(ctx, dl); err != nil {
+		return nil, err
+	}
+
+	headers := make(map[string]string, len(msg.Headers)+7)
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers[HeaderDeadLetterID] = dl.ID
+	headers[HeaderError] = dl.Error
+	headers[HeaderAttempts] = strconv.Itoa(attempts)
+	headers[HeaderOriginalTopic] = msg.Topic
+	headers[HeaderOriginalPartition] = strconv.Itoa(msg.Partition)
+	headers[HeaderOriginalOffset] = strconv.FormatInt(msg.Offset, 10)
+	headers[HeaderFailedAt] = now.UTC().Format(time.RFC3339)
+
+	err := uc.publisher.Publish(ctx, messaging.Message{
+		Topic:   uc.topic,
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	})
+	if err != nil {
+		uc.logger.Error("Failed to publish to dead-letter topic",
+			zap.String("dead_letter_id", dl.ID),
+			zap.String("topic", uc.topic),
+			zap.Error(err),
+		)
+	}
+	return dl, nil
+}
+
+func (uc *deadLetterUseCase) ListDeadLetters(ctx context.Context, filters *dto.DeadLetterFilters) ([]model.DeadLetter, int, error) {
+	return uc.repo.FindAll(ctx, filters)
+}
+
+// ReplayDeadLetter feeds the message back to its listener. Consumers are idempotent, so a
+// replay of a message that did get applied in the meantime is a no-op.
+func (uc *deadLetterUseCase) ReplayDeadLetter(ctx context.Context, id string) (*model.DeadLetter, error) {
+	dl, err := uc.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if dl == nil {
+		return nil, deadletter.ErrDeadLetterNotFound
+	}
+
+	err = uc.publisher.Publish(ctx, messaging.Message{
+		Topic:   dl.Topic,
+		Key:     dl.MessageKey,
+		Value:   dl.Payload,
+		Headers: map[string]string{HeaderReplayedFrom: dl.ID},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	dl.Status = model.DeadLetterStatusReplayed
+	dl.ReplayCount++
+	dl.LastReplayedAt = &now
+	dl.UpdatedAt = now
+	if err := uc.repo.MarkReplayed(ctx, dl); err != nil {
+		return nil, err
+	}
+
+	uc.logger.Info("Dead letter replayed",
+		zap.String("dead_letter_id", dl.ID),
+		zap.String("topic", dl.Topic),
+		zap.Int("replay_count", dl.ReplayCount),
+	)
+	return dl, nil
+}
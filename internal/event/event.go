@@ -0,0 +1,66 @@
+// Package event defines the domain events this service publishes for other services.
+package event
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SchemaVersion is stamped on every event. Bump it when a payload changes in a way
+// consumers must handle, and keep the old fields while both versions are in flight.
+const SchemaVersion = 1
+
+// Event types
+const (
+	ProductCreated = "ProductCreated"
+	ProductUpdated = "ProductUpdated"
+	ProductDeleted = "ProductDeleted"
+
+	VariantCreated     = "VariantCreated"
+	VariantUpdated     = "VariantUpdated"
+	VariantDeactivated = "VariantDeactivated"
+
+	CategoryCreated = "CategoryCreated"
+	CategoryUpdated = "CategoryUpdated"
+	CategoryDeleted = "CategoryDeleted"
+
+	StockChanged    = "StockChanged"
+	LowStockReached = "LowStockReached"
+)
+
+// Aggregate types. Events of one aggregate share its ID as message key, so they keep their order.
+const (
+	AggregateProduct   = "product" // Products and their variants
+	AggregateCategory  = "category"
+	AggregateInventory = "inventory" // One inventory location
+)
+
+type Event struct {
+	EventID       string      `json:"event_id"`
+	EventType     string      `json:"event_type"`
+	Version       int         `json:"version"`
+	AggregateType string      `json:"aggregate_type"`
+	AggregateID   string      `json:"aggregate_id"`
+	MerchantID    string      `json:"merchant_id"`
+	Payload       interface{} `json:"payload"`
+	Timestamp     time.Time   `json:"timestamp"`
+}
+
+func New(eventType, aggregateType, aggregateID, merchantID string, payload interface{}) Event {
+	return Event{
+		EventID:       uuid.New().String(),
+		EventType:     eventType,
+		Version:       SchemaVersion,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		MerchantID:    merchantID,
+		Payload:       payload,
+		Timestamp:     time.Now().UTC(),
+	}
+}
+
+type Publisher interface {
+	Publish(ctx context.Context, events ...Event) error
+}
@@ -0,0 +1,220 @@
+package event
+
+import (
+	"time"
+
+	"github.com/fekuna/omnipos-product-service/internal/model"
+)
+
+type ProductPayload struct {
+	ID             string    `json:"id"`
+	MerchantID     string    `json:"merchant_id"`
+	CategoryID     *string   `json:"category_id"`
+	SKU            string    `json:"sku"`
+	Barcode        *string   `json:"barcode"`
+	Name           string    `json:"name"`
+	Description    *string   `json:"description"`
+	BasePrice      float64   `json:"base_price"`
+	CostPrice      *float64  `json:"cost_price"`
+	TaxRate        float64   `json:"tax_rate"`
+	HasVariants    bool      `json:"has_variants"`
+	TrackInventory bool      `json:"track_inventory"`
+	ImageURL       *string   `json:"image_url"`
+	IsActive       bool      `json:"is_active"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type VariantPayload struct {
+	ID              string                `json:"id"`
+	ProductID       string                `json:"product_id"`
+	SKU             string                `json:"sku"`
+	Barcode         *string               `json:"barcode"`
+	VariantName     string                `json:"variant_name"`
+	PriceAdjustment float64               `json:"price_adjustment"`
+	CostPrice       *float64              `json:"cost_price"`
+	IsActive        bool                  `json:"is_active"`
+	OptionValues    model.OptionSelection `json:"option_values"`
+	UpdatedAt       time.Time             `json:"updated_at"`
+}
+
+type CategoryPayload struct {
+	ID          string    `json:"id"`
+	MerchantID  string    `json:"merchant_id"`
+	ParentID    *string   `json:"parent_id"`
+	Name        string    `json:"name"`
+	Description *string   `json:"description"`
+	ImageURL    *string   `json:"image_url"`
+	SortOrder   int       `json:"sort_order"`
+	IsActive    bool      `json:"is_active"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type StockChangedPayload struct {
+	InventoryID       string    `json:"inventory_id"`
+	MovementID        string    `json:"movement_id"`
+	StoreID           *string   `json:"store_id"`
+	ProductID         string    `json:"product_id"`
+	VariantID         *string   `json:"variant_id"`
+	MovementType      string    `json:"movement_type"`
+	QuantityChange    float64   `json:"quantity_change"`
+	QuantityBefore    float64   `json:"quantity_before"`
+	QuantityAfter     float64   `json:"quantity_after"`
+	AvailableQuantity *float64  `json:"available_quantity"` // After the movement, when known
+	ReferenceType     *string   `json:"reference_type"`
+	ReferenceID       *string   `json:"reference_id"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+type LowStockPayload struct {
+	InventoryID       string  `json:"inventory_id"`
+	StoreID           *string `json:"store_id"`
+	ProductID         string  `json:"product_id"`
+	VariantID         *string `json:"variant_id"`
+	Quantity          float64 `json:"quantity"`
+	AvailableQuantity float64 `json:"available_quantity"`
+	ReorderPoint      float64 `json:"reorder_point"`
+	ReorderQuantity   float64 `json:"reorder_quantity"`
+}
+
+func ForProduct(eventType string, p *model.Product) Event {
+	return New(eventType, AggregateProduct, p.ID, p.MerchantID, ProductPayload{
+		ID:             p.ID,
+		MerchantID:     p.MerchantID,
+		CategoryID:     p.CategoryID,
+		SKU:            p.SKU,
+		Barcode:        p.Barcode,
+		Name:           p.Name,
+		Description:    p.Description,
+		BasePrice:      p.BasePrice,
+		CostPrice:      p.CostPrice,
+		TaxRate:        p.TaxRate,
+		HasVariants:    p.HasVariants,
+		TrackInventory: p.TrackInventory,
+		ImageURL:       p.ImageURL,
+		IsActive:       p.IsActive,
+		CreatedAt:      p.CreatedAt,
+		UpdatedAt:      p.UpdatedAt,
+	})
+}
+
+// ForVariant keys the event by the parent product so it stays ordered with product events.
+func ForVariant(eventType, merchantID string, v *model.ProductVariant) Event {
+	return New(eventType, AggregateProduct, v.ProductID, merchantID, VariantPayload{
+		ID:              v.ID,
+		ProductID:       v.ProductID,
+		SKU:             v.SKU,
+		Barcode:         v.Barcode,
+		VariantName:     v.VariantName,
+		PriceAdjustment: v.PriceAdjustment,
+		CostPrice:       v.CostPrice,
+		IsActive:        v.IsActive,
+		OptionValues:    v.OptionValues,
+		UpdatedAt:       v.UpdatedAt,
+	})
+}
+
+func ForCategory(eventType string, c *model.Category) Event {
+	return New(eventType, AggregateCategory, c.ID, c.MerchantID, CategoryPayload{
+		ID:          c.ID,
+		MerchantID:  c.MerchantID,
+		ParentID:    c.ParentID,
+		Name:        c.Name,
+		Description: c.Description,
+		ImageURL:    c.ImageURL,
+		SortOrder:   c.SortOrder,
+		IsActive:    c.IsActive,
+		UpdatedAt:   c.UpdatedAt,
+	})
+}
+
+// ForMovement describes one movement of the location inventoryID. available is the
+// location's available quantity after the movement, nil when the caller doesn't know it.
+func ForMovement(inventoryID string, m *model.InventoryMovement, available *float64) Event {
+	return New(StockChanged, AggregateInventory, inventoryID, m.MerchantID, StockChangedPayload{
+		InventoryID:       inventoryID,
+		MovementID:        m.ID,
+		StoreID:           m.StoreID,
+		ProductID:         m.ProductID,
+		VariantID:         m.VariantID,
+		MovementType:      string(m.MovementType),
+		QuantityChange:    m.QuantityChange,
+		QuantityBefore:    m.QuantityBefore,
+		QuantityAfter:     m.QuantityAfter,
+		AvailableQuantity: available,
+		ReferenceType:     m.ReferenceType,
+		ReferenceID:       m.ReferenceID,
+		CreatedAt:         m.CreatedAt,
+	})
+}
+
+// ReachedLowStock reports whether a change of availableChange took the location from above
+// its reorder point to at or below it. Locations without a reorder point never run low.
+func ReachedLowStock(inv *model.Inventory, availableChange float64) bool {
+	if inv.ReorderPoint <= 0 {
+		return false
+	}
+	after := inv.Quantity - inv.ReservedQuantity
+	before := after - availableChange
+	return before > inv.ReorderPoint && after <= inv.ReorderPoint
+}
+
+func ForLowStock(inv *model.Inventory) Event {
+	return New(LowStockReached, AggregateInventory, inv.ID, inv.MerchantID, LowStockPayload{
+		InventoryID:       inv.ID,
+		StoreID:           inv.StoreID,
+		ProductID:         inv.ProductID,
+		VariantID:         inv.VariantID,
+		Quantity:          inv.Quantity,
+		AvailableQuantity: inv.Quantity - inv.ReservedQuantity,
+		ReorderPoint:      inv.ReorderPoint,
+		ReorderQuantity:   inv.ReorderQuantity,
+	})
+}
+
+// ForStockChange builds the StockChanged events of a batch of movements and a LowStockReached
+// for every location the batch took down to its reorder point. invs hold the state after the
+// batch, so only the last movement of each location carries the available quantity.
+func ForStockChange(invs []*model.Inventory, movements []*model.InventoryMovement) []Event {
+	byLocation := make(map[string]*model.Inventory, len(invs))
+	for _, inv := range invs {
+		byLocation[locationKey(inv.StoreID, inv.ProductID, inv.VariantID)] = inv
+	}
+
+	events := make([]Event, 0, len(movements))
+	changes := make(map[*model.Inventory]float64, len(invs))
+	last := make(map[*model.Inventory]int, len(invs))
+	for _, m := range movements {
+		inv := byLocation[locationKey(m.StoreID, m.ProductID, m.VariantID)]
+		if inv == nil {
+			continue
+		}
+		changes[inv] += m.QuantityChange
+		last[inv] = len(events)
+		events = append(events, ForMovement(inv.ID, m, nil))
+	}
+
+	for _, inv := range invs {
+		i, ok := last[inv]
+		if !ok {
+			continue
+		}
+		available := inv.Quantity - inv.ReservedQuantity
+		p := events[i].Payload.(StockChangedPayload)
+		p.AvailableQuantity = &available
+		events[i].Payload = p
+
+		if ReachedLowStock(inv, changes[inv]) {
+			events = append(events, ForLowStock(inv))
+		}
+	}
+	return events
+}
+
+func locationKey(storeID *string, productID string, variantID *string) string {
+	key := model.LocationKey(productID, variantID)
+	if storeID != nil {
+		key = *storeID + "/" + key
+	}
+	return key
+}
@@ -0,0 +1,62 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/fekuna/omnipos-product-service/internal/messaging"
+)
+
+// Headers set on every published event, so consumers can route without decoding the payload.
+const (
+	HeaderEventType = "x-event-type"
+	HeaderVersion   = "x-event-version"
+)
+
+// Producer publishes events to one topic per aggregate family, keyed by aggregate ID.
+type Producer struct {
+	publisher      messaging.Publisher
+	catalogTopic   string // Products, variants and categories
+	inventoryTopic string
+}
+
+func NewProducer(publisher messaging.Publisher, catalogTopic, inventoryTopic string) *Producer {
+	return &Producer{
+		publisher:      publisher,
+		catalogTopic:   catalogTopic,
+		inventoryTopic: inventoryTopic,
+	}
+}
+
+func (p *Producer) Publish(ctx context.Context, events ...Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	msgs := make([]messaging.Message, len(events))
+	for i, e := range events {
+		value, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s event: %w", e.EventType, err)
+		}
+		msgs[i] = messaging.Message{
+			Topic: p.topic(e.AggregateType),
+			Key:   []byte(e.AggregateID),
+			Value: value,
+			Headers: map[string]string{
+				HeaderEventType: e.EventType,
+				HeaderVersion:   strconv.Itoa(e.Version),
+			},
+		}
+	}
+	return p.publisher.Publish(ctx, msgs...)
+}
+
+func (p *Producer) topic(aggregateType string) string {
+	if aggregateType == AggregateInventory {
+		return p.inventoryTopic
+	}
+	return p.catalogTopic
+}
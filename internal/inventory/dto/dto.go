@@ -1,12 +1,17 @@
 package dto
 
-import "time"
+import (
+	"time"
+
+	"github.com/fekuna/omnipos-product-service/internal/model"
+)
 
 type InventoryFilters struct {
 	MerchantID string
 	StoreID    *string // Nil for global/warehouse if we distinguish, or just filter
 	ProductID  string
-	LowStock   bool // If true, filter by available_quantity <= reorder_point
+	VariantID  *string // Nil for any, empty for the product's own row
+	LowStock   bool    // If true, filter by available_quantity <= reorder_point
 	Page       int
 	PageSize   int
 }
@@ -15,9 +20,88 @@ type MovementFilters struct {
 	MerchantID   string
 	ProductID    string
 	StoreID      *string
-	MovementType string
+	MovementType model.MovementType
 	StartDate    *time.Time
 	EndDate      *time.Time
 	Page         int
 	PageSize     int
 }
+
+type StockShortageFilters struct {
+	MerchantID string
+	StoreID    *string
+	Status     string
+	Page       int
+	PageSize   int
+}
+
+type OrderSaleResult struct {
+	AlreadyProcessed bool // The event or every line of the order was applied before
+	Applied          int
+	Skipped          int // Lines applied by an earlier delivery
+	Shortages        int // Lines that overdrew stock under InsufficientStockAllowNegative
+}
+
+type OrderReturnResult struct {
+	AlreadyProcessed bool
+	Restocked        float64
+	WrittenOff       float64
+}
+
+// StockLevel is a sum of inventory quantities.
+type StockLevel struct {
+	Quantity          float64
+	ReservedQuantity  float64
+	AvailableQuantity float64
+}
+
+func (l *StockLevel) Add(inv *model.Inventory) {
+	l.Quantity += inv.Quantity
+	l.ReservedQuantity += inv.ReservedQuantity
+	l.AvailableQuantity += inv.AvailableQuantity
+}
+
+// ProductStock rolls the stock of a product up over its variants and stores.
+type ProductStock struct {
+	ProductID string
+	Total     StockLevel
+	Variants  []VariantStock // Over all stores, the product's own row has a nil VariantID
+	Stores    []StoreStock   // Over all variants, the warehouse has a nil StoreID
+}
+
+type VariantStock struct {
+	VariantID *string
+	StockLevel
+	LowStock bool // At or below the reorder point in at least one store
+}
+
+type StoreStock struct {
+	StoreID *string
+	StockLevel
+}
+
+// BarcodeMatch is a product or variant found by its barcode.
+type BarcodeMatch struct {
+	ProductID string  `db:"product_id"`
+	VariantID *string `db:"variant_id"`
+}
+
+// StockAvailability is what can be sold of a product or variant at each store, most first.
+type StockAvailability struct {
+	ProductID string
+	VariantID *string
+	Stores    []StoreAvailability
+}
+
+type StoreAvailability struct {
+	StoreID           *string // Nil for the warehouse
+	AvailableQuantity float64
+}
+
+// StockUpdate is one message of a stock watch. A location can be sent again without having
+// changed, so watchers apply updates as upserts.
+type StockUpdate struct {
+	Snapshot    bool // Items are every location of the store, replacing what the watcher holds
+	Items       []model.Inventory
+	ResumeToken string // Resumes the watch after this update
+}
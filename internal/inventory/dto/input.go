@@ -1,14 +1,17 @@
 package dto
 
+import "github.com/fekuna/omnipos-product-service/internal/model"
+
 type AdjustInventoryInput struct {
 	MerchantID     string
 	StoreID        *string
 	ProductID      string
 	VariantID      *string
+	MovementType   model.MovementType // Adjustment when empty
 	QuantityChange float64
 	Reason         string
 	ReferenceID    string
-	ReferenceType  string // 'manual_adjustment', 'sale', 'return'
+	ReferenceType  string // 'adjustment' when empty
 	UserID         string
 }
 
@@ -22,3 +25,56 @@ type TransferInventoryInput struct {
 	Reason        string
 	UserID        string
 }
+
+type OrderSaleInput struct {
+	EventID    string // Empty for producers that don't send one, lines are still deduplicated
+	EventType  string
+	MerchantID string
+	OrderID    string
+	StoreID    *string
+	Lines      []OrderLineInput
+}
+
+type OrderLineInput struct {
+	ProductID string
+	VariantID *string
+	Quantity  float64
+}
+
+// OrderReturnInput puts sold stock back, for cancellations, refunds and returns.
+type OrderReturnInput struct {
+	EventID    string // Required, a reversal is applied once per event
+	EventType  string
+	MerchantID string
+	OrderID    string
+	Notes      string
+	Lines      []OrderReturnLineInput // Empty to return everything not yet returned
+}
+
+type OrderReturnLineInput struct {
+	ProductID string
+	VariantID *string
+	Quantity  float64
+	Damaged   bool // Written off instead of going back to sellable stock
+}
+
+type ResolveStockShortageInput struct {
+	MerchantID string
+	ShortageID string
+	Notes      string
+	UserID     string
+}
+
+type StockAvailabilityInput struct {
+	MerchantID string
+	ProductID  string
+	VariantID  *string
+	Barcode    string   // Resolves ProductID and VariantID when ProductID is empty
+	StoreIDs   []string // Empty for every store, an empty ID for the warehouse
+}
+
+type WatchStockInput struct {
+	MerchantID  string
+	StoreID     *string // Nil for the warehouse
+	ResumeToken string  // From the last update received, empty to start with a snapshot
+}
@@ -0,0 +1,23 @@
+package inventory
+
+import "github.com/fekuna/omnipos-product-service/internal/apperror"
+
+// Errors that retrying cannot fix.
+var (
+	ErrInsufficientInventory = apperror.New(apperror.FailedPrecondition, "INSUFFICIENT_INVENTORY", "insufficient inventory{{if .ProductID}} for product {{.ProductID}}{{end}}")
+	ErrInvalidQuantity       = apperror.Invalid("INVALID_QUANTITY", "quantity", "quantity must be positive")
+	ErrEventIDRequired       = apperror.Invalid("EVENT_ID_REQUIRED", "event_id", "event id is required")
+	ErrReturnExceedsSale     = apperror.New(apperror.FailedPrecondition, "RETURN_EXCEEDS_SALE", "returned quantity of product {{.ProductID}} exceeds what was sold")
+	ErrSameStore             = apperror.Invalid("SAME_STORE", "target_store_id", "source and target store must differ")
+	ErrLocationGone          = apperror.New(apperror.FailedPrecondition, "INVENTORY_LOCATION_GONE", "inventory for product {{.ProductID}} no longer exists")
+	ErrInvalidMovementType   = apperror.Invalid("INVALID_MOVEMENT_TYPE", "movement_type", `movement type "{{.MovementType}}" cannot be used here`)
+	ErrMovementDirection     = apperror.Invalid("MOVEMENT_DIRECTION", "quantity_change", "a {{.MovementType}} movement cannot {{if gt .Direction 0}}remove{{else}}add{{end}} stock")
+	ErrStockShortageNotFound = apperror.New(apperror.NotFound, "STOCK_SHORTAGE_NOT_FOUND", "stock shortage not found")
+	ErrItemRequired          = apperror.Invalid("PRODUCT_OR_BARCODE_REQUIRED", "product_id", "either a product or a barcode is required")
+	ErrBarcodeNotFound       = apperror.New(apperror.NotFound, "BARCODE_NOT_FOUND", `no product carries barcode "{{.Barcode}}"`)
+	ErrBarcodeAmbiguous      = apperror.Invalid("BARCODE_AMBIGUOUS", "barcode", `barcode "{{.Barcode}}" belongs to several products, pass the product instead`)
+	ErrInvalidResumeToken    = apperror.Invalid("INVALID_RESUME_TOKEN", "resume_token", "resume token is not valid")
+)
+
+// ErrInventoryBusy is returned when another transaction created the same location first.
+var ErrInventoryBusy = apperror.New(apperror.Aborted, "INVENTORY_BUSY", "system busy, please try again later")
@@ -0,0 +1,18 @@
+package inventory
+
+// StockChannelPrefix starts the Redis channel of every merchant's stock wakeups. The
+// message is the store whose stock changed, empty for the warehouse.
+const StockChannelPrefix = "inventory:stock:"
+
+// StockChannel is the Redis channel of a merchant's stock wakeups.
+func StockChannel(merchantID string) string {
+	return StockChannelPrefix + merchantID
+}
+
+// StockFeed wakes stock watchers up after the stock of a store changed. Wakeups carry no
+// data and can be lost, so a watcher rereads the store from its resume token.
+type StockFeed interface {
+	// Subscribe returns a channel receiving a value after changes at the store, coalescing
+	// wakeups the watcher has not taken yet, and a func ending the subscription.
+	Subscribe(merchantID string, storeID *string) (<-chan struct{}, func())
+}
@@ -9,9 +9,7 @@ import (
 	"github.com/fekuna/omnipos-product-service/internal/inventory/dto"
 	"github.com/fekuna/omnipos-product-service/internal/model"
 	productv1 "github.com/fekuna/omnipos-proto/gen/go/omnipos/product/v1"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/metadata"
-	"google.golang.org/grpc/status"
+	"google.golang.org/grpc"
 	"google.golang.org/protobuf/types/known/emptypb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
@@ -38,16 +36,144 @@ func (h *InventoryHandler) GetProductInventory(ctx context.Context, req *product
 		storeID = &s
 	}
 
-	inv, err := h.uc.GetProductInventory(ctx, merchantID, req.ProductId, storeID)
+	variantID := (*string)(nil)
+	if req.VariantId != "" {
+		v := req.VariantId
+		variantID = &v
+	}
+
+	items, err := h.uc.GetProductInventory(ctx, &dto.InventoryFilters{
+		MerchantID: merchantID,
+		ProductID:  req.ProductId,
+		VariantID:  variantID,
+		StoreID:    storeID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*productv1.InventoryEntry, len(items))
+	for i, item := range items {
+		entries[i] = mapInventoryToProto(&item)
+	}
+
+	return &productv1.ProductInventoryResponse{Inventory: entries}, nil
+}
+
+func (h *InventoryHandler) GetProductStock(ctx context.Context, req *productv1.GetProductStockRequest) (*productv1.ProductStockResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+
+	stock, err := h.uc.GetProductStock(ctx, merchantID, req.ProductId)
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, err
+	}
+
+	variants := make([]*productv1.VariantStock, len(stock.Variants))
+	for i, v := range stock.Variants {
+		variantID := ""
+		if v.VariantID != nil {
+			variantID = *v.VariantID
+		}
+		variants[i] = &productv1.VariantStock{
+			VariantId: variantID,
+			Stock:     mapStockLevelToProto(v.StockLevel),
+			LowStock:  v.LowStock,
+		}
+	}
+
+	stores := make([]*productv1.StoreStock, len(stock.Stores))
+	for i, s := range stock.Stores {
+		storeID := ""
+		if s.StoreID != nil {
+			storeID = *s.StoreID
+		}
+		stores[i] = &productv1.StoreStock{
+			StoreId: storeID,
+			Stock:   mapStockLevelToProto(s.StockLevel),
+		}
 	}
 
-	return &productv1.ProductInventoryResponse{
-		Inventory: []*productv1.InventoryEntry{mapInventoryToProto(inv)},
+	return &productv1.ProductStockResponse{
+		ProductId: stock.ProductID,
+		Total:     mapStockLevelToProto(stock.Total),
+		Variants:  variants,
+		Stores:    stores,
 	}, nil
 }
 
+func (h *InventoryHandler) GetStockAvailability(ctx context.Context, req *productv1.GetStockAvailabilityRequest) (*productv1.StockAvailabilityResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+
+	variantID := (*string)(nil)
+	if req.VariantId != "" {
+		v := req.VariantId
+		variantID = &v
+	}
+
+	availability, err := h.uc.GetStockAvailability(ctx, &dto.StockAvailabilityInput{
+		MerchantID: merchantID,
+		ProductID:  req.ProductId,
+		VariantID:  variantID,
+		Barcode:    req.Barcode,
+		StoreIDs:   req.StoreIds,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stores := make([]*productv1.StoreAvailability, len(availability.Stores))
+	for i, s := range availability.Stores {
+		storeID := ""
+		if s.StoreID != nil {
+			storeID = *s.StoreID
+		}
+		stores[i] = &productv1.StoreAvailability{
+			StoreId:           storeID,
+			AvailableQuantity: s.AvailableQuantity,
+		}
+	}
+
+	resp := &productv1.StockAvailabilityResponse{
+		ProductId: availability.ProductID,
+		Stores:    stores,
+	}
+	if availability.VariantID != nil {
+		resp.VariantId = *availability.VariantID
+	}
+	return resp, nil
+}
+
+// WatchStock streams the stock of one store to a POS terminal: a snapshot, then the
+// locations changed by every commit. A terminal reconnecting with the resume token of the
+// last update it got receives only the changes it missed.
+func (h *InventoryHandler) WatchStock(req *productv1.WatchStockRequest, stream grpc.ServerStreamingServer[productv1.StockUpdate]) error {
+	ctx := stream.Context()
+
+	storeID := (*string)(nil)
+	if req.StoreId != "" {
+		s := req.StoreId
+		storeID = &s
+	}
+
+	input := &dto.WatchStockInput{
+		MerchantID:  auth.GetMerchantID(ctx),
+		StoreID:     storeID,
+		ResumeToken: req.ResumeToken,
+	}
+
+	return h.uc.WatchStock(ctx, input, func(u *dto.StockUpdate) error {
+		entries := make([]*productv1.InventoryEntry, len(u.Items))
+		for i, item := range u.Items {
+			entries[i] = mapInventoryToProto(&item)
+		}
+		return stream.Send(&productv1.StockUpdate{
+			Snapshot:    u.Snapshot,
+			Inventory:   entries,
+			ResumeToken: u.ResumeToken,
+		})
+	})
+}
+
 func (h *InventoryHandler) ListLowStock(ctx context.Context, req *productv1.ListLowStockRequest) (*productv1.ListLowStockResponse, error) {
 	merchantID := auth.GetMerchantID(ctx)
 
@@ -57,9 +183,22 @@ func (h *InventoryHandler) ListLowStock(ctx context.Context, req *productv1.List
 		storeID = &s
 	}
 
-	items, count, err := h.uc.ListLowStock(ctx, merchantID, storeID, int(req.Page), int(req.PageSize))
+	variantID := (*string)(nil)
+	if req.VariantId != "" {
+		v := req.VariantId
+		variantID = &v
+	}
+
+	items, count, err := h.uc.ListLowStock(ctx, &dto.InventoryFilters{
+		MerchantID: merchantID,
+		StoreID:    storeID,
+		ProductID:  req.ProductId,
+		VariantID:  variantID,
+		Page:       int(req.Page),
+		PageSize:   int(req.PageSize),
+	})
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, err
 	}
 
 	entries := make([]*productv1.InventoryEntry, len(items))
@@ -76,12 +215,7 @@ func (h *InventoryHandler) ListLowStock(ctx context.Context, req *productv1.List
 func (h *InventoryHandler) AdjustInventory(ctx context.Context, req *productv1.AdjustInventoryRequest) (*productv1.InventoryEntry, error) {
 	merchantID := auth.GetMerchantID(ctx)
 
-	userID := ""
-	if md, ok := metadata.FromIncomingContext(ctx); ok {
-		if val := md.Get("x-user-id"); len(val) > 0 {
-			userID = val[0]
-		}
-	}
+	userID := auth.GetUserID(ctx)
 
 	storeID := (*string)(nil)
 	if req.StoreId != "" {
@@ -100,23 +234,60 @@ func (h *InventoryHandler) AdjustInventory(ctx context.Context, req *productv1.A
 		StoreID:        storeID,
 		ProductID:      req.ProductId,
 		VariantID:      variantID,
+		MovementType:   model.MovementType(req.MovementType),
 		QuantityChange: req.QuantityChange,
 		Reason:         req.Reason,
 		ReferenceID:    req.ReferenceId,
-		ReferenceType:  "manual", // Simplified
 		UserID:         userID,
 	}
 
 	inv, err := h.uc.AdjustInventory(ctx, input)
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, err
 	}
 
 	return mapInventoryToProto(inv), nil
 }
 
 func (h *InventoryHandler) TransferInventory(ctx context.Context, req *productv1.TransferInventoryRequest) (*emptypb.Empty, error) {
-	return nil, status.Error(codes.Unimplemented, "Transfer not implemented yet")
+	merchantID := auth.GetMerchantID(ctx)
+
+	userID := auth.GetUserID(ctx)
+
+	sourceStoreID := (*string)(nil)
+	if req.SourceStoreId != "" {
+		s := req.SourceStoreId
+		sourceStoreID = &s
+	}
+
+	targetStoreID := (*string)(nil)
+	if req.TargetStoreId != "" {
+		s := req.TargetStoreId
+		targetStoreID = &s
+	}
+
+	variantID := (*string)(nil)
+	if req.VariantId != "" {
+		v := req.VariantId
+		variantID = &v
+	}
+
+	input := &dto.TransferInventoryInput{
+		MerchantID:    merchantID,
+		SourceStoreID: sourceStoreID,
+		TargetStoreID: targetStoreID,
+		ProductID:     req.ProductId,
+		VariantID:     variantID,
+		Quantity:      req.Quantity,
+		Reason:        req.Reason,
+		UserID:        userID,
+	}
+
+	if err := h.uc.TransferInventory(ctx, input); err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
 }
 
 func (h *InventoryHandler) ListInventoryMovements(ctx context.Context, req *productv1.ListInventoryMovementsRequest) (*productv1.ListInventoryMovementsResponse, error) {
@@ -132,14 +303,14 @@ func (h *InventoryHandler) ListInventoryMovements(ctx context.Context, req *prod
 		MerchantID:   merchantID,
 		ProductID:    req.ProductId,
 		StoreID:      sID,
-		MovementType: req.MovementType,
+		MovementType: model.MovementType(req.MovementType),
 		Page:         int(req.Page),
 		PageSize:     int(req.PageSize),
 	}
 
 	mvs, count, err := h.uc.ListMovements(ctx, filters)
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, err
 	}
 
 	protoMovements := make([]*productv1.InventoryMovement, len(mvs))
@@ -153,6 +324,59 @@ func (h *InventoryHandler) ListInventoryMovements(ctx context.Context, req *prod
 	}, nil
 }
 
+func (h *InventoryHandler) ListStockShortages(ctx context.Context, req *productv1.ListStockShortagesRequest) (*productv1.ListStockShortagesResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+
+	sID := (*string)(nil)
+	if req.StoreId != "" {
+		s := req.StoreId
+		sID = &s
+	}
+
+	filters := &dto.StockShortageFilters{
+		MerchantID: merchantID,
+		StoreID:    sID,
+		Status:     req.Status,
+		Page:       int(req.Page),
+		PageSize:   int(req.PageSize),
+	}
+
+	shortages, count, err := h.uc.ListStockShortages(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	protos := make([]*productv1.StockShortage, len(shortages))
+	for i, s := range shortages {
+		protos[i] = mapShortageToProto(&s)
+	}
+
+	return &productv1.ListStockShortagesResponse{
+		Shortages: protos,
+		Total:     int32(count),
+	}, nil
+}
+
+func (h *InventoryHandler) ResolveStockShortage(ctx context.Context, req *productv1.ResolveStockShortageRequest) (*productv1.StockShortage, error) {
+	merchantID := auth.GetMerchantID(ctx)
+
+	userID := auth.GetUserID(ctx)
+
+	input := &dto.ResolveStockShortageInput{
+		MerchantID: merchantID,
+		ShortageID: req.Id,
+		Notes:      req.Notes,
+		UserID:     userID,
+	}
+
+	s, err := h.uc.ResolveStockShortage(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapShortageToProto(s), nil
+}
+
 func mapInventoryToProto(m *model.Inventory) *productv1.InventoryEntry {
 	if m == nil {
 		return nil
@@ -211,21 +435,82 @@ func mapMovementToProto(m *model.InventoryMovement) *productv1.InventoryMovement
 	if m.CreatedBy != nil {
 		createdBy = *m.CreatedBy
 	}
+	reversesID := ""
+	if m.ReversesMovementID != nil {
+		reversesID = *m.ReversesMovementID
+	}
 
 	return &productv1.InventoryMovement{
-		Id:             m.ID,
-		MerchantId:     m.MerchantID,
-		StoreId:        storeID,
-		ProductId:      m.ProductID,
-		VariantId:      variantID,
-		MovementType:   m.MovementType,
-		QuantityChange: m.QuantityChange,
-		QuantityBefore: m.QuantityBefore,
-		QuantityAfter:  m.QuantityAfter,
-		ReferenceType:  refType,
-		ReferenceId:    refID,
-		Notes:          m.Notes,
-		CreatedBy:      createdBy,
-		CreatedAt:      timestamppb.New(m.CreatedAt),
+		Id:                 m.ID,
+		MerchantId:         m.MerchantID,
+		StoreId:            storeID,
+		ProductId:          m.ProductID,
+		VariantId:          variantID,
+		MovementType:       string(m.MovementType),
+		QuantityChange:     m.QuantityChange,
+		QuantityBefore:     m.QuantityBefore,
+		QuantityAfter:      m.QuantityAfter,
+		ReferenceType:      refType,
+		ReferenceId:        refID,
+		ReversesMovementId: reversesID,
+		Notes:              m.Notes,
+		CreatedBy:          createdBy,
+		CreatedAt:          timestamppb.New(m.CreatedAt),
+	}
+}
+
+func mapStockLevelToProto(l dto.StockLevel) *productv1.StockLevel {
+	return &productv1.StockLevel{
+		Quantity:          l.Quantity,
+		ReservedQuantity:  l.ReservedQuantity,
+		AvailableQuantity: l.AvailableQuantity,
+	}
+}
+
+func mapShortageToProto(m *model.StockShortage) *productv1.StockShortage {
+	if m == nil {
+		return nil
+	}
+
+	storeID := ""
+	if m.StoreID != nil {
+		storeID = *m.StoreID
+	}
+	variantID := ""
+	if m.VariantID != nil {
+		variantID = *m.VariantID
+	}
+	movementID := ""
+	if m.MovementID != nil {
+		movementID = *m.MovementID
+	}
+	notes := ""
+	if m.Notes != nil {
+		notes = *m.Notes
+	}
+	resolvedBy := ""
+	if m.ResolvedBy != nil {
+		resolvedBy = *m.ResolvedBy
+	}
+	var resolvedAt *timestamppb.Timestamp
+	if m.ResolvedAt != nil {
+		resolvedAt = timestamppb.New(*m.ResolvedAt)
+	}
+
+	return &productv1.StockShortage{
+		Id:            m.ID,
+		MerchantId:    m.MerchantID,
+		InventoryId:   m.InventoryID,
+		StoreId:       storeID,
+		ProductId:     m.ProductID,
+		VariantId:     variantID,
+		OrderId:       m.OrderID,
+		MovementId:    movementID,
+		QuantityShort: m.QuantityShort,
+		Status:        m.Status,
+		Notes:         notes,
+		ResolvedBy:    resolvedBy,
+		ResolvedAt:    resolvedAt,
+		CreatedAt:     timestamppb.New(m.CreatedAt),
 	}
 }
@@ -3,26 +3,36 @@ package listener
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"time"
 
-	"github.com/fekuna/omnipos-pkg/broker"
 	"github.com/fekuna/omnipos-pkg/logger"
+	"github.com/fekuna/omnipos-product-service/internal/database"
+	"github.com/fekuna/omnipos-product-service/internal/deadletter"
 	"github.com/fekuna/omnipos-product-service/internal/inventory"
 	"github.com/fekuna/omnipos-product-service/internal/inventory/dto"
+	"github.com/fekuna/omnipos-product-service/internal/messaging"
+	"github.com/fekuna/omnipos-product-service/internal/product"
 	"go.uber.org/zap"
 )
 
 type InventoryListener struct {
-	consumer *broker.KafkaConsumer
-	uc       inventory.UseCase
-	logger   logger.ZapLogger
+	consumer     messaging.Reader
+	uc           inventory.UseCase
+	reservations product.UseCase
+	deadLetters  deadletter.UseCase
+	retry        RetryPolicy
+	logger       logger.ZapLogger
 }
 
-func NewInventoryListener(consumer *broker.KafkaConsumer, uc inventory.UseCase, logger logger.ZapLogger) *InventoryListener {
+func NewInventoryListener(consumer messaging.Reader, uc inventory.UseCase, reservations product.UseCase, deadLetters deadletter.UseCase, retry RetryPolicy, logger logger.ZapLogger) *InventoryListener {
 	return &InventoryListener{
-		consumer: consumer,
-		uc:       uc,
-		logger:   logger,
+		consumer:     consumer,
+		uc:           uc,
+		reservations: reservations,
+		deadLetters:  deadLetters,
+		retry:        retry,
+		logger:       logger,
 	}
 }
 
@@ -44,12 +54,88 @@ func (l *InventoryListener) Start(ctx context.Context) {
 				time.Sleep(1 * time.Second)
 				continue
 			}
-			l.processMessage(ctx, msg.Value)
+			l.handleMessage(ctx, msg)
 		}
 	}
 }
 
-type OrderCreatedEvent struct {
+// handleMessage retries transient failures with backoff and dead-letters the message once
+// the attempts run out or the failure is permanent.
+func (l *InventoryListener) handleMessage(ctx context.Context, msg messaging.Message) {
+	attempt := 1
+	for {
+		err := l.processMessage(ctx, msg.Value)
+		if err == nil {
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		if !isRetryable(err) || attempt >= l.retry.MaxAttempts {
+			l.deadLetter(ctx, msg, err, attempt)
+			return
+		}
+
+		wait := l.retry.Backoff(attempt)
+		l.logger.Warn("Retrying inventory event",
+			zap.Int64("offset", msg.Offset),
+			zap.Int("attempt", attempt),
+			zap.Duration("backoff", wait),
+			zap.Error(err),
+		)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		attempt++
+	}
+}
+
+// deadLetter records the message, retrying with backoff until the record is stored. The
+// reader has already committed the offset, so giving up would lose the message; the
+// partition waits instead, as it does while a message is retried.
+func (l *InventoryListener) deadLetter(ctx context.Context, msg messaging.Message, cause error, attempts int) {
+	for try := 1; ; try++ {
+		dl, err := l.deadLetters.Record(ctx, msg, cause, attempts)
+		if err == nil {
+			l.logger.Error("Inventory event dead-lettered",
+				zap.String("dead_letter_id", dl.ID),
+				zap.Int64("offset", msg.Offset),
+				zap.Int("attempts", attempts),
+				zap.Error(cause),
+			)
+			return
+		}
+
+		wait := l.retry.Backoff(try)
+		l.logger.Error("Failed to dead-letter inventory event",
+			zap.String("topic", msg.Topic),
+			zap.Int64("offset", msg.Offset),
+			zap.Duration("backoff", wait),
+			zap.NamedError("cause", cause),
+			zap.Error(err),
+		)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Order event types consumed by the listener.
+const (
+	EventOrderCreated      = "OrderCreated"
+	EventOrderCancelled    = "OrderCancelled"
+	EventOrderRefunded     = "OrderRefunded"
+	EventOrderItemReturned = "OrderItemReturned"
+)
+
+type OrderEvent struct {
 	EventID   string       `json:"event_id"`
 	EventType string       `json:"event_type"`
 	Payload   OrderPayload `json:"payload"`
@@ -60,49 +146,157 @@ type OrderPayload struct {
 	ID         string             `json:"id"`
 	MerchantID string             `json:"merchant_id"`
 	StoreID    string             `json:"store_id"`
-	Items      []OrderItemPayload `json:"items"`
+	Items      []OrderItemPayload `json:"items"` // Refunds without items refund the whole order
 }
 
 type OrderItemPayload struct {
 	ProductID string  `json:"product_id"`
 	VariantID *string `json:"variant_id"`
 	Quantity  float64 `json:"quantity"`
+	Condition string  `json:"condition"` // Returns only, "damaged" items are written off
 }
 
-func (l *InventoryListener) processMessage(ctx context.Context, value []byte) {
-	var event OrderCreatedEvent
+func (l *InventoryListener) processMessage(ctx context.Context, value []byte) error {
+	var event OrderEvent
 	if err := json.Unmarshal(value, &event); err != nil {
-		l.logger.Error("Failed to unmarshal event", zap.Error(err))
-		return
+		return fmt.Errorf("%w: %v", errMalformedEvent, err)
 	}
+	ctx = database.WithTenant(ctx, event.Payload.MerchantID)
 
-	if event.EventType != "OrderCreated" {
-		return
+	switch event.EventType {
+	case EventOrderCreated:
+		return l.handleOrderCreated(ctx, &event)
+	case EventOrderCancelled:
+		return l.handleOrderCancelled(ctx, &event)
+	case EventOrderRefunded:
+		return l.handleOrderReturn(ctx, &event, "Order refunded")
+	case EventOrderItemReturned:
+		if len(event.Payload.Items) == 0 {
+			return fmt.Errorf("%w: return without items", errMalformedEvent)
+		}
+		return l.handleOrderReturn(ctx, &event, "Order item returned")
+	default:
+		return nil
 	}
+}
 
-	l.logger.Info("Processing OrderCreated event", zap.String("order_id", event.Payload.ID))
+func (l *InventoryListener) handleOrderCreated(ctx context.Context, event *OrderEvent) error {
+	l.logger.Info("Processing OrderCreated event",
+		zap.String("event_id", event.EventID),
+		zap.String("order_id", event.Payload.ID),
+	)
 
-	for _, item := range event.Payload.Items {
-		input := &dto.AdjustInventoryInput{
-			MerchantID:     event.Payload.MerchantID,
-			StoreID:        &event.Payload.StoreID,
-			ProductID:      item.ProductID,
-			VariantID:      item.VariantID,
-			QuantityChange: -item.Quantity, // Deduction
-			Reason:         "Order Sale",
-			ReferenceID:    event.Payload.ID,
-			ReferenceType:  "sale",
-			UserID:         "system",
+	var storeID *string
+	if event.Payload.StoreID != "" {
+		storeID = &event.Payload.StoreID
+	}
+
+	lines := make([]dto.OrderLineInput, len(event.Payload.Items))
+	for i, item := range event.Payload.Items {
+		lines[i] = dto.OrderLineInput{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
 		}
+	}
 
-		_, err := l.uc.AdjustInventory(ctx, input)
-		if err != nil {
-			l.logger.Error("Failed to adjust inventory for order item",
-				zap.String("order_id", event.Payload.ID),
-				zap.String("product_id", item.ProductID),
-				zap.Error(err),
-			)
-			// TODO: Retry?
+	input := &dto.OrderSaleInput{
+		EventID:    event.EventID,
+		EventType:  event.EventType,
+		MerchantID: event.Payload.MerchantID,
+		OrderID:    event.Payload.ID,
+		StoreID:    storeID,
+		Lines:      lines,
+	}
+
+	res, err := l.uc.ApplyOrderSale(ctx, input)
+	if err != nil {
+		l.logger.Error("Failed to apply order to inventory",
+			zap.String("event_id", event.EventID),
+			zap.String("order_id", event.Payload.ID),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	if res.AlreadyProcessed {
+		l.logger.Info("Skipping order that was already applied",
+			zap.String("event_id", event.EventID),
+			zap.String("order_id", event.Payload.ID),
+		)
+		return nil
+	}
+	if res.Skipped > 0 {
+		l.logger.Info("Skipped order lines applied by an earlier delivery",
+			zap.String("order_id", event.Payload.ID),
+			zap.Int("skipped", res.Skipped),
+			zap.Int("applied", res.Applied),
+		)
+	}
+	return nil
+}
+
+// handleOrderCancelled gives back a hold that was never committed and reverses whatever
+// was sold. The hold is released first: it is idempotent, while the reversal marks the event
+// processed.
+func (l *InventoryListener) handleOrderCancelled(ctx context.Context, event *OrderEvent) error {
+	l.logger.Info("Processing OrderCancelled event",
+		zap.String("event_id", event.EventID),
+		zap.String("order_id", event.Payload.ID),
+	)
+
+	res, err := l.reservations.ReleaseActiveReservation(ctx, event.Payload.MerchantID, event.Payload.ID)
+	if err != nil {
+		l.logger.Error("Failed to release reservation of cancelled order",
+			zap.String("order_id", event.Payload.ID),
+			zap.Error(err),
+		)
+		return err
+	}
+	if res != nil {
+		l.logger.Info("Released reservation of cancelled order", zap.String("order_id", event.Payload.ID))
+	}
+
+	event.Payload.Items = nil
+	return l.handleOrderReturn(ctx, event, "Order cancelled")
+}
+
+func (l *InventoryListener) handleOrderReturn(ctx context.Context, event *OrderEvent, notes string) error {
+	lines := make([]dto.OrderReturnLineInput, len(event.Payload.Items))
+	for i, item := range event.Payload.Items {
+		lines[i] = dto.OrderReturnLineInput{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+			Damaged:   item.Condition == "damaged",
 		}
 	}
+
+	input := &dto.OrderReturnInput{
+		EventID:    event.EventID,
+		EventType:  event.EventType,
+		MerchantID: event.Payload.MerchantID,
+		OrderID:    event.Payload.ID,
+		Notes:      notes,
+		Lines:      lines,
+	}
+
+	res, err := l.uc.ApplyOrderReturn(ctx, input)
+	if err != nil {
+		l.logger.Error("Failed to return order stock",
+			zap.String("event_id", event.EventID),
+			zap.String("event_type", event.EventType),
+			zap.String("order_id", event.Payload.ID),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	if res.AlreadyProcessed {
+		l.logger.Info("Skipping order return that was already applied",
+			zap.String("event_id", event.EventID),
+			zap.String("order_id", event.Payload.ID),
+		)
+	}
+	return nil
 }
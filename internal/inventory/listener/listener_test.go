@@ -0,0 +1,293 @@
+package listener
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fekuna/omnipos-pkg/logger"
+	"github.com/fekuna/omnipos-product-service/internal/deadletter"
+	dlqUCPkg "github.com/fekuna/omnipos-product-service/internal/deadletter/usecase"
+	"github.com/fekuna/omnipos-product-service/internal/inventory"
+	"github.com/fekuna/omnipos-product-service/internal/inventory/dto"
+	"github.com/fekuna/omnipos-product-service/internal/messaging"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"go.uber.org/zap"
+)
+
+const (
+	testTopic           = "orders"
+	testDeadLetterTopic = "orders.dlq"
+)
+
+var testRetry = RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 4 * time.Millisecond}
+
+type nopLogger struct{ logger.ZapLogger }
+
+func (nopLogger) Debug(string, ...zap.Field) {}
+func (nopLogger) Info(string, ...zap.Field)  {}
+func (nopLogger) Warn(string, ...zap.Field)  {}
+func (nopLogger) Error(string, ...zap.Field) {}
+
+// fakeInventory fails ApplyOrderSale with the queued errors, then succeeds.
+type fakeInventory struct {
+	inventory.UseCase
+	mu    sync.Mutex
+	errs  []error
+	sales []*dto.OrderSaleInput
+}
+
+func (f *fakeInventory) ApplyOrderSale(ctx context.Context, input *dto.OrderSaleInput) (*dto.OrderSaleResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sales = append(f.sales, input)
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return nil, err
+	}
+	return &dto.OrderSaleResult{Applied: len(input.Lines)}, nil
+}
+
+func (f *fakeInventory) calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.sales)
+}
+
+// fakeDeadLetterRepo fails Create with the queued errors, then stores the dead letters.
+type fakeDeadLetterRepo struct {
+	deadletter.Repository
+	mu      sync.Mutex
+	errs    []error
+	created []*model.DeadLetter
+}
+
+func (r *fakeDeadLetterRepo) Create(ctx context.Context, dl *model.DeadLetter) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.errs) > 0 {
+		err := r.errs[0]
+		r.errs = r.errs[1:]
+		return err
+	}
+	r.created = append(r.created, dl)
+	return nil
+}
+
+func (r *fakeDeadLetterRepo) stored() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.created)
+}
+
+type listenerTest struct {
+	broker *messaging.FakeBroker
+	inv    *fakeInventory
+	dlq    *fakeDeadLetterRepo
+}
+
+// startListener runs a listener on the fake broker until the test ends.
+func startListener(t *testing.T, errs ...error) *listenerTest {
+	t.Helper()
+	lt := &listenerTest{
+		broker: messaging.NewFakeBroker(),
+		inv:    &fakeInventory{errs: errs},
+		dlq:    &fakeDeadLetterRepo{},
+	}
+	deadLetters := dlqUCPkg.NewDeadLetterUseCase(lt.dlq, lt.broker, testDeadLetterTopic, nopLogger{})
+	l := NewInventoryListener(lt.broker.Reader(testTopic), lt.inv, nil, deadLetters, testRetry, nopLogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.Start(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return lt
+}
+
+func (lt *listenerTest) publish(t *testing.T, value []byte) {
+	t.Helper()
+	if err := lt.broker.Publish(context.Background(), messaging.Message{Topic: testTopic, Value: value}); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+}
+
+func orderCreated(t *testing.T, eventID string) []byte {
+	t.Helper()
+	value, err := json.Marshal(OrderEvent{
+		EventID:   eventID,
+		EventType: EventOrderCreated,
+		Payload: OrderPayload{
+			ID:         "order-" + eventID,
+			MerchantID: "merchant-1",
+			Items:      []OrderItemPayload{{ProductID: "product-1", Quantity: 2}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return value
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestListenerRetriesTransientFailures(t *testing.T) {
+	lt := startListener(t, errors.New("connection reset"), inventory.ErrInventoryBusy)
+	lt.publish(t, orderCreated(t, "evt-1"))
+
+	waitFor(t, "the third attempt", func() bool { return lt.inv.calls() == 3 })
+	lt.publish(t, orderCreated(t, "evt-2"))
+	waitFor(t, "the next message", func() bool { return lt.inv.calls() == 4 })
+
+	if n := len(lt.broker.Messages(testDeadLetterTopic)); n != 0 {
+		t.Errorf("dead-lettered %d messages, want none", n)
+	}
+}
+
+func TestListenerDeadLettersExhaustedRetries(t *testing.T) {
+	lt := startListener(t, inventory.ErrInventoryBusy, inventory.ErrInventoryBusy, inventory.ErrInventoryBusy)
+	value := orderCreated(t, "evt-1")
+	lt.publish(t, value)
+
+	waitFor(t, "the dead letter", func() bool { return len(lt.broker.Messages(testDeadLetterTopic)) == 1 })
+	if n := lt.inv.calls(); n != testRetry.MaxAttempts {
+		t.Errorf("applied %d times, want %d", n, testRetry.MaxAttempts)
+	}
+
+	dl := lt.broker.Messages(testDeadLetterTopic)[0]
+	if string(dl.Value) != string(value) {
+		t.Errorf("dead letter payload = %s, want the original message", dl.Value)
+	}
+	if got := dl.Headers[dlqUCPkg.HeaderAttempts]; got != fmt.Sprint(testRetry.MaxAttempts) {
+		t.Errorf("attempts header = %q, want %d", got, testRetry.MaxAttempts)
+	}
+	if got := dl.Headers[dlqUCPkg.HeaderOriginalTopic]; got != testTopic {
+		t.Errorf("original topic header = %q, want %q", got, testTopic)
+	}
+	if len(lt.dlq.created) != 1 {
+		t.Errorf("stored %d dead letters, want 1", len(lt.dlq.created))
+	}
+}
+
+func TestListenerDeadLettersPermanentFailureWithoutRetry(t *testing.T) {
+	lt := startListener(t, inventory.ErrInsufficientInventory)
+	lt.publish(t, orderCreated(t, "evt-1"))
+
+	waitFor(t, "the dead letter", func() bool { return len(lt.broker.Messages(testDeadLetterTopic)) == 1 })
+	if n := lt.inv.calls(); n != 1 {
+		t.Errorf("applied %d times, want 1", n)
+	}
+	if got := lt.broker.Messages(testDeadLetterTopic)[0].Headers[dlqUCPkg.HeaderAttempts]; got != "1" {
+		t.Errorf("attempts header = %q, want 1", got)
+	}
+}
+
+func TestListenerRetriesDeadLetterUntilStored(t *testing.T) {
+	lt := startListener(t, inventory.ErrInsufficientInventory)
+	lt.dlq.mu.Lock()
+	lt.dlq.errs = []error{errors.New("connection reset"), errors.New("connection reset")}
+	lt.dlq.mu.Unlock()
+
+	lt.publish(t, orderCreated(t, "evt-1"))
+	lt.publish(t, orderCreated(t, "evt-2"))
+
+	waitFor(t, "the next message", func() bool { return lt.inv.calls() == 2 })
+	if n := lt.dlq.stored(); n != 1 {
+		t.Errorf("stored %d dead letters before the next message, want 1", n)
+	}
+	if n := len(lt.broker.Messages(testDeadLetterTopic)); n != 1 {
+		t.Errorf("dead-lettered %d messages, want 1", n)
+	}
+}
+
+func TestListenerDeadLettersMalformedMessage(t *testing.T) {
+	lt := startListener(t)
+	lt.publish(t, []byte("{not json"))
+	lt.publish(t, orderCreated(t, "evt-2"))
+
+	waitFor(t, "the next message", func() bool { return lt.inv.calls() == 1 })
+	dls := lt.broker.Messages(testDeadLetterTopic)
+	if len(dls) != 1 {
+		t.Fatalf("dead-lettered %d messages, want 1", len(dls))
+	}
+	if got := dls[0].Headers[dlqUCPkg.HeaderError]; !strings.Contains(got, errMalformedEvent.Error()) {
+		t.Errorf("error header = %q, want a malformed event", got)
+	}
+	if got := dls[0].Headers[dlqUCPkg.HeaderAttempts]; got != "1" {
+		t.Errorf("attempts header = %q, want 1", got)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	transient := errors.New("connection reset")
+	malformed := fmt.Errorf("%w: unexpected end of JSON input", errMalformedEvent)
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"unknown error", transient, true},
+		{"aborted domain error", inventory.ErrInventoryBusy, true},
+		{"wrapped aborted domain error", fmt.Errorf("apply: %w", inventory.ErrInventoryBusy), true},
+		{"failed precondition", inventory.ErrInsufficientInventory.With("ProductID", "p"), false},
+		{"invalid argument", inventory.ErrInvalidQuantity, false},
+		{"malformed event", malformed, false},
+		{"joined with a transient error", errors.Join(inventory.ErrInsufficientInventory, transient), true},
+		{"joined permanent errors", errors.Join(inventory.ErrInsufficientInventory, malformed), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryable(tt.err); got != tt.want {
+				t.Errorf("isRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 10, InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	tests := []struct {
+		attempt int
+		ceiling time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{40, time.Second},
+	}
+	for _, tt := range tests {
+		for range 50 {
+			got := p.Backoff(tt.attempt)
+			if got < tt.ceiling/2 || got > tt.ceiling {
+				t.Fatalf("Backoff(%d) = %v, want within [%v, %v]", tt.attempt, got, tt.ceiling/2, tt.ceiling)
+			}
+		}
+	}
+
+	if got := (RetryPolicy{}).Backoff(1); got != 0 {
+		t.Errorf("Backoff without a policy = %v, want 0", got)
+	}
+}
@@ -0,0 +1,57 @@
+package listener
+
+import (
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/fekuna/omnipos-product-service/internal/apperror"
+)
+
+// RetryPolicy bounds how long a failing message holds up the partition before it is
+// dead-lettered.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// Backoff returns the wait after the given failed attempt: the initial backoff doubled per
+// attempt and capped, with jitter over its upper half so consumers do not retry in lockstep.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	d := p.MaxBackoff
+	if attempt < 32 {
+		if exp := p.InitialBackoff << (attempt - 1); exp > 0 && exp < p.MaxBackoff {
+			d = exp
+		}
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// errMalformedEvent marks a message that can never be decoded.
+var errMalformedEvent = errors.New("malformed event")
+
+// isRetryable reports whether err may be transient, such as lock contention or a lost
+// database connection. A joined error is retried when any of its parts is.
+func isRetryable(err error) bool {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range joined.Unwrap() {
+			if isRetryable(e) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if errors.Is(err, errMalformedEvent) {
+		return false
+	}
+	// Domain errors are about the order itself, only a lost race is worth another try.
+	if e, ok := apperror.As(err); ok {
+		return e.Kind == apperror.Aborted
+	}
+	return true
+}
@@ -0,0 +1,12 @@
+package inventory
+
+// InsufficientStockPolicy decides what an order sale does when a line asks for more than
+// is available.
+type InsufficientStockPolicy string
+
+const (
+	// InsufficientStockReject fails the whole order, nothing is deducted.
+	InsufficientStockReject InsufficientStockPolicy = "reject"
+	// InsufficientStockAllowNegative deducts anyway and records a StockShortage for review.
+	InsufficientStockAllowNegative InsufficientStockPolicy = "allow_negative"
+)
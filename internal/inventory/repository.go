@@ -2,6 +2,7 @@ package inventory
 
 import (
 	"context"
+	"time"
 
 	"github.com/fekuna/omnipos-product-service/internal/inventory/dto"
 	"github.com/fekuna/omnipos-product-service/internal/model"
@@ -9,9 +10,11 @@ import (
 
 type Repository interface {
 	// Inventory Items
-	GetByProduct(ctx context.Context, merchantID, productID string, storeID *string) (*model.Inventory, error)
-	BatchGetByProducts(ctx context.Context, merchantID string, productIDs []string, storeID *string) ([]model.Inventory, error)
+	GetByLocation(ctx context.Context, merchantID, productID string, variantID, storeID *string) (*model.Inventory, error)
+	BatchGetByProducts(ctx context.Context, merchantID string, productIDs []string, storeIDs []string) ([]model.Inventory, error)
+	FindByBarcode(ctx context.Context, merchantID, barcode string) ([]dto.BarcodeMatch, error)
 	FindAll(ctx context.Context, filters *dto.InventoryFilters) ([]model.Inventory, int, error)
+	IsProductOwned(ctx context.Context, merchantID, productID string, variantID *string) (bool, error)
 
 	// Core stock operations
 	CreateOrUpdate(ctx context.Context, inv *model.Inventory) error
@@ -21,5 +24,31 @@ type Repository interface {
 	ListMovements(ctx context.Context, filters *dto.MovementFilters) ([]model.InventoryMovement, int, error)
 
 	// Transaction support
+	// These join the transaction of a database.TxManager when ctx carries one.
+	GetByLocationForUpdate(ctx context.Context, merchantID, productID string, variantID, storeID *string) (*model.Inventory, error)
 	AdjustStockWithMovement(ctx context.Context, inv *model.Inventory, movement *model.InventoryMovement) error
+	AdjustStockWithMovements(ctx context.Context, invs []*model.Inventory, movements []*model.InventoryMovement) error
+	MarkCounted(ctx context.Context, inventoryID string, countedAt time.Time) error
+
+	// Idempotent event processing
+	IsEventProcessed(ctx context.Context, eventID string) (bool, error)
+	MarkEventProcessed(ctx context.Context, event *model.ProcessedEvent) error
+	CountProcessedOrderLines(ctx context.Context, merchantID, orderID string) (int, error)
+	ClaimOrderLine(ctx context.Context, line *model.ProcessedOrderLine) (bool, error)
+	ClaimEvent(ctx context.Context, event *model.ProcessedEvent) (bool, error)
+	FindOrderSalesForUpdate(ctx context.Context, merchantID, orderID string) ([]model.OrderSale, error)
+
+	// Reservations of orders being sold
+	FindOrderReservationForUpdate(ctx context.Context, merchantID, orderID string) (*model.StockReservation, error)
+	ConsumeReservation(ctx context.Context, reservation *model.StockReservation) error
+
+	// Stock watch
+	ChangeToken(ctx context.Context) (string, error)
+	FindChangedSince(ctx context.Context, merchantID string, storeID *string, token string) ([]model.Inventory, error)
+
+	// Sales that overdrew stock
+	CreateStockShortages(ctx context.Context, shortages []*model.StockShortage) error
+	FindStockShortageByID(ctx context.Context, merchantID, id string) (*model.StockShortage, error)
+	FindStockShortages(ctx context.Context, filters *dto.StockShortageFilters) ([]model.StockShortage, int, error)
+	ResolveStockShortage(ctx context.Context, shortage *model.StockShortage) error
 }
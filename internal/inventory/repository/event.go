@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/fekuna/omnipos-product-service/internal/model"
+)
+
+func (r *PGRepository) IsEventProcessed(ctx context.Context, eventID string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM processed_events WHERE event_id = $1)`
+	err := r.conn(ctx).GetContext(ctx, &exists, query, eventID)
+	return exists, err
+}
+
+// MarkEventProcessed records the event. Recording it twice is a no-op.
+func (r *PGRepository) MarkEventProcessed(ctx context.Context, e *model.ProcessedEvent) error {
+	query := `
+        INSERT INTO processed_events (event_id, event_type, merchant_id, order_id, processed_at)
+        VALUES (:event_id, :event_type, :merchant_id, :order_id, :processed_at)
+        ON CONFLICT (event_id) DO NOTHING
+    `
+	_, err := r.conn(ctx).NamedExecContext(ctx, query, e)
+	return err
+}
+
+func (r *PGRepository) CountProcessedOrderLines(ctx context.Context, merchantID, orderID string) (int, error) {
+	var count int
+	query := `SELECT count(*) FROM processed_order_lines WHERE merchant_id = $1 AND order_id = $2`
+	err := r.conn(ctx).GetContext(ctx, &count, query, merchantID, orderID)
+	return count, err
+}
+
+// ClaimOrderLine records an order line as applied and reports false if it already was.
+// A concurrent claim of the same line waits for the first transaction to finish.
+func (r *PGRepository) ClaimOrderLine(ctx context.Context, line *model.ProcessedOrderLine) (bool, error) {
+	query := `
+        INSERT INTO processed_order_lines (merchant_id, order_id, line_no, event_id, movement_id, processed_at)
+        VALUES (:merchant_id, :order_id, :line_no, :event_id, :movement_id, :processed_at)
+        ON CONFLICT (merchant_id, order_id, line_no) DO NOTHING
+    `
+	res, err := r.conn(ctx).NamedExecContext(ctx, query, line)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ClaimEvent records the event and reports false if it already was. Called inside the
+// transaction that applies the event, so the event and its effects commit together.
+func (r *PGRepository) ClaimEvent(ctx context.Context, e *model.ProcessedEvent) (bool, error) {
+	query := `
+        INSERT INTO processed_events (event_id, event_type, merchant_id, order_id, processed_at)
+        VALUES (:event_id, :event_type, :merchant_id, :order_id, :processed_at)
+        ON CONFLICT (event_id) DO NOTHING
+    `
+	res, err := r.conn(ctx).NamedExecContext(ctx, query, e)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// FindOrderSalesForUpdate locks the sale movements of an order and sums what has been
+// returned against each. The sums are read after the lock, so concurrent returns of the
+// same order see each other.
+func (r *PGRepository) FindOrderSalesForUpdate(ctx context.Context, merchantID, orderID string) ([]model.OrderSale, error) {
+	lockQuery := `
+        SELECT id FROM inventory_movements
+        WHERE merchant_id = $1 AND reference_type = 'order' AND reference_id = $2 AND movement_type = 'sale'
+        ORDER BY created_at, id
+        FOR UPDATE
+    `
+	var ids []string
+	if err := r.conn(ctx).SelectContext(ctx, &ids, lockQuery, merchantID, orderID); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return []model.OrderSale{}, nil
+	}
+
+	query := `
+        SELECT m.*,
+            COALESCE((
+                SELECT SUM(r.quantity_change) FROM inventory_movements r
+                WHERE r.reverses_movement_id = m.id AND r.movement_type = 'return'
+            ), 0) AS returned_quantity
+        FROM inventory_movements m
+        WHERE m.merchant_id = $1 AND m.reference_type = 'order' AND m.reference_id = $2 AND m.movement_type = 'sale'
+        ORDER BY m.created_at, m.id
+    `
+	sales := []model.OrderSale{}
+	err := r.conn(ctx).SelectContext(ctx, &sales, query, merchantID, orderID)
+	return sales, err
+}
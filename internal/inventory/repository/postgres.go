@@ -6,7 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/fekuna/omnipos-product-service/internal/database"
+	"github.com/fekuna/omnipos-product-service/internal/inventory"
 	"github.com/fekuna/omnipos-product-service/internal/inventory/dto"
 	"github.com/fekuna/omnipos-product-service/internal/model"
 	"github.com/jmoiron/sqlx"
@@ -20,30 +23,22 @@ func NewPGRepository(db *sqlx.DB) *PGRepository {
 	return &PGRepository{DB: db}
 }
 
-func (r *PGRepository) GetByProduct(ctx context.Context, merchantID, productID string, storeID *string) (*model.Inventory, error) {
-	var inv model.Inventory
-	query := `SELECT * FROM inventory WHERE merchant_id = $1 AND product_id = $2`
-	args := []interface{}{merchantID, productID}
-
-	if storeID != nil && *storeID != "" {
-		query += ` AND store_id = $3`
-		args = append(args, *storeID)
-	} else {
-		query += ` AND store_id IS NULL`
-	}
+// conn returns the transaction started by the TxManager if ctx carries one.
+func (r *PGRepository) conn(ctx context.Context) database.DBTX {
+	return database.Conn(ctx, r.DB)
+}
 
-	err := r.DB.GetContext(ctx, &inv, query, args...)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil // Return nil if no record found (caller handles creating defaults)
-		}
-		return nil, err
-	}
-	return &inv, nil
+// GetByLocation returns the stock of one product or variant at one store, nil when the
+// location has never been stocked.
+func (r *PGRepository) GetByLocation(ctx context.Context, merchantID, productID string, variantID, storeID *string) (*model.Inventory, error) {
+	return r.getByLocation(ctx, merchantID, productID, variantID, storeID, "")
 }
 
-func (r *PGRepository) BatchGetByProducts(ctx context.Context, merchantID string, productIDs []string, storeID *string) ([]model.Inventory, error) {
-	if len(productIDs) == 0 {
+// BatchGetByProducts returns one row per product or variant of the given products and
+// store. Nil storeIDs means every store and the warehouse; an empty ID in storeIDs stands
+// for the warehouse.
+func (r *PGRepository) BatchGetByProducts(ctx context.Context, merchantID string, productIDs []string, storeIDs []string) ([]model.Inventory, error) {
+	if len(productIDs) == 0 || (storeIDs != nil && len(storeIDs) == 0) {
 		return []model.Inventory{}, nil
 	}
 
@@ -55,36 +50,76 @@ func (r *PGRepository) BatchGetByProducts(ctx context.Context, merchantID string
 		return nil, err
 	}
 
-	if storeID != nil && *storeID != "" {
-		query += ` AND store_id = ?`
-		args = append(args, *storeID)
-	} else {
-		query += ` AND store_id IS NULL`
+	if storeIDs != nil {
+		var stores []string
+		warehouse := false
+		for _, id := range storeIDs {
+			if id == "" {
+				warehouse = true
+			} else {
+				stores = append(stores, id)
+			}
+		}
+
+		var conditions []string
+		if len(stores) > 0 {
+			in, inArgs, err := sqlx.In(`store_id IN (?)`, stores)
+			if err != nil {
+				return nil, err
+			}
+			conditions = append(conditions, in)
+			args = append(args, inArgs...)
+		}
+		if warehouse {
+			conditions = append(conditions, `store_id IS NULL`)
+		}
+		query += ` AND (` + strings.Join(conditions, " OR ") + `)`
 	}
 
 	// Rebind for Postgres ($1, $2...)
 	query = r.DB.Rebind(query)
 
 	var items []model.Inventory
-	err = r.DB.SelectContext(ctx, &items, query, args...)
+	err = r.conn(ctx).SelectContext(ctx, &items, query, args...)
 	return items, err
 }
 
+// FindByBarcode returns the product or variant of the merchant carrying barcode. Variant
+// barcodes are only unique per product, so more than one item can match.
+func (r *PGRepository) FindByBarcode(ctx context.Context, merchantID, barcode string) ([]dto.BarcodeMatch, error) {
+	query := `
+        SELECT p.id AS product_id, NULL::uuid AS variant_id
+        FROM products p
+        WHERE p.merchant_id = $1 AND p.barcode = $2
+        UNION ALL
+        SELECT v.product_id, v.id AS variant_id
+        FROM product_variants v
+        JOIN products p ON p.id = v.product_id
+        WHERE p.merchant_id = $1 AND v.barcode = $2
+    `
+	matches := []dto.BarcodeMatch{}
+	err := r.conn(ctx).SelectContext(ctx, &matches, query, merchantID, barcode)
+	return matches, err
+}
+
 func (r *PGRepository) FindAll(ctx context.Context, f *dto.InventoryFilters) ([]model.Inventory, int, error) {
 	var items []model.Inventory
 	var count int
 
-	conditions := []string{}
-	args := map[string]interface{}{}
-
-	if f.MerchantID != "" {
-		conditions = append(conditions, "merchant_id = :merchant_id")
-		args["merchant_id"] = f.MerchantID
-	}
+	conditions := []string{"merchant_id = :merchant_id"}
+	args := map[string]interface{}{"merchant_id": f.MerchantID}
 	if f.ProductID != "" {
 		conditions = append(conditions, "product_id = :product_id")
 		args["product_id"] = f.ProductID
 	}
+	if f.VariantID != nil {
+		if *f.VariantID == "" {
+			conditions = append(conditions, "variant_id IS NULL")
+		} else {
+			conditions = append(conditions, "variant_id = :variant_id")
+			args["variant_id"] = *f.VariantID
+		}
+	}
 	if f.StoreID != nil {
 		if *f.StoreID == "" {
 			conditions = append(conditions, "store_id IS NULL")
@@ -103,28 +138,17 @@ func (r *PGRepository) FindAll(ctx context.Context, f *dto.InventoryFilters) ([]
 	}
 
 	countQuery := "SELECT count(*) FROM inventory" + whereClause
-	rows, err := r.DB.NamedQueryContext(ctx, countQuery, args)
-	if err != nil {
+	if err := database.NamedGet(ctx, r.conn(ctx), &count, countQuery, args); err != nil {
 		return nil, 0, err
 	}
-	defer rows.Close()
-	if rows.Next() {
-		rows.Scan(&count)
-	}
 
-	query := "SELECT * FROM inventory" + whereClause + " ORDER BY updated_at DESC"
+	query := "SELECT * FROM inventory" + whereClause + " ORDER BY updated_at DESC, id"
 	if f.PageSize > 0 {
 		offset := (f.Page - 1) * f.PageSize
 		query += fmt.Sprintf(" LIMIT %d OFFSET %d", f.PageSize, offset)
 	}
 
-	nstmt, err := r.DB.PrepareNamedContext(ctx, query)
-	if err != nil {
-		return nil, 0, err
-	}
-	defer nstmt.Close()
-
-	err = nstmt.SelectContext(ctx, &items, args)
+	err := database.NamedSelect(ctx, r.conn(ctx), &items, query, args)
 	return items, count, err
 }
 
@@ -132,12 +156,12 @@ func (r *PGRepository) CreateOrUpdate(ctx context.Context, inv *model.Inventory)
 	query := `
         INSERT INTO inventory (
             id, merchant_id, store_id, product_id, variant_id, 
-            quantity, reserved_quantity, reorder_point, reorder_quantity, 
+            quantity, reserved_quantity, reorder_point, reorder_quantity, max_stock_level,
             last_counted_at, updated_at
         ) 
         VALUES (
             :id, :merchant_id, :store_id, :product_id, :variant_id, 
-            :quantity, :reserved_quantity, :reorder_point, :reorder_quantity, 
+            :quantity, :reserved_quantity, :reorder_point, :reorder_quantity, :max_stock_level,
             :last_counted_at, :updated_at
         )
         ON CONFLICT (merchant_id, store_id, product_id, variant_id) 
@@ -146,11 +170,12 @@ func (r *PGRepository) CreateOrUpdate(ctx context.Context, inv *model.Inventory)
             reserved_quantity = EXCLUDED.reserved_quantity,
             reorder_point = EXCLUDED.reorder_point,
             reorder_quantity = EXCLUDED.reorder_quantity,
+            max_stock_level = EXCLUDED.max_stock_level,
             last_counted_at = EXCLUDED.last_counted_at,
             updated_at = EXCLUDED.updated_at
     `
 	// Note: available_quantity is generated column, so we don't insert/update it
-	_, err := r.DB.NamedExecContext(ctx, query, inv)
+	_, err := r.conn(ctx).NamedExecContext(ctx, query, inv)
 	return err
 }
 
@@ -159,15 +184,15 @@ func (r *PGRepository) LogMovement(ctx context.Context, m *model.InventoryMoveme
         INSERT INTO inventory_movements (
             id, merchant_id, store_id, product_id, variant_id, 
             movement_type, quantity_change, quantity_before, quantity_after, 
-            reference_type, reference_id, notes, created_by, created_at
+            reference_type, reference_id, reverses_movement_id, notes, created_by, created_at
         )
         VALUES (
             :id, :merchant_id, :store_id, :product_id, :variant_id, 
             :movement_type, :quantity_change, :quantity_before, :quantity_after, 
-            :reference_type, :reference_id, :notes, :created_by, :created_at
+            :reference_type, :reference_id, :reverses_movement_id, :notes, :created_by, :created_at
         )
     `
-	_, err := r.DB.NamedExecContext(ctx, query, m)
+	_, err := r.conn(ctx).NamedExecContext(ctx, query, m)
 	return err
 }
 
@@ -175,13 +200,8 @@ func (r *PGRepository) ListMovements(ctx context.Context, f *dto.MovementFilters
 	var items []model.InventoryMovement
 	var count int
 
-	conditions := []string{}
-	args := map[string]interface{}{}
-
-	if f.MerchantID != "" {
-		conditions = append(conditions, "merchant_id = :merchant_id")
-		args["merchant_id"] = f.MerchantID
-	}
+	conditions := []string{"merchant_id = :merchant_id"}
+	args := map[string]interface{}{"merchant_id": f.MerchantID}
 	if f.ProductID != "" {
 		conditions = append(conditions, "product_id = :product_id")
 		args["product_id"] = f.ProductID
@@ -197,14 +217,9 @@ func (r *PGRepository) ListMovements(ctx context.Context, f *dto.MovementFilters
 	}
 
 	countQuery := "SELECT count(*) FROM inventory_movements" + whereClause
-	rows, err := r.DB.NamedQueryContext(ctx, countQuery, args)
-	if err != nil {
+	if err := database.NamedGet(ctx, r.conn(ctx), &count, countQuery, args); err != nil {
 		return nil, 0, err
 	}
-	defer rows.Close()
-	if rows.Next() {
-		rows.Scan(&count)
-	}
 
 	query := "SELECT * FROM inventory_movements" + whereClause + " ORDER BY created_at DESC"
 	if f.PageSize > 0 {
@@ -212,67 +227,131 @@ func (r *PGRepository) ListMovements(ctx context.Context, f *dto.MovementFilters
 		query += fmt.Sprintf(" LIMIT %d OFFSET %d", f.PageSize, offset)
 	}
 
-	nstmt, err := r.DB.PrepareNamedContext(ctx, query)
-	if err != nil {
-		return nil, 0, err
-	}
-	defer nstmt.Close()
-
-	err = nstmt.SelectContext(ctx, &items, args)
+	err := database.NamedSelect(ctx, r.conn(ctx), &items, query, args)
 	return items, count, err
 }
 
-func (r *PGRepository) AdjustStockWithMovement(ctx context.Context, inv *model.Inventory, movement *model.InventoryMovement) error {
-	tx, err := r.DB.BeginTxx(ctx, nil)
+func (r *PGRepository) GetByLocationForUpdate(ctx context.Context, merchantID, productID string, variantID, storeID *string) (*model.Inventory, error) {
+	return r.getByLocation(ctx, merchantID, productID, variantID, storeID, " FOR UPDATE")
+}
+
+func (r *PGRepository) getByLocation(ctx context.Context, merchantID, productID string, variantID, storeID *string, lockClause string) (*model.Inventory, error) {
+	var inv model.Inventory
+	query := `
+        SELECT * FROM inventory
+        WHERE merchant_id = $1 AND product_id = $2
+            AND variant_id IS NOT DISTINCT FROM $3
+            AND store_id IS NOT DISTINCT FROM $4
+    ` + lockClause
+	err := r.conn(ctx).GetContext(ctx, &inv, query, merchantID, productID, variantID, storeID)
 	if err != nil {
-		return err
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
 	}
-	defer tx.Rollback()
+	return &inv, nil
+}
 
-	// 1. Update Inventory
-	upsertQuery := `
-        INSERT INTO inventory (
-            id, merchant_id, store_id, product_id, variant_id, 
-            quantity, reserved_quantity, reorder_point, reorder_quantity, 
-            last_counted_at, updated_at
-        ) 
-        VALUES (
-            :id, :merchant_id, :store_id, :product_id, :variant_id, 
-            :quantity, :reserved_quantity, :reorder_point, :reorder_quantity, 
-            :last_counted_at, :updated_at
-        )
-        ON CONFLICT (merchant_id, store_id, product_id, variant_id) 
-        DO UPDATE SET 
-            quantity = EXCLUDED.quantity,
-            reserved_quantity = EXCLUDED.reserved_quantity,
-            last_counted_at = EXCLUDED.last_counted_at,
-            updated_at = EXCLUDED.updated_at
-    `
-	// Only updating quantity fields for adjustment usually, but upsert overrides everything.
-	// Ensure 'inv' has correct current values if it exists, or new values.
+func (r *PGRepository) AdjustStockWithMovement(ctx context.Context, inv *model.Inventory, movement *model.InventoryMovement) error {
+	return r.AdjustStockWithMovements(ctx, []*model.Inventory{inv}, []*model.InventoryMovement{movement})
+}
 
-	_, err = tx.NamedExecContext(ctx, upsertQuery, inv)
-	if err != nil {
-		return fmt.Errorf("failed to update inventory: %w", err)
+// AdjustStockWithMovements writes the final state of every location and all movements
+// that led to it in one transaction, so a multi-line change is applied completely or not at all.
+func (r *PGRepository) AdjustStockWithMovements(ctx context.Context, invs []*model.Inventory, movements []*model.InventoryMovement) error {
+	if len(invs) == 0 && len(movements) == 0 {
+		return nil
 	}
 
-	// 2. Log Movement
-	insertLogQuery := `
-        INSERT INTO inventory_movements (
-            id, merchant_id, store_id, product_id, variant_id, 
-            movement_type, quantity_change, quantity_before, quantity_after, 
-            reference_type, reference_id, notes, created_by, created_at
-        )
-        VALUES (
-            :id, :merchant_id, :store_id, :product_id, :variant_id, 
-            :movement_type, :quantity_change, :quantity_before, :quantity_after, 
-            :reference_type, :reference_id, :notes, :created_by, :created_at
+	return database.RunInTx(ctx, r.DB, func(ctx context.Context) error {
+		tx := r.conn(ctx)
+
+		// 1. Update Inventory
+		// Conflict on the location key, which treats NULL store and variant as equal. A row
+		// created at the location by a concurrent transaction has another id and is left
+		// alone: the caller never locked it, so its quantity cannot be trusted.
+		upsertQuery := `
+            INSERT INTO inventory (
+                id, merchant_id, store_id, product_id, variant_id, 
+                quantity, reserved_quantity, reorder_point, reorder_quantity, 
+                last_counted_at, updated_at, negative_allowed
+            ) 
+            VALUES (
+                :id, :merchant_id, :store_id, :product_id, :variant_id, 
+                :quantity, :reserved_quantity, :reorder_point, :reorder_quantity, 
+                :last_counted_at, :updated_at, :negative_allowed
+            )
+            ON CONFLICT (merchant_id, store_id, product_id, variant_id) 
+            DO UPDATE SET 
+                quantity = EXCLUDED.quantity,
+                negative_allowed = (inventory.negative_allowed OR EXCLUDED.negative_allowed)
+                    AND EXCLUDED.quantity < inventory.reserved_quantity,
+                last_counted_at = EXCLUDED.last_counted_at,
+                updated_at = EXCLUDED.updated_at
+            WHERE inventory.id = EXCLUDED.id
+        `
+		// Callers hold the row lock, so the absolute quantity is safe to write. Holds are only
+		// changed by the reservation queries, so reserved_quantity is never written back.
+		// An oversold location keeps negative_allowed until it is no longer short.
+
+		for _, inv := range invs {
+			res, err := tx.NamedExecContext(ctx, upsertQuery, inv)
+			if err != nil {
+				return fmt.Errorf("failed to update inventory: %w", err)
+			}
+			n, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+			if n == 0 {
+				return inventory.ErrInventoryBusy
+			}
+		}
+		if len(movements) == 0 {
+			return nil
+		}
+
+		// 2. Log Movements, in one multi-row insert
+		insertLogQuery := `
+            INSERT INTO inventory_movements (
+                id, merchant_id, store_id, product_id, variant_id, 
+                movement_type, quantity_change, quantity_before, quantity_after, 
+                reference_type, reference_id, reverses_movement_id, notes, created_by, created_at
+            )
+            VALUES (
+                :id, :merchant_id, :store_id, :product_id, :variant_id, 
+                :movement_type, :quantity_change, :quantity_before, :quantity_after, 
+                :reference_type, :reference_id, :reverses_movement_id, :notes, :created_by, :created_at
+            )
+        `
+		if _, err := tx.NamedExecContext(ctx, insertLogQuery, movements); err != nil {
+			return fmt.Errorf("failed to log movement: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// MarkCounted stamps last_counted_at on a location whose count matched the books.
+func (r *PGRepository) MarkCounted(ctx context.Context, inventoryID string, countedAt time.Time) error {
+	query := `UPDATE inventory SET last_counted_at = $2, updated_at = NOW() WHERE id = $1`
+	_, err := r.conn(ctx).ExecContext(ctx, query, inventoryID, countedAt)
+	return err
+}
+
+// IsProductOwned reports whether the product, and the variant if given, belong to the merchant.
+func (r *PGRepository) IsProductOwned(ctx context.Context, merchantID, productID string, variantID *string) (bool, error) {
+	var owned bool
+	query := `
+        SELECT EXISTS (
+            SELECT 1 FROM products p
+            WHERE p.id = $2 AND p.merchant_id = $1
+                AND ($3::uuid IS NULL OR EXISTS (
+                    SELECT 1 FROM product_variants v WHERE v.id = $3 AND v.product_id = p.id
+                ))
         )
     `
-	_, err = tx.NamedExecContext(ctx, insertLogQuery, movement)
-	if err != nil {
-		return fmt.Errorf("failed to log movement: %w", err)
-	}
-
-	return tx.Commit()
+	err := r.conn(ctx).GetContext(ctx, &owned, query, merchantID, productID, variantID)
+	return owned, err
 }
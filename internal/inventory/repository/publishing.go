@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/fekuna/omnipos-product-service/internal/database"
+	"github.com/fekuna/omnipos-product-service/internal/event"
+	"github.com/fekuna/omnipos-product-service/internal/inventory"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+)
+
+// Notifier records a pub/sub message to be sent once the transaction commits.
+type Notifier interface {
+	Notify(ctx context.Context, aggregateType, aggregateID, merchantID, channel, message string) error
+}
+
+// PublishingRepository records StockChanged and LowStockReached for every write through
+// AdjustStockWithMovement(s), and a wakeup for the watchers of every store written, in the
+// same transaction as the write. That covers adjustments, transfers, receipts, stocktakes,
+// order sales and returns. Reservations write inventory directly: ReserveStock,
+// CommitReservation and ReleaseReservation in the product repository, and
+// ConsumeReservation here, which only frees holds for the order sale that follows it. The
+// product use case records their events and wakeups itself.
+type PublishingRepository struct {
+	inventory.Repository
+	tx     database.TxManager
+	events event.Publisher // Writes to the outbox
+	notify Notifier        // Writes to the outbox
+}
+
+func NewPublishingRepository(repo inventory.Repository, tx database.TxManager, events event.Publisher, notify Notifier) *PublishingRepository {
+	return &PublishingRepository{
+		Repository: repo,
+		tx:         tx,
+		events:     events,
+		notify:     notify,
+	}
+}
+
+func (r *PublishingRepository) AdjustStockWithMovement(ctx context.Context, inv *model.Inventory, movement *model.InventoryMovement) error {
+	return r.AdjustStockWithMovements(ctx, []*model.Inventory{inv}, []*model.InventoryMovement{movement})
+}
+
+func (r *PublishingRepository) AdjustStockWithMovements(ctx context.Context, invs []*model.Inventory, movements []*model.InventoryMovement) error {
+	return r.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := r.Repository.AdjustStockWithMovements(ctx, invs, movements); err != nil {
+			return err
+		}
+		if err := r.events.Publish(ctx, event.ForStockChange(invs, movements)...); err != nil {
+			return err
+		}
+		return r.notifyStores(ctx, invs)
+	})
+}
+
+// notifyStores wakes up the watchers of each store in invs once.
+func (r *PublishingRepository) notifyStores(ctx context.Context, invs []*model.Inventory) error {
+	notified := make(map[string]bool, len(invs))
+	for _, inv := range invs {
+		store := ""
+		if inv.StoreID != nil {
+			store = *inv.StoreID
+		}
+		key := inv.MerchantID + ":" + store
+		if notified[key] {
+			continue
+		}
+		notified[key] = true
+
+		if err := r.notify.Notify(ctx, event.AggregateInventory, key, inv.MerchantID, inventory.StockChannel(inv.MerchantID), store); err != nil {
+			return err
+		}
+	}
+	return nil
+}
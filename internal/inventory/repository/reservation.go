@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/fekuna/omnipos-product-service/internal/model"
+)
+
+// FindOrderReservationForUpdate row-locks the reservation of an order, so commit, release
+// and the expiry sweeper wait for the sale. Returns nil when the order has none.
+func (r *PGRepository) FindOrderReservationForUpdate(ctx context.Context, merchantID, orderID string) (*model.StockReservation, error) {
+	var res model.StockReservation
+	query := `SELECT * FROM stock_reservations WHERE merchant_id = $1 AND order_id = $2 FOR UPDATE`
+	err := r.conn(ctx).GetContext(ctx, &res, query, merchantID, orderID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	items := []model.StockReservationItem{}
+	err = r.conn(ctx).SelectContext(ctx, &items, `SELECT * FROM stock_reservation_items WHERE reservation_id = $1 ORDER BY product_id, variant_id NULLS FIRST`, res.ID)
+	if err != nil {
+		return nil, err
+	}
+	res.Items = items
+
+	return &res, nil
+}
+
+// ConsumeReservation frees the holds of an active reservation whose order was sold without
+// committing it, and marks it committed. The sale deducts the stock itself.
+func (r *PGRepository) ConsumeReservation(ctx context.Context, res *model.StockReservation) error {
+	tx := r.conn(ctx)
+
+	releaseQuery := `
+        UPDATE inventory
+        SET reserved_quantity = GREATEST(reserved_quantity - $1, 0), updated_at = NOW()
+        WHERE id = $2
+    `
+	for _, item := range res.Items {
+		if _, err := tx.ExecContext(ctx, releaseQuery, item.Quantity, item.InventoryID); err != nil {
+			return fmt.Errorf("failed to release reserved stock: %w", err)
+		}
+	}
+
+	query := `UPDATE stock_reservations SET status = $1, updated_at = NOW() WHERE id = $2`
+	if _, err := tx.ExecContext(ctx, query, model.ReservationStatusCommitted, res.ID); err != nil {
+		return fmt.Errorf("failed to update reservation status: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fekuna/omnipos-product-service/internal/database"
+	"github.com/fekuna/omnipos-product-service/internal/inventory/dto"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+)
+
+func (r *PGRepository) CreateStockShortages(ctx context.Context, shortages []*model.StockShortage) error {
+	if len(shortages) == 0 {
+		return nil
+	}
+	query := `
+        INSERT INTO stock_shortages (
+            id, merchant_id, inventory_id, store_id, product_id, variant_id, order_id,
+            movement_id, quantity_short, status, notes, resolved_by, resolved_at, created_at
+        )
+        VALUES (
+            :id, :merchant_id, :inventory_id, :store_id, :product_id, :variant_id, :order_id,
+            :movement_id, :quantity_short, :status, :notes, :resolved_by, :resolved_at, :created_at
+        )
+    `
+	if _, err := r.conn(ctx).NamedExecContext(ctx, query, shortages); err != nil {
+		return fmt.Errorf("failed to flag stock shortage: %w", err)
+	}
+	return nil
+}
+
+func (r *PGRepository) FindStockShortageByID(ctx context.Context, merchantID, id string) (*model.StockShortage, error) {
+	var s model.StockShortage
+	query := `SELECT * FROM stock_shortages WHERE id = $1 AND merchant_id = $2`
+	err := r.conn(ctx).GetContext(ctx, &s, query, id, merchantID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *PGRepository) FindStockShortages(ctx context.Context, f *dto.StockShortageFilters) ([]model.StockShortage, int, error) {
+	var shortages []model.StockShortage
+	var count int
+
+	conditions := []string{"merchant_id = :merchant_id"}
+	args := map[string]interface{}{"merchant_id": f.MerchantID}
+
+	if f.StoreID != nil {
+		conditions = append(conditions, "store_id = :store_id")
+		args["store_id"] = *f.StoreID
+	}
+	if f.Status != "" {
+		conditions = append(conditions, "status = :status")
+		args["status"] = f.Status
+	}
+
+	whereClause := " WHERE " + strings.Join(conditions, " AND ")
+
+	countQuery := "SELECT count(*) FROM stock_shortages" + whereClause
+	if err := database.NamedGet(ctx, r.conn(ctx), &count, countQuery, args); err != nil {
+		return nil, 0, err
+	}
+
+	query := "SELECT * FROM stock_shortages" + whereClause + " ORDER BY created_at DESC"
+	if f.PageSize > 0 {
+		offset := (f.Page - 1) * f.PageSize
+		query += fmt.Sprintf(" LIMIT %d OFFSET %d", f.PageSize, offset)
+	}
+
+	err := database.NamedSelect(ctx, r.conn(ctx), &shortages, query, args)
+	return shortages, count, err
+}
+
+func (r *PGRepository) ResolveStockShortage(ctx context.Context, s *model.StockShortage) error {
+	query := `
+        UPDATE stock_shortages
+        SET status = :status,
+            notes = :notes,
+            resolved_by = :resolved_by,
+            resolved_at = :resolved_at
+        WHERE id = :id AND merchant_id = :merchant_id
+    `
+	_, err := r.conn(ctx).NamedExecContext(ctx, query, s)
+	return err
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/fekuna/omnipos-product-service/internal/database"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+)
+
+// ChangeToken returns the oldest transaction still running. Every change that a read
+// started afterwards misses was made by this transaction or a later one.
+func (r *PGRepository) ChangeToken(ctx context.Context) (string, error) {
+	return database.ChangeToken(ctx, r.conn(ctx))
+}
+
+// FindChangedSince returns the locations of one store changed by the transaction token or
+// a later one, every location of the store when token is empty. A nil storeID is the
+// warehouse.
+func (r *PGRepository) FindChangedSince(ctx context.Context, merchantID string, storeID *string, token string) ([]model.Inventory, error) {
+	query := `
+        SELECT * FROM inventory
+        WHERE merchant_id = $1 AND store_id IS NOT DISTINCT FROM $2
+            AND ($3 = '' OR change_xid >= NULLIF($3, '')::xid8)
+        ORDER BY product_id, variant_id NULLS FIRST
+    `
+	items := []model.Inventory{}
+	err := r.conn(ctx).SelectContext(ctx, &items, query, merchantID, storeID, token)
+	return items, err
+}
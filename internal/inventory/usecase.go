@@ -8,9 +8,19 @@ import (
 )
 
 type UseCase interface {
-	GetProductInventory(ctx context.Context, merchantID, productID string, storeID *string) (*model.Inventory, error)
-	ListLowStock(ctx context.Context, merchantID string, storeID *string, page, pageSize int) ([]model.Inventory, int, error)
+	GetProductInventory(ctx context.Context, filters *dto.InventoryFilters) ([]model.Inventory, error)
+	GetProductStock(ctx context.Context, merchantID, productID string) (*dto.ProductStock, error)
+	GetStockAvailability(ctx context.Context, input *dto.StockAvailabilityInput) (*dto.StockAvailability, error)
+	ListLowStock(ctx context.Context, filters *dto.InventoryFilters) ([]model.Inventory, int, error)
 	AdjustInventory(ctx context.Context, input *dto.AdjustInventoryInput) (*model.Inventory, error)
 	TransferInventory(ctx context.Context, input *dto.TransferInventoryInput) error
 	ListMovements(ctx context.Context, filters *dto.MovementFilters) ([]model.InventoryMovement, int, error)
+	// WatchStock sends a store's stock and then its changes until ctx ends or send fails.
+	WatchStock(ctx context.Context, input *dto.WatchStockInput, send func(*dto.StockUpdate) error) error
+	ListStockShortages(ctx context.Context, filters *dto.StockShortageFilters) ([]model.StockShortage, int, error)
+	ResolveStockShortage(ctx context.Context, input *dto.ResolveStockShortageInput) (*model.StockShortage, error)
+
+	// Broker events
+	ApplyOrderSale(ctx context.Context, input *dto.OrderSaleInput) (*dto.OrderSaleResult, error)
+	ApplyOrderReturn(ctx context.Context, input *dto.OrderReturnInput) (*dto.OrderReturnResult, error)
 }
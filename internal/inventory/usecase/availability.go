@@ -0,0 +1,135 @@
+package usecase
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/fekuna/omnipos-product-service/internal/inventory"
+	"github.com/fekuna/omnipos-product-service/internal/inventory/dto"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+)
+
+// availabilityCacheTTL is kept short because the cache is not invalidated on stock changes.
+const availabilityCacheTTL = 30 * time.Second
+
+// GetStockAvailability returns the available quantity of a product or variant at every
+// store holding it plus the warehouse, or at the requested stores only. Without a variant
+// the product is summed over its variants. Stores are sorted by quantity, most first.
+func (uc *inventoryUseCase) GetStockAvailability(ctx context.Context, input *dto.StockAvailabilityInput) (*dto.StockAvailability, error) {
+	productID, variantID, err := uc.resolveItem(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	var storeIDs []string
+	if len(input.StoreIDs) > 0 {
+		seen := make(map[string]bool, len(input.StoreIDs))
+		for _, id := range input.StoreIDs {
+			if !seen[id] {
+				seen[id] = true
+				storeIDs = append(storeIDs, id)
+			}
+		}
+		sort.Strings(storeIDs)
+	}
+
+	cacheKey, err := availabilityCacheKey(input.MerchantID, productID, variantID, storeIDs)
+	if err == nil {
+		if val, err := uc.cache.Client.Get(ctx, cacheKey).Result(); err == nil {
+			var cached dto.StockAvailability
+			if err := json.Unmarshal([]byte(val), &cached); err == nil {
+				return &cached, nil
+			}
+		}
+	}
+
+	rows, err := uc.repo.BatchGetByProducts(ctx, input.MerchantID, []string{productID}, storeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &dto.StockAvailability{ProductID: productID, VariantID: variantID}
+	stores := make(map[string]int)
+	addStore := func(storeID *string) int {
+		i, ok := stores[model.StoreKey(storeID)]
+		if !ok {
+			i = len(result.Stores)
+			stores[model.StoreKey(storeID)] = i
+			result.Stores = append(result.Stores, dto.StoreAvailability{StoreID: storeID})
+		}
+		return i
+	}
+
+	// Requested stores and the warehouse are listed even when they never held the item.
+	if storeIDs == nil {
+		addStore(nil)
+	}
+	for _, id := range storeIDs {
+		addStore(emptyToNil(&id))
+	}
+	for i := range rows {
+		inv := &rows[i]
+		if variantID != nil && (inv.VariantID == nil || *inv.VariantID != *variantID) {
+			continue
+		}
+		result.Stores[addStore(inv.StoreID)].AvailableQuantity += inv.AvailableQuantity
+	}
+
+	sort.SliceStable(result.Stores, func(i, j int) bool {
+		a, b := result.Stores[i], result.Stores[j]
+		if a.AvailableQuantity != b.AvailableQuantity {
+			return a.AvailableQuantity > b.AvailableQuantity
+		}
+		return model.StoreKey(a.StoreID) < model.StoreKey(b.StoreID)
+	})
+
+	if cacheKey != "" {
+		if data, err := json.Marshal(result); err == nil {
+			uc.cache.Client.Set(ctx, cacheKey, data, availabilityCacheTTL)
+		}
+	}
+
+	return result, nil
+}
+
+// resolveItem returns the product and variant of input, looking them up by barcode when no
+// product is given.
+func (uc *inventoryUseCase) resolveItem(ctx context.Context, input *dto.StockAvailabilityInput) (string, *string, error) {
+	if input.ProductID == "" {
+		if input.Barcode == "" {
+			return "", nil, inventory.ErrItemRequired
+		}
+		matches, err := uc.repo.FindByBarcode(ctx, input.MerchantID, input.Barcode)
+		if err != nil {
+			return "", nil, err
+		}
+		switch len(matches) {
+		case 0:
+			return "", nil, inventory.ErrBarcodeNotFound.With("Barcode", input.Barcode)
+		case 1:
+			return matches[0].ProductID, matches[0].VariantID, nil
+		default:
+			return "", nil, inventory.ErrBarcodeAmbiguous.With("Barcode", input.Barcode)
+		}
+	}
+
+	if err := uc.checkProductOwned(ctx, input.MerchantID, input.ProductID, input.VariantID); err != nil {
+		return "", nil, err
+	}
+	return input.ProductID, input.VariantID, nil
+}
+
+func availabilityCacheKey(merchantID, productID string, variantID *string, storeIDs []string) (string, error) {
+	data, err := json.Marshal(struct {
+		VariantID *string
+		StoreIDs  []string
+	}{variantID, storeIDs})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("inventory:availability:%s:%s:%x", merchantID, productID, md5.Sum(data)), nil
+}
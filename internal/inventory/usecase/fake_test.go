@@ -0,0 +1,98 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/fekuna/omnipos-pkg/logger"
+	"github.com/fekuna/omnipos-product-service/internal/inventory"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"go.uber.org/zap"
+)
+
+type nopLogger struct{ logger.ZapLogger }
+
+func (nopLogger) Debug(string, ...zap.Field) {}
+func (nopLogger) Info(string, ...zap.Field)  {}
+func (nopLogger) Warn(string, ...zap.Field)  {}
+func (nopLogger) Error(string, ...zap.Field) {}
+
+// fakeTx runs fn without a transaction; a failing fn leaves whatever the fake repository
+// already recorded.
+type fakeTx struct{}
+
+func (fakeTx) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// fakeRepo keeps the stock of one store by model.LocationKey and records every movement.
+type fakeRepo struct {
+	inventory.Repository
+	stock     map[string]*model.Inventory
+	sales     []model.OrderSale
+	movements []*model.InventoryMovement
+	shortages []*model.StockShortage
+}
+
+func newFakeRepo(stock ...model.Inventory) *fakeRepo {
+	r := &fakeRepo{stock: make(map[string]*model.Inventory)}
+	for i := range stock {
+		inv := stock[i]
+		r.stock[model.LocationKey(inv.ProductID, inv.VariantID)] = &inv
+	}
+	return r
+}
+
+func (r *fakeRepo) GetByLocationForUpdate(ctx context.Context, merchantID, productID string, variantID, storeID *string) (*model.Inventory, error) {
+	inv, ok := r.stock[model.LocationKey(productID, variantID)]
+	if !ok {
+		return nil, nil
+	}
+	found := *inv
+	return &found, nil
+}
+
+func (r *fakeRepo) AdjustStockWithMovement(ctx context.Context, inv *model.Inventory, movement *model.InventoryMovement) error {
+	return r.AdjustStockWithMovements(ctx, []*model.Inventory{inv}, []*model.InventoryMovement{movement})
+}
+
+func (r *fakeRepo) AdjustStockWithMovements(ctx context.Context, invs []*model.Inventory, movements []*model.InventoryMovement) error {
+	for _, inv := range invs {
+		stored := *inv
+		r.stock[model.LocationKey(inv.ProductID, inv.VariantID)] = &stored
+	}
+	r.movements = append(r.movements, movements...)
+	return nil
+}
+
+func (r *fakeRepo) ClaimEvent(ctx context.Context, event *model.ProcessedEvent) (bool, error) {
+	return true, nil
+}
+
+func (r *fakeRepo) FindOrderSalesForUpdate(ctx context.Context, merchantID, orderID string) ([]model.OrderSale, error) {
+	return r.sales, nil
+}
+
+func (r *fakeRepo) IsEventProcessed(ctx context.Context, eventID string) (bool, error) {
+	return false, nil
+}
+
+func (r *fakeRepo) MarkEventProcessed(ctx context.Context, event *model.ProcessedEvent) error {
+	return nil
+}
+
+func (r *fakeRepo) CountProcessedOrderLines(ctx context.Context, merchantID, orderID string) (int, error) {
+	return 0, nil
+}
+
+func (r *fakeRepo) ClaimOrderLine(ctx context.Context, line *model.ProcessedOrderLine) (bool, error) {
+	return true, nil
+}
+
+func (r *fakeRepo) FindOrderReservationForUpdate(ctx context.Context, merchantID, orderID string) (*model.StockReservation, error) {
+	return nil, nil
+}
+
+func (r *fakeRepo) CreateStockShortages(ctx context.Context, shortages []*model.StockShortage) error {
+	r.shortages = append(r.shortages, shortages...)
+	return nil
+}
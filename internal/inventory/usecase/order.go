@@ -0,0 +1,270 @@
+package usecase
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/fekuna/omnipos-product-service/internal/inventory"
+	"github.com/fekuna/omnipos-product-service/internal/inventory/dto"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ApplyOrderSale deducts the stock of an order exactly once and all-or-nothing: every line
+// is claimed and deducted in one transaction, so a failing line leaves the order untouched.
+// Lines claimed by an earlier delivery are skipped. An active reservation of the order is
+// consumed by the sale, and stock a committed one already deducted is not deducted again.
+// When a line is short, the configured InsufficientStockPolicy either rejects the order or
+// lets the location go negative and records a StockShortage.
+func (uc *inventoryUseCase) ApplyOrderSale(ctx context.Context, input *dto.OrderSaleInput) (*dto.OrderSaleResult, error) {
+	result := &dto.OrderSaleResult{}
+
+	if input.EventID != "" {
+		processed, err := uc.repo.IsEventProcessed(ctx, input.EventID)
+		if err != nil {
+			return nil, err
+		}
+		if processed {
+			result.AlreadyProcessed = true
+			return result, nil
+		}
+	}
+
+	applied, err := uc.repo.CountProcessedOrderLines(ctx, input.MerchantID, input.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	if applied >= len(input.Lines) {
+		result.AlreadyProcessed = true
+		return result, uc.markEventProcessed(ctx, input)
+	}
+
+	for i, line := range input.Lines {
+		if line.Quantity <= 0 {
+			return nil, inventory.ErrInvalidQuantity.With("Line", i)
+		}
+	}
+
+	err = uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		now := time.Now()
+
+		// Locked before the stock, in the order CommitReservation takes them.
+		res, err := uc.repo.FindOrderReservationForUpdate(ctx, input.MerchantID, input.OrderID)
+		if err != nil {
+			return err
+		}
+		locations, err := uc.lockOrderLocations(ctx, input)
+		if err != nil {
+			return err
+		}
+		quantities, err := uc.settleReservation(ctx, input, res, locations)
+		if err != nil {
+			return err
+		}
+
+		var movements []*model.InventoryMovement
+		var shortages []*model.StockShortage
+		touched := map[string]bool{}
+		var invs []*model.Inventory
+
+		for i, line := range input.Lines {
+			quantity := quantities[i]
+			var movementID *string
+			if quantity > 0 {
+				id := uuid.New().String()
+				movementID = &id
+			}
+			claimed, err := uc.claimOrderLine(ctx, input, i, movementID, now)
+			if err != nil {
+				return err
+			}
+			if !claimed || quantity == 0 {
+				result.Skipped++
+				continue
+			}
+
+			key := model.LocationKey(line.ProductID, line.VariantID)
+			inv := locations[key]
+			if inv == nil || inv.AvailableQuantity < quantity {
+				if uc.salePolicy != inventory.InsufficientStockAllowNegative {
+					return inventory.ErrInsufficientInventory.With("Line", i).With("ProductID", line.ProductID)
+				}
+				if inv == nil {
+					inv = &model.Inventory{
+						ID:         uuid.New().String(),
+						MerchantID: input.MerchantID,
+						StoreID:    input.StoreID,
+						ProductID:  line.ProductID,
+						VariantID:  line.VariantID,
+					}
+					locations[key] = inv
+				}
+				inv.NegativeAllowed = true
+				shortages = append(shortages, &model.StockShortage{
+					ID:            uuid.New().String(),
+					MerchantID:    input.MerchantID,
+					InventoryID:   inv.ID,
+					StoreID:       input.StoreID,
+					ProductID:     line.ProductID,
+					VariantID:     line.VariantID,
+					OrderID:       input.OrderID,
+					MovementID:    movementID,
+					QuantityShort: quantity - math.Max(inv.AvailableQuantity, 0),
+					Status:        model.StockShortageStatusOpen,
+					CreatedAt:     now,
+				})
+			}
+
+			quantityBefore := inv.Quantity
+			inv.Quantity -= quantity
+			inv.AvailableQuantity -= quantity
+			inv.UpdatedAt = now
+			if !touched[key] {
+				touched[key] = true
+				invs = append(invs, inv)
+			}
+
+			refType := "order"
+			refID := input.OrderID
+			movements = append(movements, &model.InventoryMovement{
+				ID:             *movementID,
+				MerchantID:     input.MerchantID,
+				StoreID:        input.StoreID,
+				ProductID:      line.ProductID,
+				VariantID:      line.VariantID,
+				MovementType:   model.MovementSale,
+				QuantityChange: -quantity,
+				QuantityBefore: quantityBefore,
+				QuantityAfter:  inv.Quantity,
+				ReferenceType:  &refType,
+				ReferenceID:    &refID,
+				Notes:          "Order Sale",
+				CreatedAt:      now,
+			})
+			result.Applied++
+		}
+
+		if err := uc.repo.AdjustStockWithMovements(ctx, invs, movements); err != nil {
+			return err
+		}
+		if err := uc.repo.CreateStockShortages(ctx, shortages); err != nil {
+			return err
+		}
+		result.Shortages = len(shortages)
+
+		return uc.markEventProcessed(ctx, input)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Shortages > 0 {
+		uc.logger.Warn("Order sold more than was in stock, flagged for review",
+			zap.String("order_id", input.OrderID),
+			zap.Int("shortages", result.Shortages),
+		)
+	}
+	return result, nil
+}
+
+// lockOrderLocations row-locks the inventory of every product in the order, in a fixed order
+// so two orders sharing products cannot deadlock. Products without a location map to nil.
+func (uc *inventoryUseCase) lockOrderLocations(ctx context.Context, input *dto.OrderSaleInput) (map[string]*model.Inventory, error) {
+	keys := make([]string, 0, len(input.Lines))
+	lines := make(map[string]dto.OrderLineInput, len(input.Lines))
+	for _, line := range input.Lines {
+		key := model.LocationKey(line.ProductID, line.VariantID)
+		if _, ok := lines[key]; !ok {
+			keys = append(keys, key)
+			lines[key] = line
+		}
+	}
+	sort.Strings(keys)
+
+	locations := make(map[string]*model.Inventory, len(keys))
+	for _, key := range keys {
+		line := lines[key]
+		inv, err := uc.repo.GetByLocationForUpdate(ctx, input.MerchantID, line.ProductID, line.VariantID, input.StoreID)
+		if err != nil {
+			return nil, err
+		}
+		locations[key] = inv
+	}
+	return locations, nil
+}
+
+// settleReservation returns the quantity each line still has to deduct. The holds of an
+// active reservation are freed for the sale to take; what a committed one sold is taken off
+// the lines of its locations in order, so every delivery splits it the same way.
+func (uc *inventoryUseCase) settleReservation(ctx context.Context, input *dto.OrderSaleInput, res *model.StockReservation, locations map[string]*model.Inventory) ([]float64, error) {
+	quantities := make([]float64, len(input.Lines))
+	for i, line := range input.Lines {
+		quantities[i] = line.Quantity
+	}
+	if res == nil {
+		return quantities, nil
+	}
+
+	switch res.Status {
+	case model.ReservationStatusActive:
+		if err := uc.repo.ConsumeReservation(ctx, res); err != nil {
+			return nil, err
+		}
+		for _, item := range res.Items {
+			for _, inv := range locations {
+				if inv != nil && inv.ID == item.InventoryID {
+					inv.ReservedQuantity = math.Max(inv.ReservedQuantity-item.Quantity, 0)
+					inv.AvailableQuantity = inv.Quantity - inv.ReservedQuantity
+				}
+			}
+		}
+
+	case model.ReservationStatusCommitted:
+		sold := make(map[string]float64)
+		for _, item := range res.Items {
+			if model.StoreKey(item.StoreID) == model.StoreKey(input.StoreID) {
+				sold[model.LocationKey(item.ProductID, item.VariantID)] += item.Quantity
+			}
+		}
+		for i, line := range input.Lines {
+			key := model.LocationKey(line.ProductID, line.VariantID)
+			taken := math.Min(sold[key], quantities[i])
+			sold[key] -= taken
+			quantities[i] -= taken
+		}
+	}
+	return quantities, nil
+}
+
+// claimOrderLine reports false when the line was applied by an earlier delivery.
+func (uc *inventoryUseCase) claimOrderLine(ctx context.Context, input *dto.OrderSaleInput, lineNo int, movementID *string, now time.Time) (bool, error) {
+	var eventID *string
+	if input.EventID != "" {
+		eventID = &input.EventID
+	}
+	return uc.repo.ClaimOrderLine(ctx, &model.ProcessedOrderLine{
+		MerchantID:  input.MerchantID,
+		OrderID:     input.OrderID,
+		LineNo:      lineNo,
+		EventID:     eventID,
+		MovementID:  movementID,
+		ProcessedAt: now,
+	})
+}
+
+func (uc *inventoryUseCase) markEventProcessed(ctx context.Context, input *dto.OrderSaleInput) error {
+	if input.EventID == "" {
+		return nil
+	}
+	orderID := input.OrderID
+	return uc.repo.MarkEventProcessed(ctx, &model.ProcessedEvent{
+		EventID:     input.EventID,
+		EventType:   input.EventType,
+		MerchantID:  input.MerchantID,
+		OrderID:     &orderID,
+		ProcessedAt: time.Now(),
+	})
+}
@@ -0,0 +1,133 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/fekuna/omnipos-product-service/internal/inventory"
+	"github.com/fekuna/omnipos-product-service/internal/inventory/dto"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+)
+
+func TestApplyOrderSale(t *testing.T) {
+	stock := []model.Inventory{
+		{ID: "inv-1", ProductID: "product-1", Quantity: 5, AvailableQuantity: 5},
+		{ID: "inv-2", ProductID: "product-2", Quantity: 3, ReservedQuantity: 2, AvailableQuantity: 1},
+	}
+	type shortage struct {
+		inventoryID string
+		productID   string
+		short       float64
+	}
+	tests := []struct {
+		name          string
+		policy        inventory.InsufficientStockPolicy
+		lines         []dto.OrderLineInput
+		wantErr       error
+		wantQuantity  map[string]float64 // by product, after the sale
+		wantShortages []shortage
+	}{
+		{
+			name:         "enough stock",
+			policy:       inventory.InsufficientStockReject,
+			lines:        []dto.OrderLineInput{{ProductID: "product-1", Quantity: 2}, {ProductID: "product-1", Quantity: 3}},
+			wantQuantity: map[string]float64{"product-1": 0, "product-2": 3},
+		},
+		{
+			name:         "reject fails the whole order",
+			policy:       inventory.InsufficientStockReject,
+			lines:        []dto.OrderLineInput{{ProductID: "product-1", Quantity: 2}, {ProductID: "product-2", Quantity: 2}},
+			wantErr:      inventory.ErrInsufficientInventory,
+			wantQuantity: map[string]float64{"product-1": 5, "product-2": 3},
+		},
+		{
+			name:         "reject a product never stocked",
+			policy:       inventory.InsufficientStockReject,
+			lines:        []dto.OrderLineInput{{ProductID: "product-3", Quantity: 1}},
+			wantErr:      inventory.ErrInsufficientInventory,
+			wantQuantity: map[string]float64{"product-1": 5, "product-2": 3},
+		},
+		{
+			name:         "allow negative with enough stock records no shortage",
+			policy:       inventory.InsufficientStockAllowNegative,
+			lines:        []dto.OrderLineInput{{ProductID: "product-1", Quantity: 4}},
+			wantQuantity: map[string]float64{"product-1": 1, "product-2": 3},
+		},
+		{
+			name:          "allow negative records what was not available",
+			policy:        inventory.InsufficientStockAllowNegative,
+			lines:         []dto.OrderLineInput{{ProductID: "product-1", Quantity: 7}, {ProductID: "product-2", Quantity: 2}},
+			wantQuantity:  map[string]float64{"product-1": -2, "product-2": 1},
+			wantShortages: []shortage{{"inv-1", "product-1", 2}, {"inv-2", "product-2", 1}},
+		},
+		{
+			name:          "allow negative on a location already below zero",
+			policy:        inventory.InsufficientStockAllowNegative,
+			lines:         []dto.OrderLineInput{{ProductID: "product-1", Quantity: 6}, {ProductID: "product-1", Quantity: 2}},
+			wantQuantity:  map[string]float64{"product-1": -3, "product-2": 3},
+			wantShortages: []shortage{{"inv-1", "product-1", 1}, {"inv-1", "product-1", 2}},
+		},
+		{
+			name:          "allow negative on a product never stocked",
+			policy:        inventory.InsufficientStockAllowNegative,
+			lines:         []dto.OrderLineInput{{ProductID: "product-3", Quantity: 2}},
+			wantQuantity:  map[string]float64{"product-1": 5, "product-2": 3, "product-3": -2},
+			wantShortages: []shortage{{"", "product-3", 2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo(stock...)
+			uc := NewInventoryUseCase(repo, fakeTx{}, nil, nil, tt.policy, nopLogger{})
+
+			result, err := uc.ApplyOrderSale(context.Background(), &dto.OrderSaleInput{
+				EventID: "evt-1",
+				OrderID: "order-1",
+				Lines:   tt.lines,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ApplyOrderSale error = %v, want %v", err, tt.wantErr)
+			}
+
+			quantities := make(map[string]float64)
+			for _, inv := range repo.stock {
+				quantities[inv.ProductID] = inv.Quantity
+			}
+			if !reflect.DeepEqual(quantities, tt.wantQuantity) {
+				t.Errorf("quantities = %v, want %v", quantities, tt.wantQuantity)
+			}
+
+			var shortages []shortage
+			for _, s := range repo.shortages {
+				id := s.InventoryID
+				if s.ProductID == "product-3" {
+					if inv := repo.stock[model.LocationKey("product-3", nil)]; inv == nil || inv.ID != id {
+						t.Errorf("shortage of product-3 points at inventory %q, not the created location", id)
+					}
+					id = ""
+				}
+				if !repo.stock[model.LocationKey(s.ProductID, s.VariantID)].NegativeAllowed {
+					t.Errorf("location of %s overdrawn without NegativeAllowed", s.ProductID)
+				}
+				shortages = append(shortages, shortage{id, s.ProductID, s.QuantityShort})
+			}
+			if !reflect.DeepEqual(shortages, tt.wantShortages) {
+				t.Errorf("shortages = %v, want %v", shortages, tt.wantShortages)
+			}
+
+			if err != nil {
+				return
+			}
+			if result.Applied != len(tt.lines) || result.Shortages != len(tt.wantShortages) {
+				t.Errorf("result = %+v, want %d applied and %d shortages", result, len(tt.lines), len(tt.wantShortages))
+			}
+			for _, m := range repo.movements {
+				if m.MovementType != model.MovementSale || m.QuantityAfter != m.QuantityBefore+m.QuantityChange {
+					t.Errorf("movement %+v is not a consistent sale", m)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package usecase
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/fekuna/omnipos-product-service/internal/inventory"
+	"github.com/fekuna/omnipos-product-service/internal/inventory/dto"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// quantityEpsilon is half the DECIMAL(15,3) precision of quantities.
+const quantityEpsilon = 0.0005
+
+// returnAllocation is the part of a returned line taken back from one sale movement.
+type returnAllocation struct {
+	sale     *model.OrderSale
+	quantity float64
+	damaged  bool
+}
+
+// ApplyOrderReturn reverses sale movements of an order with 'return' movements that point
+// at the sale they undo. Damaged lines are written off right after being returned, so the
+// sale is still reversed but sellable stock does not grow. The event is claimed in the same
+// transaction, so a redelivery is a no-op.
+func (uc *inventoryUseCase) ApplyOrderReturn(ctx context.Context, input *dto.OrderReturnInput) (*dto.OrderReturnResult, error) {
+	if input.EventID == "" {
+		return nil, inventory.ErrEventIDRequired
+	}
+	for _, line := range input.Lines {
+		if line.Quantity <= 0 {
+			return nil, inventory.ErrInvalidQuantity
+		}
+	}
+
+	result := &dto.OrderReturnResult{}
+	err := uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		orderID := input.OrderID
+		claimed, err := uc.repo.ClaimEvent(ctx, &model.ProcessedEvent{
+			EventID:     input.EventID,
+			EventType:   input.EventType,
+			MerchantID:  input.MerchantID,
+			OrderID:     &orderID,
+			ProcessedAt: now,
+		})
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			result.AlreadyProcessed = true
+			return nil
+		}
+
+		sales, err := uc.repo.FindOrderSalesForUpdate(ctx, input.MerchantID, input.OrderID)
+		if err != nil {
+			return err
+		}
+
+		allocations, err := allocateReturns(sales, input.Lines)
+		if err != nil {
+			return err
+		}
+
+		for _, a := range allocations {
+			if err := uc.postReturn(ctx, input, a, now); err != nil {
+				return err
+			}
+			if a.damaged {
+				result.WrittenOff += a.quantity
+			} else {
+				result.Restocked += a.quantity
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !result.AlreadyProcessed {
+		uc.logger.Info("Order return applied",
+			zap.String("event_id", input.EventID),
+			zap.String("order_id", input.OrderID),
+			zap.Float64("restocked", result.Restocked),
+			zap.Float64("written_off", result.WrittenOff),
+		)
+	}
+	return result, nil
+}
+
+// allocateReturns spreads each returned line over the order's sales of the same product,
+// oldest first. Without lines, whatever has not been returned yet is returned.
+func allocateReturns(sales []model.OrderSale, lines []dto.OrderReturnLineInput) ([]returnAllocation, error) {
+	remaining := make([]float64, len(sales))
+	for i, s := range sales {
+		remaining[i] = s.Remaining()
+	}
+
+	var allocations []returnAllocation
+	if len(lines) == 0 {
+		for i := range sales {
+			if remaining[i] > quantityEpsilon {
+				allocations = append(allocations, returnAllocation{sale: &sales[i], quantity: remaining[i]})
+			}
+		}
+		return allocations, nil
+	}
+
+	for _, line := range lines {
+		left := line.Quantity
+		for i := range sales {
+			if left <= quantityEpsilon {
+				break
+			}
+			s := &sales[i]
+			if s.ProductID != line.ProductID || !sameVariant(s.VariantID, line.VariantID) || remaining[i] <= quantityEpsilon {
+				continue
+			}
+
+			q := math.Min(left, remaining[i])
+			remaining[i] -= q
+			left -= q
+			allocations = append(allocations, returnAllocation{sale: s, quantity: q, damaged: line.Damaged})
+		}
+		if left > quantityEpsilon {
+			return nil, inventory.ErrReturnExceedsSale.With("ProductID", line.ProductID)
+		}
+	}
+	return allocations, nil
+}
+
+// postReturn books the return at the store the sale was made from.
+func (uc *inventoryUseCase) postReturn(ctx context.Context, input *dto.OrderReturnInput, a returnAllocation, now time.Time) error {
+	sale := a.sale
+	inv, err := uc.repo.GetByLocationForUpdate(ctx, input.MerchantID, sale.ProductID, sale.VariantID, sale.StoreID)
+	if err != nil {
+		return err
+	}
+	if inv == nil {
+		return inventory.ErrLocationGone.With("ProductID", sale.ProductID)
+	}
+
+	refType := "order"
+	refID := input.OrderID
+	saleID := sale.ID
+
+	quantityBefore := inv.Quantity
+	inv.Quantity += a.quantity
+	inv.AvailableQuantity += a.quantity
+	inv.UpdatedAt = now
+
+	movement := &model.InventoryMovement{
+		ID:                 uuid.New().String(),
+		MerchantID:         input.MerchantID,
+		StoreID:            sale.StoreID,
+		ProductID:          sale.ProductID,
+		VariantID:          sale.VariantID,
+		MovementType:       model.MovementReturn,
+		QuantityChange:     a.quantity,
+		QuantityBefore:     quantityBefore,
+		QuantityAfter:      inv.Quantity,
+		ReferenceType:      &refType,
+		ReferenceID:        &refID,
+		ReversesMovementID: &saleID,
+		Notes:              input.Notes,
+		CreatedAt:          now,
+	}
+	if err := uc.repo.AdjustStockWithMovement(ctx, inv, movement); err != nil {
+		return err
+	}
+	if !a.damaged {
+		return nil
+	}
+
+	quantityBefore = inv.Quantity
+	inv.Quantity -= a.quantity
+	inv.AvailableQuantity -= a.quantity
+
+	writeOff := &model.InventoryMovement{
+		ID:             uuid.New().String(),
+		MerchantID:     input.MerchantID,
+		StoreID:        sale.StoreID,
+		ProductID:      sale.ProductID,
+		VariantID:      sale.VariantID,
+		MovementType:   model.MovementWriteOff,
+		QuantityChange: -a.quantity,
+		QuantityBefore: quantityBefore,
+		QuantityAfter:  inv.Quantity,
+		ReferenceType:  &refType,
+		ReferenceID:    &refID,
+		Notes:          "Damaged return written off",
+		CreatedAt:      now,
+	}
+	return uc.repo.AdjustStockWithMovement(ctx, inv, writeOff)
+}
+
+func sameVariant(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
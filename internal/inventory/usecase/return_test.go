@@ -0,0 +1,153 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/fekuna/omnipos-product-service/internal/inventory"
+	"github.com/fekuna/omnipos-product-service/internal/inventory/dto"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+)
+
+func sale(id, productID string, sold, returned float64) model.OrderSale {
+	return model.OrderSale{
+		InventoryMovement: model.InventoryMovement{
+			ID:             id,
+			ProductID:      productID,
+			MovementType:   model.MovementSale,
+			QuantityChange: -sold,
+		},
+		ReturnedQuantity: returned,
+	}
+}
+
+func TestAllocateReturns(t *testing.T) {
+	variant := "variant-1"
+	sales := []model.OrderSale{
+		sale("sale-1", "product-1", 2, 1),
+		sale("sale-2", "product-2", 5, 0),
+		sale("sale-3", "product-1", 3, 0),
+	}
+	variantSale := sale("sale-4", "product-1", 4, 0)
+	variantSale.VariantID = &variant
+
+	type allocation struct {
+		sale     string
+		quantity float64
+		damaged  bool
+	}
+	tests := []struct {
+		name    string
+		sales   []model.OrderSale
+		lines   []dto.OrderReturnLineInput
+		want    []allocation
+		wantErr error
+	}{
+		{
+			name:  "partial return spread over sales oldest first",
+			sales: sales,
+			lines: []dto.OrderReturnLineInput{{ProductID: "product-1", Quantity: 3}},
+			want:  []allocation{{"sale-1", 1, false}, {"sale-3", 2, false}},
+		},
+		{
+			name:  "variant lines only take their variant's sales",
+			sales: append([]model.OrderSale{variantSale}, sales...),
+			lines: []dto.OrderReturnLineInput{{ProductID: "product-1", Quantity: 1}, {ProductID: "product-1", VariantID: &variant, Quantity: 1}},
+			want:  []allocation{{"sale-1", 1, false}, {"sale-4", 1, false}},
+		},
+		{
+			name:  "damaged line",
+			sales: sales,
+			lines: []dto.OrderReturnLineInput{{ProductID: "product-2", Quantity: 2, Damaged: true}},
+			want:  []allocation{{"sale-2", 2, true}},
+		},
+		{
+			name:  "no lines return whatever is left",
+			sales: sales,
+			want:  []allocation{{"sale-1", 1, false}, {"sale-2", 5, false}, {"sale-3", 3, false}},
+		},
+		{
+			name:    "more than was sold",
+			sales:   sales,
+			lines:   []dto.OrderReturnLineInput{{ProductID: "product-1", Quantity: 5}},
+			wantErr: inventory.ErrReturnExceedsSale,
+		},
+		{
+			name:    "over the second line after the first took the rest",
+			sales:   sales,
+			lines:   []dto.OrderReturnLineInput{{ProductID: "product-1", Quantity: 4}, {ProductID: "product-1", Quantity: 0.5}},
+			wantErr: inventory.ErrReturnExceedsSale,
+		},
+		{
+			name:    "product not sold",
+			sales:   sales,
+			lines:   []dto.OrderReturnLineInput{{ProductID: "product-3", Quantity: 1}},
+			wantErr: inventory.ErrReturnExceedsSale,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allocations, err := allocateReturns(tt.sales, tt.lines)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("allocateReturns error = %v, want %v", err, tt.wantErr)
+			}
+			var got []allocation
+			for _, a := range allocations {
+				got = append(got, allocation{a.sale.ID, a.quantity, a.damaged})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("allocateReturns = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyOrderReturnWritesOffDamagedLines(t *testing.T) {
+	repo := newFakeRepo(model.Inventory{ID: "inv-1", ProductID: "product-1", Quantity: 10, AvailableQuantity: 10})
+	repo.sales = []model.OrderSale{sale("sale-1", "product-1", 3, 0)}
+	uc := NewInventoryUseCase(repo, fakeTx{}, nil, nil, inventory.InsufficientStockReject, nopLogger{})
+
+	result, err := uc.ApplyOrderReturn(context.Background(), &dto.OrderReturnInput{
+		EventID: "evt-1",
+		OrderID: "order-1",
+		Lines: []dto.OrderReturnLineInput{
+			{ProductID: "product-1", Quantity: 2, Damaged: true},
+			{ProductID: "product-1", Quantity: 1},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ApplyOrderReturn: %v", err)
+	}
+	if result.WrittenOff != 2 || result.Restocked != 1 {
+		t.Errorf("result = %+v, want 2 written off and 1 restocked", result)
+	}
+
+	type movement struct {
+		movementType model.MovementType
+		change       float64
+		before       float64
+		after        float64
+		reverses     string
+	}
+	var got []movement
+	for _, m := range repo.movements {
+		reverses := ""
+		if m.ReversesMovementID != nil {
+			reverses = *m.ReversesMovementID
+		}
+		got = append(got, movement{m.MovementType, m.QuantityChange, m.QuantityBefore, m.QuantityAfter, reverses})
+	}
+	want := []movement{
+		{model.MovementReturn, 2, 10, 12, "sale-1"},
+		{model.MovementWriteOff, -2, 12, 10, ""},
+		{model.MovementReturn, 1, 10, 11, "sale-1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("movements = %v, want %v", got, want)
+	}
+	if inv := repo.stock[model.LocationKey("product-1", nil)]; inv.Quantity != 11 || inv.AvailableQuantity != 11 {
+		t.Errorf("stock = %v/%v, want 11/11", inv.Quantity, inv.AvailableQuantity)
+	}
+}
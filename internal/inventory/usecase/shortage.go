@@ -0,0 +1,45 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/fekuna/omnipos-product-service/internal/inventory"
+	"github.com/fekuna/omnipos-product-service/internal/inventory/dto"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+)
+
+func (uc *inventoryUseCase) ListStockShortages(ctx context.Context, filters *dto.StockShortageFilters) ([]model.StockShortage, int, error) {
+	return uc.repo.FindStockShortages(ctx, filters)
+}
+
+// ResolveStockShortage closes a flag once someone has looked into it, typically after a
+// recount or a correcting adjustment. It does not move stock itself.
+func (uc *inventoryUseCase) ResolveStockShortage(ctx context.Context, input *dto.ResolveStockShortageInput) (*model.StockShortage, error) {
+	s, err := uc.repo.FindStockShortageByID(ctx, input.MerchantID, input.ShortageID)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, inventory.ErrStockShortageNotFound
+	}
+	if s.Status == model.StockShortageStatusResolved {
+		return s, nil
+	}
+
+	now := time.Now()
+	s.Status = model.StockShortageStatusResolved
+	s.ResolvedAt = &now
+	if input.UserID != "" {
+		s.ResolvedBy = &input.UserID
+	}
+	if notes := strings.TrimSpace(input.Notes); notes != "" {
+		s.Notes = &notes
+	}
+
+	if err := uc.repo.ResolveStockShortage(ctx, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
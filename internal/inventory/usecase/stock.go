@@ -0,0 +1,59 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/fekuna/omnipos-product-service/internal/inventory/dto"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/fekuna/omnipos-product-service/internal/product"
+)
+
+// GetProductStock sums the stock of a product per variant, per store and overall. Variants
+// and stores are listed in the order their first row was found.
+func (uc *inventoryUseCase) GetProductStock(ctx context.Context, merchantID, productID string) (*dto.ProductStock, error) {
+	owned, err := uc.repo.IsProductOwned(ctx, merchantID, productID, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !owned {
+		return nil, product.ErrProductNotFound
+	}
+
+	rows, _, err := uc.repo.FindAll(ctx, &dto.InventoryFilters{MerchantID: merchantID, ProductID: productID})
+	if err != nil {
+		return nil, err
+	}
+
+	stock := &dto.ProductStock{ProductID: productID}
+	variants := make(map[string]int)
+	stores := make(map[string]int)
+	for i := range rows {
+		inv := &rows[i]
+		stock.Total.Add(inv)
+
+		vi, ok := variants[model.LocationKey(productID, inv.VariantID)]
+		if !ok {
+			vi = len(stock.Variants)
+			variants[model.LocationKey(productID, inv.VariantID)] = vi
+			stock.Variants = append(stock.Variants, dto.VariantStock{VariantID: inv.VariantID})
+		}
+		stock.Variants[vi].Add(inv)
+		if isLowStock(inv) {
+			stock.Variants[vi].LowStock = true
+		}
+
+		si, ok := stores[model.StoreKey(inv.StoreID)]
+		if !ok {
+			si = len(stock.Stores)
+			stores[model.StoreKey(inv.StoreID)] = si
+			stock.Stores = append(stock.Stores, dto.StoreStock{StoreID: inv.StoreID})
+		}
+		stock.Stores[si].Add(inv)
+	}
+	return stock, nil
+}
+
+// isLowStock matches the low stock filter of the repository.
+func isLowStock(inv *model.Inventory) bool {
+	return inv.ReorderPoint > 0 && inv.AvailableQuantity <= inv.ReorderPoint
+}
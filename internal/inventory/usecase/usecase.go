@@ -2,172 +2,304 @@ package usecase
 
 import (
 	"context"
-	"errors"
-	"fmt"
+	"sort"
 	"time"
 
 	"github.com/fekuna/omnipos-pkg/cache"
 	"github.com/fekuna/omnipos-pkg/logger"
+	"github.com/fekuna/omnipos-product-service/internal/database"
 	"github.com/fekuna/omnipos-product-service/internal/inventory"
 	"github.com/fekuna/omnipos-product-service/internal/inventory/dto"
 	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/fekuna/omnipos-product-service/internal/product"
 	"github.com/google/uuid"
-	"go.uber.org/zap"
 )
 
 type inventoryUseCase struct {
-	repo   inventory.Repository
-	cache  *cache.RedisClient
-	logger logger.ZapLogger
+	repo       inventory.Repository
+	tx         database.TxManager
+	cache      *cache.RedisClient
+	feed       inventory.StockFeed
+	salePolicy inventory.InsufficientStockPolicy
+	logger     logger.ZapLogger
 }
 
-func NewInventoryUseCase(repo inventory.Repository, cache *cache.RedisClient, log logger.ZapLogger) inventory.UseCase {
+func NewInventoryUseCase(repo inventory.Repository, tx database.TxManager, cache *cache.RedisClient, feed inventory.StockFeed, salePolicy inventory.InsufficientStockPolicy, log logger.ZapLogger) inventory.UseCase {
+	if salePolicy != inventory.InsufficientStockAllowNegative {
+		salePolicy = inventory.InsufficientStockReject
+	}
 	return &inventoryUseCase{
-		repo:   repo,
-		cache:  cache,
-		logger: log,
+		repo:       repo,
+		tx:         tx,
+		cache:      cache,
+		feed:       feed,
+		salePolicy: salePolicy,
+		logger:     log,
 	}
 }
 
-func (uc *inventoryUseCase) GetProductInventory(ctx context.Context, merchantID, productID string, storeID *string) (*model.Inventory, error) {
-	inv, err := uc.repo.GetByProduct(ctx, merchantID, productID, storeID)
+// GetProductInventory returns one row per variant and store of a product, narrowed by the
+// variant and store of filters. A single location that was never stocked comes back as a
+// zero row, so callers can tell it apart from a filter that matched nothing.
+func (uc *inventoryUseCase) GetProductInventory(ctx context.Context, filters *dto.InventoryFilters) ([]model.Inventory, error) {
+	items, _, err := uc.repo.FindAll(ctx, &dto.InventoryFilters{
+		MerchantID: filters.MerchantID,
+		ProductID:  filters.ProductID,
+		VariantID:  filters.VariantID,
+		StoreID:    filters.StoreID,
+	})
 	if err != nil {
 		return nil, err
 	}
-	if inv == nil {
-		// Return zero inventory if not found, or nil depending on requirement.
-		// Usually good to return a zero object.
-		return &model.Inventory{
-			MerchantID:        merchantID,
-			StoreID:           storeID,
-			ProductID:         productID,
-			Quantity:          0,
-			AvailableQuantity: 0,
-		}, nil
-	}
-	return inv, nil
+	if len(items) == 0 && filters.ProductID != "" {
+		// Tell a product without stock apart from one of another merchant.
+		if err := uc.checkProductOwned(ctx, filters.MerchantID, filters.ProductID, emptyToNil(filters.VariantID)); err != nil {
+			return nil, err
+		}
+	}
+	if len(items) == 0 && filters.VariantID != nil && filters.StoreID != nil {
+		return []model.Inventory{{
+			MerchantID: filters.MerchantID,
+			StoreID:    emptyToNil(filters.StoreID),
+			ProductID:  filters.ProductID,
+			VariantID:  emptyToNil(filters.VariantID),
+		}}, nil
+	}
+	return items, nil
 }
 
-func (uc *inventoryUseCase) ListLowStock(ctx context.Context, merchantID string, storeID *string, page, pageSize int) ([]model.Inventory, int, error) {
-	return uc.repo.FindAll(ctx, &dto.InventoryFilters{
-		MerchantID: merchantID,
-		StoreID:    storeID,
-		LowStock:   true,
-		Page:       page,
-		PageSize:   pageSize,
-	})
+// ListLowStock lists every product or variant location at or below its reorder point.
+func (uc *inventoryUseCase) ListLowStock(ctx context.Context, filters *dto.InventoryFilters) ([]model.Inventory, int, error) {
+	f := *filters
+	f.LowStock = true
+	return uc.repo.FindAll(ctx, &f)
 }
 
 func (uc *inventoryUseCase) AdjustInventory(ctx context.Context, input *dto.AdjustInventoryInput) (*model.Inventory, error) {
-	// 0. Acquire Lock
-	// Key: lock:inventory:{merchantID}:{productID}:{variantID}:{storeID} // simplied to lock per product/variant
-	lockKey := fmt.Sprintf("lock:inventory:%s:%s", input.MerchantID, input.ProductID)
-	if input.VariantID != nil {
-		lockKey += ":" + *input.VariantID
-	}
-	if input.StoreID != nil {
-		lockKey += ":" + *input.StoreID
-	}
-
-	lockValue := uuid.New().String()
-	// Try to acquire lock with retry mechanism or simple fail
-	// Simple retry loop (e.g. 3 attempts)
-	acquired := false
-	for i := 0; i < 3; i++ {
-		ok, err := uc.cache.AcquireLock(ctx, lockKey, lockValue, 5*time.Second)
+	movementType, err := adjustmentType(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *model.Inventory
+	err = uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Lock the location, so transfers, sales and reservations can't change it in between.
+		inv, err := uc.repo.GetByLocationForUpdate(ctx, input.MerchantID, input.ProductID, input.VariantID, input.StoreID)
 		if err != nil {
-			uc.logger.Error("failed to acquire lock redis error", zap.Error(err))
+			return err
 		}
-		if ok {
-			acquired = true
-			break
+
+		now := time.Now()
+
+		if inv == nil {
+			// A new location must not point at another merchant's product.
+			owned, err := uc.repo.IsProductOwned(ctx, input.MerchantID, input.ProductID, input.VariantID)
+			if err != nil {
+				return err
+			}
+			if !owned {
+				return product.ErrProductNotFound
+			}
+
+			inv = &model.Inventory{
+				ID:         uuid.New().String(),
+				MerchantID: input.MerchantID,
+				StoreID:    input.StoreID,
+				ProductID:  input.ProductID,
+				VariantID:  input.VariantID,
+				Quantity:   0,
+				UpdatedAt:  now,
+			}
 		}
-		time.Sleep(100 * time.Millisecond) // wait before retry
-	}
 
-	if !acquired {
-		return nil, errors.New("system busy, please try again later (lock)")
-	}
+		quantityBefore := inv.Quantity
+		inv.Quantity += input.QuantityChange
+		inv.AvailableQuantity += input.QuantityChange
+		inv.UpdatedAt = now
+
+		// Stock held by reservations can't be adjusted away. A location oversold by an order
+		// may be restocked while it is still short.
+		if inv.Quantity < inv.ReservedQuantity && (input.QuantityChange < 0 || !inv.NegativeAllowed) {
+			return inventory.ErrInsufficientInventory
+		}
+
+		var refID *string
+		if input.ReferenceID != "" {
+			refID = &input.ReferenceID
+		}
+		refType := input.ReferenceType
+		if refType == "" {
+			refType = "adjustment"
+		}
 
-	defer uc.cache.ReleaseLock(ctx, lockKey, lockValue)
+		var createdBy *string
+		if input.UserID != "" && input.UserID != "unknown" {
+			createdBy = &input.UserID
+		}
+
+		movement := &model.InventoryMovement{
+			ID:             uuid.New().String(),
+			MerchantID:     input.MerchantID,
+			StoreID:        input.StoreID,
+			ProductID:      input.ProductID,
+			VariantID:      input.VariantID,
+			MovementType:   movementType,
+			QuantityChange: input.QuantityChange,
+			QuantityBefore: quantityBefore,
+			QuantityAfter:  inv.Quantity,
+			ReferenceType:  &refType,
+			ReferenceID:    refID,
+			Notes:          input.Reason,
+			CreatedBy:      createdBy,
+			CreatedAt:      now,
+		}
 
-	// 1. Get current inventory
-	inv, err := uc.repo.GetByProduct(ctx, input.MerchantID, input.ProductID, input.StoreID)
+		if err := uc.repo.AdjustStockWithMovement(ctx, inv, movement); err != nil {
+			return err
+		}
+		result = inv
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	now := time.Now()
+	return result, nil
+}
 
-	if inv == nil {
-		// Create new
-		inv = &model.Inventory{
-			ID:         uuid.New().String(),
-			MerchantID: input.MerchantID,
-			StoreID:    input.StoreID,
-			ProductID:  input.ProductID,
-			VariantID:  input.VariantID,
-			Quantity:   0,
-			UpdatedAt:  now,
-		}
+func (uc *inventoryUseCase) TransferInventory(ctx context.Context, input *dto.TransferInventoryInput) error {
+	if input.Quantity <= 0 {
+		return inventory.ErrInvalidQuantity
+	}
+	if model.StoreKey(input.SourceStoreID) == model.StoreKey(input.TargetStoreID) {
+		return inventory.ErrSameStore
 	}
 
-	quantityBefore := inv.Quantity
-	inv.Quantity += input.QuantityChange
-	inv.UpdatedAt = now
+	return uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Lock both rows in a fixed order so opposite transfers can't deadlock.
+		locked := make(map[string]*model.Inventory, 2)
+		stores := []*string{input.SourceStoreID, input.TargetStoreID}
+		sort.Slice(stores, func(i, j int) bool { return model.StoreKey(stores[i]) < model.StoreKey(stores[j]) })
+		for _, storeID := range stores {
+			inv, err := uc.repo.GetByLocationForUpdate(ctx, input.MerchantID, input.ProductID, input.VariantID, storeID)
+			if err != nil {
+				return err
+			}
+			locked[model.StoreKey(storeID)] = inv
+		}
 
-	if inv.Quantity < 0 {
-		return nil, errors.New("insufficient inventory")
-	}
+		source := locked[model.StoreKey(input.SourceStoreID)]
+		if source == nil {
+			if err := uc.checkProductOwned(ctx, input.MerchantID, input.ProductID, input.VariantID); err != nil {
+				return err
+			}
+		}
+		if source == nil || source.AvailableQuantity < input.Quantity {
+			return inventory.ErrInsufficientInventory.With("ProductID", input.ProductID)
+		}
 
-	var refID *string
-	if input.ReferenceID != "" {
-		refID = &input.ReferenceID
-	}
-	var refType *string
-	if input.ReferenceType != "" {
-		refType = &input.ReferenceType
-	}
+		now := time.Now()
+		target := locked[model.StoreKey(input.TargetStoreID)]
+		if target == nil {
+			target = &model.Inventory{
+				ID:         uuid.New().String(),
+				MerchantID: input.MerchantID,
+				StoreID:    input.TargetStoreID,
+				ProductID:  input.ProductID,
+				VariantID:  input.VariantID,
+			}
+		}
+
+		// Both legs share one reference ID so the pair can be traced back to this transfer.
+		referenceID := uuid.New().String()
+
+		outMovement := uc.applyTransferLeg(source, -input.Quantity, model.MovementTransferOut, referenceID, input, now)
+		if err := uc.repo.AdjustStockWithMovement(ctx, source, outMovement); err != nil {
+			return err
+		}
+
+		inMovement := uc.applyTransferLeg(target, input.Quantity, model.MovementTransferIn, referenceID, input, now)
+		return uc.repo.AdjustStockWithMovement(ctx, target, inMovement)
+	})
+}
 
+// applyTransferLeg changes inv by change and returns the movement that records it.
+func (uc *inventoryUseCase) applyTransferLeg(inv *model.Inventory, change float64, movementType model.MovementType, referenceID string, input *dto.TransferInventoryInput, now time.Time) *model.InventoryMovement {
+	quantityBefore := inv.Quantity
+	inv.Quantity += change
+	inv.AvailableQuantity += change
+	inv.UpdatedAt = now
+
+	refType := "transfer"
 	var createdBy *string
-	if input.UserID != "" && input.UserID != "unknown" {
+	if input.UserID != "" {
 		createdBy = &input.UserID
 	}
 
-	// 2. Prepare Movement Log
-	movement := &model.InventoryMovement{
+	return &model.InventoryMovement{
 		ID:             uuid.New().String(),
-		MerchantID:     input.MerchantID,
-		StoreID:        input.StoreID,
-		ProductID:      input.ProductID,
-		VariantID:      input.VariantID,
-		MovementType:   "adjustment", // or input derived
-		QuantityChange: input.QuantityChange,
+		MerchantID:     inv.MerchantID,
+		StoreID:        inv.StoreID,
+		ProductID:      inv.ProductID,
+		VariantID:      inv.VariantID,
+		MovementType:   movementType,
+		QuantityChange: change,
 		QuantityBefore: quantityBefore,
 		QuantityAfter:  inv.Quantity,
-		ReferenceType:  refType,
-		ReferenceID:    refID,
+		ReferenceType:  &refType,
+		ReferenceID:    &referenceID,
 		Notes:          input.Reason,
 		CreatedBy:      createdBy,
 		CreatedAt:      now,
 	}
+}
 
-	err = uc.repo.AdjustStockWithMovement(ctx, inv, movement)
+// checkProductOwned returns a not-found error unless the merchant owns the product, and the
+// variant when one is given.
+func (uc *inventoryUseCase) checkProductOwned(ctx context.Context, merchantID, productID string, variantID *string) error {
+	owned, err := uc.repo.IsProductOwned(ctx, merchantID, productID, variantID)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if !owned {
+		if variantID != nil {
+			return product.ErrVariantNotFound
+		}
+		return product.ErrProductNotFound
 	}
+	return nil
+}
 
-	return inv, nil
+func (uc *inventoryUseCase) ListMovements(ctx context.Context, filters *dto.MovementFilters) ([]model.InventoryMovement, int, error) {
+	if filters.MovementType != "" && !filters.MovementType.Valid() {
+		return nil, 0, inventory.ErrInvalidMovementType.With("MovementType", filters.MovementType)
+	}
+	return uc.repo.ListMovements(ctx, filters)
 }
 
-func (uc *inventoryUseCase) TransferInventory(ctx context.Context, input *dto.TransferInventoryInput) error {
-	// This requires transactional adjustment of two store inventories.
-	// Since our repo only supports single 'AdjustStockWithMovement' transactionally,
-	// we ideally need a Transaction Manager to wrap two repo calls.
-	// TODO: Implement Transaction Manager
-	return errors.New("transfer not implemented yet")
+// adjustmentType validates the movement type of a manual adjustment against the direction
+// of its change. Transfers move stock between two locations and have their own calls, and a
+// change of zero would only record an empty movement.
+func adjustmentType(input *dto.AdjustInventoryInput) (model.MovementType, error) {
+	if input.QuantityChange == 0 {
+		return "", inventory.ErrInvalidQuantity
+	}
+	t := input.MovementType
+	if t == "" {
+		t = model.MovementAdjustment
+	}
+	if !t.Valid() || t == model.MovementTransferIn || t == model.MovementTransferOut || t == model.MovementTransitLoss {
+		return "", inventory.ErrInvalidMovementType.With("MovementType", t)
+	}
+	if d := t.Direction(); (d > 0 && input.QuantityChange < 0) || (d < 0 && input.QuantityChange > 0) {
+		return "", inventory.ErrMovementDirection.With("MovementType", t).With("Direction", d)
+	}
+	return t, nil
 }
 
-func (uc *inventoryUseCase) ListMovements(ctx context.Context, filters *dto.MovementFilters) ([]model.InventoryMovement, int, error) {
-	return uc.repo.ListMovements(ctx, filters)
+func emptyToNil(s *string) *string {
+	if s == nil || *s == "" {
+		return nil
+	}
+	return s
 }
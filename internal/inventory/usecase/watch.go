@@ -0,0 +1,59 @@
+package usecase
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/fekuna/omnipos-product-service/internal/inventory"
+	"github.com/fekuna/omnipos-product-service/internal/inventory/dto"
+)
+
+// watchResyncInterval bounds how long a watcher misses a change whose wakeup was lost.
+const watchResyncInterval = 30 * time.Second
+
+// WatchStock sends every location of a store, or only the locations changed since the
+// resume token, and then the locations changed by each commit at the store.
+func (uc *inventoryUseCase) WatchStock(ctx context.Context, input *dto.WatchStockInput, send func(*dto.StockUpdate) error) error {
+	if input.ResumeToken != "" {
+		if _, err := strconv.ParseUint(input.ResumeToken, 10, 64); err != nil {
+			return inventory.ErrInvalidResumeToken
+		}
+	}
+
+	// Subscribe before the first read so no wakeup falls between a read and the wait.
+	wakeups, unsubscribe := uc.feed.Subscribe(input.MerchantID, input.StoreID)
+	defer unsubscribe()
+
+	resync := time.NewTicker(watchResyncInterval)
+	defer resync.Stop()
+
+	token := input.ResumeToken
+	snapshot := token == ""
+	for {
+		// Taken before the read, so the next read covers everything this one could miss.
+		next, err := uc.repo.ChangeToken(ctx)
+		if err != nil {
+			return err
+		}
+		items, err := uc.repo.FindChangedSince(ctx, input.MerchantID, input.StoreID, token)
+		if err != nil {
+			return err
+		}
+
+		if snapshot || len(items) > 0 {
+			if err := send(&dto.StockUpdate{Snapshot: snapshot, Items: items, ResumeToken: next}); err != nil {
+				return err
+			}
+		}
+		token = next
+		snapshot = false
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-wakeups:
+		case <-resync.C:
+		}
+	}
+}
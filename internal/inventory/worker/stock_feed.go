@@ -0,0 +1,92 @@
+package worker
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/fekuna/omnipos-pkg/cache"
+	"github.com/fekuna/omnipos-pkg/logger"
+	"github.com/fekuna/omnipos-product-service/internal/inventory"
+)
+
+var _ inventory.StockFeed = (*RedisStockFeed)(nil)
+
+// RedisStockFeed fans the stock wakeups of every merchant out to the watchers of this
+// instance over a single Redis subscription.
+type RedisStockFeed struct {
+	cache    *cache.RedisClient
+	mu       sync.Mutex
+	watchers map[string]map[chan struct{}]struct{} // By merchant and store
+	logger   logger.ZapLogger
+}
+
+func NewRedisStockFeed(cache *cache.RedisClient, logger logger.ZapLogger) *RedisStockFeed {
+	return &RedisStockFeed{
+		cache:    cache,
+		watchers: make(map[string]map[chan struct{}]struct{}),
+		logger:   logger,
+	}
+}
+
+func (f *RedisStockFeed) Start(ctx context.Context) {
+	f.logger.Info("Starting Stock Feed")
+	pubsub := f.cache.Client.PSubscribe(ctx, inventory.StockChannel("*"))
+	defer pubsub.Close()
+
+	// The channel survives reconnects; wakeups sent while disconnected are lost.
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			f.logger.Info("Stopping Stock Feed")
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			merchantID := strings.TrimPrefix(msg.Channel, inventory.StockChannelPrefix)
+			f.wake(watcherKey(merchantID, msg.Payload))
+		}
+	}
+}
+
+func (f *RedisStockFeed) Subscribe(merchantID string, storeID *string) (<-chan struct{}, func()) {
+	store := ""
+	if storeID != nil {
+		store = *storeID
+	}
+	key := watcherKey(merchantID, store)
+	ch := make(chan struct{}, 1)
+
+	f.mu.Lock()
+	if f.watchers[key] == nil {
+		f.watchers[key] = make(map[chan struct{}]struct{})
+	}
+	f.watchers[key][ch] = struct{}{}
+	f.mu.Unlock()
+
+	return ch, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		delete(f.watchers[key], ch)
+		if len(f.watchers[key]) == 0 {
+			delete(f.watchers, key)
+		}
+	}
+}
+
+func (f *RedisStockFeed) wake(key string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for ch := range f.watchers[key] {
+		select {
+		case ch <- struct{}{}:
+		default: // A wakeup is already pending
+		}
+	}
+}
+
+func watcherKey(merchantID, store string) string {
+	return merchantID + ":" + store
+}
@@ -0,0 +1,137 @@
+// Package isolation_test checks that one merchant can never reach another merchant's data,
+// through the RPCs and through row-level security.
+//
+// The tests run against a migrated database and Redis, configured with the same environment
+// variables as the service and connecting as its role (omnipos_app), and are skipped unless
+// OMNIPOS_INTEGRATION is set:
+//
+//	make migrate_up dev_app_role && POSTGRES_USER=omnipos_app POSTGRES_PASSWORD=omnipos_app make test_isolation
+package isolation_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/fekuna/omnipos-pkg/cache"
+	"github.com/fekuna/omnipos-pkg/database/postgres"
+	"github.com/fekuna/omnipos-pkg/logger"
+	"github.com/fekuna/omnipos-pkg/search"
+	"github.com/fekuna/omnipos-product-service/config"
+	"github.com/fekuna/omnipos-product-service/internal/auth"
+	catH "github.com/fekuna/omnipos-product-service/internal/category/handler"
+	catRepoPkg "github.com/fekuna/omnipos-product-service/internal/category/repository"
+	catUCPkg "github.com/fekuna/omnipos-product-service/internal/category/usecase"
+	"github.com/fekuna/omnipos-product-service/internal/database"
+	"github.com/fekuna/omnipos-product-service/internal/inventory"
+	invH "github.com/fekuna/omnipos-product-service/internal/inventory/handler"
+	invRepoPkg "github.com/fekuna/omnipos-product-service/internal/inventory/repository"
+	invUCPkg "github.com/fekuna/omnipos-product-service/internal/inventory/usecase"
+	invWorkerPkg "github.com/fekuna/omnipos-product-service/internal/inventory/worker"
+	"github.com/fekuna/omnipos-product-service/internal/outbox"
+	outboxRepoPkg "github.com/fekuna/omnipos-product-service/internal/outbox/repository"
+	prodH "github.com/fekuna/omnipos-product-service/internal/product/handler"
+	prodRepoPkg "github.com/fekuna/omnipos-product-service/internal/product/repository"
+	prodUCPkg "github.com/fekuna/omnipos-product-service/internal/product/usecase"
+	purH "github.com/fekuna/omnipos-product-service/internal/purchase/handler"
+	purRepoPkg "github.com/fekuna/omnipos-product-service/internal/purchase/repository"
+	purUCPkg "github.com/fekuna/omnipos-product-service/internal/purchase/usecase"
+	stkH "github.com/fekuna/omnipos-product-service/internal/stocktake/handler"
+	stkRepoPkg "github.com/fekuna/omnipos-product-service/internal/stocktake/repository"
+	stkUCPkg "github.com/fekuna/omnipos-product-service/internal/stocktake/usecase"
+	trfH "github.com/fekuna/omnipos-product-service/internal/transfer/handler"
+	trfRepoPkg "github.com/fekuna/omnipos-product-service/internal/transfer/repository"
+	trfUCPkg "github.com/fekuna/omnipos-product-service/internal/transfer/usecase"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type nopLogger struct{ logger.ZapLogger }
+
+func (nopLogger) Debug(string, ...zap.Field) {}
+func (nopLogger) Info(string, ...zap.Field)  {}
+func (nopLogger) Warn(string, ...zap.Field)  {}
+func (nopLogger) Error(string, ...zap.Field) {}
+
+// env is the service wired as in cmd/grpc, without the broker, search and background workers.
+type env struct {
+	db         *sqlx.DB
+	categories *catH.CategoryHandler
+	products   *prodH.ProductHandler
+	inventory  *invH.InventoryHandler
+	transfers  *trfH.TransferHandler
+	purchases  *purH.PurchaseHandler
+	stocktakes *stkH.StocktakeHandler
+	invUC      inventory.UseCase
+}
+
+func newEnv(t testing.TB) *env {
+	t.Helper()
+	if os.Getenv("OMNIPOS_INTEGRATION") == "" {
+		t.Skip("set OMNIPOS_INTEGRATION to run against the database and Redis of the environment")
+	}
+	cfg := config.LoadEnv()
+
+	db, err := postgres.NewPostgres(&postgres.Config{
+		Host:            cfg.Postgres.Host,
+		Port:            cfg.Postgres.Port,
+		User:            cfg.Postgres.User,
+		Password:        cfg.Postgres.Password,
+		DBName:          cfg.Postgres.DBName,
+		SSLMode:         cfg.Postgres.SSLMode,
+		MaxOpenConns:    cfg.Postgres.MaxOpenConns,
+		MaxIdleConns:    cfg.Postgres.MaxIdleConns,
+		ConnMaxLifetime: time.Duration(cfg.Postgres.ConnMaxLifetime) * time.Second,
+		ConnMaxIdleTime: time.Duration(cfg.Postgres.ConnMaxIdleTime) * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("connect to database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := database.CheckRowLevelSecurity(context.Background(), db); err != nil {
+		t.Fatal(err)
+	}
+
+	redisClient, err := cache.NewRedisClient(&cache.Config{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	if err != nil {
+		t.Fatalf("connect to Redis: %v", err)
+	}
+	t.Cleanup(func() { redisClient.Close() })
+
+	log := nopLogger{}
+	txManager := database.NewTxManager(db)
+	outboxWriter := outbox.NewWriter(outboxRepoPkg.NewPGRepository(db), false)
+	stockRepo := invRepoPkg.NewPublishingRepository(invRepoPkg.NewPGRepository(db), txManager, outboxWriter, outboxWriter)
+	prodRepo := prodRepoPkg.NewPGRepository(db)
+
+	// Overselling is allowed so the fixtures can record a stock shortage.
+	invUC := invUCPkg.NewInventoryUseCase(stockRepo, txManager, redisClient, invWorkerPkg.NewRedisStockFeed(redisClient, log), inventory.InsufficientStockAllowNegative, log)
+	var es *search.Client // Without Elasticsearch, product search falls back to the database
+
+	return &env{
+		db:         db,
+		categories: catH.NewCategoryHandler(catUCPkg.NewCategoryUseCase(catRepoPkg.NewPGRepository(db), txManager, outboxWriter, log), log),
+		products:   prodH.NewProductHandler(prodUCPkg.NewProductUseCase(prodRepo, redisClient, es, txManager, outboxWriter, log), log),
+		inventory:  invH.NewInventoryHandler(invUC, log),
+		transfers:  trfH.NewTransferHandler(trfUCPkg.NewTransferUseCase(trfRepoPkg.NewPGRepository(db), stockRepo, txManager, log), log),
+		purchases:  purH.NewPurchaseHandler(purUCPkg.NewPurchaseUseCase(purRepoPkg.NewPGRepository(db), stockRepo, prodRepo, txManager, outboxWriter, 0, log), log),
+		stocktakes: stkH.NewStocktakeHandler(stkUCPkg.NewStocktakeUseCase(stkRepoPkg.NewPGRepository(db), stockRepo, txManager, log), log),
+		invUC:      invUC,
+	}
+}
+
+// asMerchant returns the context the auth interceptor gives a call of the merchant's owner.
+func asMerchant(merchantID string) context.Context {
+	ctx := auth.WithUser(context.Background(), &auth.UserContext{
+		MerchantID: merchantID,
+		UserID:     uuid.New().String(),
+		Role:       auth.RoleOwner,
+	})
+	return database.WithTenant(ctx, merchantID)
+}
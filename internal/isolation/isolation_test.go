@@ -0,0 +1,830 @@
+package isolation_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fekuna/omnipos-product-service/internal/apperror"
+	"github.com/fekuna/omnipos-product-service/internal/database"
+	"github.com/fekuna/omnipos-product-service/internal/inventory/dto"
+	"github.com/fekuna/omnipos-product-service/internal/inventory/listener"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	prodDTO "github.com/fekuna/omnipos-product-service/internal/product/dto"
+	productv1 "github.com/fekuna/omnipos-proto/gen/go/omnipos/product/v1"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// merchantData is the catalog, stock and stock operations of one merchant, created through
+// the RPCs.
+type merchantData struct {
+	merchantID      string
+	storeID         string
+	categoryID      string
+	productID       string
+	variantID       string
+	barcode         string
+	reservedFor     string // Order holding an active reservation
+	shortageID      string
+	shortOrderID    string // Order sold beyond the stock, leaving the shortage
+	supplierID      string
+	purchaseOrderID string // Submitted, nothing received yet
+	poItemID        string
+	suggestionID    string // Open reorder suggestion for the store
+	transferID      string // Draft transfer out of the store
+	transferItemID  string
+	stocktakeID     string // Full count of the store, still counting
+}
+
+// seedCostingMethod is the costing method of every seeded merchant, not the default one.
+const seedCostingMethod = model.CostingMethodLastCost
+
+// seedMerchant creates a category, a product with a variant and stock at a store, a
+// reservation and a stock shortage for a new merchant, then a supplier with a submitted
+// purchase order, a reorder suggestion, a draft transfer and a running stocktake.
+func seedMerchant(t *testing.T, e *env) *merchantData {
+	t.Helper()
+	d := &merchantData{
+		merchantID:   uuid.New().String(),
+		storeID:      uuid.New().String(),
+		barcode:      "ISO-" + uuid.New().String()[:8],
+		reservedFor:  uuid.New().String(),
+		shortOrderID: uuid.New().String(),
+	}
+	ctx := asMerchant(d.merchantID)
+
+	cat, err := e.categories.CreateCategory(ctx, &productv1.CreateCategoryRequest{Name: "Drinks"})
+	if err != nil {
+		t.Fatalf("seed category: %v", err)
+	}
+	d.categoryID = cat.Category.Id
+
+	p, err := e.products.CreateProduct(ctx, &productv1.CreateProductRequest{
+		CategoryId:     d.categoryID,
+		Sku:            "ISO-TEA",
+		Barcode:        d.barcode,
+		Name:           "Isolation tea",
+		BasePrice:      20,
+		TrackInventory: true,
+	})
+	if err != nil {
+		t.Fatalf("seed product: %v", err)
+	}
+	d.productID = p.Product.Id
+
+	v, err := e.products.AddVariant(ctx, &productv1.AddVariantRequest{ProductId: d.productID, Sku: "ISO-TEA-L", VariantName: "Large"})
+	if err != nil {
+		t.Fatalf("seed variant: %v", err)
+	}
+	d.variantID = v.Variant.Id
+
+	if _, err := e.inventory.AdjustInventory(ctx, &productv1.AdjustInventoryRequest{
+		ProductId:      d.productID,
+		VariantId:      d.variantID,
+		StoreId:        d.storeID,
+		QuantityChange: 5,
+		MovementType:   string(model.MovementPurchase),
+		Reason:         "opening stock",
+	}); err != nil {
+		t.Fatalf("seed stock: %v", err)
+	}
+
+	res, err := e.products.ReserveStock(ctx, &productv1.ReserveStockRequest{
+		OrderId: d.reservedFor,
+		StoreId: d.storeID,
+		Items:   []*productv1.ReserveStockItem{{ProductId: d.productID, VariantId: d.variantID, Quantity: 1}},
+	})
+	if err != nil || !res.Success {
+		t.Fatalf("seed reservation: %v %+v", err, res)
+	}
+
+	// Sold beyond the four units left available, which records a shortage.
+	if _, err := e.invUC.ApplyOrderSale(ctx, &dto.OrderSaleInput{
+		EventID:    uuid.New().String(),
+		EventType:  listener.EventOrderCreated,
+		MerchantID: d.merchantID,
+		OrderID:    d.shortOrderID,
+		StoreID:    &d.storeID,
+		Lines:      []dto.OrderLineInput{{ProductID: d.productID, VariantID: &d.variantID, Quantity: 10}},
+	}); err != nil {
+		t.Fatalf("seed shortage: %v", err)
+	}
+	shortages, err := e.inventory.ListStockShortages(ctx, &productv1.ListStockShortagesRequest{StoreId: d.storeID})
+	if err != nil {
+		t.Fatalf("seed shortage: %v", err)
+	}
+	if len(shortages.Shortages) == 0 {
+		t.Fatal("seed shortage: no shortage recorded")
+	}
+	d.shortageID = shortages.Shortages[0].Id
+
+	seedOperations(t, e, d)
+	return d
+}
+
+func seedOperations(t *testing.T, e *env, d *merchantData) {
+	t.Helper()
+	ctx := asMerchant(d.merchantID)
+
+	if _, err := e.purchases.SetCostingMethod(ctx, &productv1.SetCostingMethodRequest{CostingMethod: seedCostingMethod}); err != nil {
+		t.Fatalf("seed costing method: %v", err)
+	}
+
+	supplier, err := e.purchases.CreateSupplier(ctx, &productv1.CreateSupplierRequest{Name: "Isolation leaves"})
+	if err != nil {
+		t.Fatalf("seed supplier: %v", err)
+	}
+	d.supplierID = supplier.Supplier.Id
+
+	po, err := e.purchases.CreatePurchaseOrder(ctx, &productv1.CreatePurchaseOrderRequest{
+		SupplierId: d.supplierID,
+		StoreId:    d.storeID,
+		Items:      []*productv1.PurchaseOrderItemRequest{{ProductId: d.productID, VariantId: d.variantID, Quantity: 2, UnitCost: 8}},
+	})
+	if err != nil {
+		t.Fatalf("seed purchase order: %v", err)
+	}
+	d.purchaseOrderID = po.PurchaseOrder.Id
+	d.poItemID = po.PurchaseOrder.Items[0].Id
+	if _, err := e.purchases.SubmitPurchaseOrder(ctx, &productv1.SubmitPurchaseOrderRequest{Id: d.purchaseOrderID}); err != nil {
+		t.Fatalf("seed purchase order: %v", err)
+	}
+
+	// No RPC sets reorder points, so the location gets one directly.
+	err = database.RunInTx(database.WithTenant(context.Background(), d.merchantID), e.db, func(ctx context.Context) error {
+		_, err := database.Conn(ctx, e.db).ExecContext(ctx,
+			`UPDATE inventory SET reorder_point = 10, reorder_quantity = 20 WHERE merchant_id = $1`, d.merchantID)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("seed reorder point: %v", err)
+	}
+	if _, err := e.purchases.GenerateReorderSuggestions(ctx, &productv1.GenerateReorderSuggestionsRequest{}); err != nil {
+		t.Fatalf("seed reorder suggestion: %v", err)
+	}
+	d.suggestionID = openSuggestion(t, e, d)
+	if d.suggestionID == "" {
+		t.Fatal("seed reorder suggestion: no suggestion raised")
+	}
+
+	tr, err := e.transfers.CreateTransfer(ctx, &productv1.CreateTransferRequest{
+		SourceStoreId: d.storeID,
+		TargetStoreId: uuid.New().String(),
+		Items:         []*productv1.TransferItemRequest{{ProductId: d.productID, VariantId: d.variantID, Quantity: 1}},
+	})
+	if err != nil {
+		t.Fatalf("seed transfer: %v", err)
+	}
+	d.transferID = tr.Transfer.Id
+	d.transferItemID = tr.Transfer.Items[0].Id
+
+	st, err := e.stocktakes.StartStocktake(ctx, &productv1.StartStocktakeRequest{StoreId: d.storeID, Scope: model.StocktakeScopeFull})
+	if err != nil {
+		t.Fatalf("seed stocktake: %v", err)
+	}
+	if len(st.Stocktake.Items) == 0 {
+		t.Fatal("seed stocktake: nothing in scope")
+	}
+	d.stocktakeID = st.Stocktake.Id
+}
+
+// openSuggestion returns the open reorder suggestion of the merchant's store, if any.
+func openSuggestion(t *testing.T, e *env, d *merchantData) string {
+	t.Helper()
+	res, err := e.purchases.ListReorderSuggestions(asMerchant(d.merchantID), &productv1.ListReorderSuggestionsRequest{StoreId: d.storeID})
+	if err != nil {
+		t.Fatalf("ListReorderSuggestions: %v", err)
+	}
+	for _, g := range res.Groups {
+		for _, s := range g.Suggestions {
+			if s.ProductId == d.productID && s.VariantId == d.variantID {
+				return s.Id
+			}
+		}
+	}
+	return ""
+}
+
+func expectNotFound(t *testing.T, ctx context.Context, rpc string, err error) {
+	t.Helper()
+	if got := status.Code(apperror.Status(ctx, err)); got != codes.NotFound {
+		t.Errorf("%s: code = %v (%v), want NotFound", rpc, got, err)
+	}
+}
+
+// expectHidden fails when ids contains one of merchant A's rows.
+func expectHidden(t *testing.T, rpc string, ids []string, hidden ...string) {
+	t.Helper()
+	for _, id := range ids {
+		for _, h := range hidden {
+			if id == h {
+				t.Errorf("%s: returned %s of another merchant", rpc, id)
+			}
+		}
+	}
+}
+
+func TestCategoryServiceIsolation(t *testing.T) {
+	e := newEnv(t)
+	a := seedMerchant(t, e)
+	ctx := asMerchant(uuid.New().String())
+
+	_, err := e.categories.GetCategory(ctx, &productv1.GetCategoryRequest{Id: a.categoryID})
+	expectNotFound(t, ctx, "GetCategory", err)
+
+	_, err = e.categories.CreateCategory(ctx, &productv1.CreateCategoryRequest{ParentId: a.categoryID, Name: "Child"})
+	expectNotFound(t, ctx, "CreateCategory under another merchant's parent", err)
+
+	_, err = e.categories.UpdateCategory(ctx, &productv1.UpdateCategoryRequest{Id: a.categoryID, Name: "Taken", IsActive: true})
+	expectNotFound(t, ctx, "UpdateCategory", err)
+
+	_, err = e.categories.DeleteCategory(ctx, &productv1.DeleteCategoryRequest{Id: a.categoryID})
+	expectNotFound(t, ctx, "DeleteCategory", err)
+
+	list, err := e.categories.ListCategories(ctx, &productv1.ListCategoriesRequest{})
+	if err != nil {
+		t.Fatalf("ListCategories: %v", err)
+	}
+	var ids []string
+	for _, c := range list.Categories {
+		ids = append(ids, c.Id)
+	}
+	expectHidden(t, "ListCategories", ids, a.categoryID)
+
+	list, err = e.categories.ListCategories(ctx, &productv1.ListCategoriesRequest{ParentId: a.categoryID})
+	if err != nil {
+		t.Fatalf("ListCategories: %v", err)
+	}
+	ids = nil
+	for _, c := range list.Categories {
+		ids = append(ids, c.Id)
+	}
+	expectHidden(t, "ListCategories under another merchant's parent", ids, a.categoryID)
+
+	expectMerchantIntact(t, e, a)
+}
+
+func TestProductServiceIsolation(t *testing.T) {
+	e := newEnv(t)
+	a := seedMerchant(t, e)
+	ctx := asMerchant(uuid.New().String())
+
+	_, err := e.products.GetProduct(ctx, &productv1.GetProductRequest{Id: a.productID})
+	expectNotFound(t, ctx, "GetProduct", err)
+
+	_, err = e.products.CreateProduct(ctx, &productv1.CreateProductRequest{CategoryId: a.categoryID, Sku: "B-1", Name: "Intruder", BasePrice: 1})
+	expectNotFound(t, ctx, "CreateProduct in another merchant's category", err)
+
+	_, err = e.products.UpdateProduct(ctx, &productv1.UpdateProductRequest{Id: a.productID, Sku: "ISO-TEA", Name: "Taken", BasePrice: 1, IsActive: true})
+	expectNotFound(t, ctx, "UpdateProduct", err)
+
+	_, err = e.products.DeleteProduct(ctx, &productv1.DeleteProductRequest{Id: a.productID})
+	expectNotFound(t, ctx, "DeleteProduct", err)
+
+	list, err := e.products.ListProducts(ctx, &productv1.ListProductsRequest{Page: 1, PageSize: 100})
+	if err != nil {
+		t.Fatalf("ListProducts: %v", err)
+	}
+	var ids []string
+	for _, p := range list.Products {
+		ids = append(ids, p.Id)
+	}
+	expectHidden(t, "ListProducts", ids, a.productID)
+
+	list, err = e.products.ListProducts(ctx, &productv1.ListProductsRequest{CategoryId: a.categoryID, Page: 1, PageSize: 100})
+	if err != nil {
+		t.Fatalf("ListProducts: %v", err)
+	}
+	ids = nil
+	for _, p := range list.Products {
+		ids = append(ids, p.Id)
+	}
+	expectHidden(t, "ListProducts in another merchant's category", ids, a.productID)
+
+	list, err = e.products.SearchProducts(ctx, &productv1.SearchProductsRequest{Query: "Isolation", Limit: 100})
+	if err != nil {
+		t.Fatalf("SearchProducts: %v", err)
+	}
+	ids = nil
+	for _, p := range list.Products {
+		ids = append(ids, p.Id)
+	}
+	expectHidden(t, "SearchProducts", ids, a.productID)
+
+	delta, err := e.products.SyncCatalog(ctx, &productv1.SyncCatalogRequest{})
+	if err != nil {
+		t.Fatalf("SyncCatalog: %v", err)
+	}
+	ids = nil
+	for _, c := range delta.Categories {
+		ids = append(ids, c.Id)
+	}
+	for _, p := range delta.Products {
+		ids = append(ids, p.Id)
+	}
+	for _, v := range delta.Variants {
+		ids = append(ids, v.Id)
+	}
+	expectHidden(t, "SyncCatalog", ids, a.categoryID, a.productID, a.variantID)
+
+	// Another merchant's product is unknown to the reservation, so nothing is held.
+	reserved, err := e.products.ReserveStock(ctx, &productv1.ReserveStockRequest{
+		OrderId: uuid.New().String(),
+		StoreId: a.storeID,
+		Items:   []*productv1.ReserveStockItem{{ProductId: a.productID, VariantId: a.variantID, Quantity: 1}},
+	})
+	if err != nil {
+		t.Errorf("ReserveStock: %v", err)
+	} else if reserved.Success || len(reserved.Lines) != 1 || reserved.Lines[0].Status != prodDTO.ReserveLineUnknown {
+		t.Errorf("ReserveStock of another merchant's product = %+v, want an unknown line", reserved)
+	}
+
+	_, err = e.products.CommitReservation(ctx, &productv1.CommitReservationRequest{OrderId: a.reservedFor})
+	expectNotFound(t, ctx, "CommitReservation", err)
+
+	_, err = e.products.ReleaseReservation(ctx, &productv1.ReleaseReservationRequest{OrderId: a.reservedFor})
+	expectNotFound(t, ctx, "ReleaseReservation", err)
+
+	expectMerchantIntact(t, e, a)
+}
+
+func TestProductVariantServiceIsolation(t *testing.T) {
+	e := newEnv(t)
+	a := seedMerchant(t, e)
+	ctx := asMerchant(uuid.New().String())
+
+	_, err := e.products.AddVariant(ctx, &productv1.AddVariantRequest{ProductId: a.productID, Sku: "B-V", VariantName: "Intruder"})
+	expectNotFound(t, ctx, "AddVariant", err)
+
+	_, err = e.products.ListVariants(ctx, &productv1.ListVariantsRequest{ProductId: a.productID})
+	expectNotFound(t, ctx, "ListVariants", err)
+
+	_, err = e.products.UpdateVariant(ctx, &productv1.UpdateVariantRequest{Id: a.variantID, ProductId: a.productID, Sku: "ISO-TEA-L", VariantName: "Taken", IsActive: true})
+	expectNotFound(t, ctx, "UpdateVariant", err)
+
+	_, err = e.products.DeactivateVariant(ctx, &productv1.DeactivateVariantRequest{Id: a.variantID, ProductId: a.productID})
+	expectNotFound(t, ctx, "DeactivateVariant", err)
+
+	_, err = e.products.GenerateVariants(ctx, &productv1.GenerateVariantsRequest{
+		ProductId: a.productID,
+		Options:   []*productv1.VariantOption{{Name: "Size", Values: []string{"S", "M"}}},
+	})
+	expectNotFound(t, ctx, "GenerateVariants", err)
+
+	// Reaching A's variant through a product of B's own.
+	own, err := e.products.CreateProduct(ctx, &productv1.CreateProductRequest{Sku: "B-OWN", Name: "Own product", BasePrice: 1})
+	if err != nil {
+		t.Fatalf("create own product: %v", err)
+	}
+	_, err = e.products.UpdateVariant(ctx, &productv1.UpdateVariantRequest{Id: a.variantID, ProductId: own.Product.Id, Sku: "ISO-TEA-L", VariantName: "Taken", IsActive: true})
+	expectNotFound(t, ctx, "UpdateVariant through an own product", err)
+
+	_, err = e.products.DeactivateVariant(ctx, &productv1.DeactivateVariantRequest{Id: a.variantID, ProductId: own.Product.Id})
+	expectNotFound(t, ctx, "DeactivateVariant through an own product", err)
+
+	expectMerchantIntact(t, e, a)
+}
+
+func TestInventoryServiceIsolation(t *testing.T) {
+	e := newEnv(t)
+	a := seedMerchant(t, e)
+	ctx := asMerchant(uuid.New().String())
+
+	_, err := e.inventory.GetProductInventory(ctx, &productv1.GetProductInventoryRequest{ProductId: a.productID})
+	expectNotFound(t, ctx, "GetProductInventory", err)
+
+	_, err = e.inventory.GetProductInventory(ctx, &productv1.GetProductInventoryRequest{ProductId: a.productID, VariantId: a.variantID, StoreId: a.storeID})
+	expectNotFound(t, ctx, "GetProductInventory of a variant", err)
+
+	_, err = e.inventory.GetProductStock(ctx, &productv1.GetProductStockRequest{ProductId: a.productID})
+	expectNotFound(t, ctx, "GetProductStock", err)
+
+	_, err = e.inventory.GetStockAvailability(ctx, &productv1.GetStockAvailabilityRequest{ProductId: a.productID, VariantId: a.variantID})
+	expectNotFound(t, ctx, "GetStockAvailability", err)
+
+	_, err = e.inventory.GetStockAvailability(ctx, &productv1.GetStockAvailabilityRequest{Barcode: a.barcode})
+	expectNotFound(t, ctx, "GetStockAvailability by barcode", err)
+
+	_, err = e.inventory.AdjustInventory(ctx, &productv1.AdjustInventoryRequest{
+		ProductId:      a.productID,
+		VariantId:      a.variantID,
+		StoreId:        a.storeID,
+		QuantityChange: -5,
+		MovementType:   string(model.MovementAdjustment),
+	})
+	expectNotFound(t, ctx, "AdjustInventory", err)
+
+	_, err = e.inventory.TransferInventory(ctx, &productv1.TransferInventoryRequest{
+		ProductId:     a.productID,
+		VariantId:     a.variantID,
+		SourceStoreId: a.storeID,
+		TargetStoreId: uuid.New().String(),
+		Quantity:      1,
+	})
+	expectNotFound(t, ctx, "TransferInventory", err)
+
+	_, err = e.inventory.ResolveStockShortage(ctx, &productv1.ResolveStockShortageRequest{Id: a.shortageID, Notes: "not mine"})
+	expectNotFound(t, ctx, "ResolveStockShortage", err)
+
+	low, err := e.inventory.ListLowStock(ctx, &productv1.ListLowStockRequest{ProductId: a.productID, PageSize: 100})
+	if err != nil {
+		t.Fatalf("ListLowStock: %v", err)
+	}
+	var ids []string
+	for _, i := range low.Items {
+		ids = append(ids, i.ProductId)
+	}
+	expectHidden(t, "ListLowStock", ids, a.productID)
+
+	movements, err := e.inventory.ListInventoryMovements(ctx, &productv1.ListInventoryMovementsRequest{ProductId: a.productID, PageSize: 100})
+	if err != nil {
+		t.Fatalf("ListInventoryMovements: %v", err)
+	}
+	ids = nil
+	for _, m := range movements.Movements {
+		ids = append(ids, m.ProductId)
+	}
+	expectHidden(t, "ListInventoryMovements", ids, a.productID)
+
+	shortages, err := e.inventory.ListStockShortages(ctx, &productv1.ListStockShortagesRequest{StoreId: a.storeID, PageSize: 100})
+	if err != nil {
+		t.Fatalf("ListStockShortages: %v", err)
+	}
+	ids = nil
+	for _, s := range shortages.Shortages {
+		ids = append(ids, s.Id)
+	}
+	expectHidden(t, "ListStockShortages", ids, a.shortageID)
+
+	stream := newWatchStream(ctx)
+	err = e.inventory.WatchStock(&productv1.WatchStockRequest{StoreId: a.storeID}, stream)
+	if len(stream.updates) != 1 {
+		t.Errorf("WatchStock: %v, sent %d updates, want the snapshot", err, len(stream.updates))
+	} else {
+		ids = nil
+		for _, i := range stream.updates[0].Inventory {
+			ids = append(ids, i.ProductId)
+		}
+		expectHidden(t, "WatchStock", ids, a.productID)
+	}
+
+	expectMerchantIntact(t, e, a)
+}
+
+// watchStream collects the first update of a watch and then ends it.
+type watchStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	cancel  context.CancelFunc
+	updates []*productv1.StockUpdate
+}
+
+func newWatchStream(ctx context.Context) *watchStream {
+	ctx, cancel := context.WithCancel(ctx)
+	return &watchStream{ctx: ctx, cancel: cancel}
+}
+
+func (s *watchStream) Context() context.Context { return s.ctx }
+
+func (s *watchStream) Send(u *productv1.StockUpdate) error {
+	s.updates = append(s.updates, u)
+	s.cancel()
+	return nil
+}
+
+func TestStockTransferServiceIsolation(t *testing.T) {
+	e := newEnv(t)
+	a := seedMerchant(t, e)
+	ctx := asMerchant(uuid.New().String())
+
+	_, err := e.transfers.GetTransfer(ctx, &productv1.GetTransferRequest{Id: a.transferID})
+	expectNotFound(t, ctx, "GetTransfer", err)
+
+	_, err = e.transfers.DispatchTransfer(ctx, &productv1.DispatchTransferRequest{Id: a.transferID})
+	expectNotFound(t, ctx, "DispatchTransfer", err)
+
+	_, err = e.transfers.MarkTransferInTransit(ctx, &productv1.MarkTransferInTransitRequest{Id: a.transferID})
+	expectNotFound(t, ctx, "MarkTransferInTransit", err)
+
+	_, err = e.transfers.ReceiveTransfer(ctx, &productv1.ReceiveTransferRequest{
+		Id:    a.transferID,
+		Items: []*productv1.ReceiveTransferItem{{ItemId: a.transferItemID, Quantity: 1}},
+	})
+	expectNotFound(t, ctx, "ReceiveTransfer", err)
+
+	_, err = e.transfers.CloseTransfer(ctx, &productv1.CloseTransferRequest{Id: a.transferID})
+	expectNotFound(t, ctx, "CloseTransfer", err)
+
+	_, err = e.transfers.CancelTransfer(ctx, &productv1.CancelTransferRequest{Id: a.transferID})
+	expectNotFound(t, ctx, "CancelTransfer", err)
+
+	list, err := e.transfers.ListTransfers(ctx, &productv1.ListTransfersRequest{StoreId: a.storeID, Page: 1, PageSize: 100})
+	if err != nil {
+		t.Fatalf("ListTransfers: %v", err)
+	}
+	var ids []string
+	for _, tr := range list.Transfers {
+		ids = append(ids, tr.Id)
+	}
+	expectHidden(t, "ListTransfers", ids, a.transferID)
+
+	// Moving A's stock through a transfer of B's own.
+	_, err = e.transfers.CreateTransfer(ctx, &productv1.CreateTransferRequest{
+		SourceStoreId: a.storeID,
+		TargetStoreId: uuid.New().String(),
+		Items:         []*productv1.TransferItemRequest{{ProductId: a.productID, VariantId: a.variantID, Quantity: 1}},
+	})
+	expectNotFound(t, ctx, "CreateTransfer of another merchant's product", err)
+
+	own, err := e.products.CreateProduct(ctx, &productv1.CreateProductRequest{Sku: "B-OWN", Name: "Own product", BasePrice: 1, TrackInventory: true})
+	if err != nil {
+		t.Fatalf("create own product: %v", err)
+	}
+	_, err = e.transfers.CreateTransfer(ctx, &productv1.CreateTransferRequest{
+		SourceStoreId: a.storeID,
+		TargetStoreId: uuid.New().String(),
+		Items:         []*productv1.TransferItemRequest{{ProductId: own.Product.Id, VariantId: a.variantID, Quantity: 1}},
+	})
+	expectNotFound(t, ctx, "CreateTransfer of another merchant's variant", err)
+
+	expectMerchantIntact(t, e, a)
+}
+
+func TestPurchaseServiceIsolation(t *testing.T) {
+	e := newEnv(t)
+	a := seedMerchant(t, e)
+	ctx := asMerchant(uuid.New().String())
+
+	// Suppliers
+	_, err := e.purchases.UpdateSupplier(ctx, &productv1.UpdateSupplierRequest{Id: a.supplierID, Name: "Taken", IsActive: true})
+	expectNotFound(t, ctx, "UpdateSupplier", err)
+
+	suppliers, err := e.purchases.ListSuppliers(ctx, &productv1.ListSuppliersRequest{Search: "Isolation", Page: 1, PageSize: 100})
+	if err != nil {
+		t.Fatalf("ListSuppliers: %v", err)
+	}
+	var ids []string
+	for _, s := range suppliers.Suppliers {
+		ids = append(ids, s.Id)
+	}
+	expectHidden(t, "ListSuppliers", ids, a.supplierID)
+
+	// Purchase orders
+	_, err = e.purchases.CreatePurchaseOrder(ctx, &productv1.CreatePurchaseOrderRequest{
+		SupplierId: a.supplierID,
+		Items:      []*productv1.PurchaseOrderItemRequest{{ProductId: a.productID, Quantity: 1, UnitCost: 1}},
+	})
+	expectNotFound(t, ctx, "CreatePurchaseOrder from another merchant's supplier", err)
+
+	supplier, err := e.purchases.CreateSupplier(ctx, &productv1.CreateSupplierRequest{Name: "Own supplier"})
+	if err != nil {
+		t.Fatalf("create own supplier: %v", err)
+	}
+	_, err = e.purchases.CreatePurchaseOrder(ctx, &productv1.CreatePurchaseOrderRequest{
+		SupplierId: supplier.Supplier.Id,
+		StoreId:    a.storeID,
+		Items:      []*productv1.PurchaseOrderItemRequest{{ProductId: a.productID, VariantId: a.variantID, Quantity: 1, UnitCost: 1000}},
+	})
+	expectNotFound(t, ctx, "CreatePurchaseOrder of another merchant's product", err)
+
+	own, err := e.products.CreateProduct(ctx, &productv1.CreateProductRequest{Sku: "B-OWN", Name: "Own product", BasePrice: 1, TrackInventory: true})
+	if err != nil {
+		t.Fatalf("create own product: %v", err)
+	}
+	_, err = e.purchases.CreatePurchaseOrder(ctx, &productv1.CreatePurchaseOrderRequest{
+		SupplierId: supplier.Supplier.Id,
+		StoreId:    a.storeID,
+		Items:      []*productv1.PurchaseOrderItemRequest{{ProductId: own.Product.Id, VariantId: a.variantID, Quantity: 1, UnitCost: 1000}},
+	})
+	expectNotFound(t, ctx, "CreatePurchaseOrder of another merchant's variant", err)
+
+	_, err = e.purchases.GetPurchaseOrder(ctx, &productv1.GetPurchaseOrderRequest{Id: a.purchaseOrderID})
+	expectNotFound(t, ctx, "GetPurchaseOrder", err)
+
+	_, err = e.purchases.SubmitPurchaseOrder(ctx, &productv1.SubmitPurchaseOrderRequest{Id: a.purchaseOrderID})
+	expectNotFound(t, ctx, "SubmitPurchaseOrder", err)
+
+	unitCost := 1000.0
+	_, err = e.purchases.ReceivePurchaseOrder(ctx, &productv1.ReceivePurchaseOrderRequest{
+		Id:              a.purchaseOrderID,
+		UpdateCostPrice: true,
+		Items:           []*productv1.ReceivePurchaseItem{{ItemId: a.poItemID, Quantity: 2, UnitCost: &unitCost}},
+	})
+	expectNotFound(t, ctx, "ReceivePurchaseOrder", err)
+
+	_, err = e.purchases.ClosePurchaseOrder(ctx, &productv1.ClosePurchaseOrderRequest{Id: a.purchaseOrderID})
+	expectNotFound(t, ctx, "ClosePurchaseOrder", err)
+
+	_, err = e.purchases.CancelPurchaseOrder(ctx, &productv1.CancelPurchaseOrderRequest{Id: a.purchaseOrderID})
+	expectNotFound(t, ctx, "CancelPurchaseOrder", err)
+
+	orders, err := e.purchases.ListPurchaseOrders(ctx, &productv1.ListPurchaseOrdersRequest{StoreId: a.storeID, Page: 1, PageSize: 100})
+	if err != nil {
+		t.Fatalf("ListPurchaseOrders: %v", err)
+	}
+	ids = nil
+	for _, po := range orders.PurchaseOrders {
+		ids = append(ids, po.Id)
+	}
+	expectHidden(t, "ListPurchaseOrders", ids, a.purchaseOrderID)
+
+	orders, err = e.purchases.ListPurchaseOrders(ctx, &productv1.ListPurchaseOrdersRequest{SupplierId: a.supplierID, Page: 1, PageSize: 100})
+	if err != nil {
+		t.Fatalf("ListPurchaseOrders: %v", err)
+	}
+	ids = nil
+	for _, po := range orders.PurchaseOrders {
+		ids = append(ids, po.Id)
+	}
+	expectHidden(t, "ListPurchaseOrders of another merchant's supplier", ids, a.purchaseOrderID)
+
+	// Reorder suggestions
+	if _, err := e.purchases.GenerateReorderSuggestions(ctx, &productv1.GenerateReorderSuggestionsRequest{}); err != nil {
+		t.Fatalf("GenerateReorderSuggestions: %v", err)
+	}
+	suggestions, err := e.purchases.ListReorderSuggestions(ctx, &productv1.ListReorderSuggestionsRequest{StoreId: a.storeID})
+	if err != nil {
+		t.Fatalf("ListReorderSuggestions: %v", err)
+	}
+	ids = nil
+	for _, g := range suggestions.Groups {
+		for _, s := range g.Suggestions {
+			ids = append(ids, s.Id, s.InventoryId, s.ProductId)
+		}
+	}
+	expectHidden(t, "ListReorderSuggestions", ids, a.suggestionID, a.productID)
+
+	_, err = e.purchases.ConvertReorderSuggestions(ctx, &productv1.ConvertReorderSuggestionsRequest{
+		SuggestionIds: []string{a.suggestionID},
+		SupplierId:    supplier.Supplier.Id,
+	})
+	if got := status.Code(apperror.Status(ctx, err)); got != codes.FailedPrecondition {
+		t.Errorf("ConvertReorderSuggestions: code = %v (%v), want FailedPrecondition", got, err)
+	}
+
+	_, err = e.purchases.DismissReorderSuggestion(ctx, &productv1.DismissReorderSuggestionRequest{Id: a.suggestionID})
+	expectNotFound(t, ctx, "DismissReorderSuggestion", err)
+
+	// Costing
+	if _, err := e.purchases.SetCostingMethod(ctx, &productv1.SetCostingMethodRequest{CostingMethod: model.CostingMethodManual}); err != nil {
+		t.Fatalf("SetCostingMethod: %v", err)
+	}
+	method, err := e.purchases.GetCostingMethod(ctx, &productv1.GetCostingMethodRequest{})
+	if err != nil || method.CostingMethod != model.CostingMethodManual {
+		t.Errorf("GetCostingMethod = %+v (%v), want merchant B's %s", method, err, model.CostingMethodManual)
+	}
+
+	expectMerchantIntact(t, e, a)
+}
+
+func TestStocktakeServiceIsolation(t *testing.T) {
+	e := newEnv(t)
+	a := seedMerchant(t, e)
+	ctx := asMerchant(uuid.New().String())
+
+	_, err := e.stocktakes.GetStocktake(ctx, &productv1.GetStocktakeRequest{Id: a.stocktakeID})
+	expectNotFound(t, ctx, "GetStocktake", err)
+
+	_, err = e.stocktakes.RecordStocktakeCounts(ctx, &productv1.RecordStocktakeCountsRequest{
+		Id:       a.stocktakeID,
+		DeviceId: "intruder",
+		Counts:   []*productv1.StocktakeCountEntry{{ProductId: a.productID, VariantId: a.variantID, Quantity: 100}},
+	})
+	expectNotFound(t, ctx, "RecordStocktakeCounts", err)
+
+	_, err = e.stocktakes.GetStocktakeVariances(ctx, &productv1.GetStocktakeVariancesRequest{Id: a.stocktakeID})
+	expectNotFound(t, ctx, "GetStocktakeVariances", err)
+
+	_, err = e.stocktakes.ApproveStocktake(ctx, &productv1.ApproveStocktakeRequest{Id: a.stocktakeID, ZeroUncounted: true})
+	expectNotFound(t, ctx, "ApproveStocktake", err)
+
+	_, err = e.stocktakes.CancelStocktake(ctx, &productv1.CancelStocktakeRequest{Id: a.stocktakeID})
+	expectNotFound(t, ctx, "CancelStocktake", err)
+
+	list, err := e.stocktakes.ListStocktakes(ctx, &productv1.ListStocktakesRequest{StoreId: a.storeID, Page: 1, PageSize: 100})
+	if err != nil {
+		t.Fatalf("ListStocktakes: %v", err)
+	}
+	var ids []string
+	for _, st := range list.Stocktakes {
+		ids = append(ids, st.Id)
+	}
+	expectHidden(t, "ListStocktakes", ids, a.stocktakeID)
+
+	// B's own counts of A's store snapshot none of A's stock.
+	full, err := e.stocktakes.StartStocktake(ctx, &productv1.StartStocktakeRequest{StoreId: a.storeID, Scope: model.StocktakeScopeFull})
+	if err != nil {
+		t.Fatalf("StartStocktake: %v", err)
+	}
+	if n := len(full.Stocktake.Items); n != 0 {
+		t.Errorf("StartStocktake of another merchant's store snapshot %d items, want none", n)
+	}
+
+	category, err := e.stocktakes.StartStocktake(ctx, &productv1.StartStocktakeRequest{StoreId: a.storeID, Scope: model.StocktakeScopeCategory, CategoryId: a.categoryID})
+	if err != nil {
+		t.Fatalf("StartStocktake: %v", err)
+	}
+	if n := len(category.Stocktake.Items); n != 0 {
+		t.Errorf("StartStocktake of another merchant's category snapshot %d items, want none", n)
+	}
+
+	_, err = e.stocktakes.RecordStocktakeCounts(ctx, &productv1.RecordStocktakeCountsRequest{
+		Id:       full.Stocktake.Id,
+		DeviceId: "intruder",
+		Counts:   []*productv1.StocktakeCountEntry{{ProductId: a.productID, VariantId: a.variantID, Quantity: 100}},
+	})
+	if got := status.Code(apperror.Status(ctx, err)); got != codes.InvalidArgument {
+		t.Errorf("RecordStocktakeCounts of another merchant's product: code = %v (%v), want InvalidArgument", got, err)
+	}
+
+	expectMerchantIntact(t, e, a)
+}
+
+// expectMerchantIntact checks that merchant A still sees its data as seeded.
+func expectMerchantIntact(t *testing.T, e *env, a *merchantData) {
+	t.Helper()
+	ctx := asMerchant(a.merchantID)
+
+	if _, err := e.categories.GetCategory(ctx, &productv1.GetCategoryRequest{Id: a.categoryID}); err != nil {
+		t.Errorf("merchant A lost its category: %v", err)
+	}
+	p, err := e.products.GetProduct(ctx, &productv1.GetProductRequest{Id: a.productID})
+	if err != nil {
+		t.Fatalf("merchant A lost its product: %v", err)
+	}
+	if p.Product.Name != "Isolation tea" || len(p.Product.Variants) != 1 || !p.Product.Variants[0].IsActive {
+		t.Errorf("merchant A's product changed: %+v", p.Product)
+	}
+
+	inv, err := e.inventory.GetProductInventory(ctx, &productv1.GetProductInventoryRequest{ProductId: a.productID, VariantId: a.variantID, StoreId: a.storeID})
+	if err != nil || len(inv.Inventory) != 1 {
+		t.Fatalf("merchant A lost its stock: %v", err)
+	}
+	if got := inv.Inventory[0]; got.Quantity != -5 || got.ReservedQuantity != 1 {
+		t.Errorf("merchant A's stock = %v reserved %v, want -5 reserved 1", got.Quantity, got.ReservedQuantity)
+	}
+
+	shortages, err := e.inventory.ListStockShortages(ctx, &productv1.ListStockShortagesRequest{StoreId: a.storeID, Status: model.StockShortageStatusOpen})
+	if err != nil {
+		t.Fatalf("ListStockShortages: %v", err)
+	}
+	if err != nil || len(shortages.Shortages) != 1 || shortages.Shortages[0].Id != a.shortageID {
+		t.Errorf("merchant A's open shortage changed: %v", err)
+	}
+	if p.Product.CostPrice != 0 {
+		t.Errorf("merchant A's cost price = %v, want 0", p.Product.CostPrice)
+	}
+
+	expectOperationsIntact(t, e, a)
+}
+
+// expectOperationsIntact checks that merchant A's stock operations are still as seeded.
+func expectOperationsIntact(t *testing.T, e *env, a *merchantData) {
+	t.Helper()
+	ctx := asMerchant(a.merchantID)
+
+	suppliers, err := e.purchases.ListSuppliers(ctx, &productv1.ListSuppliersRequest{Search: "Isolation", Page: 1, PageSize: 100})
+	if err != nil || len(suppliers.Suppliers) != 1 || suppliers.Suppliers[0].Name != "Isolation leaves" || !suppliers.Suppliers[0].IsActive {
+		t.Errorf("merchant A's supplier changed: %v", err)
+	}
+
+	po, err := e.purchases.GetPurchaseOrder(ctx, &productv1.GetPurchaseOrderRequest{Id: a.purchaseOrderID})
+	if err != nil {
+		t.Fatalf("merchant A lost its purchase order: %v", err)
+	}
+	if po.PurchaseOrder.Status != model.PurchaseOrderStatusOrdered || len(po.PurchaseOrder.Items) != 1 || po.PurchaseOrder.Items[0].QuantityReceived != 0 {
+		t.Errorf("merchant A's purchase order changed: %+v", po.PurchaseOrder)
+	}
+
+	if id := openSuggestion(t, e, a); id != a.suggestionID {
+		t.Errorf("merchant A's open reorder suggestion = %q, want %q", id, a.suggestionID)
+	}
+
+	method, err := e.purchases.GetCostingMethod(ctx, &productv1.GetCostingMethodRequest{})
+	if err != nil || method.CostingMethod != seedCostingMethod {
+		t.Errorf("merchant A's costing method = %+v (%v), want %s", method, err, seedCostingMethod)
+	}
+
+	tr, err := e.transfers.GetTransfer(ctx, &productv1.GetTransferRequest{Id: a.transferID})
+	if err != nil {
+		t.Fatalf("merchant A lost its transfer: %v", err)
+	}
+	if tr.Transfer.Status != model.TransferStatusDraft || len(tr.Transfer.Items) != 1 || tr.Transfer.Items[0].Quantity != 1 {
+		t.Errorf("merchant A's transfer changed: %+v", tr.Transfer)
+	}
+
+	st, err := e.stocktakes.GetStocktake(ctx, &productv1.GetStocktakeRequest{Id: a.stocktakeID})
+	if err != nil {
+		t.Fatalf("merchant A lost its stocktake: %v", err)
+	}
+	if st.Stocktake.Status != model.StocktakeStatusCounting {
+		t.Errorf("merchant A's stocktake is %s, want %s", st.Stocktake.Status, model.StocktakeStatusCounting)
+	}
+	for _, item := range st.Stocktake.Items {
+		if item.CountedQuantity != nil {
+			t.Errorf("merchant A's stocktake item %s was counted", item.Id)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package isolation_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fekuna/omnipos-product-service/internal/database"
+	"github.com/google/uuid"
+)
+
+// tenantRows counts the rows of merchant A in each table under row-level security.
+var tenantRows = []struct {
+	table string
+	query string
+	arg   func(a *merchantData) string
+}{
+	{"categories", `SELECT count(*) FROM categories WHERE merchant_id = $1`, merchantOf},
+	{"products", `SELECT count(*) FROM products WHERE merchant_id = $1`, merchantOf},
+	{"product_variants", `SELECT count(*) FROM product_variants WHERE product_id = $1`, productOf},
+	{"inventory", `SELECT count(*) FROM inventory WHERE merchant_id = $1`, merchantOf},
+	{"inventory_movements", `SELECT count(*) FROM inventory_movements WHERE merchant_id = $1`, merchantOf},
+	{"stock_reservations", `SELECT count(*) FROM stock_reservations WHERE merchant_id = $1`, merchantOf},
+	{"stock_reservation_items", `SELECT count(*) FROM stock_reservation_items WHERE product_id = $1`, productOf},
+	{"stock_shortages", `SELECT count(*) FROM stock_shortages WHERE merchant_id = $1`, merchantOf},
+	{"processed_order_lines", `SELECT count(*) FROM processed_order_lines WHERE merchant_id = $1`, merchantOf},
+	{"catalog_changes", `SELECT count(*) FROM catalog_changes WHERE merchant_id = $1`, merchantOf},
+	{"merchant_inventory_settings", `SELECT count(*) FROM merchant_inventory_settings WHERE merchant_id = $1`, merchantOf},
+	{"suppliers", `SELECT count(*) FROM suppliers WHERE merchant_id = $1`, merchantOf},
+	{"purchase_orders", `SELECT count(*) FROM purchase_orders WHERE merchant_id = $1`, merchantOf},
+	{"purchase_order_items", `SELECT count(*) FROM purchase_order_items WHERE product_id = $1`, productOf},
+	{"reorder_suggestions", `SELECT count(*) FROM reorder_suggestions WHERE merchant_id = $1`, merchantOf},
+	{"stock_transfers", `SELECT count(*) FROM stock_transfers WHERE merchant_id = $1`, merchantOf},
+	{"stock_transfer_items", `SELECT count(*) FROM stock_transfer_items WHERE product_id = $1`, productOf},
+	{"stocktakes", `SELECT count(*) FROM stocktakes WHERE merchant_id = $1`, merchantOf},
+	{"stocktake_items", `SELECT count(*) FROM stocktake_items WHERE product_id = $1`, productOf},
+}
+
+func merchantOf(a *merchantData) string { return a.merchantID }
+func productOf(a *merchantData) string  { return a.productID }
+
+func TestRowLevelSecurityHidesOtherTenants(t *testing.T) {
+	e := newEnv(t)
+	a := seedMerchant(t, e)
+	merchantB := uuid.New().String()
+
+	for _, tt := range tenantRows {
+		t.Run(tt.table, func(t *testing.T) {
+			if n := countAs(t, e, a.merchantID, tt.query, tt.arg(a)); n == 0 {
+				t.Errorf("merchant A sees none of its %s", tt.table)
+			}
+			if n := countAs(t, e, merchantB, tt.query, tt.arg(a)); n != 0 {
+				t.Errorf("merchant B sees %d %s of merchant A", n, tt.table)
+			}
+		})
+	}
+}
+
+func TestRowLevelSecurityRejectsOtherTenantWrites(t *testing.T) {
+	e := newEnv(t)
+	a := seedMerchant(t, e)
+	ctx := database.WithTenant(context.Background(), uuid.New().String())
+
+	err := database.RunInTx(ctx, e.db, func(ctx context.Context) error {
+		conn := database.Conn(ctx, e.db)
+		res, err := conn.ExecContext(ctx, `UPDATE products SET name = 'Taken' WHERE id = $1`, a.productID)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n != 0 {
+			t.Errorf("merchant B updated %d products of merchant A", n)
+		}
+
+		if _, err := conn.ExecContext(ctx, `SAVEPOINT foreign_insert`); err != nil {
+			return err
+		}
+		if _, err := conn.ExecContext(ctx, `INSERT INTO categories (merchant_id, name) VALUES ($1, 'Planted')`, a.merchantID); err == nil {
+			t.Error("merchant B inserted a category for merchant A")
+		}
+		_, err = conn.ExecContext(ctx, `ROLLBACK TO SAVEPOINT foreign_insert`)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("write as merchant B: %v", err)
+	}
+}
+
+// A statement outside a transaction sets the tenant on the session of a pooled connection,
+// which must not carry over to the next statement run on that connection.
+func TestRowLevelSecurityIgnoresPreviousSessionTenant(t *testing.T) {
+	e := newEnv(t)
+	a := seedMerchant(t, e)
+	e.db.SetMaxOpenConns(1) // Every statement below runs on the same connection
+	query := `SELECT count(*) FROM products WHERE merchant_id = $1`
+
+	for _, tt := range []struct {
+		name string
+		ctx  context.Context
+		want bool
+	}{
+		{"merchant A", database.WithTenant(context.Background(), a.merchantID), true},
+		{"merchant B", database.WithTenant(context.Background(), uuid.New().String()), false},
+		{"no tenant", context.Background(), false},
+		{"system", database.AsSystem(context.Background()), true},
+		{"merchant B again", database.WithTenant(context.Background(), uuid.New().String()), false},
+	} {
+		var n int
+		if err := database.Conn(tt.ctx, e.db).GetContext(tt.ctx, &n, query, a.merchantID); err != nil {
+			t.Fatalf("%s: count products: %v", tt.name, err)
+		}
+		if got := n > 0; got != tt.want {
+			t.Errorf("%s sees %d products of merchant A", tt.name, n)
+		}
+	}
+}
+
+// BenchmarkTenantRead compares a read outside a transaction, as repositories run plain
+// reads, with the same read in a transaction of its own:
+//
+//	OMNIPOS_INTEGRATION=1 go test -run '^$' -bench TenantRead ./internal/isolation/...
+func BenchmarkTenantRead(b *testing.B) {
+	e := newEnv(b)
+	ctx := database.WithTenant(context.Background(), uuid.New().String())
+	query := `SELECT count(*) FROM products WHERE merchant_id = $1`
+	merchantID := uuid.New().String()
+
+	b.Run("statement", func(b *testing.B) {
+		var n int
+		for i := 0; i < b.N; i++ {
+			if err := database.Conn(ctx, e.db).GetContext(ctx, &n, query, merchantID); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("transaction", func(b *testing.B) {
+		var n int
+		for i := 0; i < b.N; i++ {
+			err := database.RunInTx(ctx, e.db, func(ctx context.Context) error {
+				return database.Conn(ctx, e.db).GetContext(ctx, &n, query, merchantID)
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// countAs runs a count in a transaction scoped to the merchant.
+func countAs(t *testing.T, e *env, merchantID, query string, arg string) int {
+	t.Helper()
+	var n int
+	err := database.RunInTx(database.WithTenant(context.Background(), merchantID), e.db, func(ctx context.Context) error {
+		return database.Conn(ctx, e.db).GetContext(ctx, &n, query, arg)
+	})
+	if err != nil {
+		t.Fatalf("count as %s: %v", merchantID, err)
+	}
+	return n
+}
@@ -0,0 +1,87 @@
+package messaging
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// FakeBroker is an in-memory, single-partition broker for running and exercising the
+// listeners without Kafka. Messages are kept for the lifetime of the process.
+type FakeBroker struct {
+	mu     sync.Mutex
+	topics map[string][]Message
+	notify chan struct{} // closed and replaced on every publish
+}
+
+func NewFakeBroker() *FakeBroker {
+	return &FakeBroker{
+		topics: make(map[string][]Message),
+		notify: make(chan struct{}),
+	}
+}
+
+func (b *FakeBroker) Publish(ctx context.Context, msgs ...Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, m := range msgs {
+		m.Partition = 0
+		m.Offset = int64(len(b.topics[m.Topic]))
+		if m.Time.IsZero() {
+			m.Time = time.Now()
+		}
+		b.topics[m.Topic] = append(b.topics[m.Topic], m)
+	}
+
+	close(b.notify)
+	b.notify = make(chan struct{})
+	return nil
+}
+
+func (b *FakeBroker) Close() error {
+	return nil
+}
+
+// Messages returns a copy of everything published to topic so far.
+func (b *FakeBroker) Messages(topic string) []Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	msgs := make([]Message, len(b.topics[topic]))
+	copy(msgs, b.topics[topic])
+	return msgs
+}
+
+// Reader returns a reader that consumes topic from the beginning.
+func (b *FakeBroker) Reader(topic string) *FakeReader {
+	return &FakeReader{broker: b, topic: topic}
+}
+
+type FakeReader struct {
+	broker *FakeBroker
+	topic  string
+	offset int
+}
+
+// ReadMessage blocks until the next message of the topic is published or ctx is done.
+func (r *FakeReader) ReadMessage(ctx context.Context) (Message, error) {
+	for {
+		r.broker.mu.Lock()
+		msgs := r.broker.topics[r.topic]
+		if r.offset < len(msgs) {
+			m := msgs[r.offset]
+			r.offset++
+			r.broker.mu.Unlock()
+			return m, nil
+		}
+		wait := r.broker.notify
+		r.broker.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return Message{}, ctx.Err()
+		case <-wait:
+		}
+	}
+}
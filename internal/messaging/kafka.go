@@ -0,0 +1,78 @@
+package messaging
+
+import (
+	"context"
+	"time"
+
+	"github.com/fekuna/omnipos-pkg/broker"
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaReader adapts the shared consumer to Reader.
+type KafkaReader struct {
+	consumer *broker.KafkaConsumer
+}
+
+func NewKafkaReader(consumer *broker.KafkaConsumer) *KafkaReader {
+	return &KafkaReader{consumer: consumer}
+}
+
+func (r *KafkaReader) ReadMessage(ctx context.Context) (Message, error) {
+	m, err := r.consumer.ReadMessage(ctx)
+	if err != nil {
+		return Message{}, err
+	}
+
+	headers := make(map[string]string, len(m.Headers))
+	for _, h := range m.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+
+	return Message{
+		Topic:     m.Topic,
+		Partition: m.Partition,
+		Offset:    m.Offset,
+		Key:       m.Key,
+		Value:     m.Value,
+		Headers:   headers,
+		Time:      m.Time,
+	}, nil
+}
+
+// KafkaPublisher writes to any topic; the topic is taken from each message.
+type KafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+func NewKafkaPublisher(brokers []string) *KafkaPublisher {
+	return &KafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireAll,
+			AllowAutoTopicCreation: true,
+			BatchTimeout:           10 * time.Millisecond, // Publishes are synchronous, don't wait for a full batch
+		},
+	}
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, msgs ...Message) error {
+	out := make([]kafka.Message, len(msgs))
+	for i, m := range msgs {
+		headers := make([]kafka.Header, 0, len(m.Headers))
+		for k, v := range m.Headers {
+			headers = append(headers, kafka.Header{Key: k, Value: []byte(v)})
+		}
+		out[i] = kafka.Message{
+			Topic:   m.Topic,
+			Key:     m.Key,
+			Value:   m.Value,
+			Headers: headers,
+		}
+	}
+	return p.writer.WriteMessages(ctx, out...)
+}
+
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
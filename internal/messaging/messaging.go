@@ -0,0 +1,28 @@
+// Package messaging decouples the event consumers from Kafka, so the same listener runs
+// against the in-memory FakeBroker when no broker is available.
+package messaging
+
+import (
+	"context"
+	"time"
+)
+
+type Message struct {
+	Topic     string
+	Partition int
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Headers   map[string]string
+	Time      time.Time
+}
+
+type Reader interface {
+	ReadMessage(ctx context.Context) (Message, error)
+}
+
+type Publisher interface {
+	// Publish writes every message to its own Topic.
+	Publish(ctx context.Context, msgs ...Message) error
+	Close() error
+}
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// Catalog entities tracked for POS sync, in the order a sync sends them: parents first.
+const (
+	CatalogEntityCategory = "category"
+	CatalogEntityProduct  = "product"
+	CatalogEntityVariant  = "variant"
+)
+
+// CatalogChange is the last change of a category, product or variant. It outlives a
+// deleted row as its tombstone.
+type CatalogChange struct {
+	EntityType string    `db:"entity_type"`
+	EntityID   string    `db:"entity_id"`
+	MerchantID string    `db:"merchant_id"`
+	ProductID  *string   `db:"product_id"` // Product of a variant
+	Deleted    bool      `db:"deleted"`
+	ChangeXID  string    `db:"change_xid"` // Transaction of the change
+	ChangedAt  time.Time `db:"changed_at"`
+}
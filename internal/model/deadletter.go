@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+const (
+	DeadLetterStatusPending  = "pending"
+	DeadLetterStatusReplayed = "replayed"
+)
+
+// DeadLetter is a consumed message that kept failing, stored with the error of its last attempt.
+type DeadLetter struct {
+	BaseModel
+	Topic          string     `db:"topic"`
+	Partition      int        `db:"partition"`
+	Offset         int64      `db:"offset"`
+	MessageKey     []byte     `db:"message_key"`
+	Payload        []byte     `db:"payload"`
+	Error          string     `db:"error"`
+	Attempts       int        `db:"attempts"`
+	Status         string     `db:"status"`
+	ReplayCount    int        `db:"replay_count"`
+	LastReplayedAt *time.Time `db:"last_replayed_at"`
+}
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// ProcessedEvent records a broker event that has been applied.
+type ProcessedEvent struct {
+	EventID     string    `db:"event_id"`
+	EventType   string    `db:"event_type"`
+	MerchantID  string    `db:"merchant_id"`
+	OrderID     *string   `db:"order_id"`
+	ProcessedAt time.Time `db:"processed_at"`
+}
+
+// ProcessedOrderLine records that the stock of one order line has been moved.
+type ProcessedOrderLine struct {
+	MerchantID  string    `db:"merchant_id"`
+	OrderID     string    `db:"order_id"`
+	LineNo      int       `db:"line_no"`
+	EventID     *string   `db:"event_id"`
+	MovementID  *string   `db:"movement_id"`
+	ProcessedAt time.Time `db:"processed_at"`
+}
@@ -13,23 +13,119 @@ type Inventory struct {
 	AvailableQuantity float64    `db:"available_quantity"` // Generated column
 	ReorderPoint      float64    `db:"reorder_point"`
 	ReorderQuantity   float64    `db:"reorder_quantity"`
+	MaxStockLevel     *float64   `db:"max_stock_level"` // Reorder up to this level when set
 	LastCountedAt     *time.Time `db:"last_counted_at"`
 	UpdatedAt         time.Time  `db:"updated_at"`
+	ChangeXID         string     `db:"change_xid"`       // Transaction that last changed the row
+	NegativeAllowed   bool       `db:"negative_allowed"` // Oversold by an order, may stay below its holds until restocked
+}
+
+// StoreKey identifies a store for comparison and ordering; central inventory is "" and sorts first.
+func StoreKey(storeID *string) string {
+	if storeID == nil {
+		return ""
+	}
+	return *storeID
+}
+
+// LocationKey identifies a product or variant within a store, e.g. "<product>:<variant>".
+// Stock writes lock inventory rows in the order of this key, so they can't deadlock.
+func LocationKey(productID string, variantID *string) string {
+	if variantID == nil {
+		return productID
+	}
+	return productID + ":" + *variantID
+}
+
+// MovementType classifies a stock movement. Its sign is fixed for every type but
+// adjustment, so reports can sum a type without looking at quantities.
+type MovementType string
+
+const (
+	MovementPurchase    MovementType = "purchase"
+	MovementSale        MovementType = "sale"
+	MovementAdjustment  MovementType = "adjustment" // counts and corrections, either way
+	MovementTransferIn  MovementType = "transfer_in"
+	MovementTransferOut MovementType = "transfer_out"
+	MovementReturn      MovementType = "return"
+	MovementWriteOff    MovementType = "write_off"  // expired or otherwise unsellable stock
+	MovementDamage      MovementType = "damage"     // stock broken in the store or in storage
+	MovementProduction  MovementType = "production" // stock made in-house
+	// MovementTransitLoss books stock a transfer lost on the way. It is logged at the source,
+	// whose on-hand already dropped with the transfer_out, and changes no inventory row.
+	MovementTransitLoss MovementType = "transit_loss"
+)
+
+// Direction is 1 for types that add stock, -1 for types that remove it and 0 for
+// adjustments. Unknown types are 0 as well, check Valid first.
+func (t MovementType) Direction() int {
+	switch t {
+	case MovementPurchase, MovementTransferIn, MovementReturn, MovementProduction:
+		return 1
+	case MovementSale, MovementTransferOut, MovementWriteOff, MovementDamage, MovementTransitLoss:
+		return -1
+	}
+	return 0
+}
+
+func (t MovementType) Valid() bool {
+	switch t {
+	case MovementPurchase, MovementSale, MovementAdjustment, MovementTransferIn, MovementTransferOut,
+		MovementReturn, MovementWriteOff, MovementDamage, MovementProduction, MovementTransitLoss:
+		return true
+	}
+	return false
 }
 
 type InventoryMovement struct {
-	ID             string    `db:"id"`
-	MerchantID     string    `db:"merchant_id"`
-	StoreID        *string   `db:"store_id"`
-	ProductID      string    `db:"product_id"`
-	VariantID      *string   `db:"variant_id"`
-	MovementType   string    `db:"movement_type"`
-	QuantityChange float64   `db:"quantity_change"`
-	QuantityBefore float64   `db:"quantity_before"`
-	QuantityAfter  float64   `db:"quantity_after"`
-	ReferenceType  *string   `db:"reference_type"`
-	ReferenceID    *string   `db:"reference_id"`
-	Notes          string    `db:"notes"`
-	CreatedBy      *string   `db:"created_by"`
-	CreatedAt      time.Time `db:"created_at"`
+	ID                 string       `db:"id"`
+	MerchantID         string       `db:"merchant_id"`
+	StoreID            *string      `db:"store_id"`
+	ProductID          string       `db:"product_id"`
+	VariantID          *string      `db:"variant_id"`
+	MovementType       MovementType `db:"movement_type"`
+	QuantityChange     float64      `db:"quantity_change"`
+	QuantityBefore     float64      `db:"quantity_before"`
+	QuantityAfter      float64      `db:"quantity_after"`
+	ReferenceType      *string      `db:"reference_type"`
+	ReferenceID        *string      `db:"reference_id"`
+	ReversesMovementID *string      `db:"reverses_movement_id"` // Sale movement a return undoes
+	Notes              string       `db:"notes"`
+	CreatedBy          *string      `db:"created_by"`
+	CreatedAt          time.Time    `db:"created_at"`
+	ChangeXID          *string      `db:"change_xid"` // Transaction that wrote the movement
+}
+
+// OrderSale is a sale movement of an order with the quantity already returned against it.
+type OrderSale struct {
+	InventoryMovement
+	ReturnedQuantity float64 `db:"returned_quantity"`
+}
+
+// Remaining is the sold quantity that can still be returned.
+func (s OrderSale) Remaining() float64 {
+	return -s.QuantityChange - s.ReturnedQuantity
+}
+
+const (
+	StockShortageStatusOpen     = "open"
+	StockShortageStatusResolved = "resolved"
+)
+
+// StockShortage flags a sale that took a location below zero, for someone to review.
+type StockShortage struct {
+	ID            string     `db:"id"`
+	MerchantID    string     `db:"merchant_id"`
+	InventoryID   string     `db:"inventory_id"`
+	StoreID       *string    `db:"store_id"`
+	ProductID     string     `db:"product_id"`
+	VariantID     *string    `db:"variant_id"`
+	OrderID       string     `db:"order_id"`
+	MovementID    *string    `db:"movement_id"`
+	QuantityShort float64    `db:"quantity_short"`
+	Status        string     `db:"status"`
+	Notes         *string    `db:"notes"`
+	ResolvedBy    *string    `db:"resolved_by"`
+	ResolvedAt    *time.Time `db:"resolved_at"`
+	CreatedAt     time.Time  `db:"created_at"`
 }
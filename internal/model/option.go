@@ -0,0 +1,57 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// ProductOption is an option axis (e.g. Size: S/M/L) used to generate variants.
+type ProductOption struct {
+	BaseModel
+	ProductID string       `db:"product_id" json:"product_id"`
+	Name      string       `db:"name" json:"name"`
+	Values    OptionValues `db:"option_values" json:"values"`
+	Position  int          `db:"position" json:"position"`
+}
+
+// OptionValues is a JSONB list of option values.
+type OptionValues []string
+
+func (v OptionValues) Value() (driver.Value, error) {
+	if v == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(v)
+}
+
+func (v *OptionValues) Scan(src interface{}) error {
+	return scanJSON(src, v)
+}
+
+// OptionSelection maps an option name to the chosen value for one variant, stored as JSONB.
+type OptionSelection map[string]string
+
+func (s OptionSelection) Value() (driver.Value, error) {
+	if s == nil {
+		return nil, nil
+	}
+	return json.Marshal(s)
+}
+
+func (s *OptionSelection) Scan(src interface{}) error {
+	return scanJSON(src, s)
+}
+
+func scanJSON(src interface{}, dest interface{}) error {
+	switch data := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(data, dest)
+	case string:
+		return json.Unmarshal([]byte(data), dest)
+	default:
+		return errors.New("unsupported JSON source type")
+	}
+}
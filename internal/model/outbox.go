@@ -0,0 +1,38 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Where an outbox entry is delivered
+const (
+	OutboxDestinationKafka  = "kafka"
+	OutboxDestinationSearch = "search"
+	OutboxDestinationCache  = "cache"
+	OutboxDestinationNotify = "notify"
+)
+
+const (
+	OutboxStatusPending   = "pending"
+	OutboxStatusDelivered = "delivered"
+	OutboxStatusFailed    = "failed" // given up on after the maximum attempts
+)
+
+// OutboxEntry is a side effect of a change, stored with the change and delivered by the relay.
+// Entries of one destination and aggregate are delivered one at a time, in Seq order.
+type OutboxEntry struct {
+	ID            string          `db:"id"`
+	Seq           int64           `db:"seq"`
+	Destination   string          `db:"destination"`
+	AggregateType string          `db:"aggregate_type"`
+	AggregateID   string          `db:"aggregate_id"`
+	MerchantID    *string         `db:"merchant_id"`
+	Payload       json.RawMessage `db:"payload"`
+	Status        string          `db:"status"`
+	Attempts      int             `db:"attempts"`
+	LastError     *string         `db:"last_error"`
+	AvailableAt   time.Time       `db:"available_at"`
+	DeliveredAt   *time.Time      `db:"delivered_at"`
+	CreatedAt     time.Time       `db:"created_at"`
+}
@@ -21,11 +21,13 @@ type Product struct {
 
 type ProductVariant struct {
 	BaseModel
-	ProductID       string   `db:"product_id" json:"product_id"`
-	SKU             string   `db:"sku" json:"sku"`
-	Barcode         *string  `db:"barcode" json:"barcode"`
-	VariantName     string   `db:"variant_name" json:"variant_name"`
-	PriceAdjustment float64  `db:"price_adjustment" json:"price_adjustment"`
-	CostPrice       *float64 `db:"cost_price" json:"cost_price"`
-	IsActive        bool     `db:"is_active" json:"is_active"`
+	ProductID       string          `db:"product_id" json:"product_id"`
+	SKU             string          `db:"sku" json:"sku"`
+	Barcode         *string         `db:"barcode" json:"barcode"`
+	VariantName     string          `db:"variant_name" json:"variant_name"`
+	PriceAdjustment float64         `db:"price_adjustment" json:"price_adjustment"`
+	CostPrice       *float64        `db:"cost_price" json:"cost_price"`
+	IsActive        bool            `db:"is_active" json:"is_active"`
+	OptionValues    OptionSelection `db:"option_values" json:"option_values"`     // Nil for manually added variants
+	CombinationKey  *string         `db:"combination_key" json:"combination_key"` // Set for matrix-generated variants
 }
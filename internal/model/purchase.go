@@ -0,0 +1,80 @@
+package model
+
+import "time"
+
+const (
+	PurchaseOrderStatusDraft             = "draft"
+	PurchaseOrderStatusOrdered           = "ordered"
+	PurchaseOrderStatusPartiallyReceived = "partially_received"
+	PurchaseOrderStatusReceived          = "received"
+	PurchaseOrderStatusClosed            = "closed"
+	PurchaseOrderStatusCancelled         = "cancelled"
+)
+
+// Costing methods decide how receipts change cost_price.
+const (
+	CostingMethodManual          = "manual"           // receipts never touch cost_price
+	CostingMethodLastCost        = "last_cost"        // cost_price becomes the latest unit cost
+	CostingMethodWeightedAverage = "weighted_average" // on-hand quantity and receipt are averaged
+)
+
+type Supplier struct {
+	BaseModel
+	MerchantID  string  `db:"merchant_id"`
+	Name        string  `db:"name"`
+	ContactName *string `db:"contact_name"`
+	Email       *string `db:"email"`
+	Phone       *string `db:"phone"`
+	Address     *string `db:"address"`
+	IsActive    bool    `db:"is_active"`
+}
+
+type PurchaseOrder struct {
+	BaseModel
+	MerchantID string              `db:"merchant_id"`
+	SupplierID string              `db:"supplier_id"`
+	StoreID    *string             `db:"store_id"` // Receiving location
+	PONumber   string              `db:"po_number"`
+	Status     string              `db:"status"`
+	Notes      string              `db:"notes"`
+	ExpectedAt *time.Time          `db:"expected_at"`
+	OrderedAt  *time.Time          `db:"ordered_at"`
+	ReceivedAt *time.Time          `db:"received_at"`
+	ClosedAt   *time.Time          `db:"closed_at"`
+	CreatedBy  *string             `db:"created_by"`
+	Items      []PurchaseOrderItem `db:"-"`
+}
+
+type PurchaseOrderItem struct {
+	ID               string  `db:"id"`
+	PurchaseOrderID  string  `db:"purchase_order_id"`
+	ProductID        string  `db:"product_id"`
+	VariantID        *string `db:"variant_id"`
+	QuantityOrdered  float64 `db:"quantity_ordered"`
+	QuantityReceived float64 `db:"quantity_received"`
+	UnitCost         float64 `db:"unit_cost"`
+}
+
+type PurchaseReceipt struct {
+	ID              string                `db:"id"`
+	MerchantID      string                `db:"merchant_id"`
+	PurchaseOrderID string                `db:"purchase_order_id"`
+	Notes           string                `db:"notes"`
+	ReceivedBy      *string               `db:"received_by"`
+	CreatedAt       time.Time             `db:"created_at"`
+	Items           []PurchaseReceiptItem `db:"-"`
+}
+
+type PurchaseReceiptItem struct {
+	ID                  string  `db:"id"`
+	ReceiptID           string  `db:"receipt_id"`
+	PurchaseOrderItemID string  `db:"purchase_order_item_id"`
+	Quantity            float64 `db:"quantity"`
+	UnitCost            float64 `db:"unit_cost"`
+}
+
+// CostBasis is the current cost_price and total on-hand quantity of a product or variant.
+type CostBasis struct {
+	CostPrice *float64 `db:"cost_price"`
+	OnHand    float64  `db:"on_hand"`
+}
@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+const (
+	ReorderSuggestionStatusOpen      = "open"
+	ReorderSuggestionStatusConverted = "converted"
+	ReorderSuggestionStatusDismissed = "dismissed"
+	ReorderSuggestionStatusObsolete  = "obsolete" // stock recovered or is covered before anyone acted
+)
+
+// ReorderSuggestion is a draft replenishment line for an inventory location that dropped
+// to its reorder point.
+type ReorderSuggestion struct {
+	BaseModel
+	MerchantID        string     `db:"merchant_id"`
+	InventoryID       string     `db:"inventory_id"`
+	StoreID           *string    `db:"store_id"`
+	SupplierID        *string    `db:"supplier_id"`
+	ProductID         string     `db:"product_id"`
+	VariantID         *string    `db:"variant_id"`
+	AvailableQuantity float64    `db:"available_quantity"`
+	IncomingQuantity  float64    `db:"incoming_quantity"`
+	ReorderPoint      float64    `db:"reorder_point"`
+	ReorderQuantity   float64    `db:"reorder_quantity"`
+	MaxStockLevel     *float64   `db:"max_stock_level"`
+	SuggestedQuantity float64    `db:"suggested_quantity"`
+	UnitCost          float64    `db:"unit_cost"`
+	Status            string     `db:"status"`
+	PurchaseOrderID   *string    `db:"purchase_order_id"`
+	DismissedUntil    *time.Time `db:"dismissed_until"` // nil holds a dismissal until the location changes
+}
+
+// ReorderCandidate is an inventory location at or below its reorder point, with the
+// quantity already on its way from open purchase orders and inbound transfers.
+type ReorderCandidate struct {
+	InventoryID       string   `db:"inventory_id"`
+	MerchantID        string   `db:"merchant_id"`
+	StoreID           *string  `db:"store_id"`
+	ProductID         string   `db:"product_id"`
+	VariantID         *string  `db:"variant_id"`
+	AvailableQuantity float64  `db:"available_quantity"`
+	ReorderPoint      float64  `db:"reorder_point"`
+	ReorderQuantity   float64  `db:"reorder_quantity"`
+	MaxStockLevel     *float64 `db:"max_stock_level"`
+	IncomingQuantity  float64  `db:"incoming_quantity"`
+	SupplierID        *string  `db:"supplier_id"`
+	UnitCost          float64  `db:"unit_cost"`
+}
@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+const (
+	ReservationStatusActive    = "active"
+	ReservationStatusCommitted = "committed"
+	ReservationStatusReleased  = "released"
+	ReservationStatusExpired   = "expired"
+)
+
+// StockReservation holds stock for an order until it is committed, released or expires.
+type StockReservation struct {
+	BaseModel
+	MerchantID string                 `db:"merchant_id" json:"merchant_id"`
+	OrderID    string                 `db:"order_id" json:"order_id"`
+	Status     string                 `db:"status" json:"status"`
+	ExpiresAt  time.Time              `db:"expires_at" json:"expires_at"`
+	Items      []StockReservationItem `db:"-" json:"items"`
+}
+
+type StockReservationItem struct {
+	ID            string  `db:"id" json:"id"`
+	ReservationID string  `db:"reservation_id" json:"reservation_id"`
+	InventoryID   string  `db:"inventory_id" json:"inventory_id"`
+	StoreID       *string `db:"store_id" json:"store_id"`
+	ProductID     string  `db:"product_id" json:"product_id"`
+	VariantID     *string `db:"variant_id" json:"variant_id"`
+	Quantity      float64 `db:"quantity" json:"quantity"`
+}
@@ -0,0 +1,69 @@
+package model
+
+import "time"
+
+const (
+	StocktakeScopeFull     = "full"
+	StocktakeScopeCategory = "category"
+	StocktakeScopeCycle    = "cycle"
+
+	StocktakeStatusCounting  = "counting"
+	StocktakeStatusApproved  = "approved"
+	StocktakeStatusCancelled = "cancelled"
+)
+
+// Stocktake is a physical count of (part of) a store's inventory.
+type Stocktake struct {
+	BaseModel
+	MerchantID string          `db:"merchant_id"`
+	StoreID    *string         `db:"store_id"`
+	Scope      string          `db:"scope"`
+	CategoryID *string         `db:"category_id"`
+	SampleSize int             `db:"sample_size"`
+	Status     string          `db:"status"`
+	Notes      string          `db:"notes"`
+	StartedAt  time.Time       `db:"started_at"`
+	ApprovedAt *time.Time      `db:"approved_at"`
+	CreatedBy  *string         `db:"created_by"`
+	ApprovedBy *string         `db:"approved_by"`
+	Items      []StocktakeItem `db:"-"`
+}
+
+type StocktakeItem struct {
+	ID               string   `db:"id"`
+	StocktakeID      string   `db:"stocktake_id"`
+	InventoryID      string   `db:"inventory_id"`
+	ProductID        string   `db:"product_id"`
+	VariantID        *string  `db:"variant_id"`
+	ExpectedQuantity float64  `db:"expected_quantity"`
+	CountedQuantity  *float64 `db:"counted_quantity"`
+	VarianceQuantity *float64 `db:"variance_quantity"`
+	BookSnapshot     *string  `db:"book_snapshot"` // Database snapshot ExpectedQuantity was read in
+}
+
+type StocktakeCount struct {
+	ID              string    `db:"id"`
+	StocktakeItemID string    `db:"stocktake_item_id"`
+	DeviceID        string    `db:"device_id"`
+	Quantity        float64   `db:"quantity"`
+	CountedBy       *string   `db:"counted_by"`
+	CountedAt       time.Time `db:"counted_at"`
+}
+
+// StocktakeLine is a stocktake item with the counts of all devices added up and the net
+// stock movement between the snapshot and the last count of the item.
+type StocktakeLine struct {
+	ItemID           string     `db:"item_id"`
+	InventoryID      string     `db:"inventory_id"`
+	ProductID        string     `db:"product_id"`
+	VariantID        *string    `db:"variant_id"`
+	ExpectedQuantity float64    `db:"expected_quantity"`
+	MovementQuantity float64    `db:"movement_quantity"`
+	CountedQuantity  *float64   `db:"counted_quantity"` // Nil until any device counted the item
+	LastCountedAt    *time.Time `db:"last_counted_at"`
+}
+
+// ExpectedAtCount is what the books said when the item was counted.
+func (l StocktakeLine) ExpectedAtCount() float64 {
+	return l.ExpectedQuantity + l.MovementQuantity
+}
@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+const (
+	TransferStatusDraft             = "draft"
+	TransferStatusDispatched        = "dispatched"
+	TransferStatusInTransit         = "in_transit"
+	TransferStatusPartiallyReceived = "partially_received"
+	TransferStatusReceived          = "received"
+	TransferStatusClosed            = "closed"
+	TransferStatusCancelled         = "cancelled"
+)
+
+// StockTransfer is a shipment of stock between two stores (or a store and the warehouse).
+type StockTransfer struct {
+	BaseModel
+	MerchantID    string              `db:"merchant_id"`
+	SourceStoreID *string             `db:"source_store_id"`
+	TargetStoreID *string             `db:"target_store_id"`
+	Status        string              `db:"status"`
+	Notes         string              `db:"notes"`
+	CreatedBy     *string             `db:"created_by"`
+	DispatchedAt  *time.Time          `db:"dispatched_at"`
+	ReceivedAt    *time.Time          `db:"received_at"`
+	ClosedAt      *time.Time          `db:"closed_at"`
+	Items         []StockTransferItem `db:"-"`
+}
+
+type StockTransferItem struct {
+	ID                string  `db:"id"`
+	TransferID        string  `db:"transfer_id"`
+	ProductID         string  `db:"product_id"`
+	VariantID         *string `db:"variant_id"`
+	Quantity          float64 `db:"quantity"`
+	QuantityReceived  float64 `db:"quantity_received"`
+	QuantityShrinkage float64 `db:"quantity_shrinkage"`
+}
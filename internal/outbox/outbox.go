@@ -0,0 +1,140 @@
+// Package outbox stores side effects of a change in the same transaction as the change, for the
+// relay to deliver afterwards. Delivery is at least once, so consumers must tolerate repeats.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/fekuna/omnipos-product-service/internal/event"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/google/uuid"
+)
+
+// Search operations
+const (
+	SearchOpIndex  = "index"
+	SearchOpDelete = "delete"
+)
+
+// SearchPayload indexes or deletes one search document.
+type SearchPayload struct {
+	Op         string          `json:"op"`
+	Index      string          `json:"index"`
+	DocumentID string          `json:"document_id"`
+	Document   json.RawMessage `json:"document,omitempty"` // Only for index
+}
+
+// CachePayload deletes every cache key matching one of the patterns.
+type CachePayload struct {
+	Patterns []string `json:"patterns"`
+}
+
+// NotifyPayload publishes one message on a Redis pub/sub channel.
+type NotifyPayload struct {
+	Channel string `json:"channel"`
+	Message string `json:"message"`
+}
+
+// Writer records side effects in the outbox. Pass it the ctx of the transaction making the
+// change; it writes nothing on its own.
+type Writer struct {
+	repo   Repository
+	search bool // Whether search entries are recorded, there is nothing to deliver them to otherwise
+}
+
+func NewWriter(repo Repository, search bool) *Writer {
+	return &Writer{repo: repo, search: search}
+}
+
+// Publish records events for Kafka. It makes Writer an event.Publisher.
+func (w *Writer) Publish(ctx context.Context, events ...event.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	entries := make([]*model.OutboxEntry, len(events))
+	for i, e := range events {
+		entry, err := newEntry(model.OutboxDestinationKafka, e.AggregateType, e.AggregateID, e.MerchantID, e)
+		if err != nil {
+			return err
+		}
+		entries[i] = entry
+	}
+	return w.repo.Add(ctx, entries...)
+}
+
+// IndexDocument records an upsert of doc into a search index.
+func (w *Writer) IndexDocument(ctx context.Context, aggregateType, merchantID, index, id string, doc interface{}) error {
+	if !w.search {
+		return nil
+	}
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to encode search document %s: %w", id, err)
+	}
+	return w.add(ctx, model.OutboxDestinationSearch, aggregateType, id, merchantID, SearchPayload{
+		Op:         SearchOpIndex,
+		Index:      index,
+		DocumentID: id,
+		Document:   body,
+	})
+}
+
+// DeleteDocument records the removal of a document from a search index.
+func (w *Writer) DeleteDocument(ctx context.Context, aggregateType, merchantID, index, id string) error {
+	if !w.search {
+		return nil
+	}
+	return w.add(ctx, model.OutboxDestinationSearch, aggregateType, id, merchantID, SearchPayload{
+		Op:         SearchOpDelete,
+		Index:      index,
+		DocumentID: id,
+	})
+}
+
+// InvalidateCache records the deletion of the cache keys matching patterns.
+func (w *Writer) InvalidateCache(ctx context.Context, aggregateType, aggregateID, merchantID string, patterns ...string) error {
+	return w.add(ctx, model.OutboxDestinationCache, aggregateType, aggregateID, merchantID, CachePayload{Patterns: patterns})
+}
+
+// Notify records a message for a Redis pub/sub channel, published once the change commits.
+func (w *Writer) Notify(ctx context.Context, aggregateType, aggregateID, merchantID, channel, message string) error {
+	return w.add(ctx, model.OutboxDestinationNotify, aggregateType, aggregateID, merchantID, NotifyPayload{
+		Channel: channel,
+		Message: message,
+	})
+}
+
+func (w *Writer) add(ctx context.Context, destination, aggregateType, aggregateID, merchantID string, payload interface{}) error {
+	entry, err := newEntry(destination, aggregateType, aggregateID, merchantID, payload)
+	if err != nil {
+		return err
+	}
+	return w.repo.Add(ctx, entry)
+}
+
+func newEntry(destination, aggregateType, aggregateID, merchantID string, payload interface{}) (*model.OutboxEntry, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s outbox entry: %w", destination, err)
+	}
+
+	now := time.Now()
+	entry := &model.OutboxEntry{
+		ID:            uuid.New().String(),
+		Destination:   destination,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Payload:       body,
+		Status:        model.OutboxStatusPending,
+		AvailableAt:   now,
+		CreatedAt:     now,
+	}
+	if merchantID != "" {
+		entry.MerchantID = &merchantID
+	}
+	return entry, nil
+}
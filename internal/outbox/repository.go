@@ -0,0 +1,30 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/fekuna/omnipos-product-service/internal/model"
+)
+
+type Repository interface {
+	// Add joins the transaction carried by ctx, so entries commit with the change they describe.
+	Add(ctx context.Context, entries ...*model.OutboxEntry) error
+
+	// ClaimPending leases up to limit deliverable entries until leaseUntil: the oldest pending
+	// entry of each destination and aggregate, skipping entries another relay is claiming. A
+	// leased entry stays pending but is not claimed again before its lease runs out, so a relay
+	// that dies mid-delivery only delays it. Entries are returned in Seq order.
+	ClaimPending(ctx context.Context, now, leaseUntil time.Time, limit int) ([]model.OutboxEntry, error)
+	// MarkDelivered, MarkFailed and Park settle a claimed entry. They do nothing once its lease
+	// has been taken over by another claim.
+	MarkDelivered(ctx context.Context, entry model.OutboxEntry, deliveredAt time.Time) error
+	MarkFailed(ctx context.Context, entry model.OutboxEntry, cause string, retryAt time.Time) error
+	// Park gives up on an entry: it stays in the outbox as failed and is no longer retried.
+	Park(ctx context.Context, entry model.OutboxEntry, cause string) error
+	PurgeDelivered(ctx context.Context, before time.Time) (int64, error)
+
+	// FindSearchDeletes returns the IDs of documents removed from index since a time, for
+	// index rebuilds to replay.
+	FindSearchDeletes(ctx context.Context, index string, since time.Time) ([]string, error)
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/fekuna/omnipos-product-service/internal/database"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type PGRepository struct {
+	DB *sqlx.DB
+}
+
+func NewPGRepository(db *sqlx.DB) *PGRepository {
+	return &PGRepository{DB: db}
+}
+
+// conn returns the transaction started by the TxManager if ctx carries one.
+func (r *PGRepository) conn(ctx context.Context) database.DBTX {
+	return database.Conn(ctx, r.DB)
+}
+
+func (r *PGRepository) Add(ctx context.Context, entries ...*model.OutboxEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	// seq is assigned in VALUES order, which keeps the entries of one change in order.
+	query := `
+        INSERT INTO outbox (
+            id, destination, aggregate_type, aggregate_id, merchant_id, payload,
+            status, attempts, available_at, created_at
+        )
+        VALUES (
+            :id, :destination, :aggregate_type, :aggregate_id, :merchant_id, :payload,
+            :status, :attempts, :available_at, :created_at
+        )
+    `
+	if _, err := r.conn(ctx).NamedExecContext(ctx, query, entries); err != nil {
+		return fmt.Errorf("failed to write outbox: %w", err)
+	}
+	return nil
+}
+
+func (r *PGRepository) ClaimPending(ctx context.Context, now, leaseUntil time.Time, limit int) ([]model.OutboxEntry, error) {
+	// An entry waits while an older one of its destination and aggregate is pending, even when
+	// that one is backing off or leased, so per-aggregate order survives retries.
+	query := `
+        UPDATE outbox SET available_at = $2
+        WHERE id IN (
+            SELECT o.id FROM outbox o
+            WHERE o.status = 'pending' AND o.available_at <= $1
+                AND NOT EXISTS (
+                    SELECT 1 FROM outbox e
+                    WHERE e.status = 'pending'
+                        AND e.destination = o.destination
+                        AND e.aggregate_type = o.aggregate_type
+                        AND e.aggregate_id = o.aggregate_id
+                        AND e.seq < o.seq
+                )
+            ORDER BY o.seq ASC
+            LIMIT $3
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING *
+    `
+	entries := []model.OutboxEntry{}
+	if err := r.conn(ctx).SelectContext(ctx, &entries, query, now, leaseUntil, limit); err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Seq < entries[j].Seq })
+	return entries, nil
+}
+
+// MarkDelivered matches the lease held in available_at, as do MarkFailed and Park, so a relay
+// whose lease ran out cannot settle an entry that was claimed again since.
+func (r *PGRepository) MarkDelivered(ctx context.Context, entry model.OutboxEntry, deliveredAt time.Time) error {
+	query := `
+        UPDATE outbox
+        SET status = 'delivered', attempts = attempts + 1, last_error = NULL, delivered_at = $3
+        WHERE id = $1 AND status = 'pending' AND available_at = $2
+    `
+	_, err := r.conn(ctx).ExecContext(ctx, query, entry.ID, entry.AvailableAt, deliveredAt)
+	return err
+}
+
+func (r *PGRepository) MarkFailed(ctx context.Context, entry model.OutboxEntry, cause string, retryAt time.Time) error {
+	query := `
+        UPDATE outbox SET attempts = attempts + 1, last_error = $3, available_at = $4
+        WHERE id = $1 AND status = 'pending' AND available_at = $2
+    `
+	_, err := r.conn(ctx).ExecContext(ctx, query, entry.ID, entry.AvailableAt, cause, retryAt)
+	return err
+}
+
+func (r *PGRepository) Park(ctx context.Context, entry model.OutboxEntry, cause string) error {
+	query := `
+        UPDATE outbox SET status = 'failed', attempts = attempts + 1, last_error = $3
+        WHERE id = $1 AND status = 'pending' AND available_at = $2
+    `
+	_, err := r.conn(ctx).ExecContext(ctx, query, entry.ID, entry.AvailableAt, cause)
+	return err
+}
+
+func (r *PGRepository) PurgeDelivered(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.conn(ctx).ExecContext(ctx, `DELETE FROM outbox WHERE status = 'delivered' AND delivered_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (r *PGRepository) FindSearchDeletes(ctx context.Context, index string, since time.Time) ([]string, error) {
+	query := `
+        SELECT DISTINCT payload->>'document_id' FROM outbox
+        WHERE destination = 'search' AND created_at >= $1
+            AND payload->>'op' = 'delete' AND payload->>'index' = $2
+    `
+	ids := []string{}
+	err := r.conn(ctx).SelectContext(ctx, &ids, query, since, index)
+	return ids, err
+}
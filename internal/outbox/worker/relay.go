@@ -0,0 +1,286 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fekuna/omnipos-pkg/cache"
+	"github.com/fekuna/omnipos-pkg/logger"
+	"github.com/fekuna/omnipos-pkg/search"
+	"github.com/fekuna/omnipos-product-service/internal/database"
+	"github.com/fekuna/omnipos-product-service/internal/event"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/fekuna/omnipos-product-service/internal/outbox"
+	"go.uber.org/zap"
+)
+
+const (
+	initialRetryDelay = time.Second
+	defaultLease      = time.Minute
+	purgeInterval     = time.Hour
+)
+
+var errSearchUnavailable = errors.New("search is not configured")
+
+type RelayOptions struct {
+	Interval    time.Duration // between polls once the outbox is drained
+	BatchSize   int
+	MaxBackoff  time.Duration // cap of the delay between attempts of one entry
+	MaxAttempts int           // failed attempts before an entry is parked, zero retries forever
+	Lease       time.Duration // how long a claimed batch has to be delivered before it can be claimed again
+	Retention   time.Duration // how long delivered entries are kept
+}
+
+// Relay delivers outbox entries to Kafka, Elasticsearch and Redis. Entries are leased in a
+// short transaction and delivered with no transaction open, so several instances can run side
+// by side and a slow destination neither pins a connection nor holds back the change tokens
+// of stock watch and catalog sync. An entry that fails is retried with backoff and holds back
+// the later entries of its aggregate until it goes through or is parked after MaxAttempts.
+type Relay struct {
+	repo      outbox.Repository
+	tx        database.TxManager
+	events    event.Publisher // Publishes to Kafka
+	es        *search.Client
+	cache     *cache.RedisClient
+	mappings  map[string]string // Search index name to the mapping it is created with
+	created   map[string]bool
+	opts      RelayOptions
+	lastPurge time.Time
+	logger    logger.ZapLogger
+}
+
+func NewRelay(repo outbox.Repository, tx database.TxManager, events event.Publisher, es *search.Client, cache *cache.RedisClient, mappings map[string]string, opts RelayOptions, logger logger.ZapLogger) *Relay {
+	if opts.Lease <= 0 {
+		opts.Lease = defaultLease
+	}
+	return &Relay{
+		repo:     repo,
+		tx:       tx,
+		events:   events,
+		es:       es,
+		cache:    cache,
+		mappings: mappings,
+		created:  make(map[string]bool),
+		opts:     opts,
+		logger:   logger,
+	}
+}
+
+func (r *Relay) Start(ctx context.Context) {
+	r.logger.Info("Starting Outbox Relay", zap.Duration("interval", r.opts.Interval))
+	ctx = database.AsSystem(ctx) // runs across merchants
+	ticker := time.NewTicker(r.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.Info("Stopping Outbox Relay")
+			return
+		case <-ticker.C:
+			r.drain(ctx)
+			r.purge(ctx)
+		}
+	}
+}
+
+func (r *Relay) drain(ctx context.Context) {
+	for {
+		claimed, err := r.relayBatch(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				r.logger.Error("Failed to relay outbox", zap.Error(err))
+			}
+			return
+		}
+		// A full batch means there may be more waiting.
+		if claimed < r.opts.BatchSize {
+			return
+		}
+	}
+}
+
+// relayBatch leases one batch, delivers it and then settles it in a second transaction.
+// Delivery has to finish within the lease, or the batch would be claimed again meanwhile.
+func (r *Relay) relayBatch(ctx context.Context) (int, error) {
+	now := time.Now()
+	entries, err := r.repo.ClaimPending(ctx, now, now.Add(r.opts.Lease), r.opts.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	deliverCtx, cancel := context.WithTimeout(ctx, r.opts.Lease)
+	defer cancel()
+
+	causes := make([]error, len(entries))
+	var events []int
+	for i, entry := range entries {
+		if entry.Destination == model.OutboxDestinationKafka {
+			events = append(events, i)
+			continue
+		}
+		causes[i] = r.deliver(deliverCtx, entry)
+	}
+
+	// Each aggregate has at most one entry in a batch, so events can go out in one write.
+	if len(events) > 0 {
+		batch := make([]model.OutboxEntry, len(events))
+		for j, i := range events {
+			batch[j] = entries[i]
+		}
+		cause := r.publish(deliverCtx, batch)
+		for _, i := range events {
+			causes[i] = cause
+		}
+	}
+
+	err = r.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		for i, entry := range entries {
+			if err := r.settle(ctx, entry, causes[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return len(entries), err
+}
+
+// settle records the outcome of one delivery.
+func (r *Relay) settle(ctx context.Context, entry model.OutboxEntry, cause error) error {
+	if cause == nil {
+		return r.repo.MarkDelivered(ctx, entry, time.Now())
+	}
+
+	if r.opts.MaxAttempts > 0 && entry.Attempts+1 >= r.opts.MaxAttempts {
+		r.logger.Error("Outbox delivery failed too often, parking entry",
+			zap.String("outbox_id", entry.ID),
+			zap.String("destination", entry.Destination),
+			zap.String("aggregate_id", entry.AggregateID),
+			zap.Int("attempts", entry.Attempts+1),
+			zap.Error(cause),
+		)
+		return r.repo.Park(ctx, entry, cause.Error())
+	}
+
+	delay := r.backoff(entry.Attempts)
+	r.logger.Warn("Outbox delivery failed, will retry",
+		zap.String("outbox_id", entry.ID),
+		zap.String("destination", entry.Destination),
+		zap.String("aggregate_id", entry.AggregateID),
+		zap.Int("attempt", entry.Attempts+1),
+		zap.Duration("retry_in", delay),
+		zap.Error(cause),
+	)
+	return r.repo.MarkFailed(ctx, entry, cause.Error(), time.Now().Add(delay))
+}
+
+// backoff doubles the delay with every failed attempt, up to MaxBackoff.
+func (r *Relay) backoff(attempts int) time.Duration {
+	delay := initialRetryDelay
+	for i := 0; i < attempts && delay < r.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, r.opts.MaxBackoff)
+}
+
+func (r *Relay) publish(ctx context.Context, entries []model.OutboxEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	events := make([]event.Event, len(entries))
+	for i, entry := range entries {
+		// Decode into a raw payload so it is published exactly as it was recorded.
+		var payload json.RawMessage
+		events[i].Payload = &payload
+		if err := json.Unmarshal(entry.Payload, &events[i]); err != nil {
+			return fmt.Errorf("failed to decode event %s: %w", entry.ID, err)
+		}
+	}
+	return r.events.Publish(ctx, events...)
+}
+
+func (r *Relay) deliver(ctx context.Context, entry model.OutboxEntry) error {
+	switch entry.Destination {
+	case model.OutboxDestinationSearch:
+		var payload outbox.SearchPayload
+		if err := json.Unmarshal(entry.Payload, &payload); err != nil {
+			return err
+		}
+		return r.syncSearch(ctx, payload)
+	case model.OutboxDestinationCache:
+		var payload outbox.CachePayload
+		if err := json.Unmarshal(entry.Payload, &payload); err != nil {
+			return err
+		}
+		return r.invalidateCache(ctx, payload)
+	case model.OutboxDestinationNotify:
+		var payload outbox.NotifyPayload
+		if err := json.Unmarshal(entry.Payload, &payload); err != nil {
+			return err
+		}
+		return r.cache.Client.Publish(ctx, payload.Channel, payload.Message).Err()
+	default:
+		return fmt.Errorf("unknown outbox destination %q", entry.Destination)
+	}
+}
+
+func (r *Relay) syncSearch(ctx context.Context, payload outbox.SearchPayload) error {
+	if r.es == nil {
+		return errSearchUnavailable
+	}
+
+	switch payload.Op {
+	case outbox.SearchOpIndex:
+		if mapping, ok := r.mappings[payload.Index]; ok && !r.created[payload.Index] {
+			// Fails when the index already exists, which is fine.
+			_ = r.es.CreateIndex(ctx, payload.Index, mapping)
+			r.created[payload.Index] = true
+		}
+		return r.es.Index(ctx, payload.Index, payload.DocumentID, payload.Document)
+	case outbox.SearchOpDelete:
+		return r.es.Delete(ctx, payload.Index, payload.DocumentID)
+	default:
+		return fmt.Errorf("unknown search operation %q", payload.Op)
+	}
+}
+
+func (r *Relay) invalidateCache(ctx context.Context, payload outbox.CachePayload) error {
+	for _, pattern := range payload.Patterns {
+		keys, err := r.cache.Client.Keys(ctx, pattern).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) == 0 {
+			continue
+		}
+		if err := r.cache.Client.Del(ctx, keys...).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Relay) purge(ctx context.Context) {
+	if r.opts.Retention <= 0 || time.Since(r.lastPurge) < purgeInterval {
+		return
+	}
+	r.lastPurge = time.Now()
+
+	purged, err := r.repo.PurgeDelivered(ctx, time.Now().Add(-r.opts.Retention))
+	if err != nil {
+		if ctx.Err() == nil {
+			r.logger.Error("Failed to purge delivered outbox entries", zap.Error(err))
+		}
+		return
+	}
+	if purged > 0 {
+		r.logger.Info("Purged delivered outbox entries", zap.Int64("count", purged))
+	}
+}
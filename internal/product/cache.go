@@ -0,0 +1,9 @@
+package product
+
+import "fmt"
+
+// ListCachePattern matches every cached product list of a merchant, for whoever changes
+// what those lists show.
+func ListCachePattern(merchantID string) string {
+	return fmt.Sprintf("products:list:%s:*", merchantID)
+}
@@ -1,5 +1,7 @@
 package dto
 
+import "github.com/fekuna/omnipos-product-service/internal/model"
+
 type ProductFilters struct {
 	MerchantID  string
 	CategoryID  string
@@ -10,3 +12,47 @@ type ProductFilters struct {
 	Page        int
 	PageSize    int
 }
+
+type GenerateVariantsResult struct {
+	Options     []model.ProductOption
+	Variants    []model.ProductVariant // All variants of the product after generation
+	Created     int
+	Reactivated int
+	Deactivated int
+}
+
+// Per-line outcomes of a stock reservation
+const (
+	ReserveLineReserved     = "reserved"     // Held in the reservation
+	ReserveLineAvailable    = "available"    // Enough stock, but another line failed so nothing was held
+	ReserveLineInsufficient = "insufficient" // Inventory row exists with too little available stock
+	ReserveLineUnknown      = "unknown"      // No such product, variant or inventory row for the store
+	ReserveLineSkipped      = "skipped"      // Product does not track inventory
+)
+
+type ReserveStockLineResult struct {
+	ProductID         string
+	VariantID         *string
+	Quantity          float64
+	AvailableQuantity float64 // Only set for insufficient lines
+	Status            string
+	Inventory         *model.Inventory // Location after the hold, only set for reserved lines
+}
+
+type ReserveStockResult struct {
+	Success     bool
+	Reservation *model.StockReservation // Nil when Success is false
+	Lines       []ReserveStockLineResult
+}
+
+// CatalogDelta is one page of a catalog sync. Categories come before products and products
+// before variants; an entry can be sent again without having changed.
+type CatalogDelta struct {
+	FullSync   bool // Synced from scratch: drop whatever the terminal holds first
+	Categories []model.Category
+	Products   []model.Product
+	Variants   []model.ProductVariant
+	Tombstones []model.CatalogChange // Deleted rows. The variants of a deleted product have none
+	SyncToken  string                // Fetches the next page, or the next delta once HasMore is false
+	HasMore    bool
+}
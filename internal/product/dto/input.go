@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 type CreateProductInput struct {
 	MerchantID     string
 	CategoryID     string // Optional?
@@ -32,21 +34,59 @@ type UpdateProductInput struct {
 }
 
 type CreateVariantInput struct {
+	MerchantID      string // Owner of the parent product
 	ProductID       string
 	SKU             string
 	Barcode         string
 	VariantName     string
 	PriceAdjustment float64
-	CostPrice       float64
+	CostPrice       *float64 // Nil leaves the cost unset
 }
 
 type UpdateVariantInput struct {
 	ID              string
+	MerchantID      string
 	ProductID       string
 	SKU             string
 	Barcode         string
 	VariantName     string
 	PriceAdjustment float64
-	CostPrice       float64
+	CostPrice       *float64 // Nil keeps the current cost
+	ClearCostPrice  bool     // Unsets the cost, CostPrice is ignored
 	IsActive        bool
 }
+
+type OptionAxisInput struct {
+	Name   string   // e.g. "Size"
+	Values []string // e.g. ["S", "M", "L"]
+}
+
+type GenerateVariantsInput struct {
+	MerchantID   string
+	ProductID    string
+	Options      []OptionAxisInput
+	SKUTemplate  string // e.g. "{parent_sku}-{size}-{colour}", defaults to parent SKU + every axis
+	NameTemplate string // e.g. "{size} / {colour}", defaults to every axis joined by " / "
+}
+
+type ReserveStockInput struct {
+	MerchantID string
+	OrderID    string
+	StoreID    *string       // Nil for central/warehouse inventory
+	TTL        time.Duration // How long the hold lasts before the sweeper releases it
+	Items      []ReserveStockItemInput
+}
+
+type ReserveStockItemInput struct {
+	ProductID string
+	VariantID *string
+	Quantity  float64
+}
+
+// SyncCatalogInput asks for a page of a merchant's catalog changes. It takes no store: the
+// catalog and its prices are the same at every store, so each store syncs the same delta.
+type SyncCatalogInput struct {
+	MerchantID string
+	SyncToken  string // From the last page received, empty for a full sync
+	PageSize   int
+}
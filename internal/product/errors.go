@@ -0,0 +1,41 @@
+package product
+
+import (
+	"errors"
+
+	"github.com/fekuna/omnipos-product-service/internal/apperror"
+)
+
+var (
+	// ErrProductNotFound is also returned for a product of another merchant, so IDs of other
+	// merchants cannot be told apart from IDs that don't exist.
+	ErrProductNotFound     = apperror.New(apperror.NotFound, "PRODUCT_NOT_FOUND", "product not found")
+	ErrVariantNotFound     = apperror.New(apperror.NotFound, "VARIANT_NOT_FOUND", "variant not found")
+	ErrReservationNotFound = apperror.New(apperror.NotFound, "RESERVATION_NOT_FOUND", "reservation not found")
+
+	ErrSKUExists            = apperror.New(apperror.AlreadyExists, "SKU_ALREADY_EXISTS", "SKU already exists")
+	ErrBarcodeExists        = apperror.New(apperror.AlreadyExists, "BARCODE_ALREADY_EXISTS", "barcode already exists")
+	ErrVariantSKUExists     = apperror.New(apperror.AlreadyExists, "VARIANT_SKU_ALREADY_EXISTS", "variant SKU {{.SKU}} already exists")
+	ErrVariantBarcodeExists = apperror.New(apperror.AlreadyExists, "VARIANT_BARCODE_ALREADY_EXISTS", "variant barcode {{.Barcode}} already exists")
+
+	ErrReservationClosed    = apperror.New(apperror.FailedPrecondition, "RESERVATION_CLOSED", "reservation for order {{.OrderID}} is already {{.Status}}")
+	ErrReservationNotActive = apperror.New(apperror.FailedPrecondition, "RESERVATION_NOT_ACTIVE", "reservation is already {{.Status}}")
+	ErrNothingToReserve     = apperror.Invalid("NOTHING_TO_RESERVE", "items", "no valid items to reserve")
+	ErrInvalidSyncToken     = apperror.Invalid("INVALID_SYNC_TOKEN", "sync_token", "sync token is not valid")
+)
+
+// Errors in the options and templates of a variant matrix.
+var (
+	ErrOptionsRequired       = apperror.Invalid("OPTIONS_REQUIRED", "options", "at least one option axis is required")
+	ErrOptionNameRequired    = apperror.Invalid("OPTION_NAME_REQUIRED", "options.name", "option name is required")
+	ErrOptionNameReserved    = apperror.Invalid("OPTION_NAME_RESERVED", "options.name", `option name "{{.Name}}" is reserved`)
+	ErrDuplicateOption       = apperror.Invalid("DUPLICATE_OPTION", "options.name", `duplicate option "{{.Name}}"`)
+	ErrOptionWithoutValues   = apperror.Invalid("OPTION_WITHOUT_VALUES", "options.values", `option "{{.Name}}" has no values`)
+	ErrTooManyCombinations   = apperror.Invalid("TOO_MANY_VARIANT_COMBINATIONS", "options", "too many variant combinations ({{.Count}}), maximum is {{.Max}}")
+	ErrUnknownPlaceholder    = apperror.Invalid("UNKNOWN_PLACEHOLDER", "", `unknown placeholder {{.Placeholder}} in template "{{.Template}}"`)
+	ErrGeneratedFieldTooLong = apperror.Invalid("GENERATED_FIELD_TOO_LONG", "", `generated SKU or name for "{{.Name}}" exceeds {{.Max}} characters`)
+)
+
+// ErrStockNotHeld is returned with the per-line results when a reservation could not hold
+// every line. Nothing is held then.
+var ErrStockNotHeld = errors.New("stock could not be held for every line")
@@ -2,8 +2,10 @@ package handler
 
 import (
 	"context"
+	"time"
 
 	"github.com/fekuna/omnipos-pkg/logger"
+	"github.com/fekuna/omnipos-product-service/internal/apperror"
 	"github.com/fekuna/omnipos-product-service/internal/auth"
 	"github.com/fekuna/omnipos-product-service/internal/model"
 	"github.com/fekuna/omnipos-product-service/internal/product"
@@ -60,7 +62,7 @@ func (h *ProductHandler) CreateProduct(ctx context.Context, req *productv1.Creat
 	p, err := h.uc.CreateProduct(ctx, input)
 	if err != nil {
 		h.logger.Error("failed to create product", zap.Error(err))
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, err
 	}
 
 	return &productv1.ProductResponse{
@@ -69,12 +71,11 @@ func (h *ProductHandler) CreateProduct(ctx context.Context, req *productv1.Creat
 }
 
 func (h *ProductHandler) GetProduct(ctx context.Context, req *productv1.GetProductRequest) (*productv1.ProductResponse, error) {
-	p, err := h.uc.GetProduct(ctx, req.Id)
+	merchantID := auth.GetMerchantID(ctx)
+
+	p, err := h.uc.GetProduct(ctx, merchantID, req.Id)
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
-	}
-	if p == nil {
-		return nil, status.Error(codes.NotFound, "product not found")
+		return nil, err
 	}
 
 	return &productv1.ProductResponse{Product: mapProductToProto(p)}, nil
@@ -101,7 +102,7 @@ func (h *ProductHandler) ListProducts(ctx context.Context, req *productv1.ListPr
 
 	products, count, err := h.uc.ListProducts(ctx, filters)
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, err
 	}
 
 	protos := make([]*productv1.Product, len(products))
@@ -129,7 +130,7 @@ func (h *ProductHandler) SearchProducts(ctx context.Context, req *productv1.Sear
 
 	products, count, err := h.uc.ListProducts(ctx, filters)
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, err
 	}
 
 	protos := make([]*productv1.Product, len(products))
@@ -164,20 +165,195 @@ func (h *ProductHandler) UpdateProduct(ctx context.Context, req *productv1.Updat
 
 	p, err := h.uc.UpdateProduct(ctx, input)
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, err
 	}
 
 	return &productv1.ProductResponse{Product: mapProductToProto(p)}, nil
 }
 
 func (h *ProductHandler) DeleteProduct(ctx context.Context, req *productv1.DeleteProductRequest) (*emptypb.Empty, error) {
-	err := h.uc.DeleteProduct(ctx, req.Id)
+	merchantID := auth.GetMerchantID(ctx)
+
+	err := h.uc.DeleteProduct(ctx, merchantID, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// SyncCatalog returns a page of the catalog changes a POS terminal needs to keep selling
+// offline. The terminal calls again with the returned token while HasMore is set, and
+// stores the last token for its next sync. Sync covers the merchant's whole catalog, which
+// has no per-store assortment or prices.
+func (h *ProductHandler) SyncCatalog(ctx context.Context, req *productv1.SyncCatalogRequest) (*productv1.SyncCatalogResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+
+	delta, err := h.uc.SyncCatalog(ctx, &dto.SyncCatalogInput{
+		MerchantID: merchantID,
+		SyncToken:  req.SyncToken,
+		PageSize:   int(req.PageSize),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	categories := make([]*productv1.Category, len(delta.Categories))
+	for i, c := range delta.Categories {
+		categories[i] = mapCategoryToProto(&c)
+	}
+	products := make([]*productv1.Product, len(delta.Products))
+	for i, p := range delta.Products {
+		products[i] = mapProductToProto(&p)
+	}
+	variants := make([]*productv1.ProductVariant, len(delta.Variants))
+	for i, v := range delta.Variants {
+		variants[i] = mapVariantToProto(&v)
+	}
+	tombstones := make([]*productv1.CatalogTombstone, len(delta.Tombstones))
+	for i, t := range delta.Tombstones {
+		productID := ""
+		if t.ProductID != nil {
+			productID = *t.ProductID
+		}
+		tombstones[i] = &productv1.CatalogTombstone{
+			EntityType: t.EntityType,
+			Id:         t.EntityID,
+			ProductId:  productID,
+			DeletedAt:  timestamppb.New(t.ChangedAt),
+		}
+	}
+
+	return &productv1.SyncCatalogResponse{
+		FullSync:   delta.FullSync,
+		Categories: categories,
+		Products:   products,
+		Variants:   variants,
+		Tombstones: tombstones,
+		SyncToken:  delta.SyncToken,
+		HasMore:    delta.HasMore,
+	}, nil
+}
+
+// --- ProductVariantService Server ---
+
+func (h *ProductHandler) AddVariant(ctx context.Context, req *productv1.AddVariantRequest) (*productv1.VariantResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+	if merchantID == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing merchant context")
+	}
+
+	input := &dto.CreateVariantInput{
+		MerchantID:      merchantID,
+		ProductID:       req.ProductId,
+		SKU:             req.Sku,
+		Barcode:         req.Barcode,
+		VariantName:     req.VariantName,
+		PriceAdjustment: req.PriceAdjustment,
+		CostPrice:       req.CostPrice,
+	}
+
+	v, err := h.uc.AddVariant(ctx, input)
+	if err != nil {
+		h.logger.Error("failed to add variant", zap.Error(err))
+		return nil, err
+	}
+
+	return &productv1.VariantResponse{Variant: mapVariantToProto(v)}, nil
+}
+
+func (h *ProductHandler) ListVariants(ctx context.Context, req *productv1.ListVariantsRequest) (*productv1.ListVariantsResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+
+	variants, err := h.uc.ListVariants(ctx, merchantID, req.ProductId, req.ActiveOnly)
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, err
+	}
+
+	protos := make([]*productv1.ProductVariant, len(variants))
+	for i, v := range variants {
+		protos[i] = mapVariantToProto(&v)
+	}
+
+	return &productv1.ListVariantsResponse{Variants: protos}, nil
+}
+
+func (h *ProductHandler) UpdateVariant(ctx context.Context, req *productv1.UpdateVariantRequest) (*productv1.VariantResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+
+	input := &dto.UpdateVariantInput{
+		ID:              req.Id,
+		MerchantID:      merchantID,
+		ProductID:       req.ProductId,
+		SKU:             req.Sku,
+		Barcode:         req.Barcode,
+		VariantName:     req.VariantName,
+		PriceAdjustment: req.PriceAdjustment,
+		CostPrice:       req.CostPrice,
+		ClearCostPrice:  req.ClearCostPrice,
+		IsActive:        req.IsActive,
+	}
+
+	v, err := h.uc.UpdateVariant(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return &productv1.VariantResponse{Variant: mapVariantToProto(v)}, nil
+}
+
+func (h *ProductHandler) DeactivateVariant(ctx context.Context, req *productv1.DeactivateVariantRequest) (*emptypb.Empty, error) {
+	merchantID := auth.GetMerchantID(ctx)
+
+	err := h.uc.DeactivateVariant(ctx, merchantID, req.ProductId, req.Id)
+	if err != nil {
+		return nil, err
 	}
 	return &emptypb.Empty{}, nil
 }
 
+func (h *ProductHandler) GenerateVariants(ctx context.Context, req *productv1.GenerateVariantsRequest) (*productv1.GenerateVariantsResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+	if merchantID == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing merchant context")
+	}
+
+	options := make([]dto.OptionAxisInput, len(req.Options))
+	for i, o := range req.Options {
+		options[i] = dto.OptionAxisInput{Name: o.Name, Values: o.Values}
+	}
+
+	input := &dto.GenerateVariantsInput{
+		MerchantID:   merchantID,
+		ProductID:    req.ProductId,
+		Options:      options,
+		SKUTemplate:  req.SkuTemplate,
+		NameTemplate: req.NameTemplate,
+	}
+
+	res, err := h.uc.GenerateVariants(ctx, input)
+	if err != nil {
+		h.logger.Error("failed to generate variants", zap.Error(err))
+		return nil, err
+	}
+
+	protoOptions := make([]*productv1.VariantOption, len(res.Options))
+	for i, o := range res.Options {
+		protoOptions[i] = &productv1.VariantOption{Name: o.Name, Values: o.Values}
+	}
+	protoVariants := make([]*productv1.ProductVariant, len(res.Variants))
+	for i, v := range res.Variants {
+		protoVariants[i] = mapVariantToProto(&v)
+	}
+
+	return &productv1.GenerateVariantsResponse{
+		Options:     protoOptions,
+		Variants:    protoVariants,
+		Created:     int32(res.Created),
+		Reactivated: int32(res.Reactivated),
+		Deactivated: int32(res.Deactivated),
+	}, nil
+}
+
 // Helper
 func mapProductToProto(m *model.Product) *productv1.Product {
 	if m == nil {
@@ -209,6 +385,14 @@ func mapProductToProto(m *model.Product) *productv1.Product {
 		imgURL = *m.ImageURL
 	}
 
+	var variants []*productv1.ProductVariant
+	if len(m.Variants) > 0 {
+		variants = make([]*productv1.ProductVariant, len(m.Variants))
+		for i, v := range m.Variants {
+			variants[i] = mapVariantToProto(&v)
+		}
+	}
+
 	return &productv1.Product{
 		Id:             m.ID,
 		MerchantId:     m.MerchantID,
@@ -226,7 +410,68 @@ func mapProductToProto(m *model.Product) *productv1.Product {
 		IsActive:       m.IsActive,
 		CreatedAt:      timestamppb.New(m.CreatedAt),
 		UpdatedAt:      timestamppb.New(m.UpdatedAt),
-		// Variants and Category would be populated if JOINed or filled
+		Variants:       variants,
+		// Category would be populated if JOINed or filled
+	}
+}
+
+func mapCategoryToProto(m *model.Category) *productv1.Category {
+	parentID := ""
+	if m.ParentID != nil {
+		parentID = *m.ParentID
+	}
+
+	desc := ""
+	if m.Description != nil {
+		desc = *m.Description
+	}
+
+	imgURL := ""
+	if m.ImageURL != nil {
+		imgURL = *m.ImageURL
+	}
+
+	return &productv1.Category{
+		Id:          m.ID,
+		MerchantId:  m.MerchantID,
+		ParentId:    parentID,
+		Name:        m.Name,
+		Description: desc,
+		ImageUrl:    imgURL,
+		SortOrder:   int32(m.SortOrder),
+		IsActive:    m.IsActive,
+		CreatedAt:   timestamppb.New(m.CreatedAt),
+		UpdatedAt:   timestamppb.New(m.UpdatedAt),
+	}
+}
+
+func mapVariantToProto(m *model.ProductVariant) *productv1.ProductVariant {
+	if m == nil {
+		return nil
+	}
+
+	barcode := ""
+	if m.Barcode != nil {
+		barcode = *m.Barcode
+	}
+
+	costPrice := 0.0
+	if m.CostPrice != nil {
+		costPrice = *m.CostPrice
+	}
+
+	return &productv1.ProductVariant{
+		Id:              m.ID,
+		ProductId:       m.ProductID,
+		Sku:             m.SKU,
+		Barcode:         barcode,
+		VariantName:     m.VariantName,
+		PriceAdjustment: m.PriceAdjustment,
+		CostPrice:       costPrice,
+		IsActive:        m.IsActive,
+		OptionValues:    m.OptionValues,
+		CreatedAt:       timestamppb.New(m.CreatedAt),
+		UpdatedAt:       timestamppb.New(m.UpdatedAt),
 	}
 }
 
@@ -235,17 +480,31 @@ func (h *ProductHandler) ReserveStock(ctx context.Context, req *productv1.Reserv
 	if merchantID == "" {
 		return nil, status.Error(codes.Unauthenticated, "missing merchant context")
 	}
+	if req.OrderId == "" {
+		return nil, status.Error(codes.InvalidArgument, "order_id is required")
+	}
+
+	storeID := (*string)(nil)
+	if req.StoreId != "" {
+		s := req.StoreId
+		storeID = &s
+	}
 
-	items := make(map[string]int32)
+	items := make([]dto.ReserveStockItemInput, 0, len(req.Items))
 	for _, item := range req.Items {
-		// If variant_id is present, use it? Or both?
-		// Implementation Plan said "Product Service: Implement Atomic DB Reservation... UPDATE products...".
-		// It mentions treating variants later.
-		// For now, key is ProductID.
 		if item.ProductId == "" {
 			continue
 		}
-		items[item.ProductId] = item.Quantity
+		variantID := (*string)(nil)
+		if item.VariantId != "" {
+			v := item.VariantId
+			variantID = &v
+		}
+		items = append(items, dto.ReserveStockItemInput{
+			ProductID: item.ProductId,
+			VariantID: variantID,
+			Quantity:  float64(item.Quantity),
+		})
 	}
 
 	if len(items) == 0 {
@@ -255,18 +514,120 @@ func (h *ProductHandler) ReserveStock(ctx context.Context, req *productv1.Reserv
 		}, nil
 	}
 
-	err := h.uc.ReserveStock(ctx, items)
+	input := &dto.ReserveStockInput{
+		MerchantID: merchantID,
+		OrderID:    req.OrderId,
+		StoreID:    storeID,
+		TTL:        time.Duration(req.TtlSeconds) * time.Second,
+		Items:      items,
+	}
+
+	res, err := h.uc.ReserveStock(ctx, input)
 	if err != nil {
-		// Log warning rather than error for business logic failures?
-		// Or return success=false.
-		// If error is "insufficient stock", return success=false
+		// Business failures (e.g. order already committed) are reported in the response, not as RPC errors.
+		e, ok := apperror.As(err)
+		if !ok {
+			return nil, err
+		}
 		return &productv1.ReserveStockResponse{
 			Success: false,
-			Message: err.Error(),
+			Message: apperror.Message(ctx, e),
+		}, nil
+	}
+
+	lines := make([]*productv1.ReserveStockLine, len(res.Lines))
+	for i, l := range res.Lines {
+		variantID := ""
+		if l.VariantID != nil {
+			variantID = *l.VariantID
+		}
+		lines[i] = &productv1.ReserveStockLine{
+			ProductId:         l.ProductID,
+			VariantId:         variantID,
+			Quantity:          l.Quantity,
+			AvailableQuantity: l.AvailableQuantity,
+			Status:            l.Status,
+		}
+	}
+
+	if !res.Success {
+		return &productv1.ReserveStockResponse{
+			Success: false,
+			Message: "Some items could not be reserved",
+			Lines:   lines,
 		}, nil
 	}
 
 	return &productv1.ReserveStockResponse{
-		Success: true,
+		Success:       true,
+		ReservationId: res.Reservation.ID,
+		ExpiresAt:     timestamppb.New(res.Reservation.ExpiresAt),
+		Lines:         lines,
 	}, nil
 }
+
+func (h *ProductHandler) CommitReservation(ctx context.Context, req *productv1.CommitReservationRequest) (*productv1.ReservationResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+	if merchantID == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing merchant context")
+	}
+
+	userID := auth.GetUserID(ctx)
+
+	res, err := h.uc.CommitReservation(ctx, merchantID, req.OrderId, userID)
+	if err != nil {
+		h.logger.Error("failed to commit reservation", zap.String("order_id", req.OrderId), zap.Error(err))
+		return nil, err
+	}
+
+	return &productv1.ReservationResponse{Reservation: mapReservationToProto(res)}, nil
+}
+
+func (h *ProductHandler) ReleaseReservation(ctx context.Context, req *productv1.ReleaseReservationRequest) (*productv1.ReservationResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+	if merchantID == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing merchant context")
+	}
+
+	res, err := h.uc.ReleaseReservation(ctx, merchantID, req.OrderId)
+	if err != nil {
+		h.logger.Error("failed to release reservation", zap.String("order_id", req.OrderId), zap.Error(err))
+		return nil, err
+	}
+
+	return &productv1.ReservationResponse{Reservation: mapReservationToProto(res)}, nil
+}
+
+func mapReservationToProto(m *model.StockReservation) *productv1.StockReservation {
+	if m == nil {
+		return nil
+	}
+
+	items := make([]*productv1.StockReservationItem, len(m.Items))
+	for i, item := range m.Items {
+		storeID := ""
+		if item.StoreID != nil {
+			storeID = *item.StoreID
+		}
+		variantID := ""
+		if item.VariantID != nil {
+			variantID = *item.VariantID
+		}
+		items[i] = &productv1.StockReservationItem{
+			ProductId: item.ProductID,
+			VariantId: variantID,
+			StoreId:   storeID,
+			Quantity:  item.Quantity,
+		}
+	}
+
+	return &productv1.StockReservation{
+		Id:         m.ID,
+		MerchantId: m.MerchantID,
+		OrderId:    m.OrderID,
+		Status:     m.Status,
+		ExpiresAt:  timestamppb.New(m.ExpiresAt),
+		CreatedAt:  timestamppb.New(m.CreatedAt),
+		Items:      items,
+	}
+}
@@ -2,6 +2,7 @@ package product
 
 import (
 	"context"
+	"time"
 
 	"github.com/fekuna/omnipos-product-service/internal/model"
 	"github.com/fekuna/omnipos-product-service/internal/product/dto"
@@ -9,14 +10,44 @@ import (
 
 type Repository interface {
 	Create(ctx context.Context, product *model.Product) error
-	FindByID(ctx context.Context, id string) (*model.Product, error)
+	FindByID(ctx context.Context, merchantID, id string) (*model.Product, error)
 	FindAll(ctx context.Context, filters *dto.ProductFilters) ([]model.Product, int, error)
 	Update(ctx context.Context, product *model.Product) error
-	Delete(ctx context.Context, id string) error
+	Delete(ctx context.Context, merchantID, id string) error
+	IsCategoryOwned(ctx context.Context, merchantID, categoryID string) (bool, error)
+
+	// Search index rebuilds
+	CountForIndexing(ctx context.Context, merchantID string, since *time.Time) (int, error)
+	FindForIndexing(ctx context.Context, merchantID string, since *time.Time, afterID string, limit int) ([]model.Product, error)
+
+	// POS catalog sync
+	CatalogSyncToken(ctx context.Context) (string, error)
+	FindCatalogChanges(ctx context.Context, merchantID, entityType, token, afterID string, limit int) ([]model.CatalogChange, error)
+	FindCategoriesByIDs(ctx context.Context, merchantID string, ids []string) ([]model.Category, error)
+	FindProductsByIDs(ctx context.Context, merchantID string, ids []string) ([]model.Product, error)
+	FindVariantsByIDs(ctx context.Context, merchantID string, ids []string) ([]model.ProductVariant, error)
 
 	// Check SKU/Barcode uniqueness
 	IsSKUUnique(ctx context.Context, merchantID, sku, excludeID string) (bool, error)
 	IsBarcodeUnique(ctx context.Context, merchantID, barcode, excludeID string) (bool, error)
 
-	ReserveStock(ctx context.Context, items map[string]int32) error
+	// Variants. They have no merchant of their own: callers check the parent product first.
+	CreateVariant(ctx context.Context, variant *model.ProductVariant) error
+	FindVariantByID(ctx context.Context, productID, id string) (*model.ProductVariant, error)
+	FindVariantsByProduct(ctx context.Context, productID string, activeOnly bool) ([]model.ProductVariant, error)
+	UpdateVariant(ctx context.Context, variant *model.ProductVariant) error
+	IsVariantSKUUnique(ctx context.Context, productID, sku, excludeID string) (bool, error)
+	IsVariantBarcodeUnique(ctx context.Context, productID, barcode, excludeID string) (bool, error)
+	SyncHasVariants(ctx context.Context, productID string) (bool, error)
+
+	// Variant matrix (option axes)
+	FindOptionsByProduct(ctx context.Context, productID string) ([]model.ProductOption, error)
+	ApplyVariantMatrix(ctx context.Context, productID string, options []model.ProductOption, create []model.ProductVariant, reactivateIDs, deactivateIDs []string) error
+
+	// Stock reservations
+	FindReservationByOrder(ctx context.Context, merchantID, orderID string) (*model.StockReservation, error)
+	ReserveStock(ctx context.Context, reservation *model.StockReservation, storeID *string, lines []dto.ReserveStockItemInput) ([]dto.ReserveStockLineResult, error)
+	CommitReservation(ctx context.Context, reservation *model.StockReservation, createdBy *string) ([]model.InventoryMovement, error)
+	ReleaseReservation(ctx context.Context, reservation *model.StockReservation, status string) error
+	FindExpiredReservations(ctx context.Context, now time.Time, limit int) ([]model.StockReservation, error)
 }
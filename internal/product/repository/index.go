@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fekuna/omnipos-product-service/internal/model"
+)
+
+// indexConditions filters products of a merchant (all when empty) changed since a time (any when nil).
+func indexConditions(merchantID string, since *time.Time) (string, []interface{}) {
+	conditions := []string{"TRUE"}
+	args := []interface{}{}
+	if merchantID != "" {
+		args = append(args, merchantID)
+		conditions = append(conditions, fmt.Sprintf("merchant_id = $%d", len(args)))
+	}
+	if since != nil {
+		args = append(args, *since)
+		conditions = append(conditions, fmt.Sprintf("updated_at >= $%d", len(args)))
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+func (r *PGRepository) CountForIndexing(ctx context.Context, merchantID string, since *time.Time) (int, error) {
+	where, args := indexConditions(merchantID, since)
+	var count int
+	err := r.conn(ctx).GetContext(ctx, &count, "SELECT count(*) FROM products WHERE "+where, args...)
+	return count, err
+}
+
+// FindForIndexing pages through products in id order: pass the last id of the previous page as afterID.
+func (r *PGRepository) FindForIndexing(ctx context.Context, merchantID string, since *time.Time, afterID string, limit int) ([]model.Product, error) {
+	where, args := indexConditions(merchantID, since)
+	if afterID != "" {
+		args = append(args, afterID)
+		where += fmt.Sprintf(" AND id > $%d", len(args))
+	}
+	args = append(args, limit)
+	query := fmt.Sprintf("SELECT * FROM products WHERE %s ORDER BY id ASC LIMIT $%d", where, len(args))
+
+	products := []model.Product{}
+	err := r.conn(ctx).SelectContext(ctx, &products, query, args...)
+	return products, err
+}
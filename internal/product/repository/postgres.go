@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/fekuna/omnipos-product-service/internal/database"
 	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/fekuna/omnipos-product-service/internal/product"
 	"github.com/fekuna/omnipos-product-service/internal/product/dto"
 	"github.com/jmoiron/sqlx"
 )
@@ -20,6 +22,11 @@ func NewPGRepository(db *sqlx.DB) *PGRepository {
 	return &PGRepository{DB: db}
 }
 
+// conn returns the transaction started by the TxManager if ctx carries one.
+func (r *PGRepository) conn(ctx context.Context) database.DBTX {
+	return database.Conn(ctx, r.DB)
+}
+
 func (r *PGRepository) Create(ctx context.Context, p *model.Product) error {
 	query := `
         INSERT INTO products (
@@ -35,14 +42,14 @@ func (r *PGRepository) Create(ctx context.Context, p *model.Product) error {
     `
 	// Note: Transaction handling for variants should normally be done in UseCase using a transaction manager.
 	// Here we just insert the product.
-	_, err := r.DB.NamedExecContext(ctx, query, p)
+	_, err := r.conn(ctx).NamedExecContext(ctx, query, p)
 	return err
 }
 
-func (r *PGRepository) FindByID(ctx context.Context, id string) (*model.Product, error) {
+func (r *PGRepository) FindByID(ctx context.Context, merchantID, id string) (*model.Product, error) {
 	var product model.Product
-	query := `SELECT * FROM products WHERE id = $1 LIMIT 1`
-	err := r.DB.GetContext(ctx, &product, query, id)
+	query := `SELECT * FROM products WHERE id = $1 AND merchant_id = $2 LIMIT 1`
+	err := r.conn(ctx).GetContext(ctx, &product, query, id, merchantID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -56,13 +63,9 @@ func (r *PGRepository) FindAll(ctx context.Context, f *dto.ProductFilters) ([]mo
 	var products []model.Product
 	var count int
 
-	conditions := []string{}
-	args := map[string]interface{}{}
-
-	if f.MerchantID != "" {
-		conditions = append(conditions, "merchant_id = :merchant_id")
-		args["merchant_id"] = f.MerchantID
-	}
+	// Always scoped: an empty merchant matches nothing rather than every merchant.
+	conditions := []string{"merchant_id = :merchant_id"}
+	args := map[string]interface{}{"merchant_id": f.MerchantID}
 	if f.CategoryID != "" {
 		conditions = append(conditions, "category_id = :category_id")
 		args["category_id"] = f.CategoryID
@@ -85,14 +88,9 @@ func (r *PGRepository) FindAll(ctx context.Context, f *dto.ProductFilters) ([]mo
 
 	// Count
 	countQuery := "SELECT count(*) FROM products" + whereClause
-	rows, err := r.DB.NamedQueryContext(ctx, countQuery, args)
-	if err != nil {
+	if err := database.NamedGet(ctx, r.conn(ctx), &count, countQuery, args); err != nil {
 		return nil, 0, err
 	}
-	defer rows.Close()
-	if rows.Next() {
-		rows.Scan(&count)
-	}
 
 	// List
 	orderBy := "created_at DESC"
@@ -120,13 +118,7 @@ func (r *PGRepository) FindAll(ctx context.Context, f *dto.ProductFilters) ([]mo
 		query += fmt.Sprintf(" LIMIT %d OFFSET %d", f.PageSize, offset)
 	}
 
-	nstmt, err := r.DB.PrepareNamedContext(ctx, query)
-	if err != nil {
-		return nil, 0, err
-	}
-	defer nstmt.Close()
-
-	err = nstmt.SelectContext(ctx, &products, args)
+	err := database.NamedSelect(ctx, r.conn(ctx), &products, query, args)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -152,13 +144,40 @@ func (r *PGRepository) Update(ctx context.Context, p *model.Product) error {
             updated_at = :updated_at
         WHERE id = :id AND merchant_id = :merchant_id
     `
-	_, err := r.DB.NamedExecContext(ctx, query, p)
-	return err
+	res, err := r.conn(ctx).NamedExecContext(ctx, query, p)
+	if err != nil {
+		return err
+	}
+	return requireRow(res, product.ErrProductNotFound)
 }
 
-func (r *PGRepository) Delete(ctx context.Context, id string) error {
-	_, err := r.DB.ExecContext(ctx, "DELETE FROM products WHERE id = $1", id)
-	return err
+// Delete removes the product and its variants. A trigger leaves its tombstone in
+// catalog_changes for catalog sync.
+func (r *PGRepository) Delete(ctx context.Context, merchantID, id string) error {
+	res, err := r.conn(ctx).ExecContext(ctx, "DELETE FROM products WHERE id = $1 AND merchant_id = $2", id, merchantID)
+	if err != nil {
+		return err
+	}
+	return requireRow(res, product.ErrProductNotFound)
+}
+
+// requireRow returns notFound when a write matched no row, e.g. a row of another merchant.
+func requireRow(res sql.Result, notFound error) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return notFound
+	}
+	return nil
+}
+
+func (r *PGRepository) IsCategoryOwned(ctx context.Context, merchantID, categoryID string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM categories WHERE id = $1 AND merchant_id = $2)`
+	err := r.conn(ctx).GetContext(ctx, &exists, query, categoryID, merchantID)
+	return exists, err
 }
 
 func (r *PGRepository) IsSKUUnique(ctx context.Context, merchantID, sku, excludeID string) (bool, error) {
@@ -170,7 +189,7 @@ func (r *PGRepository) IsSKUUnique(ctx context.Context, merchantID, sku, exclude
 		args = append(args, excludeID)
 	}
 
-	err := r.DB.GetContext(ctx, &count, query, args...)
+	err := r.conn(ctx).GetContext(ctx, &count, query, args...)
 	if err != nil {
 		return false, err
 	}
@@ -189,55 +208,9 @@ func (r *PGRepository) IsBarcodeUnique(ctx context.Context, merchantID, barcode,
 		args = append(args, excludeID)
 	}
 
-	err := r.DB.GetContext(ctx, &count, query, args...)
+	err := r.conn(ctx).GetContext(ctx, &count, query, args...)
 	if err != nil {
 		return false, err
 	}
 	return count == 0, nil
 }
-
-func (r *PGRepository) ReserveStock(ctx context.Context, items map[string]int32) error {
-	tx, err := r.DB.BeginTxx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Prepare statement for efficiency? Not explicitly needed for simple query but good practice.
-	// We handle variants later. For now, assuming products have inventory.
-	// Note: If HasVariants=true, we should probably update Variant table.
-	// But let's assume simple Product inventory for this iteration or check logic.
-	// The implementation plan says "UPDATE products SET inventory_count = inventory_count - $1...".
-	// Let's stick to Product for now. Variants logic can be added if item map has VariantID.
-
-	query := `
-		UPDATE inventory 
-		SET quantity = quantity - $1, updated_at = NOW() 
-		WHERE product_id = $2 AND quantity >= $1
-	`
-
-	for productID, qty := range items {
-		// Validate inputs
-		if qty <= 0 {
-			continue
-		}
-
-		res, err := tx.ExecContext(ctx, query, qty, productID)
-		if err != nil {
-			return err
-		}
-
-		rows, err := res.RowsAffected()
-		if err != nil {
-			return err
-		}
-
-		if rows == 0 {
-			// Failed to update (Out of Stock or ID not found)
-			// Return specific error?
-			return fmt.Errorf("insufficient stock for product %s", productID)
-		}
-	}
-
-	return tx.Commit()
-}
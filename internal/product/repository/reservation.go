@@ -0,0 +1,336 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/fekuna/omnipos-product-service/internal/database"
+	"github.com/fekuna/omnipos-product-service/internal/inventory"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/fekuna/omnipos-product-service/internal/product"
+	"github.com/fekuna/omnipos-product-service/internal/product/dto"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// reservationItemsQuery loads the items of a reservation in lock order.
+const reservationItemsQuery = `SELECT * FROM stock_reservation_items WHERE reservation_id = $1 ORDER BY product_id, variant_id NULLS FIRST`
+
+func (r *PGRepository) FindReservationByOrder(ctx context.Context, merchantID, orderID string) (*model.StockReservation, error) {
+	var res model.StockReservation
+	query := `SELECT * FROM stock_reservations WHERE merchant_id = $1 AND order_id = $2 LIMIT 1`
+	err := r.conn(ctx).GetContext(ctx, &res, query, merchantID, orderID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	items := []model.StockReservationItem{}
+	err = r.conn(ctx).SelectContext(ctx, &items, reservationItemsQuery, res.ID)
+	if err != nil {
+		return nil, err
+	}
+	res.Items = items
+
+	return &res, nil
+}
+
+// ReserveStock holds stock for every line on the exact (merchant, store, product, variant)
+// inventory row. Lines of products that don't track inventory are skipped. The reservation is
+// only saved when no line failed; otherwise the transaction is rolled back and ErrStockNotHeld
+// is returned with the per-line results explaining why. Results are in the order of lines.
+func (r *PGRepository) ReserveStock(ctx context.Context, res *model.StockReservation, storeID *string, lines []dto.ReserveStockItemInput) ([]dto.ReserveStockLineResult, error) {
+	var results []dto.ReserveStockLineResult
+	err := database.RunInTx(ctx, r.DB, func(ctx context.Context) error {
+		tx := r.conn(ctx)
+
+		tracked, err := trackedProducts(ctx, tx, res.MerchantID, lines)
+		if err != nil {
+			return err
+		}
+
+		holdQuery := `
+        UPDATE inventory
+        SET reserved_quantity = reserved_quantity + $1, updated_at = NOW()
+        WHERE merchant_id = $2 AND product_id = $3
+            AND store_id IS NOT DISTINCT FROM $4
+            AND variant_id IS NOT DISTINCT FROM $5
+            AND available_quantity >= $1
+        RETURNING *
+    `
+		availableQuery := `
+        SELECT available_quantity FROM inventory
+        WHERE merchant_id = $1 AND product_id = $2
+            AND store_id IS NOT DISTINCT FROM $3
+            AND variant_id IS NOT DISTINCT FROM $4
+    `
+
+		results = make([]dto.ReserveStockLineResult, len(lines))
+		failed := false
+		for _, i := range lockOrder(lines) {
+			line := lines[i]
+			result := dto.ReserveStockLineResult{
+				ProductID: line.ProductID,
+				VariantID: line.VariantID,
+				Quantity:  line.Quantity,
+			}
+
+			trackInventory, known := tracked[line.ProductID]
+			switch {
+			case !known:
+				result.Status = dto.ReserveLineUnknown
+			case !trackInventory:
+				result.Status = dto.ReserveLineSkipped
+			default:
+				var inv model.Inventory
+				err := tx.GetContext(ctx, &inv, holdQuery, line.Quantity, res.MerchantID, line.ProductID, storeID, line.VariantID)
+				if err == nil {
+					result.Status = dto.ReserveLineReserved
+					result.Inventory = &inv
+					res.Items = append(res.Items, model.StockReservationItem{
+						ID:            uuid.New().String(),
+						ReservationID: res.ID,
+						InventoryID:   inv.ID,
+						StoreID:       storeID,
+						ProductID:     line.ProductID,
+						VariantID:     line.VariantID,
+						Quantity:      line.Quantity,
+					})
+					break
+				}
+				if !errors.Is(err, sql.ErrNoRows) {
+					return err
+				}
+
+				// Nothing held: tell apart a missing location from a short one.
+				var available float64
+				err = tx.GetContext(ctx, &available, availableQuery, res.MerchantID, line.ProductID, storeID, line.VariantID)
+				if errors.Is(err, sql.ErrNoRows) {
+					result.Status = dto.ReserveLineUnknown
+				} else if err != nil {
+					return err
+				} else {
+					result.Status = dto.ReserveLineInsufficient
+					result.AvailableQuantity = available
+				}
+			}
+
+			if result.Status == dto.ReserveLineUnknown || result.Status == dto.ReserveLineInsufficient {
+				failed = true
+			}
+			results[i] = result
+		}
+
+		if failed {
+			res.Items = nil
+			for i := range results {
+				if results[i].Status == dto.ReserveLineReserved {
+					results[i].Status = dto.ReserveLineAvailable
+					results[i].Inventory = nil
+				}
+			}
+			return product.ErrStockNotHeld // rolling back releases the holds taken so far
+		}
+
+		insertQuery := `
+        INSERT INTO stock_reservations (id, merchant_id, order_id, status, expires_at, created_at, updated_at)
+        VALUES (:id, :merchant_id, :order_id, :status, :expires_at, :created_at, :updated_at)
+    `
+		if _, err := tx.NamedExecContext(ctx, insertQuery, res); err != nil {
+			return fmt.Errorf("failed to create reservation: %w", err)
+		}
+
+		insertItemQuery := `
+        INSERT INTO stock_reservation_items (id, reservation_id, inventory_id, store_id, product_id, variant_id, quantity)
+        VALUES (:id, :reservation_id, :inventory_id, :store_id, :product_id, :variant_id, :quantity)
+    `
+		for _, item := range res.Items {
+			if _, err := tx.NamedExecContext(ctx, insertItemQuery, item); err != nil {
+				return fmt.Errorf("failed to save reservation item: %w", err)
+			}
+		}
+
+		return nil
+	})
+	if errors.Is(err, product.ErrStockNotHeld) {
+		return results, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// lockOrder returns the indexes of lines sorted by product and variant, the order every
+// stock write locks inventory rows in, so two carts holding the same items listed in a
+// different order can't deadlock.
+func lockOrder(lines []dto.ReserveStockItemInput) []int {
+	order := make([]int, len(lines))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		a, b = order[a], order[b]
+		return model.LocationKey(lines[a].ProductID, lines[a].VariantID) < model.LocationKey(lines[b].ProductID, lines[b].VariantID)
+	})
+	return order
+}
+
+// trackedProducts maps each of the merchant's products referenced by lines to its track_inventory flag.
+func trackedProducts(ctx context.Context, tx database.DBTX, merchantID string, lines []dto.ReserveStockItemInput) (map[string]bool, error) {
+	productIDs := make([]string, 0, len(lines))
+	for _, line := range lines {
+		productIDs = append(productIDs, line.ProductID)
+	}
+
+	query, args, err := sqlx.In(`SELECT id, track_inventory FROM products WHERE merchant_id = ? AND id IN (?)`, merchantID, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		ID             string `db:"id"`
+		TrackInventory bool   `db:"track_inventory"`
+	}
+	if err := tx.SelectContext(ctx, &rows, tx.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+
+	tracked := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		tracked[row.ID] = row.TrackInventory
+	}
+	return tracked, nil
+}
+
+// CommitReservation turns the held quantities into sales: stock and reservation are
+// decremented together and a 'sale' movement referencing the order is written per item.
+// The movements are returned in the order of res.Items.
+func (r *PGRepository) CommitReservation(ctx context.Context, res *model.StockReservation, createdBy *string) ([]model.InventoryMovement, error) {
+	var movements []model.InventoryMovement
+	err := database.RunInTx(ctx, r.DB, func(ctx context.Context) error {
+		tx := r.conn(ctx)
+
+		if err := lockActiveReservation(ctx, tx, res.ID); err != nil {
+			return err
+		}
+
+		saleQuery := `
+        WITH updated AS (
+            UPDATE inventory
+            SET quantity = quantity - $1::numeric,
+                reserved_quantity = reserved_quantity - $1::numeric,
+                updated_at = NOW()
+            WHERE id = $2
+            RETURNING merchant_id, store_id, product_id, variant_id, quantity
+        )
+        INSERT INTO inventory_movements (
+            merchant_id, store_id, product_id, variant_id,
+            movement_type, quantity_change, quantity_before, quantity_after,
+            reference_type, reference_id, notes, created_by, created_at
+        )
+        SELECT merchant_id, store_id, product_id, variant_id,
+            'sale', -$1::numeric, quantity + $1::numeric, quantity,
+            'order', $3, 'Reservation committed', $4, NOW()
+        FROM updated
+        RETURNING *
+    `
+		movements = make([]model.InventoryMovement, len(res.Items))
+		for i, item := range res.Items {
+			err := tx.GetContext(ctx, &movements[i], saleQuery, item.Quantity, item.InventoryID, res.OrderID, createdBy)
+			if errors.Is(err, sql.ErrNoRows) {
+				return inventory.ErrLocationGone.With("ProductID", item.ProductID)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to commit reserved stock: %w", err)
+			}
+		}
+
+		return setReservationStatus(ctx, tx, res.ID, model.ReservationStatusCommitted)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+// ReleaseReservation gives the held quantities back to available stock and closes the
+// reservation with the given status ('released' or 'expired').
+func (r *PGRepository) ReleaseReservation(ctx context.Context, res *model.StockReservation, status string) error {
+	return database.RunInTx(ctx, r.DB, func(ctx context.Context) error {
+		tx := r.conn(ctx)
+
+		if err := lockActiveReservation(ctx, tx, res.ID); err != nil {
+			return err
+		}
+
+		releaseQuery := `
+        UPDATE inventory
+        SET reserved_quantity = GREATEST(reserved_quantity - $1, 0), updated_at = NOW()
+        WHERE id = $2
+    `
+		for _, item := range res.Items {
+			if _, err := tx.ExecContext(ctx, releaseQuery, item.Quantity, item.InventoryID); err != nil {
+				return fmt.Errorf("failed to release reserved stock: %w", err)
+			}
+		}
+
+		return setReservationStatus(ctx, tx, res.ID, status)
+	})
+}
+
+// FindExpiredReservations returns active reservations whose hold has run out, oldest first.
+func (r *PGRepository) FindExpiredReservations(ctx context.Context, now time.Time, limit int) ([]model.StockReservation, error) {
+	reservations := []model.StockReservation{}
+	query := `
+        SELECT * FROM stock_reservations
+        WHERE status = 'active' AND expires_at <= $1
+        ORDER BY expires_at ASC
+        LIMIT $2
+    `
+	if err := r.conn(ctx).SelectContext(ctx, &reservations, query, now, limit); err != nil {
+		return nil, err
+	}
+
+	for i := range reservations {
+		items := []model.StockReservationItem{}
+		err := r.conn(ctx).SelectContext(ctx, &items, reservationItemsQuery, reservations[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		reservations[i].Items = items
+	}
+
+	return reservations, nil
+}
+
+// lockActiveReservation row-locks the reservation so commit, release and the expiry sweeper
+// can't both act on it.
+func lockActiveReservation(ctx context.Context, tx database.DBTX, id string) error {
+	var status string
+	err := tx.GetContext(ctx, &status, `SELECT status FROM stock_reservations WHERE id = $1 FOR UPDATE`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return product.ErrReservationNotFound
+		}
+		return err
+	}
+	if status != model.ReservationStatusActive {
+		return product.ErrReservationNotActive.With("Status", status)
+	}
+	return nil
+}
+
+func setReservationStatus(ctx context.Context, tx database.DBTX, id, status string) error {
+	_, err := tx.ExecContext(ctx, `UPDATE stock_reservations SET status = $1, updated_at = NOW() WHERE id = $2`, status, id)
+	if err != nil {
+		return fmt.Errorf("failed to update reservation status: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/fekuna/omnipos-product-service/internal/database"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/jmoiron/sqlx"
+)
+
+// CatalogSyncToken returns the oldest transaction still running. Every change that a read
+// started afterwards misses was made by this transaction or a later one.
+func (r *PGRepository) CatalogSyncToken(ctx context.Context) (string, error) {
+	return database.ChangeToken(ctx, r.conn(ctx))
+}
+
+// FindCatalogChanges pages through the changes of one entity type by ID. With a token it
+// returns the entries changed by that transaction or a later one, tombstones included;
+// without one it returns every live entry.
+func (r *PGRepository) FindCatalogChanges(ctx context.Context, merchantID, entityType, token, afterID string, limit int) ([]model.CatalogChange, error) {
+	query := `
+        SELECT * FROM catalog_changes
+        WHERE merchant_id = $1 AND entity_type = $2
+            AND ($3 = '' OR change_xid >= NULLIF($3, '')::xid8)
+            AND ($3 <> '' OR NOT deleted)
+            AND ($4 = '' OR entity_id > NULLIF($4, '')::uuid)
+        ORDER BY entity_id
+        LIMIT $5
+    `
+	changes := []model.CatalogChange{}
+	err := r.conn(ctx).SelectContext(ctx, &changes, query, merchantID, entityType, token, afterID, limit)
+	return changes, err
+}
+
+func (r *PGRepository) FindCategoriesByIDs(ctx context.Context, merchantID string, ids []string) ([]model.Category, error) {
+	categories := []model.Category{}
+	if len(ids) == 0 {
+		return categories, nil
+	}
+	query, args, err := sqlx.In(`SELECT * FROM categories WHERE merchant_id = ? AND id IN (?) ORDER BY id`, merchantID, ids)
+	if err != nil {
+		return nil, err
+	}
+	conn := r.conn(ctx)
+	err = conn.SelectContext(ctx, &categories, conn.Rebind(query), args...)
+	return categories, err
+}
+
+func (r *PGRepository) FindProductsByIDs(ctx context.Context, merchantID string, ids []string) ([]model.Product, error) {
+	products := []model.Product{}
+	if len(ids) == 0 {
+		return products, nil
+	}
+	query, args, err := sqlx.In(`SELECT * FROM products WHERE merchant_id = ? AND id IN (?) ORDER BY id`, merchantID, ids)
+	if err != nil {
+		return nil, err
+	}
+	conn := r.conn(ctx)
+	err = conn.SelectContext(ctx, &products, conn.Rebind(query), args...)
+	return products, err
+}
+
+func (r *PGRepository) FindVariantsByIDs(ctx context.Context, merchantID string, ids []string) ([]model.ProductVariant, error) {
+	variants := []model.ProductVariant{}
+	if len(ids) == 0 {
+		return variants, nil
+	}
+	query, args, err := sqlx.In(`
+        SELECT v.* FROM product_variants v
+        JOIN products p ON p.id = v.product_id
+        WHERE p.merchant_id = ? AND v.id IN (?)
+        ORDER BY v.id
+    `, merchantID, ids)
+	if err != nil {
+		return nil, err
+	}
+	conn := r.conn(ctx)
+	err = conn.SelectContext(ctx, &variants, conn.Rebind(query), args...)
+	return variants, err
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fekuna/omnipos-product-service/internal/database"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+)
+
+func (r *PGRepository) CreateVariant(ctx context.Context, v *model.ProductVariant) error {
+	query := `
+        INSERT INTO product_variants (
+            id, product_id, sku, barcode, variant_name, price_adjustment,
+            cost_price, is_active, option_values, combination_key, created_at, updated_at
+        )
+        VALUES (
+            :id, :product_id, :sku, :barcode, :variant_name, :price_adjustment,
+            :cost_price, :is_active, :option_values, :combination_key, :created_at, :updated_at
+        )
+    `
+	_, err := r.conn(ctx).NamedExecContext(ctx, query, v)
+	return err
+}
+
+func (r *PGRepository) FindVariantByID(ctx context.Context, productID, id string) (*model.ProductVariant, error) {
+	var variant model.ProductVariant
+	query := `SELECT * FROM product_variants WHERE id = $1 AND product_id = $2 LIMIT 1`
+	err := r.conn(ctx).GetContext(ctx, &variant, query, id, productID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &variant, nil
+}
+
+func (r *PGRepository) FindVariantsByProduct(ctx context.Context, productID string, activeOnly bool) ([]model.ProductVariant, error) {
+	query := `SELECT * FROM product_variants WHERE product_id = $1`
+	if activeOnly {
+		query += ` AND is_active = TRUE`
+	}
+	query += ` ORDER BY created_at ASC`
+
+	variants := []model.ProductVariant{}
+	err := r.conn(ctx).SelectContext(ctx, &variants, query, productID)
+	return variants, err
+}
+
+func (r *PGRepository) UpdateVariant(ctx context.Context, v *model.ProductVariant) error {
+	query := `
+        UPDATE product_variants
+        SET sku = :sku,
+            barcode = :barcode,
+            variant_name = :variant_name,
+            price_adjustment = :price_adjustment,
+            cost_price = :cost_price,
+            is_active = :is_active,
+            updated_at = :updated_at
+        WHERE id = :id AND product_id = :product_id
+    `
+	_, err := r.conn(ctx).NamedExecContext(ctx, query, v)
+	return err
+}
+
+func (r *PGRepository) IsVariantSKUUnique(ctx context.Context, productID, sku, excludeID string) (bool, error) {
+	var count int
+	query := `SELECT count(*) FROM product_variants WHERE product_id = $1 AND sku = $2`
+	args := []interface{}{productID, sku}
+	if excludeID != "" {
+		query += ` AND id != $3`
+		args = append(args, excludeID)
+	}
+
+	err := r.conn(ctx).GetContext(ctx, &count, query, args...)
+	if err != nil {
+		return false, err
+	}
+	return count == 0, nil
+}
+
+func (r *PGRepository) IsVariantBarcodeUnique(ctx context.Context, productID, barcode, excludeID string) (bool, error) {
+	if barcode == "" {
+		return true, nil
+	}
+	var count int
+	query := `SELECT count(*) FROM product_variants WHERE product_id = $1 AND barcode = $2`
+	args := []interface{}{productID, barcode}
+	if excludeID != "" {
+		query += ` AND id != $3`
+		args = append(args, excludeID)
+	}
+
+	err := r.conn(ctx).GetContext(ctx, &count, query, args...)
+	if err != nil {
+		return false, err
+	}
+	return count == 0, nil
+}
+
+// SyncHasVariants recomputes products.has_variants from the product's active variants.
+func (r *PGRepository) SyncHasVariants(ctx context.Context, productID string) (bool, error) {
+	var hasVariants bool
+	query := `
+        UPDATE products
+        SET has_variants = EXISTS (
+                SELECT 1 FROM product_variants
+                WHERE product_id = $1 AND is_active = TRUE
+            ),
+            updated_at = NOW()
+        WHERE id = $1
+        RETURNING has_variants
+    `
+	err := r.conn(ctx).GetContext(ctx, &hasVariants, query, productID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return hasVariants, nil
+}
+
+func (r *PGRepository) FindOptionsByProduct(ctx context.Context, productID string) ([]model.ProductOption, error) {
+	options := []model.ProductOption{}
+	query := `SELECT * FROM product_options WHERE product_id = $1 ORDER BY position ASC`
+	err := r.conn(ctx).SelectContext(ctx, &options, query, productID)
+	return options, err
+}
+
+// ApplyVariantMatrix replaces the product's option axes and applies the variant changes
+// computed from them in a single transaction. Variants are only ever deactivated, never
+// deleted, so inventory history keeps pointing at a valid row.
+func (r *PGRepository) ApplyVariantMatrix(ctx context.Context, productID string, options []model.ProductOption, create []model.ProductVariant, reactivateIDs, deactivateIDs []string) error {
+	return database.RunInTx(ctx, r.DB, func(ctx context.Context) error {
+		tx := r.conn(ctx)
+
+		// 1. Replace option axes
+		if _, err := tx.ExecContext(ctx, `DELETE FROM product_options WHERE product_id = $1`, productID); err != nil {
+			return fmt.Errorf("failed to clear options: %w", err)
+		}
+		insertOptionQuery := `
+        INSERT INTO product_options (id, product_id, name, option_values, position, created_at, updated_at)
+        VALUES (:id, :product_id, :name, :option_values, :position, :created_at, :updated_at)
+    `
+		for _, o := range options {
+			if _, err := tx.NamedExecContext(ctx, insertOptionQuery, o); err != nil {
+				return fmt.Errorf("failed to save option %s: %w", o.Name, err)
+			}
+		}
+
+		// 2. Create missing combinations
+		insertVariantQuery := `
+        INSERT INTO product_variants (
+            id, product_id, sku, barcode, variant_name, price_adjustment,
+            cost_price, is_active, option_values, combination_key, created_at, updated_at
+        )
+        VALUES (
+            :id, :product_id, :sku, :barcode, :variant_name, :price_adjustment,
+            :cost_price, :is_active, :option_values, :combination_key, :created_at, :updated_at
+        )
+    `
+		for _, v := range create {
+			if _, err := tx.NamedExecContext(ctx, insertVariantQuery, v); err != nil {
+				return fmt.Errorf("failed to create variant %s: %w", v.SKU, err)
+			}
+		}
+
+		// 3. Toggle existing combinations
+		now := time.Now()
+		setActiveQuery := `UPDATE product_variants SET is_active = $1, updated_at = $2 WHERE id = $3 AND product_id = $4`
+		for _, id := range reactivateIDs {
+			if _, err := tx.ExecContext(ctx, setActiveQuery, true, now, id, productID); err != nil {
+				return fmt.Errorf("failed to reactivate variant %s: %w", id, err)
+			}
+		}
+		for _, id := range deactivateIDs {
+			if _, err := tx.ExecContext(ctx, setActiveQuery, false, now, id, productID); err != nil {
+				return fmt.Errorf("failed to deactivate variant %s: %w", id, err)
+			}
+		}
+
+		return nil
+	})
+}
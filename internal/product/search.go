@@ -0,0 +1,20 @@
+package product
+
+// SearchIndex is the Elasticsearch index products are indexed in, for all merchants.
+// Queries filter on merchant_id.
+const SearchIndex = "products"
+
+// SearchIndexMapping is created with the index when it doesn't exist yet.
+const SearchIndexMapping = `{
+	"mappings": {
+		"properties": {
+			"merchant_id": { "type": "keyword" },
+			"name": { "type": "text" },
+			"description": { "type": "text" },
+			"sku": { "type": "keyword" },
+			"barcode": { "type": "keyword" },
+			"base_price": { "type": "double" },
+			"created_at": { "type": "date" }
+		}
+	}
+}`
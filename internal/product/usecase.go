@@ -9,14 +9,23 @@ import (
 
 type UseCase interface {
 	CreateProduct(ctx context.Context, input *dto.CreateProductInput) (*model.Product, error)
-	GetProduct(ctx context.Context, id string) (*model.Product, error)
+	GetProduct(ctx context.Context, merchantID, id string) (*model.Product, error)
 	ListProducts(ctx context.Context, filters *dto.ProductFilters) ([]model.Product, int, error)
 	UpdateProduct(ctx context.Context, input *dto.UpdateProductInput) (*model.Product, error)
-	DeleteProduct(ctx context.Context, id string) error
+	DeleteProduct(ctx context.Context, merchantID, id string) error
+	SyncCatalog(ctx context.Context, input *dto.SyncCatalogInput) (*dto.CatalogDelta, error)
 
 	// Variant ops
 	AddVariant(ctx context.Context, input *dto.CreateVariantInput) (*model.ProductVariant, error)
-	ListVariants(ctx context.Context, productID string) ([]model.ProductVariant, error)
+	ListVariants(ctx context.Context, merchantID, productID string, activeOnly bool) ([]model.ProductVariant, error)
+	UpdateVariant(ctx context.Context, input *dto.UpdateVariantInput) (*model.ProductVariant, error)
+	DeactivateVariant(ctx context.Context, merchantID, productID, variantID string) error
+	GenerateVariants(ctx context.Context, input *dto.GenerateVariantsInput) (*dto.GenerateVariantsResult, error)
 
-	ReserveStock(ctx context.Context, items map[string]int32) error
+	// Stock reservations
+	ReserveStock(ctx context.Context, input *dto.ReserveStockInput) (*dto.ReserveStockResult, error)
+	CommitReservation(ctx context.Context, merchantID, orderID, userID string) (*model.StockReservation, error)
+	ReleaseReservation(ctx context.Context, merchantID, orderID string) (*model.StockReservation, error)
+	ReleaseActiveReservation(ctx context.Context, merchantID, orderID string) (*model.StockReservation, error)
+	ReleaseExpiredReservations(ctx context.Context, limit int) (int, error)
 }
@@ -0,0 +1,298 @@
+package usecase
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fekuna/omnipos-product-service/internal/event"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/fekuna/omnipos-product-service/internal/product"
+	"github.com/fekuna/omnipos-product-service/internal/product/dto"
+	"github.com/google/uuid"
+)
+
+const (
+	maxVariantCombinations = 500
+	maxVariantFieldLength  = 100 // product_variants.sku / variant_name are VARCHAR(100)
+)
+
+var (
+	placeholderPattern = regexp.MustCompile(`\{[^{}]*\}`)
+	skuUnsafePattern   = regexp.MustCompile(`[^A-Za-z0-9]+`)
+)
+
+// GenerateVariants stores the option axes of a product and creates one variant per
+// combination of option values. Running it again only creates the missing combinations,
+// reactivates combinations that came back and deactivates the ones that were removed.
+func (uc *productUseCase) GenerateVariants(ctx context.Context, input *dto.GenerateVariantsInput) (*dto.GenerateVariantsResult, error) {
+	p, err := uc.getOwnedProduct(ctx, input.MerchantID, input.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	options, err := buildOptionAxes(p.ID, input.Options, now)
+	if err != nil {
+		return nil, err
+	}
+
+	combinations := cartesianProduct(options)
+	if len(combinations) > maxVariantCombinations {
+		return nil, product.ErrTooManyCombinations.With("Count", len(combinations)).With("Max", maxVariantCombinations)
+	}
+
+	existing, err := uc.repo.FindVariantsByProduct(ctx, p.ID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string]*model.ProductVariant)
+	skuOwner := make(map[string]string)
+	for i := range existing {
+		v := &existing[i]
+		if v.CombinationKey != nil {
+			byKey[*v.CombinationKey] = v
+		}
+		skuOwner[v.SKU] = v.ID
+	}
+
+	result := &dto.GenerateVariantsResult{Options: options}
+	var create []model.ProductVariant
+	var reactivateIDs, deactivateIDs []string
+	wanted := make(map[string]bool, len(combinations))
+
+	for _, combo := range combinations {
+		key := combinationKey(options, combo)
+		wanted[key] = true
+
+		// Existing combinations keep their SKU and name, labels may already be printed.
+		if v, ok := byKey[key]; ok {
+			if !v.IsActive {
+				reactivateIDs = append(reactivateIDs, v.ID)
+			}
+			continue
+		}
+
+		sku, err := renderVariantTemplate(input.SKUTemplate, defaultSKUTemplate(options), p, options, combo, true)
+		if err != nil {
+			return nil, err
+		}
+		name, err := renderVariantTemplate(input.NameTemplate, defaultNameTemplate(options), p, options, combo, false)
+		if err != nil {
+			return nil, err
+		}
+		if len(sku) > maxVariantFieldLength || len(name) > maxVariantFieldLength {
+			return nil, product.ErrGeneratedFieldTooLong.With("Name", name).With("Max", maxVariantFieldLength)
+		}
+		if _, taken := skuOwner[sku]; taken {
+			return nil, product.ErrVariantSKUExists.With("SKU", sku)
+		}
+
+		id := uuid.New().String()
+		skuOwner[sku] = id
+		comboKey := key
+		create = append(create, model.ProductVariant{
+			BaseModel:      model.BaseModel{ID: id, CreatedAt: now, UpdatedAt: now},
+			ProductID:      p.ID,
+			SKU:            sku,
+			VariantName:    name,
+			IsActive:       true,
+			OptionValues:   selectionOf(options, combo),
+			CombinationKey: &comboKey,
+		})
+	}
+
+	for key, v := range byKey {
+		if !wanted[key] && v.IsActive {
+			deactivateIDs = append(deactivateIDs, v.ID)
+		}
+	}
+
+	err = uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.repo.ApplyVariantMatrix(ctx, p.ID, options, create, reactivateIDs, deactivateIDs); err != nil {
+			return err
+		}
+		if err := uc.syncHasVariants(ctx, p); err != nil {
+			return err
+		}
+
+		variants, err := uc.repo.FindVariantsByProduct(ctx, p.ID, false)
+		if err != nil {
+			return err
+		}
+		result.Variants = variants
+		return uc.outbox.Publish(ctx, variantMatrixEvents(p.MerchantID, variants, create, reactivateIDs, deactivateIDs)...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Created = len(create)
+	result.Reactivated = len(reactivateIDs)
+	result.Deactivated = len(deactivateIDs)
+
+	return result, nil
+}
+
+// variantMatrixEvents describes what GenerateVariants changed, using the stored variants.
+func variantMatrixEvents(merchantID string, variants []model.ProductVariant, created []model.ProductVariant, reactivateIDs, deactivateIDs []string) []event.Event {
+	eventTypes := make(map[string]string, len(created)+len(reactivateIDs)+len(deactivateIDs))
+	for _, v := range created {
+		eventTypes[v.ID] = event.VariantCreated
+	}
+	for _, id := range reactivateIDs {
+		eventTypes[id] = event.VariantUpdated
+	}
+	for _, id := range deactivateIDs {
+		eventTypes[id] = event.VariantDeactivated
+	}
+
+	events := make([]event.Event, 0, len(eventTypes))
+	for i := range variants {
+		if eventType, ok := eventTypes[variants[i].ID]; ok {
+			events = append(events, event.ForVariant(eventType, merchantID, &variants[i]))
+		}
+	}
+	return events
+}
+
+// buildOptionAxes validates the axes and drops blank or duplicate values, keeping input order.
+func buildOptionAxes(productID string, inputs []dto.OptionAxisInput, now time.Time) ([]model.ProductOption, error) {
+	if len(inputs) == 0 {
+		return nil, product.ErrOptionsRequired
+	}
+
+	seenNames := make(map[string]bool)
+	options := make([]model.ProductOption, 0, len(inputs))
+	for i, in := range inputs {
+		name := strings.TrimSpace(in.Name)
+		if name == "" {
+			return nil, product.ErrOptionNameRequired
+		}
+		token := placeholderName(name)
+		if token == "parent_sku" || token == "parent_name" {
+			return nil, product.ErrOptionNameReserved.With("Name", name)
+		}
+		if seenNames[token] {
+			return nil, product.ErrDuplicateOption.With("Name", name)
+		}
+		seenNames[token] = true
+
+		seenValues := make(map[string]bool)
+		values := model.OptionValues{}
+		for _, raw := range in.Values {
+			value := strings.TrimSpace(raw)
+			if value == "" || seenValues[strings.ToLower(value)] {
+				continue
+			}
+			seenValues[strings.ToLower(value)] = true
+			values = append(values, value)
+		}
+		if len(values) == 0 {
+			return nil, product.ErrOptionWithoutValues.With("Name", name)
+		}
+
+		options = append(options, model.ProductOption{
+			BaseModel: model.BaseModel{ID: uuid.New().String(), CreatedAt: now, UpdatedAt: now},
+			ProductID: productID,
+			Name:      name,
+			Values:    values,
+			Position:  i,
+		})
+	}
+	return options, nil
+}
+
+// cartesianProduct returns every combination of option values, each combination holding
+// one value per axis in axis order.
+func cartesianProduct(options []model.ProductOption) [][]string {
+	combinations := [][]string{{}}
+	for _, o := range options {
+		next := make([][]string, 0, len(combinations)*len(o.Values))
+		for _, combo := range combinations {
+			for _, value := range o.Values {
+				c := make([]string, len(combo), len(combo)+1)
+				copy(c, combo)
+				next = append(next, append(c, value))
+			}
+		}
+		combinations = next
+	}
+	return combinations
+}
+
+// combinationKey is a case-insensitive canonical form of a combination, e.g. "colour=red|size=l".
+// It does not depend on axis order, so reordering axes does not recreate variants.
+func combinationKey(options []model.ProductOption, combo []string) string {
+	parts := make([]string, len(options))
+	for i, o := range options {
+		parts[i] = placeholderName(o.Name) + "=" + strings.ToLower(combo[i])
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "|")
+}
+
+func selectionOf(options []model.ProductOption, combo []string) model.OptionSelection {
+	selection := make(model.OptionSelection, len(options))
+	for i, o := range options {
+		selection[o.Name] = combo[i]
+	}
+	return selection
+}
+
+func defaultSKUTemplate(options []model.ProductOption) string {
+	parts := []string{"{parent_sku}"}
+	for _, o := range options {
+		parts = append(parts, "{"+placeholderName(o.Name)+"}")
+	}
+	return strings.Join(parts, "-")
+}
+
+func defaultNameTemplate(options []model.ProductOption) string {
+	parts := make([]string, len(options))
+	for i, o := range options {
+		parts[i] = "{" + placeholderName(o.Name) + "}"
+	}
+	return strings.Join(parts, " / ")
+}
+
+// renderVariantTemplate fills {parent_sku}, {parent_name} and one {<option>} placeholder per axis.
+// SKU values are upper-cased and stripped of anything but letters and digits.
+func renderVariantTemplate(tmpl, fallback string, p *model.Product, options []model.ProductOption, combo []string, forSKU bool) (string, error) {
+	if strings.TrimSpace(tmpl) == "" {
+		tmpl = fallback
+	}
+
+	values := map[string]string{
+		"parent_sku":  p.SKU,
+		"parent_name": p.Name,
+	}
+	for i, o := range options {
+		value := combo[i]
+		if forSKU {
+			value = strings.Trim(skuUnsafePattern.ReplaceAllString(strings.ToUpper(value), "-"), "-")
+		}
+		values[placeholderName(o.Name)] = value
+	}
+
+	var unknown string
+	out := placeholderPattern.ReplaceAllStringFunc(tmpl, func(match string) string {
+		name := placeholderName(strings.Trim(match, "{}"))
+		if v, ok := values[name]; ok {
+			return v
+		}
+		unknown = match
+		return match
+	})
+	if unknown != "" {
+		return "", product.ErrUnknownPlaceholder.With("Placeholder", unknown).With("Template", tmpl)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// placeholderName turns an option name into its template token, e.g. "Sleeve Length" -> "sleeve_length".
+func placeholderName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), "_")
+}
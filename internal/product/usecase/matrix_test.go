@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/fekuna/omnipos-product-service/internal/product"
+	"github.com/fekuna/omnipos-product-service/internal/product/dto"
+)
+
+func axes(pairs ...interface{}) []model.ProductOption {
+	var options []model.ProductOption
+	for i := 0; i < len(pairs); i += 2 {
+		options = append(options, model.ProductOption{
+			Name:   pairs[i].(string),
+			Values: model.OptionValues(pairs[i+1].([]string)),
+		})
+	}
+	return options
+}
+
+func TestCartesianProduct(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []model.ProductOption
+		want    [][]string
+	}{
+		{"no axes", nil, [][]string{{}}},
+		{"single axis", axes("Size", []string{"S", "M"}), [][]string{{"S"}, {"M"}}},
+		{"single value axes", axes("Size", []string{"M"}, "Colour", []string{"Red"}), [][]string{{"M", "Red"}}},
+		{
+			"two axes in axis order",
+			axes("Size", []string{"S", "M"}, "Colour", []string{"Red", "Blue"}),
+			[][]string{{"S", "Red"}, {"S", "Blue"}, {"M", "Red"}, {"M", "Blue"}},
+		},
+		{"axis without values", axes("Size", []string{"S", "M"}, "Colour", []string{}), [][]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cartesianProduct(tt.options); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cartesianProduct = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCartesianProductDoesNotShareCombinations(t *testing.T) {
+	combos := cartesianProduct(axes("Size", []string{"S", "M"}, "Colour", []string{"Red", "Blue"}, "Fit", []string{"Slim", "Wide"}))
+	combos[0][2] = "changed"
+	if combos[1][2] != "Wide" {
+		t.Errorf("changing one combination changed another: %v", combos[1])
+	}
+}
+
+func TestBuildOptionAxes(t *testing.T) {
+	tests := []struct {
+		name    string
+		inputs  []dto.OptionAxisInput
+		want    []model.OptionValues
+		wantErr error
+	}{
+		{
+			name:   "duplicate values are dropped case-insensitively",
+			inputs: []dto.OptionAxisInput{{Name: "Size", Values: []string{"S", " M ", "s", "", "M", "L"}}},
+			want:   []model.OptionValues{{"S", "M", "L"}},
+		},
+		{
+			name:   "single axis",
+			inputs: []dto.OptionAxisInput{{Name: "Colour", Values: []string{"Red"}}},
+			want:   []model.OptionValues{{"Red"}},
+		},
+		{name: "no axes", wantErr: product.ErrOptionsRequired},
+		{
+			name:    "axis with only blank values",
+			inputs:  []dto.OptionAxisInput{{Name: "Size", Values: []string{" ", ""}}},
+			wantErr: product.ErrOptionWithoutValues,
+		},
+		{
+			name:    "duplicate axis names",
+			inputs:  []dto.OptionAxisInput{{Name: "Sleeve Length", Values: []string{"Short"}}, {Name: "sleeve  length", Values: []string{"Long"}}},
+			wantErr: product.ErrDuplicateOption,
+		},
+		{
+			name:    "reserved axis name",
+			inputs:  []dto.OptionAxisInput{{Name: "Parent SKU", Values: []string{"X"}}},
+			wantErr: product.ErrOptionNameReserved,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options, err := buildOptionAxes("product-1", tt.inputs, time.Now())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("buildOptionAxes error = %v, want %v", err, tt.wantErr)
+			}
+			var got []model.OptionValues
+			for _, o := range options {
+				got = append(got, o.Values)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildOptionAxes values = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderVariantTemplate(t *testing.T) {
+	p := &model.Product{SKU: "TEE", Name: "Tee"}
+	options := axes("Size", []string{"XL"}, "Sleeve Length", []string{"Short / Cap"})
+	combo := []string{"XL", "Short / Cap"}
+
+	tests := []struct {
+		name    string
+		tmpl    string
+		forSKU  bool
+		want    string
+		wantErr error
+	}{
+		{name: "default SKU", forSKU: true, want: "TEE-XL-SHORT-CAP"},
+		{name: "default name", want: "XL / Short / Cap"},
+		{name: "custom SKU", tmpl: "{parent_sku}/{sleeve_length}", forSKU: true, want: "TEE/SHORT-CAP"},
+		{name: "placeholders match option names loosely", tmpl: "{parent_name} {Sleeve Length}", want: "Tee Short / Cap"},
+		{name: "blank template uses the default", tmpl: "  ", want: "XL / Short / Cap"},
+		{name: "unknown placeholder", tmpl: "{parent_sku}-{colour}", forSKU: true, wantErr: product.ErrUnknownPlaceholder},
+		{name: "empty placeholder", tmpl: "{parent_sku}-{}", forSKU: true, wantErr: product.ErrUnknownPlaceholder},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fallback := defaultNameTemplate(options)
+			if tt.forSKU {
+				fallback = defaultSKUTemplate(options)
+			}
+			got, err := renderVariantTemplate(tt.tmpl, fallback, p, options, combo, tt.forSKU)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("renderVariantTemplate error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("renderVariantTemplate = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCombinationKey(t *testing.T) {
+	sizeFirst := axes("Size", []string{"L"}, "Colour", []string{"Red"})
+	colourFirst := axes("colour", []string{"red"}, "SIZE", []string{"l"})
+
+	tests := []struct {
+		name    string
+		options []model.ProductOption
+		combo   []string
+		want    string
+	}{
+		{"single axis", axes("Size", []string{"L"}), []string{"L"}, "size=l"},
+		{"axes sorted by name", sizeFirst, []string{"L", "Red"}, "colour=red|size=l"},
+		{"same key when axes and case change", colourFirst, []string{"Red", "L"}, "colour=red|size=l"},
+		{"multi-word axis", axes("Sleeve Length", []string{"Long"}), []string{"Long"}, "sleeve_length=long"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := combinationKey(tt.options, tt.combo); got != tt.want {
+				t.Errorf("combinationKey = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"slices"
+	"strconv"
+
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/fekuna/omnipos-product-service/internal/product"
+	"github.com/fekuna/omnipos-product-service/internal/product/dto"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultSyncPageSize = 500
+	maxSyncPageSize     = 1000
+)
+
+// syncEntities are synced in this order, so a terminal gets parents before children.
+var syncEntities = []string{model.CatalogEntityCategory, model.CatalogEntityProduct, model.CatalogEntityVariant}
+
+// syncCursor is the decoded sync token. Between syncs only Since is set; while paging,
+// Until holds the token of the next delta and Entity/After the position reached.
+type syncCursor struct {
+	Since  string `json:"s,omitempty"` // Changes of this transaction or later, empty for a full sync
+	Until  string `json:"u,omitempty"`
+	Entity string `json:"e,omitempty"`
+	After  string `json:"a,omitempty"`
+}
+
+// SyncCatalog returns one page of the categories, products and variants changed since the
+// sync token, with tombstones for the deleted ones. An empty token syncs the whole catalog.
+func (uc *productUseCase) SyncCatalog(ctx context.Context, input *dto.SyncCatalogInput) (*dto.CatalogDelta, error) {
+	cur, err := decodeSyncToken(input.SyncToken)
+	if err != nil {
+		return nil, err
+	}
+
+	pageSize := input.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultSyncPageSize
+	}
+	pageSize = min(pageSize, maxSyncPageSize)
+
+	if cur.Until == "" {
+		// Taken before the first read, so the next delta covers everything this sync misses.
+		if cur.Until, err = uc.repo.CatalogSyncToken(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	delta := &dto.CatalogDelta{FullSync: input.SyncToken == ""}
+	live := make(map[string][]string, len(syncEntities))
+	remaining := pageSize
+
+	start := 0
+	for i, entity := range syncEntities {
+		if entity == cur.Entity {
+			start = i
+		}
+	}
+	for _, entity := range syncEntities[start:] {
+		changes, err := uc.repo.FindCatalogChanges(ctx, input.MerchantID, entity, cur.Since, cur.After, remaining)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range changes {
+			if c.Deleted {
+				delta.Tombstones = append(delta.Tombstones, c)
+			} else {
+				live[entity] = append(live[entity], c.EntityID)
+			}
+		}
+
+		remaining -= len(changes)
+		if remaining == 0 {
+			cur.Entity = entity
+			cur.After = changes[len(changes)-1].EntityID
+			delta.HasMore = true
+			break
+		}
+		cur.After = ""
+	}
+
+	if delta.Categories, err = uc.repo.FindCategoriesByIDs(ctx, input.MerchantID, live[model.CatalogEntityCategory]); err != nil {
+		return nil, err
+	}
+	if delta.Products, err = uc.repo.FindProductsByIDs(ctx, input.MerchantID, live[model.CatalogEntityProduct]); err != nil {
+		return nil, err
+	}
+	if delta.Variants, err = uc.repo.FindVariantsByIDs(ctx, input.MerchantID, live[model.CatalogEntityVariant]); err != nil {
+		return nil, err
+	}
+
+	next := syncCursor{Since: cur.Until}
+	if delta.HasMore {
+		next = cur
+	}
+	if delta.SyncToken, err = encodeSyncToken(next); err != nil {
+		return nil, err
+	}
+	return delta, nil
+}
+
+func encodeSyncToken(cur syncCursor) (string, error) {
+	data, err := json.Marshal(cur)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeSyncToken(token string) (syncCursor, error) {
+	var cur syncCursor
+	if token == "" {
+		return cur, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || json.Unmarshal(data, &cur) != nil {
+		return cur, product.ErrInvalidSyncToken
+	}
+	for _, xid := range []string{cur.Since, cur.Until} {
+		if _, err := strconv.ParseUint(xid, 10, 64); xid != "" && err != nil {
+			return cur, product.ErrInvalidSyncToken
+		}
+	}
+	if cur.After != "" {
+		if _, err := uuid.Parse(cur.After); err != nil {
+			return cur, product.ErrInvalidSyncToken
+		}
+	}
+	if cur.Entity != "" && !slices.Contains(syncEntities, cur.Entity) {
+		return cur, product.ErrInvalidSyncToken
+	}
+	if cur.Until == "" && (cur.Entity != "" || cur.After != "") {
+		return cur, product.ErrInvalidSyncToken
+	}
+	return cur, nil
+}
@@ -11,36 +11,51 @@ import (
 	"github.com/fekuna/omnipos-pkg/cache"
 	"github.com/fekuna/omnipos-pkg/logger"
 	"github.com/fekuna/omnipos-pkg/search"
+	"github.com/fekuna/omnipos-product-service/internal/category"
+	"github.com/fekuna/omnipos-product-service/internal/database"
+	"github.com/fekuna/omnipos-product-service/internal/event"
+	"github.com/fekuna/omnipos-product-service/internal/inventory"
 	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/fekuna/omnipos-product-service/internal/outbox"
 	"github.com/fekuna/omnipos-product-service/internal/product"
 	"github.com/fekuna/omnipos-product-service/internal/product/dto"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
+const defaultReservationTTL = 15 * time.Minute
+
 type productUseCase struct {
 	repo   product.Repository
 	cache  *cache.RedisClient
 	es     *search.Client
+	tx     database.TxManager
+	outbox *outbox.Writer
 	logger logger.ZapLogger
 }
 
-func NewProductUseCase(repo product.Repository, cache *cache.RedisClient, es *search.Client, log logger.ZapLogger) product.UseCase {
+func NewProductUseCase(repo product.Repository, cache *cache.RedisClient, es *search.Client, tx database.TxManager, outbox *outbox.Writer, log logger.ZapLogger) product.UseCase {
 	return &productUseCase{
 		repo:   repo,
 		cache:  cache,
 		es:     es,
+		tx:     tx,
+		outbox: outbox,
 		logger: log,
 	}
 }
 
 func (uc *productUseCase) CreateProduct(ctx context.Context, input *dto.CreateProductInput) (*model.Product, error) {
+	if err := uc.checkCategory(ctx, input.MerchantID, input.CategoryID); err != nil {
+		return nil, err
+	}
+
 	unique, err := uc.repo.IsSKUUnique(ctx, input.MerchantID, input.SKU, "")
 	if err != nil {
 		return nil, err
 	}
 	if !unique {
-		return nil, errors.New("SKU already exists")
+		return nil, product.ErrSKUExists
 	}
 
 	if input.Barcode != "" {
@@ -49,7 +64,7 @@ func (uc *productUseCase) CreateProduct(ctx context.Context, input *dto.CreatePr
 			return nil, err
 		}
 		if !unique {
-			return nil, errors.New("Barcode already exists")
+			return nil, product.ErrBarcodeExists
 		}
 	}
 
@@ -83,54 +98,46 @@ func (uc *productUseCase) CreateProduct(ctx context.Context, input *dto.CreatePr
 		IsActive:       true,
 	}
 
-	err = uc.repo.Create(ctx, p)
+	err = uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.repo.Create(ctx, p); err != nil {
+			return err
+		}
+		return uc.recordProductChange(ctx, p, event.ForProduct(event.ProductCreated, p))
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Invalidate Cache
-	go uc.invalidateProductCache(context.Background(), input.MerchantID)
-
-	// Sync to Elastic
-	go uc.syncToElastic(context.Background(), p)
-
 	return p, nil
 }
 
-func (uc *productUseCase) syncToElastic(ctx context.Context, p *model.Product) {
-	if uc.es == nil {
-		return
-	}
-	// Use a simplified model or the same model for indexing
-	// Alternatively use a global alias "products" with routing
-	// For simplicity, let's use "products" index for all and filter by merchant_id in query
-	const indexName = "products"
-
-	// Ensure index exists (maybe do this on startup, but doing lazily here for resilience)
-	// In production, run migration script. Here, we try to be helpful.
-	// Mapping def:
-	mapping := `{
-		"mappings": {
-			"properties": {
-				"merchant_id": { "type": "keyword" },
-				"name": { "type": "text" },
-				"description": { "type": "text" },
-				"sku": { "type": "keyword" },
-				"barcode": { "type": "keyword" },
-				"base_price": { "type": "double" },
-				"created_at": { "type": "date" }
-			}
-		}
-	}`
-	_ = uc.es.CreateIndex(ctx, indexName, mapping)
-
-	if err := uc.es.Index(ctx, indexName, p.ID, p); err != nil {
-		uc.logger.Error("failed to index product", zap.Error(err))
+// recordProductChange queues the side effects of a stored product change in the outbox, in
+// the transaction of the change: list cache invalidation, reindexing and events.
+func (uc *productUseCase) recordProductChange(ctx context.Context, p *model.Product, events ...event.Event) error {
+	if err := uc.outbox.InvalidateCache(ctx, event.AggregateProduct, p.ID, p.MerchantID, product.ListCachePattern(p.MerchantID)); err != nil {
+		return err
 	}
+	if err := uc.outbox.IndexDocument(ctx, event.AggregateProduct, p.MerchantID, product.SearchIndex, p.ID, p); err != nil {
+		return err
+	}
+	return uc.outbox.Publish(ctx, events...)
 }
 
-func (uc *productUseCase) GetProduct(ctx context.Context, id string) (*model.Product, error) {
-	return uc.repo.FindByID(ctx, id)
+func (uc *productUseCase) GetProduct(ctx context.Context, merchantID, id string) (*model.Product, error) {
+	p, err := uc.getOwnedProduct(ctx, merchantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.HasVariants {
+		variants, err := uc.repo.FindVariantsByProduct(ctx, p.ID, false)
+		if err != nil {
+			return nil, err
+		}
+		p.Variants = variants
+	}
+
+	return p, nil
 }
 
 func (uc *productUseCase) ListProducts(ctx context.Context, filters *dto.ProductFilters) ([]model.Product, int, error) {
@@ -178,7 +185,7 @@ func (uc *productUseCase) ListProducts(ctx context.Context, filters *dto.Product
 			q["size"] = filters.PageSize
 		}
 
-		res, err := uc.es.Search(ctx, "products", q)
+		res, err := uc.es.Search(ctx, product.SearchIndex, q)
 		if err == nil {
 			// Map hits to products
 			var esProducts []model.Product
@@ -227,22 +234,13 @@ func (uc *productUseCase) generateCacheKey(filters *dto.ProductFilters) (string,
 	return fmt.Sprintf("products:list:%s:%x", filters.MerchantID, md5.Sum(data)), nil
 }
 
-func (uc *productUseCase) invalidateProductCache(ctx context.Context, merchantID string) {
-	// Invalidate all list caches for this merchant
-	pattern := fmt.Sprintf("products:list:%s:*", merchantID)
-	keys, err := uc.cache.Client.Keys(ctx, pattern).Result()
-	if err == nil && len(keys) > 0 {
-		uc.cache.Client.Del(ctx, keys...)
-	}
-}
-
 func (uc *productUseCase) UpdateProduct(ctx context.Context, input *dto.UpdateProductInput) (*model.Product, error) {
-	p, err := uc.repo.FindByID(ctx, input.ID)
+	p, err := uc.getOwnedProduct(ctx, input.MerchantID, input.ID)
 	if err != nil {
 		return nil, err
 	}
-	if p == nil {
-		return nil, errors.New("product not found")
+	if err := uc.checkCategory(ctx, input.MerchantID, input.CategoryID); err != nil {
+		return nil, err
 	}
 
 	if p.SKU != input.SKU {
@@ -251,7 +249,7 @@ func (uc *productUseCase) UpdateProduct(ctx context.Context, input *dto.UpdatePr
 			return nil, err
 		}
 		if !unique {
-			return nil, errors.New("SKU already exists")
+			return nil, product.ErrSKUExists
 		}
 	}
 
@@ -280,59 +278,482 @@ func (uc *productUseCase) UpdateProduct(ctx context.Context, input *dto.UpdatePr
 	}
 
 	p.UpdatedAt = time.Now()
-	err = uc.repo.Update(ctx, p)
+	err = uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.repo.Update(ctx, p); err != nil {
+			return err
+		}
+		return uc.recordProductChange(ctx, p, event.ForProduct(event.ProductUpdated, p))
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Invalidate Cache
-	go uc.invalidateProductCache(context.Background(), p.MerchantID)
-	// Sync ES
-	go uc.syncToElastic(context.Background(), p)
-
 	return p, nil
 }
 
-func (uc *productUseCase) DeleteProduct(ctx context.Context, id string) error {
-	// Get product to know merchant ID for invalidation
-	p, err := uc.repo.FindByID(ctx, id)
+func (uc *productUseCase) DeleteProduct(ctx context.Context, merchantID, id string) error {
+	// Load it first, the event carries the last known state.
+	p, err := uc.getOwnedProduct(ctx, merchantID, id)
+	if err != nil {
+		return err
+	}
+
+	return uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.repo.Delete(ctx, p.MerchantID, p.ID); err != nil {
+			return err
+		}
+		if err := uc.outbox.InvalidateCache(ctx, event.AggregateProduct, p.ID, p.MerchantID, product.ListCachePattern(p.MerchantID)); err != nil {
+			return err
+		}
+		if err := uc.outbox.DeleteDocument(ctx, event.AggregateProduct, p.MerchantID, product.SearchIndex, p.ID); err != nil {
+			return err
+		}
+		return uc.outbox.Publish(ctx, event.ForProduct(event.ProductDeleted, p))
+	})
+}
+
+func (uc *productUseCase) AddVariant(ctx context.Context, input *dto.CreateVariantInput) (*model.ProductVariant, error) {
+	p, err := uc.getOwnedProduct(ctx, input.MerchantID, input.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	unique, err := uc.repo.IsVariantSKUUnique(ctx, p.ID, input.SKU, "")
+	if err != nil {
+		return nil, err
+	}
+	if !unique {
+		return nil, product.ErrVariantSKUExists.With("SKU", input.SKU)
+	}
+
+	if input.Barcode != "" {
+		unique, err := uc.repo.IsVariantBarcodeUnique(ctx, p.ID, input.Barcode, "")
+		if err != nil {
+			return nil, err
+		}
+		if !unique {
+			return nil, product.ErrVariantBarcodeExists.With("Barcode", input.Barcode)
+		}
+	}
+
+	now := time.Now()
+	v := &model.ProductVariant{
+		BaseModel:       model.BaseModel{ID: uuid.New().String(), CreatedAt: now, UpdatedAt: now},
+		ProductID:       p.ID,
+		SKU:             input.SKU,
+		Barcode:         optionalString(input.Barcode),
+		VariantName:     input.VariantName,
+		PriceAdjustment: input.PriceAdjustment,
+		CostPrice:       input.CostPrice,
+		IsActive:        true,
+	}
+
+	err = uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.repo.CreateVariant(ctx, v); err != nil {
+			return err
+		}
+		if err := uc.outbox.Publish(ctx, event.ForVariant(event.VariantCreated, p.MerchantID, v)); err != nil {
+			return err
+		}
+		return uc.syncHasVariants(ctx, p)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func (uc *productUseCase) ListVariants(ctx context.Context, merchantID, productID string, activeOnly bool) ([]model.ProductVariant, error) {
+	p, err := uc.getOwnedProduct(ctx, merchantID, productID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.repo.FindVariantsByProduct(ctx, p.ID, activeOnly)
+}
+
+func (uc *productUseCase) UpdateVariant(ctx context.Context, input *dto.UpdateVariantInput) (*model.ProductVariant, error) {
+	p, err := uc.getOwnedProduct(ctx, input.MerchantID, input.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := uc.repo.FindVariantByID(ctx, p.ID, input.ID)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, product.ErrVariantNotFound
+	}
+
+	if v.SKU != input.SKU {
+		unique, err := uc.repo.IsVariantSKUUnique(ctx, p.ID, input.SKU, v.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !unique {
+			return nil, product.ErrVariantSKUExists.With("SKU", input.SKU)
+		}
+	}
+
+	if input.Barcode != "" && (v.Barcode == nil || *v.Barcode != input.Barcode) {
+		unique, err := uc.repo.IsVariantBarcodeUnique(ctx, p.ID, input.Barcode, v.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !unique {
+			return nil, product.ErrVariantBarcodeExists.With("Barcode", input.Barcode)
+		}
+	}
+
+	wasActive := v.IsActive
+
+	v.SKU = input.SKU
+	v.Barcode = optionalString(input.Barcode)
+	v.VariantName = input.VariantName
+	v.PriceAdjustment = input.PriceAdjustment
+	// Receipts cost variants too, so an omitted cost keeps what is stored.
+	switch {
+	case input.ClearCostPrice:
+		v.CostPrice = nil
+	case input.CostPrice != nil:
+		v.CostPrice = input.CostPrice
+	}
+	v.IsActive = input.IsActive
+	v.UpdatedAt = time.Now()
+
+	eventType := event.VariantUpdated
+	if wasActive && !v.IsActive {
+		eventType = event.VariantDeactivated
+	}
+
+	err = uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.repo.UpdateVariant(ctx, v); err != nil {
+			return err
+		}
+		if err := uc.outbox.Publish(ctx, event.ForVariant(eventType, p.MerchantID, v)); err != nil {
+			return err
+		}
+		if wasActive != v.IsActive {
+			return uc.syncHasVariants(ctx, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func (uc *productUseCase) DeactivateVariant(ctx context.Context, merchantID, productID, variantID string) error {
+	p, err := uc.getOwnedProduct(ctx, merchantID, productID)
+	if err != nil {
+		return err
+	}
+
+	v, err := uc.repo.FindVariantByID(ctx, p.ID, variantID)
 	if err != nil {
 		return err
 	}
+	if v == nil {
+		return product.ErrVariantNotFound
+	}
+	if !v.IsActive {
+		return nil // Already deactivated
+	}
+
+	// Variants are never hard-deleted so inventory rows and movements keep their reference.
+	v.IsActive = false
+	v.UpdatedAt = time.Now()
+	return uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.repo.UpdateVariant(ctx, v); err != nil {
+			return err
+		}
+		if err := uc.outbox.Publish(ctx, event.ForVariant(event.VariantDeactivated, p.MerchantID, v)); err != nil {
+			return err
+		}
+		return uc.syncHasVariants(ctx, p)
+	})
+}
+
+// getOwnedProduct loads a product of the merchant.
+func (uc *productUseCase) getOwnedProduct(ctx context.Context, merchantID, productID string) (*model.Product, error) {
+	p, err := uc.repo.FindByID(ctx, merchantID, productID)
+	if err != nil {
+		return nil, err
+	}
 	if p == nil {
-		return nil // Already deleted
+		return nil, product.ErrProductNotFound
 	}
+	return p, nil
+}
 
-	err = uc.repo.Delete(ctx, id)
+// checkCategory verifies that a product is filed under a category of its own merchant.
+func (uc *productUseCase) checkCategory(ctx context.Context, merchantID, categoryID string) error {
+	if categoryID == "" {
+		return nil
+	}
+	owned, err := uc.repo.IsCategoryOwned(ctx, merchantID, categoryID)
 	if err != nil {
 		return err
 	}
+	if !owned {
+		return category.ErrCategoryNotFound
+	}
+	return nil
+}
+
+// syncHasVariants keeps the parent's has_variants flag in line with its active variants.
+func (uc *productUseCase) syncHasVariants(ctx context.Context, p *model.Product) error {
+	hasVariants, err := uc.repo.SyncHasVariants(ctx, p.ID)
+	if err != nil {
+		return fmt.Errorf("failed to sync has_variants: %w", err)
+	}
+	if hasVariants == p.HasVariants {
+		return nil
+	}
+	p.HasVariants = hasVariants
+
+	return uc.recordProductChange(ctx, p, event.ForProduct(event.ProductUpdated, p))
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func (uc *productUseCase) ReserveStock(ctx context.Context, input *dto.ReserveStockInput) (*dto.ReserveStockResult, error) {
+	existing, err := uc.repo.FindReservationByOrder(ctx, input.MerchantID, input.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		// Retried request for the same order: hand back the hold we already have.
+		if existing.Status == model.ReservationStatusActive {
+			return reservedResult(existing), nil
+		}
+		return nil, product.ErrReservationClosed.With("OrderID", input.OrderID).With("Status", existing.Status)
+	}
+
+	lines := mergeReserveLines(input.Items)
+	if len(lines) == 0 {
+		return nil, product.ErrNothingToReserve
+	}
+
+	ttl := input.TTL
+	if ttl <= 0 {
+		ttl = defaultReservationTTL
+	}
+
+	now := time.Now()
+	res := &model.StockReservation{
+		BaseModel:  model.BaseModel{ID: uuid.New().String(), CreatedAt: now, UpdatedAt: now},
+		MerchantID: input.MerchantID,
+		OrderID:    input.OrderID,
+		Status:     model.ReservationStatusActive,
+		ExpiresAt:  now.Add(ttl),
+	}
+
+	var results []dto.ReserveStockLineResult
+	err = uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		results, err = uc.repo.ReserveStock(ctx, res, input.StoreID, lines)
+		if err != nil {
+			return err
+		}
+		if err := uc.notifyReservationStores(ctx, res); err != nil {
+			return err
+		}
 
-	// Invalidate Cache
-	go uc.invalidateProductCache(context.Background(), p.MerchantID)
-	// Remove from ES
-	if uc.es != nil {
-		go func() {
-			err := uc.es.Delete(context.Background(), "products", id)
-			if err != nil {
-				uc.logger.Error("failed to delete product from ES", zap.Error(err))
+		// A hold lowers available stock without a movement, so it can be what hits the reorder point.
+		var events []event.Event
+		for _, r := range results {
+			if r.Inventory != nil && event.ReachedLowStock(r.Inventory, -r.Quantity) {
+				events = append(events, event.ForLowStock(r.Inventory))
 			}
-		}()
+		}
+		return uc.outbox.Publish(ctx, events...)
+	})
+	if errors.Is(err, product.ErrStockNotHeld) {
+		return &dto.ReserveStockResult{Success: false, Lines: results}, nil
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return &dto.ReserveStockResult{Success: true, Reservation: res, Lines: results}, nil
 }
 
-func (uc *productUseCase) AddVariant(ctx context.Context, input *dto.CreateVariantInput) (*model.ProductVariant, error) {
-	// Placeholder
-	return nil, nil
+// mergeReserveLines sums duplicate product/variant lines and drops non-positive quantities,
+// keeping the order of the request. The repository takes the locks in its own order.
+func mergeReserveLines(items []dto.ReserveStockItemInput) []dto.ReserveStockItemInput {
+	index := make(map[string]int)
+	var lines []dto.ReserveStockItemInput
+	for _, item := range items {
+		if item.ProductID == "" || item.Quantity <= 0 {
+			continue
+		}
+		key := model.LocationKey(item.ProductID, item.VariantID)
+		if i, ok := index[key]; ok {
+			lines[i].Quantity += item.Quantity
+			continue
+		}
+		index[key] = len(lines)
+		lines = append(lines, item)
+	}
+	return lines
+}
+
+func reservedResult(res *model.StockReservation) *dto.ReserveStockResult {
+	lines := make([]dto.ReserveStockLineResult, len(res.Items))
+	for i, item := range res.Items {
+		lines[i] = dto.ReserveStockLineResult{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+			Status:    dto.ReserveLineReserved,
+		}
+	}
+	return &dto.ReserveStockResult{Success: true, Reservation: res, Lines: lines}
+}
+
+func (uc *productUseCase) CommitReservation(ctx context.Context, merchantID, orderID, userID string) (*model.StockReservation, error) {
+	res, err := uc.repo.FindReservationByOrder(ctx, merchantID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, product.ErrReservationNotFound
+	}
+	if res.Status == model.ReservationStatusCommitted {
+		return res, nil
+	}
+
+	var createdBy *string
+	if userID != "" {
+		createdBy = &userID
+	}
+
+	err = uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		movements, err := uc.repo.CommitReservation(ctx, res, createdBy)
+		if err != nil {
+			return err
+		}
+		if err := uc.notifyReservationStores(ctx, res); err != nil {
+			return err
+		}
+
+		// Stock and reservation drop together, available stock is unchanged.
+		events := make([]event.Event, len(movements))
+		for i := range movements {
+			events[i] = event.ForMovement(res.Items[i].InventoryID, &movements[i], nil)
+		}
+		return uc.outbox.Publish(ctx, events...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	res.Status = model.ReservationStatusCommitted
+
+	return res, nil
+}
+
+func (uc *productUseCase) ReleaseReservation(ctx context.Context, merchantID, orderID string) (*model.StockReservation, error) {
+	res, err := uc.repo.FindReservationByOrder(ctx, merchantID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, product.ErrReservationNotFound
+	}
+	if res.Status == model.ReservationStatusReleased || res.Status == model.ReservationStatusExpired {
+		return res, nil
+	}
+
+	if err := uc.releaseReservation(ctx, res, model.ReservationStatusReleased); err != nil {
+		return nil, err
+	}
+	res.Status = model.ReservationStatusReleased
+
+	return res, nil
+}
+
+// ReleaseActiveReservation releases the hold of an order if it is still active. It returns nil
+// when there is nothing to release: the order was never reserved, or its hold was already
+// committed, released or expired.
+func (uc *productUseCase) ReleaseActiveReservation(ctx context.Context, merchantID, orderID string) (*model.StockReservation, error) {
+	res, err := uc.repo.FindReservationByOrder(ctx, merchantID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil || res.Status != model.ReservationStatusActive {
+		return nil, nil
+	}
+
+	if err := uc.releaseReservation(ctx, res, model.ReservationStatusReleased); err != nil {
+		return nil, err
+	}
+	res.Status = model.ReservationStatusReleased
+
+	return res, nil
+}
+
+// ReleaseExpiredReservations releases up to limit holds whose TTL has passed and returns how many were released.
+func (uc *productUseCase) ReleaseExpiredReservations(ctx context.Context, limit int) (int, error) {
+	expired, err := uc.repo.FindExpiredReservations(ctx, time.Now(), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	released := 0
+	for i := range expired {
+		res := &expired[i]
+		if err := uc.releaseReservation(ctx, res, model.ReservationStatusExpired); err != nil {
+			// Most likely committed or released concurrently, the next sweep will skip it.
+			uc.logger.Warn("failed to release expired reservation",
+				zap.String("reservation_id", res.ID),
+				zap.String("order_id", res.OrderID),
+				zap.Error(err),
+			)
+			continue
+		}
+		released++
+	}
+
+	return released, nil
 }
 
-func (uc *productUseCase) ListVariants(ctx context.Context, productID string) ([]model.ProductVariant, error) {
-	// Placeholder
-	return nil, nil
+func (uc *productUseCase) releaseReservation(ctx context.Context, res *model.StockReservation, status string) error {
+	return uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.repo.ReleaseReservation(ctx, res, status); err != nil {
+			return err
+		}
+		return uc.notifyReservationStores(ctx, res)
+	})
 }
 
-func (uc *productUseCase) ReserveStock(ctx context.Context, items map[string]int32) error {
-	return uc.repo.ReserveStock(ctx, items)
+// notifyReservationStores wakes up the stock watchers of each store the reservation holds
+// stock at. Holds change available stock without a movement, so the inventory repository
+// never sees them.
+func (uc *productUseCase) notifyReservationStores(ctx context.Context, res *model.StockReservation) error {
+	notified := make(map[string]bool, len(res.Items))
+	for _, item := range res.Items {
+		store := ""
+		if item.StoreID != nil {
+			store = *item.StoreID
+		}
+		if notified[store] {
+			continue
+		}
+		notified[store] = true
+
+		key := res.MerchantID + ":" + store
+		if err := uc.outbox.Notify(ctx, event.AggregateInventory, key, res.MerchantID, inventory.StockChannel(res.MerchantID), store); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,65 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/fekuna/omnipos-pkg/logger"
+	"github.com/fekuna/omnipos-product-service/internal/database"
+	"github.com/fekuna/omnipos-product-service/internal/product"
+	"go.uber.org/zap"
+)
+
+const sweepBatchSize = 100
+
+// ReservationSweeper periodically releases stock reservations whose TTL has passed,
+// so abandoned carts don't keep stock locked.
+type ReservationSweeper struct {
+	uc       product.UseCase
+	interval time.Duration
+	logger   logger.ZapLogger
+}
+
+func NewReservationSweeper(uc product.UseCase, interval time.Duration, logger logger.ZapLogger) *ReservationSweeper {
+	return &ReservationSweeper{
+		uc:       uc,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+func (s *ReservationSweeper) Start(ctx context.Context) {
+	s.logger.Info("Starting Reservation Sweeper", zap.Duration("interval", s.interval))
+	ctx = database.AsSystem(ctx) // runs across merchants
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Stopping Reservation Sweeper")
+			return
+		case <-ticker.C:
+			s.sweep(ctx)
+		}
+	}
+}
+
+func (s *ReservationSweeper) sweep(ctx context.Context) {
+	for {
+		released, err := s.uc.ReleaseExpiredReservations(ctx, sweepBatchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.logger.Error("Failed to release expired reservations", zap.Error(err))
+			}
+			return
+		}
+		if released > 0 {
+			s.logger.Info("Released expired reservations", zap.Int("count", released))
+		}
+		// A full batch means there may be more waiting.
+		if released < sweepBatchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package dto
+
+import "github.com/fekuna/omnipos-product-service/internal/model"
+
+type SupplierFilters struct {
+	MerchantID string
+	Search     string
+	ActiveOnly bool
+	Page       int
+	PageSize   int
+}
+
+type PurchaseOrderFilters struct {
+	MerchantID string
+	SupplierID string
+	StoreID    *string
+	Status     string
+	Page       int
+	PageSize   int
+}
+
+type ReceivePurchaseOrderResult struct {
+	PurchaseOrder *model.PurchaseOrder
+	Receipt       *model.PurchaseReceipt
+}
+
+type ReorderSuggestionFilters struct {
+	MerchantID string
+	StoreID    *string
+	SupplierID *string // Empty string matches suggestions without a known supplier
+	Status     string  // Defaults to open
+}
+
+// ReorderSuggestionGroup holds the suggestions that would end up on the same purchase order.
+type ReorderSuggestionGroup struct {
+	SupplierID  *string
+	StoreID     *string
+	Suggestions []model.ReorderSuggestion
+}
+
+type GenerateReorderSuggestionsResult struct {
+	Suggested int // Open suggestions created or refreshed
+	Obsolete  int // Open suggestions that are no longer needed
+}
@@ -0,0 +1,63 @@
+package dto
+
+import "time"
+
+type CreateSupplierInput struct {
+	MerchantID  string
+	Name        string
+	ContactName *string
+	Email       *string
+	Phone       *string
+	Address     *string
+}
+
+type UpdateSupplierInput struct {
+	ID          string
+	MerchantID  string
+	Name        string
+	ContactName *string
+	Email       *string
+	Phone       *string
+	Address     *string
+	IsActive    bool
+}
+
+type CreatePurchaseOrderInput struct {
+	MerchantID string
+	SupplierID string
+	StoreID    *string
+	PONumber   string // Generated when empty
+	Notes      string
+	ExpectedAt *time.Time
+	UserID     string
+	Items      []PurchaseOrderItemInput
+}
+
+type PurchaseOrderItemInput struct {
+	ProductID string
+	VariantID *string
+	Quantity  float64
+	UnitCost  float64
+}
+
+type ReceivePurchaseOrderInput struct {
+	MerchantID      string
+	PurchaseOrderID string
+	UserID          string
+	Notes           string
+	UpdateCostPrice bool // Apply the merchant's costing method to cost_price
+	Items           []ReceivePurchaseItemInput
+}
+
+type ReceivePurchaseItemInput struct {
+	ItemID   string
+	Quantity float64
+	UnitCost *float64 // Invoiced cost, defaults to the ordered unit cost
+}
+
+type ConvertReorderSuggestionsInput struct {
+	MerchantID    string
+	SuggestionIDs []string
+	SupplierID    *string // Used for suggestions without a known supplier
+	UserID        string
+}
@@ -0,0 +1,33 @@
+package purchase
+
+import "github.com/fekuna/omnipos-product-service/internal/apperror"
+
+var (
+	ErrSupplierNotFound      = apperror.New(apperror.NotFound, "SUPPLIER_NOT_FOUND", "supplier not found")
+	ErrSupplierNameRequired  = apperror.Invalid("SUPPLIER_NAME_REQUIRED", "name", "supplier name is required")
+	ErrSupplierInactive      = apperror.New(apperror.FailedPrecondition, "SUPPLIER_INACTIVE", "supplier is inactive")
+	ErrUnknownCostingMethod  = apperror.Invalid("UNKNOWN_COSTING_METHOD", "method", `unknown costing method "{{.Method}}"`)
+	ErrPurchaseOrderNotFound = apperror.New(apperror.NotFound, "PURCHASE_ORDER_NOT_FOUND", "purchase order not found")
+	ErrWrongStatus           = apperror.New(apperror.FailedPrecondition, "PURCHASE_ORDER_WRONG_STATUS", "purchase order is {{.Status}}")
+	ErrNoItems               = apperror.Invalid("PURCHASE_ORDER_WITHOUT_ITEMS", "items", "purchase order has no items")
+	ErrInvalidItem           = apperror.Invalid("INVALID_PURCHASE_ORDER_ITEM", "items", "each item needs a product and a positive quantity")
+	ErrNegativeUnitCost      = apperror.Invalid("NEGATIVE_UNIT_COST", "items.unit_cost", "unit cost cannot be negative")
+	ErrProductNotFound       = apperror.New(apperror.NotFound, "PRODUCT_NOT_FOUND", "product {{.ProductID}} not found")
+)
+
+// Errors in receiving a purchase order.
+var (
+	ErrNoReceiptItems     = apperror.Invalid("RECEIPT_WITHOUT_ITEMS", "items", "receipt has no items")
+	ErrUnknownItem        = apperror.Invalid("UNKNOWN_PURCHASE_ORDER_ITEM", "items.item_id", "item {{.ItemID}} is not part of this purchase order")
+	ErrDuplicateItem      = apperror.Invalid("DUPLICATE_RECEIPT_ITEM", "items.item_id", "item {{.ItemID}} is listed more than once")
+	ErrInvalidReceivedQty = apperror.Invalid("INVALID_RECEIVED_QUANTITY", "items.quantity", "received quantity must be positive")
+	ErrOverReceipt        = apperror.Invalid("PURCHASE_ORDER_OVER_RECEIPT", "items.quantity", "received quantity for product {{.ProductID}} exceeds ordered quantity {{.Ordered}}")
+)
+
+// Errors in acting on reorder suggestions.
+var (
+	ErrNoSuggestions          = apperror.Invalid("NO_SUGGESTIONS_SELECTED", "suggestion_ids", "no suggestions selected")
+	ErrSuggestionsNotOpen     = apperror.New(apperror.FailedPrecondition, "SUGGESTIONS_NOT_OPEN", "some suggestions were not found or are no longer open")
+	ErrSuggestionNotOpen      = apperror.New(apperror.NotFound, "SUGGESTION_NOT_OPEN", "suggestion not found or no longer open")
+	ErrSuggestionNeedSupplier = apperror.Invalid("SUGGESTION_WITHOUT_SUPPLIER", "supplier_id", "suggestion {{.SuggestionID}} has no known supplier, choose one")
+)
@@ -0,0 +1,495 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/fekuna/omnipos-pkg/logger"
+	"github.com/fekuna/omnipos-product-service/internal/auth"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/fekuna/omnipos-product-service/internal/purchase"
+	"github.com/fekuna/omnipos-product-service/internal/purchase/dto"
+	productv1 "github.com/fekuna/omnipos-proto/gen/go/omnipos/product/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var _ productv1.PurchaseServiceServer = (*PurchaseHandler)(nil)
+
+type PurchaseHandler struct {
+	productv1.UnimplementedPurchaseServiceServer
+	uc     purchase.UseCase
+	logger logger.ZapLogger
+}
+
+func NewPurchaseHandler(uc purchase.UseCase, log logger.ZapLogger) *PurchaseHandler {
+	return &PurchaseHandler{
+		uc:     uc,
+		logger: log,
+	}
+}
+
+// --- Suppliers ---
+
+func (h *PurchaseHandler) CreateSupplier(ctx context.Context, req *productv1.CreateSupplierRequest) (*productv1.SupplierResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+	if merchantID == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing merchant context")
+	}
+
+	input := &dto.CreateSupplierInput{
+		MerchantID:  merchantID,
+		Name:        req.Name,
+		ContactName: optionalString(req.ContactName),
+		Email:       optionalString(req.Email),
+		Phone:       optionalString(req.Phone),
+		Address:     optionalString(req.Address),
+	}
+
+	s, err := h.uc.CreateSupplier(ctx, input)
+	if err != nil {
+		h.logger.Error("failed to create supplier", zap.Error(err))
+		return nil, err
+	}
+
+	return &productv1.SupplierResponse{Supplier: mapSupplierToProto(s)}, nil
+}
+
+func (h *PurchaseHandler) ListSuppliers(ctx context.Context, req *productv1.ListSuppliersRequest) (*productv1.ListSuppliersResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+
+	filters := &dto.SupplierFilters{
+		MerchantID: merchantID,
+		Search:     req.Search,
+		ActiveOnly: req.ActiveOnly,
+		Page:       int(req.Page),
+		PageSize:   int(req.PageSize),
+	}
+
+	suppliers, count, err := h.uc.ListSuppliers(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	protos := make([]*productv1.Supplier, len(suppliers))
+	for i, s := range suppliers {
+		protos[i] = mapSupplierToProto(&s)
+	}
+
+	return &productv1.ListSuppliersResponse{
+		Suppliers: protos,
+		Total:     int32(count),
+	}, nil
+}
+
+func (h *PurchaseHandler) UpdateSupplier(ctx context.Context, req *productv1.UpdateSupplierRequest) (*productv1.SupplierResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+
+	input := &dto.UpdateSupplierInput{
+		ID:          req.Id,
+		MerchantID:  merchantID,
+		Name:        req.Name,
+		ContactName: optionalString(req.ContactName),
+		Email:       optionalString(req.Email),
+		Phone:       optionalString(req.Phone),
+		Address:     optionalString(req.Address),
+		IsActive:    req.IsActive,
+	}
+
+	s, err := h.uc.UpdateSupplier(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return &productv1.SupplierResponse{Supplier: mapSupplierToProto(s)}, nil
+}
+
+// --- Purchase Orders ---
+
+func (h *PurchaseHandler) CreatePurchaseOrder(ctx context.Context, req *productv1.CreatePurchaseOrderRequest) (*productv1.PurchaseOrderResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+	if merchantID == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing merchant context")
+	}
+
+	items := make([]dto.PurchaseOrderItemInput, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = dto.PurchaseOrderItemInput{
+			ProductID: item.ProductId,
+			VariantID: optionalString(item.VariantId),
+			Quantity:  item.Quantity,
+			UnitCost:  item.UnitCost,
+		}
+	}
+
+	var expectedAt *time.Time
+	if req.ExpectedAt != nil {
+		t := req.ExpectedAt.AsTime()
+		expectedAt = &t
+	}
+
+	input := &dto.CreatePurchaseOrderInput{
+		MerchantID: merchantID,
+		SupplierID: req.SupplierId,
+		StoreID:    optionalString(req.StoreId),
+		PONumber:   req.PoNumber,
+		Notes:      req.Notes,
+		ExpectedAt: expectedAt,
+		UserID:     auth.GetUserID(ctx),
+		Items:      items,
+	}
+
+	po, err := h.uc.CreatePurchaseOrder(ctx, input)
+	if err != nil {
+		h.logger.Error("failed to create purchase order", zap.Error(err))
+		return nil, err
+	}
+
+	return &productv1.PurchaseOrderResponse{PurchaseOrder: mapPurchaseOrderToProto(po)}, nil
+}
+
+func (h *PurchaseHandler) GetPurchaseOrder(ctx context.Context, req *productv1.GetPurchaseOrderRequest) (*productv1.PurchaseOrderResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+
+	po, err := h.uc.GetPurchaseOrder(ctx, merchantID, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &productv1.PurchaseOrderResponse{PurchaseOrder: mapPurchaseOrderToProto(po)}, nil
+}
+
+func (h *PurchaseHandler) ListPurchaseOrders(ctx context.Context, req *productv1.ListPurchaseOrdersRequest) (*productv1.ListPurchaseOrdersResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+
+	filters := &dto.PurchaseOrderFilters{
+		MerchantID: merchantID,
+		SupplierID: req.SupplierId,
+		StoreID:    optionalString(req.StoreId),
+		Status:     req.Status,
+		Page:       int(req.Page),
+		PageSize:   int(req.PageSize),
+	}
+
+	orders, count, err := h.uc.ListPurchaseOrders(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	protos := make([]*productv1.PurchaseOrder, len(orders))
+	for i, po := range orders {
+		protos[i] = mapPurchaseOrderToProto(&po)
+	}
+
+	return &productv1.ListPurchaseOrdersResponse{
+		PurchaseOrders: protos,
+		Total:          int32(count),
+	}, nil
+}
+
+func (h *PurchaseHandler) SubmitPurchaseOrder(ctx context.Context, req *productv1.SubmitPurchaseOrderRequest) (*productv1.PurchaseOrderResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+
+	po, err := h.uc.SubmitPurchaseOrder(ctx, merchantID, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &productv1.PurchaseOrderResponse{PurchaseOrder: mapPurchaseOrderToProto(po)}, nil
+}
+
+func (h *PurchaseHandler) ReceivePurchaseOrder(ctx context.Context, req *productv1.ReceivePurchaseOrderRequest) (*productv1.ReceivePurchaseOrderResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+
+	items := make([]dto.ReceivePurchaseItemInput, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = dto.ReceivePurchaseItemInput{
+			ItemID:   item.ItemId,
+			Quantity: item.Quantity,
+			UnitCost: item.UnitCost,
+		}
+	}
+
+	input := &dto.ReceivePurchaseOrderInput{
+		MerchantID:      merchantID,
+		PurchaseOrderID: req.Id,
+		UserID:          auth.GetUserID(ctx),
+		Notes:           req.Notes,
+		UpdateCostPrice: req.UpdateCostPrice,
+		Items:           items,
+	}
+
+	res, err := h.uc.ReceivePurchaseOrder(ctx, input)
+	if err != nil {
+		h.logger.Error("failed to receive purchase order", zap.String("purchase_order_id", req.Id), zap.Error(err))
+		return nil, err
+	}
+
+	return &productv1.ReceivePurchaseOrderResponse{
+		PurchaseOrder: mapPurchaseOrderToProto(res.PurchaseOrder),
+		Receipt:       mapReceiptToProto(res.Receipt),
+	}, nil
+}
+
+func (h *PurchaseHandler) ClosePurchaseOrder(ctx context.Context, req *productv1.ClosePurchaseOrderRequest) (*productv1.PurchaseOrderResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+
+	po, err := h.uc.ClosePurchaseOrder(ctx, merchantID, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &productv1.PurchaseOrderResponse{PurchaseOrder: mapPurchaseOrderToProto(po)}, nil
+}
+
+func (h *PurchaseHandler) CancelPurchaseOrder(ctx context.Context, req *productv1.CancelPurchaseOrderRequest) (*productv1.PurchaseOrderResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+
+	po, err := h.uc.CancelPurchaseOrder(ctx, merchantID, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &productv1.PurchaseOrderResponse{PurchaseOrder: mapPurchaseOrderToProto(po)}, nil
+}
+
+// --- Costing ---
+
+func (h *PurchaseHandler) GetCostingMethod(ctx context.Context, req *productv1.GetCostingMethodRequest) (*productv1.CostingMethodResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+
+	method, err := h.uc.GetCostingMethod(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &productv1.CostingMethodResponse{CostingMethod: method}, nil
+}
+
+func (h *PurchaseHandler) SetCostingMethod(ctx context.Context, req *productv1.SetCostingMethodRequest) (*productv1.CostingMethodResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+	if merchantID == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing merchant context")
+	}
+
+	if err := h.uc.SetCostingMethod(ctx, merchantID, req.CostingMethod); err != nil {
+		return nil, err
+	}
+
+	return &productv1.CostingMethodResponse{CostingMethod: req.CostingMethod}, nil
+}
+
+// --- Reorder Suggestions ---
+
+func (h *PurchaseHandler) GenerateReorderSuggestions(ctx context.Context, req *productv1.GenerateReorderSuggestionsRequest) (*productv1.GenerateReorderSuggestionsResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+	if merchantID == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing merchant context")
+	}
+
+	res, err := h.uc.GenerateReorderSuggestions(ctx, merchantID)
+	if err != nil {
+		h.logger.Error("failed to generate reorder suggestions", zap.Error(err))
+		return nil, err
+	}
+
+	return &productv1.GenerateReorderSuggestionsResponse{
+		Suggested: int32(res.Suggested),
+		Obsolete:  int32(res.Obsolete),
+	}, nil
+}
+
+func (h *PurchaseHandler) ListReorderSuggestions(ctx context.Context, req *productv1.ListReorderSuggestionsRequest) (*productv1.ListReorderSuggestionsResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+
+	filters := &dto.ReorderSuggestionFilters{
+		MerchantID: merchantID,
+		StoreID:    optionalString(req.StoreId),
+		SupplierID: optionalString(req.SupplierId),
+		Status:     req.Status,
+	}
+
+	groups, err := h.uc.ListReorderSuggestions(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	protos := make([]*productv1.ReorderSuggestionGroup, len(groups))
+	for i, g := range groups {
+		suggestions := make([]*productv1.ReorderSuggestion, len(g.Suggestions))
+		for j, s := range g.Suggestions {
+			suggestions[j] = mapSuggestionToProto(&s)
+		}
+		protos[i] = &productv1.ReorderSuggestionGroup{
+			SupplierId:  stringValue(g.SupplierID),
+			StoreId:     stringValue(g.StoreID),
+			Suggestions: suggestions,
+		}
+	}
+
+	return &productv1.ListReorderSuggestionsResponse{Groups: protos}, nil
+}
+
+func (h *PurchaseHandler) ConvertReorderSuggestions(ctx context.Context, req *productv1.ConvertReorderSuggestionsRequest) (*productv1.ConvertReorderSuggestionsResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+	if merchantID == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing merchant context")
+	}
+
+	input := &dto.ConvertReorderSuggestionsInput{
+		MerchantID:    merchantID,
+		SuggestionIDs: req.SuggestionIds,
+		SupplierID:    optionalString(req.SupplierId),
+		UserID:        auth.GetUserID(ctx),
+	}
+
+	orders, err := h.uc.ConvertReorderSuggestions(ctx, input)
+	if err != nil {
+		h.logger.Error("failed to convert reorder suggestions", zap.Error(err))
+		return nil, err
+	}
+
+	protos := make([]*productv1.PurchaseOrder, len(orders))
+	for i, po := range orders {
+		protos[i] = mapPurchaseOrderToProto(&po)
+	}
+
+	return &productv1.ConvertReorderSuggestionsResponse{PurchaseOrders: protos}, nil
+}
+
+func (h *PurchaseHandler) DismissReorderSuggestion(ctx context.Context, req *productv1.DismissReorderSuggestionRequest) (*productv1.DismissReorderSuggestionResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+
+	if err := h.uc.DismissReorderSuggestion(ctx, merchantID, req.Id); err != nil {
+		return nil, err
+	}
+
+	return &productv1.DismissReorderSuggestionResponse{Success: true}, nil
+}
+
+// --- Helpers ---
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func optionalTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func mapSupplierToProto(m *model.Supplier) *productv1.Supplier {
+	if m == nil {
+		return nil
+	}
+	return &productv1.Supplier{
+		Id:          m.ID,
+		MerchantId:  m.MerchantID,
+		Name:        m.Name,
+		ContactName: stringValue(m.ContactName),
+		Email:       stringValue(m.Email),
+		Phone:       stringValue(m.Phone),
+		Address:     stringValue(m.Address),
+		IsActive:    m.IsActive,
+		CreatedAt:   timestamppb.New(m.CreatedAt),
+		UpdatedAt:   timestamppb.New(m.UpdatedAt),
+	}
+}
+
+func mapPurchaseOrderToProto(m *model.PurchaseOrder) *productv1.PurchaseOrder {
+	if m == nil {
+		return nil
+	}
+
+	items := make([]*productv1.PurchaseOrderItem, len(m.Items))
+	for i, item := range m.Items {
+		items[i] = &productv1.PurchaseOrderItem{
+			Id:               item.ID,
+			ProductId:        item.ProductID,
+			VariantId:        stringValue(item.VariantID),
+			QuantityOrdered:  item.QuantityOrdered,
+			QuantityReceived: item.QuantityReceived,
+			UnitCost:         item.UnitCost,
+		}
+	}
+
+	return &productv1.PurchaseOrder{
+		Id:         m.ID,
+		MerchantId: m.MerchantID,
+		SupplierId: m.SupplierID,
+		StoreId:    stringValue(m.StoreID),
+		PoNumber:   m.PONumber,
+		Status:     m.Status,
+		Notes:      m.Notes,
+		CreatedBy:  stringValue(m.CreatedBy),
+		ExpectedAt: optionalTimestamp(m.ExpectedAt),
+		OrderedAt:  optionalTimestamp(m.OrderedAt),
+		ReceivedAt: optionalTimestamp(m.ReceivedAt),
+		ClosedAt:   optionalTimestamp(m.ClosedAt),
+		CreatedAt:  timestamppb.New(m.CreatedAt),
+		UpdatedAt:  timestamppb.New(m.UpdatedAt),
+		Items:      items,
+	}
+}
+
+func mapReceiptToProto(m *model.PurchaseReceipt) *productv1.PurchaseReceipt {
+	if m == nil {
+		return nil
+	}
+
+	items := make([]*productv1.PurchaseReceiptItem, len(m.Items))
+	for i, item := range m.Items {
+		items[i] = &productv1.PurchaseReceiptItem{
+			Id:                  item.ID,
+			PurchaseOrderItemId: item.PurchaseOrderItemID,
+			Quantity:            item.Quantity,
+			UnitCost:            item.UnitCost,
+		}
+	}
+
+	return &productv1.PurchaseReceipt{
+		Id:              m.ID,
+		PurchaseOrderId: m.PurchaseOrderID,
+		Notes:           m.Notes,
+		ReceivedBy:      stringValue(m.ReceivedBy),
+		CreatedAt:       timestamppb.New(m.CreatedAt),
+		Items:           items,
+	}
+}
+
+func mapSuggestionToProto(m *model.ReorderSuggestion) *productv1.ReorderSuggestion {
+	if m == nil {
+		return nil
+	}
+	return &productv1.ReorderSuggestion{
+		Id:                m.ID,
+		InventoryId:       m.InventoryID,
+		StoreId:           stringValue(m.StoreID),
+		SupplierId:        stringValue(m.SupplierID),
+		ProductId:         m.ProductID,
+		VariantId:         stringValue(m.VariantID),
+		Status:            m.Status,
+		PurchaseOrderId:   stringValue(m.PurchaseOrderID),
+		AvailableQuantity: m.AvailableQuantity,
+		IncomingQuantity:  m.IncomingQuantity,
+		ReorderPoint:      m.ReorderPoint,
+		SuggestedQuantity: m.SuggestedQuantity,
+		UnitCost:          m.UnitCost,
+		CreatedAt:         timestamppb.New(m.CreatedAt),
+		UpdatedAt:         timestamppb.New(m.UpdatedAt),
+	}
+}
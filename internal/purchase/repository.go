@@ -0,0 +1,43 @@
+package purchase
+
+import (
+	"context"
+	"time"
+
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/fekuna/omnipos-product-service/internal/purchase/dto"
+)
+
+type Repository interface {
+	// Suppliers
+	CreateSupplier(ctx context.Context, supplier *model.Supplier) error
+	FindSupplierByID(ctx context.Context, merchantID, id string) (*model.Supplier, error)
+	FindSuppliers(ctx context.Context, filters *dto.SupplierFilters) ([]model.Supplier, int, error)
+	UpdateSupplier(ctx context.Context, supplier *model.Supplier) error
+
+	// Purchase orders
+	CreatePurchaseOrder(ctx context.Context, po *model.PurchaseOrder) error
+	FindPurchaseOrderByID(ctx context.Context, merchantID, id string) (*model.PurchaseOrder, error)
+	FindPurchaseOrders(ctx context.Context, filters *dto.PurchaseOrderFilters) ([]model.PurchaseOrder, int, error)
+
+	// Used inside a transaction while stock is received
+	FindPurchaseOrderByIDForUpdate(ctx context.Context, merchantID, id string) (*model.PurchaseOrder, error)
+	UpdatePurchaseOrderStatus(ctx context.Context, po *model.PurchaseOrder) error
+	UpdatePurchaseOrderItem(ctx context.Context, item *model.PurchaseOrderItem) error
+	CreateReceipt(ctx context.Context, receipt *model.PurchaseReceipt) error
+
+	// Costing
+	GetCostingMethod(ctx context.Context, merchantID string) (string, error)
+	SetCostingMethod(ctx context.Context, merchantID, method string) error
+	// GetCostBasisForUpdate locks the product (or variant) row so concurrent receipts average in turn.
+	GetCostBasisForUpdate(ctx context.Context, merchantID, productID string, variantID *string) (*model.CostBasis, error)
+	UpdateCostPrice(ctx context.Context, merchantID, productID string, variantID *string, cost float64) error
+
+	// Reorder suggestions
+	FindReorderCandidates(ctx context.Context, merchantID string) ([]model.ReorderCandidate, error)
+	UpsertReorderSuggestion(ctx context.Context, suggestion *model.ReorderSuggestion) error
+	MarkStaleSuggestionsObsolete(ctx context.Context, merchantID string, before time.Time) (int, error)
+	FindReorderSuggestions(ctx context.Context, filters *dto.ReorderSuggestionFilters) ([]model.ReorderSuggestion, error)
+	FindOpenSuggestionsForUpdate(ctx context.Context, merchantID string, ids []string) ([]model.ReorderSuggestion, error)
+	UpdateSuggestionStatus(ctx context.Context, suggestion *model.ReorderSuggestion) error
+}
@@ -0,0 +1,259 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fekuna/omnipos-product-service/internal/database"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/fekuna/omnipos-product-service/internal/purchase/dto"
+	"github.com/jmoiron/sqlx"
+)
+
+type PGRepository struct {
+	DB *sqlx.DB
+}
+
+func NewPGRepository(db *sqlx.DB) *PGRepository {
+	return &PGRepository{DB: db}
+}
+
+// conn returns the transaction started by the TxManager if ctx carries one.
+func (r *PGRepository) conn(ctx context.Context) database.DBTX {
+	return database.Conn(ctx, r.DB)
+}
+
+func (r *PGRepository) CreatePurchaseOrder(ctx context.Context, po *model.PurchaseOrder) error {
+	return database.RunInTx(ctx, r.DB, func(ctx context.Context) error {
+		query := `
+            INSERT INTO purchase_orders (
+                id, merchant_id, supplier_id, store_id, po_number, status, notes,
+                expected_at, ordered_at, received_at, closed_at, created_by, created_at, updated_at
+            )
+            VALUES (
+                :id, :merchant_id, :supplier_id, :store_id, :po_number, :status, :notes,
+                :expected_at, :ordered_at, :received_at, :closed_at, :created_by, :created_at, :updated_at
+            )
+        `
+		if _, err := r.conn(ctx).NamedExecContext(ctx, query, po); err != nil {
+			return fmt.Errorf("failed to create purchase order: %w", err)
+		}
+
+		itemQuery := `
+            INSERT INTO purchase_order_items (
+                id, purchase_order_id, product_id, variant_id, quantity_ordered, quantity_received, unit_cost
+            )
+            VALUES (
+                :id, :purchase_order_id, :product_id, :variant_id, :quantity_ordered, :quantity_received, :unit_cost
+            )
+        `
+		for _, item := range po.Items {
+			if _, err := r.conn(ctx).NamedExecContext(ctx, itemQuery, item); err != nil {
+				return fmt.Errorf("failed to create purchase order item: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *PGRepository) FindPurchaseOrderByID(ctx context.Context, merchantID, id string) (*model.PurchaseOrder, error) {
+	return r.findPurchaseOrder(ctx, merchantID, id, "")
+}
+
+func (r *PGRepository) FindPurchaseOrderByIDForUpdate(ctx context.Context, merchantID, id string) (*model.PurchaseOrder, error) {
+	return r.findPurchaseOrder(ctx, merchantID, id, " FOR UPDATE")
+}
+
+func (r *PGRepository) findPurchaseOrder(ctx context.Context, merchantID, id, lockClause string) (*model.PurchaseOrder, error) {
+	var po model.PurchaseOrder
+	query := `SELECT * FROM purchase_orders WHERE id = $1 AND merchant_id = $2` + lockClause
+	err := r.conn(ctx).GetContext(ctx, &po, query, id, merchantID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	items := []model.PurchaseOrderItem{}
+	itemQuery := `SELECT * FROM purchase_order_items WHERE purchase_order_id = $1 ORDER BY product_id, variant_id NULLS FIRST`
+	if err := r.conn(ctx).SelectContext(ctx, &items, itemQuery, po.ID); err != nil {
+		return nil, err
+	}
+	po.Items = items
+
+	return &po, nil
+}
+
+func (r *PGRepository) FindPurchaseOrders(ctx context.Context, f *dto.PurchaseOrderFilters) ([]model.PurchaseOrder, int, error) {
+	var orders []model.PurchaseOrder
+	var count int
+
+	conditions := []string{"merchant_id = :merchant_id"}
+	args := map[string]interface{}{"merchant_id": f.MerchantID}
+	if f.SupplierID != "" {
+		conditions = append(conditions, "supplier_id = :supplier_id")
+		args["supplier_id"] = f.SupplierID
+	}
+	if f.StoreID != nil {
+		if *f.StoreID == "" {
+			conditions = append(conditions, "store_id IS NULL")
+		} else {
+			conditions = append(conditions, "store_id = :store_id")
+			args["store_id"] = *f.StoreID
+		}
+	}
+	if f.Status != "" {
+		conditions = append(conditions, "status = :status")
+		args["status"] = f.Status
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	countQuery := "SELECT count(*) FROM purchase_orders" + whereClause
+	if err := database.NamedGet(ctx, r.conn(ctx), &count, countQuery, args); err != nil {
+		return nil, 0, err
+	}
+
+	query := "SELECT * FROM purchase_orders" + whereClause + " ORDER BY created_at DESC"
+	if f.PageSize > 0 {
+		offset := (f.Page - 1) * f.PageSize
+		query += fmt.Sprintf(" LIMIT %d OFFSET %d", f.PageSize, offset)
+	}
+
+	err := database.NamedSelect(ctx, r.conn(ctx), &orders, query, args)
+	return orders, count, err
+}
+
+func (r *PGRepository) UpdatePurchaseOrderStatus(ctx context.Context, po *model.PurchaseOrder) error {
+	query := `
+        UPDATE purchase_orders
+        SET status = :status,
+            ordered_at = :ordered_at,
+            received_at = :received_at,
+            closed_at = :closed_at,
+            updated_at = :updated_at
+        WHERE id = :id AND merchant_id = :merchant_id
+    `
+	_, err := r.conn(ctx).NamedExecContext(ctx, query, po)
+	return err
+}
+
+func (r *PGRepository) UpdatePurchaseOrderItem(ctx context.Context, item *model.PurchaseOrderItem) error {
+	query := `
+        UPDATE purchase_order_items
+        SET quantity_received = :quantity_received
+        WHERE id = :id AND purchase_order_id = :purchase_order_id
+    `
+	_, err := r.conn(ctx).NamedExecContext(ctx, query, item)
+	return err
+}
+
+func (r *PGRepository) CreateReceipt(ctx context.Context, receipt *model.PurchaseReceipt) error {
+	return database.RunInTx(ctx, r.DB, func(ctx context.Context) error {
+		query := `
+            INSERT INTO purchase_receipts (id, merchant_id, purchase_order_id, notes, received_by, created_at)
+            VALUES (:id, :merchant_id, :purchase_order_id, :notes, :received_by, :created_at)
+        `
+		if _, err := r.conn(ctx).NamedExecContext(ctx, query, receipt); err != nil {
+			return fmt.Errorf("failed to create purchase receipt: %w", err)
+		}
+
+		itemQuery := `
+            INSERT INTO purchase_receipt_items (id, receipt_id, purchase_order_item_id, quantity, unit_cost)
+            VALUES (:id, :receipt_id, :purchase_order_item_id, :quantity, :unit_cost)
+        `
+		for _, item := range receipt.Items {
+			if _, err := r.conn(ctx).NamedExecContext(ctx, itemQuery, item); err != nil {
+				return fmt.Errorf("failed to create purchase receipt item: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *PGRepository) GetCostingMethod(ctx context.Context, merchantID string) (string, error) {
+	var method string
+	query := `SELECT costing_method FROM merchant_inventory_settings WHERE merchant_id = $1`
+	err := r.conn(ctx).GetContext(ctx, &method, query, merchantID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.CostingMethodWeightedAverage, nil
+		}
+		return "", err
+	}
+	return method, nil
+}
+
+func (r *PGRepository) SetCostingMethod(ctx context.Context, merchantID, method string) error {
+	query := `
+        INSERT INTO merchant_inventory_settings (merchant_id, costing_method, updated_at)
+        VALUES ($1, $2, NOW())
+        ON CONFLICT (merchant_id)
+        DO UPDATE SET costing_method = EXCLUDED.costing_method, updated_at = EXCLUDED.updated_at
+    `
+	_, err := r.conn(ctx).ExecContext(ctx, query, merchantID, method)
+	return err
+}
+
+func (r *PGRepository) GetCostBasisForUpdate(ctx context.Context, merchantID, productID string, variantID *string) (*model.CostBasis, error) {
+	var basis model.CostBasis
+	var err error
+
+	if variantID != nil {
+		query := `
+            SELECT v.cost_price,
+                COALESCE((
+                    SELECT SUM(quantity) FROM inventory
+                    WHERE merchant_id = $1 AND product_id = $2 AND variant_id = $3
+                ), 0) AS on_hand
+            FROM product_variants v
+            JOIN products p ON p.id = v.product_id
+            WHERE v.id = $3 AND v.product_id = $2 AND p.merchant_id = $1
+            FOR UPDATE OF v
+        `
+		err = r.conn(ctx).GetContext(ctx, &basis, query, merchantID, productID, *variantID)
+	} else {
+		query := `
+            SELECT cost_price,
+                COALESCE((
+                    SELECT SUM(quantity) FROM inventory
+                    WHERE merchant_id = $1 AND product_id = $2 AND variant_id IS NULL
+                ), 0) AS on_hand
+            FROM products
+            WHERE id = $2 AND merchant_id = $1
+            FOR UPDATE
+        `
+		err = r.conn(ctx).GetContext(ctx, &basis, query, merchantID, productID)
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &basis, nil
+}
+
+func (r *PGRepository) UpdateCostPrice(ctx context.Context, merchantID, productID string, variantID *string, cost float64) error {
+	if variantID != nil {
+		query := `
+            UPDATE product_variants v
+            SET cost_price = $4, updated_at = NOW()
+            FROM products p
+            WHERE v.id = $3 AND v.product_id = $2 AND p.id = v.product_id AND p.merchant_id = $1
+        `
+		_, err := r.conn(ctx).ExecContext(ctx, query, merchantID, productID, *variantID, cost)
+		return err
+	}
+
+	query := `UPDATE products SET cost_price = $3, updated_at = NOW() WHERE id = $2 AND merchant_id = $1`
+	_, err := r.conn(ctx).ExecContext(ctx, query, merchantID, productID, cost)
+	return err
+}
@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/fekuna/omnipos-product-service/internal/database"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/fekuna/omnipos-product-service/internal/purchase/dto"
+	"github.com/jmoiron/sqlx"
+)
+
+// FindReorderCandidates returns tracked inventory locations at or below their reorder point.
+// Incoming stock counts open purchase orders (ordered minus received) for the same store and
+// inbound transfers that have left the source but not fully arrived. Locations whose latest
+// closed suggestion was dismissed are left out while the dismissal holds: until it expires or
+// the available quantity or reorder settings differ from those it was raised under.
+func (r *PGRepository) FindReorderCandidates(ctx context.Context, merchantID string) ([]model.ReorderCandidate, error) {
+	query := `
+        SELECT i.id AS inventory_id, i.merchant_id, i.store_id, i.product_id, i.variant_id,
+            i.available_quantity, i.reorder_point, i.reorder_quantity, i.max_stock_level,
+            COALESCE(po.incoming, 0) + COALESCE(tr.incoming, 0) AS incoming_quantity,
+            (
+                SELECT o.supplier_id
+                FROM purchase_orders o
+                JOIN purchase_order_items oi ON oi.purchase_order_id = o.id
+                WHERE o.merchant_id = i.merchant_id AND o.status <> 'cancelled'
+                    AND oi.product_id = i.product_id
+                    AND oi.variant_id IS NOT DISTINCT FROM i.variant_id
+                ORDER BY o.created_at DESC
+                LIMIT 1
+            ) AS supplier_id,
+            COALESCE(v.cost_price, p.cost_price, 0) AS unit_cost
+        FROM inventory i
+        JOIN products p ON p.id = i.product_id
+        LEFT JOIN product_variants v ON v.id = i.variant_id
+        LEFT JOIN LATERAL (
+            SELECT SUM(oi.quantity_ordered - oi.quantity_received) AS incoming
+            FROM purchase_orders o
+            JOIN purchase_order_items oi ON oi.purchase_order_id = o.id
+            WHERE o.merchant_id = i.merchant_id
+                AND o.store_id IS NOT DISTINCT FROM i.store_id
+                AND o.status IN ('draft', 'ordered', 'partially_received')
+                AND oi.product_id = i.product_id
+                AND oi.variant_id IS NOT DISTINCT FROM i.variant_id
+        ) po ON TRUE
+        LEFT JOIN LATERAL (
+            SELECT SUM(ti.quantity - ti.quantity_received) AS incoming
+            FROM stock_transfers t
+            JOIN stock_transfer_items ti ON ti.transfer_id = t.id
+            WHERE t.merchant_id = i.merchant_id
+                AND t.target_store_id IS NOT DISTINCT FROM i.store_id
+                AND t.status IN ('dispatched', 'in_transit', 'partially_received')
+                AND ti.product_id = i.product_id
+                AND ti.variant_id IS NOT DISTINCT FROM i.variant_id
+        ) tr ON TRUE
+        WHERE i.reorder_point > 0
+            AND i.available_quantity <= i.reorder_point
+            AND p.is_active = TRUE AND p.track_inventory = TRUE
+            AND (v.id IS NULL OR v.is_active = TRUE)
+            AND NOT EXISTS (
+                SELECT 1
+                FROM (
+                    SELECT * FROM reorder_suggestions rs
+                    WHERE rs.inventory_id = i.id AND rs.status <> 'open'
+                    ORDER BY rs.updated_at DESC
+                    LIMIT 1
+                ) d
+                WHERE d.status = 'dismissed'
+                    AND (d.dismissed_until IS NULL OR d.dismissed_until > NOW())
+                    AND d.available_quantity = i.available_quantity
+                    AND d.reorder_point = i.reorder_point
+                    AND d.reorder_quantity = i.reorder_quantity
+                    AND d.max_stock_level IS NOT DISTINCT FROM i.max_stock_level
+            )
+    `
+	args := []interface{}{}
+	if merchantID != "" {
+		query += " AND i.merchant_id = $1"
+		args = append(args, merchantID)
+	}
+	query += " ORDER BY i.merchant_id, i.store_id NULLS FIRST, i.product_id"
+
+	candidates := []model.ReorderCandidate{}
+	err := r.conn(ctx).SelectContext(ctx, &candidates, query, args...)
+	return candidates, err
+}
+
+// UpsertReorderSuggestion creates the open suggestion of an inventory location or refreshes
+// the existing one.
+func (r *PGRepository) UpsertReorderSuggestion(ctx context.Context, s *model.ReorderSuggestion) error {
+	query := `
+        INSERT INTO reorder_suggestions (
+            id, merchant_id, inventory_id, store_id, supplier_id, product_id, variant_id,
+            available_quantity, incoming_quantity, reorder_point, reorder_quantity, max_stock_level,
+            suggested_quantity, unit_cost, status, purchase_order_id, created_at, updated_at
+        )
+        VALUES (
+            :id, :merchant_id, :inventory_id, :store_id, :supplier_id, :product_id, :variant_id,
+            :available_quantity, :incoming_quantity, :reorder_point, :reorder_quantity, :max_stock_level,
+            :suggested_quantity, :unit_cost, :status, :purchase_order_id, :created_at, :updated_at
+        )
+        ON CONFLICT (inventory_id) WHERE status = 'open'
+        DO UPDATE SET
+            supplier_id = EXCLUDED.supplier_id,
+            available_quantity = EXCLUDED.available_quantity,
+            incoming_quantity = EXCLUDED.incoming_quantity,
+            reorder_point = EXCLUDED.reorder_point,
+            reorder_quantity = EXCLUDED.reorder_quantity,
+            max_stock_level = EXCLUDED.max_stock_level,
+            suggested_quantity = EXCLUDED.suggested_quantity,
+            unit_cost = EXCLUDED.unit_cost,
+            updated_at = EXCLUDED.updated_at
+    `
+	_, err := r.conn(ctx).NamedExecContext(ctx, query, s)
+	return err
+}
+
+// MarkStaleSuggestionsObsolete retires open suggestions that were not refreshed since before.
+func (r *PGRepository) MarkStaleSuggestionsObsolete(ctx context.Context, merchantID string, before time.Time) (int, error) {
+	query := `UPDATE reorder_suggestions SET status = 'obsolete', updated_at = NOW() WHERE status = 'open' AND updated_at < $1`
+	args := []interface{}{before}
+	if merchantID != "" {
+		query += " AND merchant_id = $2"
+		args = append(args, merchantID)
+	}
+
+	res, err := r.conn(ctx).ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+func (r *PGRepository) FindReorderSuggestions(ctx context.Context, f *dto.ReorderSuggestionFilters) ([]model.ReorderSuggestion, error) {
+	conditions := []string{"merchant_id = :merchant_id", "status = :status"}
+	args := map[string]interface{}{
+		"merchant_id": f.MerchantID,
+		"status":      f.Status,
+	}
+	if f.Status == "" {
+		args["status"] = model.ReorderSuggestionStatusOpen
+	}
+
+	if f.StoreID != nil {
+		if *f.StoreID == "" {
+			conditions = append(conditions, "store_id IS NULL")
+		} else {
+			conditions = append(conditions, "store_id = :store_id")
+			args["store_id"] = *f.StoreID
+		}
+	}
+	if f.SupplierID != nil {
+		if *f.SupplierID == "" {
+			conditions = append(conditions, "supplier_id IS NULL")
+		} else {
+			conditions = append(conditions, "supplier_id = :supplier_id")
+			args["supplier_id"] = *f.SupplierID
+		}
+	}
+
+	query := "SELECT * FROM reorder_suggestions WHERE " + strings.Join(conditions, " AND ") +
+		" ORDER BY supplier_id NULLS LAST, store_id NULLS FIRST, product_id"
+
+	suggestions := []model.ReorderSuggestion{}
+	err := database.NamedSelect(ctx, r.conn(ctx), &suggestions, query, args)
+	return suggestions, err
+}
+
+func (r *PGRepository) FindOpenSuggestionsForUpdate(ctx context.Context, merchantID string, ids []string) ([]model.ReorderSuggestion, error) {
+	if len(ids) == 0 {
+		return []model.ReorderSuggestion{}, nil
+	}
+
+	query, args, err := sqlx.In(`
+        SELECT * FROM reorder_suggestions
+        WHERE merchant_id = ? AND status = 'open' AND id IN (?)
+        ORDER BY id
+        FOR UPDATE
+    `, merchantID, ids)
+	if err != nil {
+		return nil, err
+	}
+	query = r.DB.Rebind(query)
+
+	suggestions := []model.ReorderSuggestion{}
+	err = r.conn(ctx).SelectContext(ctx, &suggestions, query, args...)
+	return suggestions, err
+}
+
+func (r *PGRepository) UpdateSuggestionStatus(ctx context.Context, s *model.ReorderSuggestion) error {
+	query := `
+        UPDATE reorder_suggestions
+        SET status = :status,
+            purchase_order_id = :purchase_order_id,
+            dismissed_until = :dismissed_until,
+            updated_at = :updated_at
+        WHERE id = :id AND merchant_id = :merchant_id
+    `
+	_, err := r.conn(ctx).NamedExecContext(ctx, query, s)
+	return err
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fekuna/omnipos-product-service/internal/database"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/fekuna/omnipos-product-service/internal/purchase/dto"
+)
+
+func (r *PGRepository) CreateSupplier(ctx context.Context, s *model.Supplier) error {
+	query := `
+        INSERT INTO suppliers (
+            id, merchant_id, name, contact_name, email, phone, address, is_active, created_at, updated_at
+        )
+        VALUES (
+            :id, :merchant_id, :name, :contact_name, :email, :phone, :address, :is_active, :created_at, :updated_at
+        )
+    `
+	_, err := r.conn(ctx).NamedExecContext(ctx, query, s)
+	if err != nil {
+		return fmt.Errorf("failed to create supplier: %w", err)
+	}
+	return nil
+}
+
+func (r *PGRepository) FindSupplierByID(ctx context.Context, merchantID, id string) (*model.Supplier, error) {
+	var s model.Supplier
+	query := `SELECT * FROM suppliers WHERE id = $1 AND merchant_id = $2`
+	err := r.conn(ctx).GetContext(ctx, &s, query, id, merchantID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *PGRepository) FindSuppliers(ctx context.Context, f *dto.SupplierFilters) ([]model.Supplier, int, error) {
+	var suppliers []model.Supplier
+	var count int
+
+	conditions := []string{"merchant_id = :merchant_id"}
+	args := map[string]interface{}{"merchant_id": f.MerchantID}
+	if f.ActiveOnly {
+		conditions = append(conditions, "is_active = TRUE")
+	}
+	if f.Search != "" {
+		conditions = append(conditions, "(name ILIKE :search OR contact_name ILIKE :search OR email ILIKE :search)")
+		args["search"] = "%" + f.Search + "%"
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	countQuery := "SELECT count(*) FROM suppliers" + whereClause
+	if err := database.NamedGet(ctx, r.conn(ctx), &count, countQuery, args); err != nil {
+		return nil, 0, err
+	}
+
+	query := "SELECT * FROM suppliers" + whereClause + " ORDER BY name"
+	if f.PageSize > 0 {
+		offset := (f.Page - 1) * f.PageSize
+		query += fmt.Sprintf(" LIMIT %d OFFSET %d", f.PageSize, offset)
+	}
+
+	err := database.NamedSelect(ctx, r.conn(ctx), &suppliers, query, args)
+	return suppliers, count, err
+}
+
+func (r *PGRepository) UpdateSupplier(ctx context.Context, s *model.Supplier) error {
+	query := `
+        UPDATE suppliers
+        SET name = :name,
+            contact_name = :contact_name,
+            email = :email,
+            phone = :phone,
+            address = :address,
+            is_active = :is_active,
+            updated_at = :updated_at
+        WHERE id = :id AND merchant_id = :merchant_id
+    `
+	_, err := r.conn(ctx).NamedExecContext(ctx, query, s)
+	return err
+}
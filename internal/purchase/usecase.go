@@ -0,0 +1,35 @@
+package purchase
+
+import (
+	"context"
+
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/fekuna/omnipos-product-service/internal/purchase/dto"
+)
+
+type UseCase interface {
+	// Suppliers
+	CreateSupplier(ctx context.Context, input *dto.CreateSupplierInput) (*model.Supplier, error)
+	ListSuppliers(ctx context.Context, filters *dto.SupplierFilters) ([]model.Supplier, int, error)
+	UpdateSupplier(ctx context.Context, input *dto.UpdateSupplierInput) (*model.Supplier, error)
+
+	// Purchase orders: draft -> ordered -> (partially_)received -> closed
+	CreatePurchaseOrder(ctx context.Context, input *dto.CreatePurchaseOrderInput) (*model.PurchaseOrder, error)
+	GetPurchaseOrder(ctx context.Context, merchantID, id string) (*model.PurchaseOrder, error)
+	ListPurchaseOrders(ctx context.Context, filters *dto.PurchaseOrderFilters) ([]model.PurchaseOrder, int, error)
+	SubmitPurchaseOrder(ctx context.Context, merchantID, id string) (*model.PurchaseOrder, error)
+	ReceivePurchaseOrder(ctx context.Context, input *dto.ReceivePurchaseOrderInput) (*dto.ReceivePurchaseOrderResult, error)
+	ClosePurchaseOrder(ctx context.Context, merchantID, id string) (*model.PurchaseOrder, error)
+	CancelPurchaseOrder(ctx context.Context, merchantID, id string) (*model.PurchaseOrder, error)
+
+	// Costing
+	GetCostingMethod(ctx context.Context, merchantID string) (string, error)
+	SetCostingMethod(ctx context.Context, merchantID, method string) error
+
+	// Reorder suggestions
+	// An empty merchantID regenerates suggestions for every merchant (used by the scheduled job).
+	GenerateReorderSuggestions(ctx context.Context, merchantID string) (*dto.GenerateReorderSuggestionsResult, error)
+	ListReorderSuggestions(ctx context.Context, filters *dto.ReorderSuggestionFilters) ([]dto.ReorderSuggestionGroup, error)
+	ConvertReorderSuggestions(ctx context.Context, input *dto.ConvertReorderSuggestionsInput) ([]model.PurchaseOrder, error)
+	DismissReorderSuggestion(ctx context.Context, merchantID, id string) error
+}
@@ -0,0 +1,232 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/fekuna/omnipos-product-service/internal/purchase"
+	"github.com/fekuna/omnipos-product-service/internal/purchase/dto"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// GenerateReorderSuggestions creates or refreshes one open suggestion per inventory location
+// at or below its reorder point. Locations whose open purchase orders and inbound transfers
+// already lift them above the reorder point are skipped, and open suggestions that are no
+// longer needed are marked obsolete.
+func (uc *purchaseUseCase) GenerateReorderSuggestions(ctx context.Context, merchantID string) (*dto.GenerateReorderSuggestionsResult, error) {
+	result := &dto.GenerateReorderSuggestionsResult{}
+	now := time.Now()
+
+	err := uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		candidates, err := uc.repo.FindReorderCandidates(ctx, merchantID)
+		if err != nil {
+			return err
+		}
+
+		for _, c := range candidates {
+			qty := suggestedQuantity(c)
+			if qty <= 0 {
+				continue
+			}
+
+			s := &model.ReorderSuggestion{
+				BaseModel:         model.BaseModel{ID: uuid.New().String(), CreatedAt: now, UpdatedAt: now},
+				MerchantID:        c.MerchantID,
+				InventoryID:       c.InventoryID,
+				StoreID:           c.StoreID,
+				SupplierID:        c.SupplierID,
+				ProductID:         c.ProductID,
+				VariantID:         c.VariantID,
+				AvailableQuantity: c.AvailableQuantity,
+				IncomingQuantity:  c.IncomingQuantity,
+				ReorderPoint:      c.ReorderPoint,
+				ReorderQuantity:   c.ReorderQuantity,
+				MaxStockLevel:     c.MaxStockLevel,
+				SuggestedQuantity: qty,
+				UnitCost:          c.UnitCost,
+				Status:            model.ReorderSuggestionStatusOpen,
+			}
+			if err := uc.repo.UpsertReorderSuggestion(ctx, s); err != nil {
+				return err
+			}
+			result.Suggested++
+		}
+
+		result.Obsolete, err = uc.repo.MarkStaleSuggestionsObsolete(ctx, merchantID, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// suggestedQuantity returns how much to order for a candidate, or 0 when incoming stock
+// already covers it. With a max stock level the order tops the location up to that level,
+// otherwise the fixed reorder quantity is used.
+func suggestedQuantity(c model.ReorderCandidate) float64 {
+	position := c.AvailableQuantity + c.IncomingQuantity
+	if position > c.ReorderPoint {
+		return 0
+	}
+	if c.MaxStockLevel != nil && *c.MaxStockLevel > 0 {
+		return *c.MaxStockLevel - position
+	}
+	if c.ReorderQuantity > 0 {
+		return c.ReorderQuantity
+	}
+	// No reorder quantity configured: at least get back above the reorder point.
+	return c.ReorderPoint - position + 1
+}
+
+func (uc *purchaseUseCase) ListReorderSuggestions(ctx context.Context, filters *dto.ReorderSuggestionFilters) ([]dto.ReorderSuggestionGroup, error) {
+	suggestions, err := uc.repo.FindReorderSuggestions(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	// Suggestions come sorted by supplier and store, so groups are contiguous.
+	groups := []dto.ReorderSuggestionGroup{}
+	for _, s := range suggestions {
+		n := len(groups)
+		if n == 0 || groupKey(groups[n-1].SupplierID, groups[n-1].StoreID) != groupKey(s.SupplierID, s.StoreID) {
+			groups = append(groups, dto.ReorderSuggestionGroup{SupplierID: s.SupplierID, StoreID: s.StoreID})
+			n++
+		}
+		groups[n-1].Suggestions = append(groups[n-1].Suggestions, s)
+	}
+	return groups, nil
+}
+
+// ConvertReorderSuggestions turns open suggestions into draft purchase orders, one per
+// supplier and receiving store.
+func (uc *purchaseUseCase) ConvertReorderSuggestions(ctx context.Context, input *dto.ConvertReorderSuggestionsInput) ([]model.PurchaseOrder, error) {
+	if len(input.SuggestionIDs) == 0 {
+		return nil, purchase.ErrNoSuggestions
+	}
+
+	var orders []model.PurchaseOrder
+	err := uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		suggestions, err := uc.repo.FindOpenSuggestionsForUpdate(ctx, input.MerchantID, input.SuggestionIDs)
+		if err != nil {
+			return err
+		}
+		if len(suggestions) != len(uniqueStrings(input.SuggestionIDs)) {
+			return purchase.ErrSuggestionsNotOpen
+		}
+
+		var keys []string
+		byKey := make(map[string][]*model.ReorderSuggestion)
+		for i := range suggestions {
+			s := &suggestions[i]
+			if s.SupplierID == nil {
+				if input.SupplierID == nil {
+					return purchase.ErrSuggestionNeedSupplier.With("SuggestionID", s.ID)
+				}
+				s.SupplierID = input.SupplierID
+			}
+			key := groupKey(s.SupplierID, s.StoreID)
+			if _, ok := byKey[key]; !ok {
+				keys = append(keys, key)
+			}
+			byKey[key] = append(byKey[key], s)
+		}
+
+		now := time.Now()
+		for _, key := range keys {
+			group := byKey[key]
+			items := make([]dto.PurchaseOrderItemInput, len(group))
+			for i, s := range group {
+				items[i] = dto.PurchaseOrderItemInput{
+					ProductID: s.ProductID,
+					VariantID: s.VariantID,
+					Quantity:  s.SuggestedQuantity,
+					UnitCost:  s.UnitCost,
+				}
+			}
+
+			po, err := uc.CreatePurchaseOrder(ctx, &dto.CreatePurchaseOrderInput{
+				MerchantID: input.MerchantID,
+				SupplierID: *group[0].SupplierID,
+				StoreID:    group[0].StoreID,
+				Notes:      "Created from reorder suggestions",
+				UserID:     input.UserID,
+				Items:      items,
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, s := range group {
+				s.Status = model.ReorderSuggestionStatusConverted
+				s.PurchaseOrderID = &po.ID
+				s.UpdatedAt = now
+				if err := uc.repo.UpdateSuggestionStatus(ctx, s); err != nil {
+					return err
+				}
+			}
+			orders = append(orders, *po)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	uc.logger.Info("Converted reorder suggestions",
+		zap.String("merchant_id", input.MerchantID),
+		zap.Int("suggestions", len(input.SuggestionIDs)),
+		zap.Int("purchase_orders", len(orders)),
+	)
+	return orders, nil
+}
+
+// DismissReorderSuggestion hides an open suggestion. Generation runs skip the location until
+// its available quantity or reorder settings change, or until the dismissal period passes.
+func (uc *purchaseUseCase) DismissReorderSuggestion(ctx context.Context, merchantID, id string) error {
+	return uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		suggestions, err := uc.repo.FindOpenSuggestionsForUpdate(ctx, merchantID, []string{id})
+		if err != nil {
+			return err
+		}
+		if len(suggestions) == 0 {
+			return purchase.ErrSuggestionNotOpen
+		}
+
+		now := time.Now()
+		s := &suggestions[0]
+		s.Status = model.ReorderSuggestionStatusDismissed
+		s.UpdatedAt = now
+		if uc.dismissFor > 0 {
+			until := now.Add(uc.dismissFor)
+			s.DismissedUntil = &until
+		}
+		return uc.repo.UpdateSuggestionStatus(ctx, s)
+	})
+}
+
+func groupKey(supplierID, storeID *string) string {
+	key := ""
+	if supplierID != nil {
+		key = *supplierID
+	}
+	key += "|"
+	if storeID != nil {
+		key += *storeID
+	}
+	return key
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,511 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/fekuna/omnipos-pkg/logger"
+	"github.com/fekuna/omnipos-product-service/internal/database"
+	"github.com/fekuna/omnipos-product-service/internal/event"
+	"github.com/fekuna/omnipos-product-service/internal/inventory"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/fekuna/omnipos-product-service/internal/outbox"
+	"github.com/fekuna/omnipos-product-service/internal/product"
+	"github.com/fekuna/omnipos-product-service/internal/purchase"
+	"github.com/fekuna/omnipos-product-service/internal/purchase/dto"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type purchaseUseCase struct {
+	repo       purchase.Repository
+	invRepo    inventory.Repository
+	prodRepo   product.Repository
+	tx         database.TxManager
+	outbox     *outbox.Writer
+	dismissFor time.Duration // how long a dismissed suggestion holds, 0 until its location changes
+	logger     logger.ZapLogger
+}
+
+func NewPurchaseUseCase(repo purchase.Repository, invRepo inventory.Repository, prodRepo product.Repository, tx database.TxManager, outbox *outbox.Writer, dismissFor time.Duration, log logger.ZapLogger) purchase.UseCase {
+	return &purchaseUseCase{
+		repo:       repo,
+		invRepo:    invRepo,
+		prodRepo:   prodRepo,
+		tx:         tx,
+		outbox:     outbox,
+		dismissFor: dismissFor,
+		logger:     log,
+	}
+}
+
+// --- Suppliers ---
+
+func (uc *purchaseUseCase) CreateSupplier(ctx context.Context, input *dto.CreateSupplierInput) (*model.Supplier, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, purchase.ErrSupplierNameRequired
+	}
+
+	now := time.Now()
+	s := &model.Supplier{
+		BaseModel:   model.BaseModel{ID: uuid.New().String(), CreatedAt: now, UpdatedAt: now},
+		MerchantID:  input.MerchantID,
+		Name:        name,
+		ContactName: input.ContactName,
+		Email:       input.Email,
+		Phone:       input.Phone,
+		Address:     input.Address,
+		IsActive:    true,
+	}
+
+	if err := uc.repo.CreateSupplier(ctx, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (uc *purchaseUseCase) ListSuppliers(ctx context.Context, filters *dto.SupplierFilters) ([]model.Supplier, int, error) {
+	return uc.repo.FindSuppliers(ctx, filters)
+}
+
+func (uc *purchaseUseCase) UpdateSupplier(ctx context.Context, input *dto.UpdateSupplierInput) (*model.Supplier, error) {
+	s, err := uc.repo.FindSupplierByID(ctx, input.MerchantID, input.ID)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, purchase.ErrSupplierNotFound
+	}
+
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, purchase.ErrSupplierNameRequired
+	}
+
+	s.Name = name
+	s.ContactName = input.ContactName
+	s.Email = input.Email
+	s.Phone = input.Phone
+	s.Address = input.Address
+	s.IsActive = input.IsActive
+	s.UpdatedAt = time.Now()
+
+	if err := uc.repo.UpdateSupplier(ctx, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// --- Purchase Orders ---
+
+func (uc *purchaseUseCase) CreatePurchaseOrder(ctx context.Context, input *dto.CreatePurchaseOrderInput) (*model.PurchaseOrder, error) {
+	if len(input.Items) == 0 {
+		return nil, purchase.ErrNoItems
+	}
+
+	supplier, err := uc.repo.FindSupplierByID(ctx, input.MerchantID, input.SupplierID)
+	if err != nil {
+		return nil, err
+	}
+	if supplier == nil {
+		return nil, purchase.ErrSupplierNotFound
+	}
+	if !supplier.IsActive {
+		return nil, purchase.ErrSupplierInactive
+	}
+
+	id := uuid.New().String()
+	now := time.Now()
+
+	poNumber := strings.TrimSpace(input.PONumber)
+	if poNumber == "" {
+		poNumber = fmt.Sprintf("PO-%s-%s", now.Format("20060102"), strings.ToUpper(id[:8]))
+	}
+
+	var createdBy *string
+	if input.UserID != "" {
+		createdBy = &input.UserID
+	}
+
+	po := &model.PurchaseOrder{
+		BaseModel:  model.BaseModel{ID: id, CreatedAt: now, UpdatedAt: now},
+		MerchantID: input.MerchantID,
+		SupplierID: supplier.ID,
+		StoreID:    input.StoreID,
+		PONumber:   poNumber,
+		Status:     model.PurchaseOrderStatusDraft,
+		Notes:      input.Notes,
+		ExpectedAt: input.ExpectedAt,
+		CreatedBy:  createdBy,
+	}
+
+	// One line per product/variant, duplicates are summed at the first line's cost.
+	index := make(map[string]int)
+	for _, in := range input.Items {
+		if in.ProductID == "" || in.Quantity <= 0 {
+			return nil, purchase.ErrInvalidItem
+		}
+		if in.UnitCost < 0 {
+			return nil, purchase.ErrNegativeUnitCost
+		}
+		key := in.ProductID
+		if in.VariantID != nil {
+			key += ":" + *in.VariantID
+		}
+		if i, ok := index[key]; ok {
+			po.Items[i].QuantityOrdered += in.Quantity
+			continue
+		}
+		owned, err := uc.invRepo.IsProductOwned(ctx, input.MerchantID, in.ProductID, in.VariantID)
+		if err != nil {
+			return nil, err
+		}
+		if !owned {
+			return nil, purchase.ErrProductNotFound.With("ProductID", in.ProductID)
+		}
+		index[key] = len(po.Items)
+		po.Items = append(po.Items, model.PurchaseOrderItem{
+			ID:              uuid.New().String(),
+			PurchaseOrderID: id,
+			ProductID:       in.ProductID,
+			VariantID:       in.VariantID,
+			QuantityOrdered: in.Quantity,
+			UnitCost:        in.UnitCost,
+		})
+	}
+
+	if err := uc.repo.CreatePurchaseOrder(ctx, po); err != nil {
+		return nil, err
+	}
+	return po, nil
+}
+
+func (uc *purchaseUseCase) GetPurchaseOrder(ctx context.Context, merchantID, id string) (*model.PurchaseOrder, error) {
+	po, err := uc.repo.FindPurchaseOrderByID(ctx, merchantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if po == nil {
+		return nil, purchase.ErrPurchaseOrderNotFound
+	}
+	return po, nil
+}
+
+func (uc *purchaseUseCase) ListPurchaseOrders(ctx context.Context, filters *dto.PurchaseOrderFilters) ([]model.PurchaseOrder, int, error) {
+	return uc.repo.FindPurchaseOrders(ctx, filters)
+}
+
+func (uc *purchaseUseCase) SubmitPurchaseOrder(ctx context.Context, merchantID, id string) (*model.PurchaseOrder, error) {
+	return uc.transition(ctx, merchantID, id, []string{model.PurchaseOrderStatusDraft}, func(ctx context.Context, po *model.PurchaseOrder, now time.Time) error {
+		po.Status = model.PurchaseOrderStatusOrdered
+		po.OrderedAt = &now
+		return nil
+	})
+}
+
+// ReceivePurchaseOrder books a delivery into the receiving store with one purchase movement
+// per line. Deliveries can arrive in parts; each call is stored as its own receipt.
+func (uc *purchaseUseCase) ReceivePurchaseOrder(ctx context.Context, input *dto.ReceivePurchaseOrderInput) (*dto.ReceivePurchaseOrderResult, error) {
+	if len(input.Items) == 0 {
+		return nil, purchase.ErrNoReceiptItems
+	}
+
+	method := model.CostingMethodManual
+	if input.UpdateCostPrice {
+		var err error
+		method, err = uc.repo.GetCostingMethod(ctx, input.MerchantID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var receipt *model.PurchaseReceipt
+
+	allowed := []string{model.PurchaseOrderStatusOrdered, model.PurchaseOrderStatusPartiallyReceived}
+	po, err := uc.transition(ctx, input.MerchantID, input.PurchaseOrderID, allowed, func(ctx context.Context, po *model.PurchaseOrder, now time.Time) error {
+		items := make(map[string]*model.PurchaseOrderItem, len(po.Items))
+		for i := range po.Items {
+			items[po.Items[i].ID] = &po.Items[i]
+		}
+
+		var receivedBy *string
+		if input.UserID != "" {
+			receivedBy = &input.UserID
+		}
+		receipt = &model.PurchaseReceipt{
+			ID:              uuid.New().String(),
+			MerchantID:      po.MerchantID,
+			PurchaseOrderID: po.ID,
+			Notes:           input.Notes,
+			ReceivedBy:      receivedBy,
+			CreatedAt:       now,
+		}
+
+		seen := make(map[string]bool, len(input.Items))
+		for _, in := range input.Items {
+			item, ok := items[in.ItemID]
+			if !ok {
+				return purchase.ErrUnknownItem.With("ItemID", in.ItemID)
+			}
+			if seen[in.ItemID] {
+				return purchase.ErrDuplicateItem.With("ItemID", in.ItemID)
+			}
+			seen[in.ItemID] = true
+			if in.Quantity <= 0 {
+				return purchase.ErrInvalidReceivedQty
+			}
+			if item.QuantityReceived+in.Quantity > item.QuantityOrdered {
+				return purchase.ErrOverReceipt.With("ProductID", item.ProductID).With("Ordered", item.QuantityOrdered)
+			}
+
+			unitCost := item.UnitCost
+			if in.UnitCost != nil {
+				if *in.UnitCost < 0 {
+					return purchase.ErrNegativeUnitCost
+				}
+				unitCost = *in.UnitCost
+			}
+
+			// The cost basis has to be read before the stock is added.
+			if method != model.CostingMethodManual {
+				if err := uc.applyCost(ctx, po.MerchantID, *item, in.Quantity, unitCost, method); err != nil {
+					return err
+				}
+			}
+
+			if err := uc.postPurchase(ctx, po, *item, in.Quantity, input.UserID, now); err != nil {
+				return err
+			}
+
+			item.QuantityReceived += in.Quantity
+			if err := uc.repo.UpdatePurchaseOrderItem(ctx, item); err != nil {
+				return err
+			}
+
+			receipt.Items = append(receipt.Items, model.PurchaseReceiptItem{
+				ID:                  uuid.New().String(),
+				ReceiptID:           receipt.ID,
+				PurchaseOrderItemID: item.ID,
+				Quantity:            in.Quantity,
+				UnitCost:            unitCost,
+			})
+		}
+
+		if err := uc.repo.CreateReceipt(ctx, receipt); err != nil {
+			return err
+		}
+
+		po.Status = model.PurchaseOrderStatusReceived
+		for _, item := range po.Items {
+			if item.QuantityReceived < item.QuantityOrdered {
+				po.Status = model.PurchaseOrderStatusPartiallyReceived
+				break
+			}
+		}
+		po.ReceivedAt = &now
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.ReceivePurchaseOrderResult{PurchaseOrder: po, Receipt: receipt}, nil
+}
+
+// ClosePurchaseOrder finalises a purchase order. Quantities that were never delivered stay
+// on the lines as ordered minus received; no stock is posted for them.
+func (uc *purchaseUseCase) ClosePurchaseOrder(ctx context.Context, merchantID, id string) (*model.PurchaseOrder, error) {
+	allowed := []string{model.PurchaseOrderStatusPartiallyReceived, model.PurchaseOrderStatusReceived}
+	return uc.transition(ctx, merchantID, id, allowed, func(ctx context.Context, po *model.PurchaseOrder, now time.Time) error {
+		po.Status = model.PurchaseOrderStatusClosed
+		po.ClosedAt = &now
+		return nil
+	})
+}
+
+func (uc *purchaseUseCase) CancelPurchaseOrder(ctx context.Context, merchantID, id string) (*model.PurchaseOrder, error) {
+	allowed := []string{model.PurchaseOrderStatusDraft, model.PurchaseOrderStatusOrdered}
+	return uc.transition(ctx, merchantID, id, allowed, func(ctx context.Context, po *model.PurchaseOrder, now time.Time) error {
+		po.Status = model.PurchaseOrderStatusCancelled
+		return nil
+	})
+}
+
+// --- Costing ---
+
+func (uc *purchaseUseCase) GetCostingMethod(ctx context.Context, merchantID string) (string, error) {
+	return uc.repo.GetCostingMethod(ctx, merchantID)
+}
+
+func (uc *purchaseUseCase) SetCostingMethod(ctx context.Context, merchantID, method string) error {
+	switch method {
+	case model.CostingMethodManual, model.CostingMethodLastCost, model.CostingMethodWeightedAverage:
+	default:
+		return purchase.ErrUnknownCostingMethod.With("Method", method)
+	}
+	return uc.repo.SetCostingMethod(ctx, merchantID, method)
+}
+
+// --- Helpers ---
+
+// transition locks the purchase order, checks it is in one of the allowed states and runs
+// apply and the status update in one transaction.
+func (uc *purchaseUseCase) transition(ctx context.Context, merchantID, id string, allowed []string, apply func(ctx context.Context, po *model.PurchaseOrder, now time.Time) error) (*model.PurchaseOrder, error) {
+	var result *model.PurchaseOrder
+	err := uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		po, err := uc.repo.FindPurchaseOrderByIDForUpdate(ctx, merchantID, id)
+		if err != nil {
+			return err
+		}
+		if po == nil {
+			return purchase.ErrPurchaseOrderNotFound
+		}
+
+		permitted := false
+		for _, s := range allowed {
+			if po.Status == s {
+				permitted = true
+				break
+			}
+		}
+		if !permitted {
+			return purchase.ErrWrongStatus.With("Status", po.Status)
+		}
+
+		now := time.Now()
+		if err := apply(ctx, po, now); err != nil {
+			return err
+		}
+		po.UpdatedAt = now
+		if err := uc.repo.UpdatePurchaseOrderStatus(ctx, po); err != nil {
+			return err
+		}
+
+		result = po
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// postPurchase adds qty of item to the receiving store and logs a purchase movement.
+func (uc *purchaseUseCase) postPurchase(ctx context.Context, po *model.PurchaseOrder, item model.PurchaseOrderItem, qty float64, userID string, now time.Time) error {
+	inv, err := uc.invRepo.GetByLocationForUpdate(ctx, po.MerchantID, item.ProductID, item.VariantID, po.StoreID)
+	if err != nil {
+		return err
+	}
+	if inv == nil {
+		inv = &model.Inventory{
+			ID:         uuid.New().String(),
+			MerchantID: po.MerchantID,
+			StoreID:    po.StoreID,
+			ProductID:  item.ProductID,
+			VariantID:  item.VariantID,
+		}
+	}
+
+	quantityBefore := inv.Quantity
+	inv.Quantity += qty
+	inv.AvailableQuantity += qty
+	inv.UpdatedAt = now
+
+	refType := "purchase_order"
+	refID := po.ID
+	var createdBy *string
+	if userID != "" {
+		createdBy = &userID
+	}
+
+	movement := &model.InventoryMovement{
+		ID:             uuid.New().String(),
+		MerchantID:     po.MerchantID,
+		StoreID:        po.StoreID,
+		ProductID:      item.ProductID,
+		VariantID:      item.VariantID,
+		MovementType:   model.MovementPurchase,
+		QuantityChange: qty,
+		QuantityBefore: quantityBefore,
+		QuantityAfter:  inv.Quantity,
+		ReferenceType:  &refType,
+		ReferenceID:    &refID,
+		Notes:          "Received on " + po.PONumber,
+		CreatedBy:      createdBy,
+		CreatedAt:      now,
+	}
+
+	return uc.invRepo.AdjustStockWithMovement(ctx, inv, movement)
+}
+
+// applyCost updates cost_price of the item's product or variant for a receipt of qty at unitCost.
+func (uc *purchaseUseCase) applyCost(ctx context.Context, merchantID string, item model.PurchaseOrderItem, qty, unitCost float64, method string) error {
+	basis, err := uc.repo.GetCostBasisForUpdate(ctx, merchantID, item.ProductID, item.VariantID)
+	if err != nil {
+		return err
+	}
+	if basis == nil {
+		return purchase.ErrProductNotFound.With("ProductID", item.ProductID)
+	}
+
+	cost := unitCost
+	if method == model.CostingMethodWeightedAverage {
+		cost = weightedAverageCost(basis, qty, unitCost)
+	}
+
+	uc.logger.Debug("Updating cost price from purchase receipt",
+		zap.String("product_id", item.ProductID),
+		zap.String("method", method),
+		zap.Float64("cost_price", cost),
+	)
+	if err := uc.repo.UpdateCostPrice(ctx, merchantID, item.ProductID, item.VariantID, cost); err != nil {
+		return err
+	}
+	return uc.recordCostChange(ctx, merchantID, item)
+}
+
+// recordCostChange queues the side effects of a new cost price in the outbox, in the
+// receipt's transaction, as a product or variant update does.
+func (uc *purchaseUseCase) recordCostChange(ctx context.Context, merchantID string, item model.PurchaseOrderItem) error {
+	p, err := uc.prodRepo.FindByID(ctx, merchantID, item.ProductID)
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return purchase.ErrProductNotFound.With("ProductID", item.ProductID)
+	}
+	if err := uc.outbox.InvalidateCache(ctx, event.AggregateProduct, p.ID, p.MerchantID, product.ListCachePattern(p.MerchantID)); err != nil {
+		return err
+	}
+
+	if item.VariantID == nil {
+		if err := uc.outbox.IndexDocument(ctx, event.AggregateProduct, p.MerchantID, product.SearchIndex, p.ID, p); err != nil {
+			return err
+		}
+		return uc.outbox.Publish(ctx, event.ForProduct(event.ProductUpdated, p))
+	}
+
+	v, err := uc.prodRepo.FindVariantByID(ctx, p.ID, *item.VariantID)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		return purchase.ErrProductNotFound.With("ProductID", item.ProductID)
+	}
+	return uc.outbox.Publish(ctx, event.ForVariant(event.VariantUpdated, p.MerchantID, v))
+}
+
+// weightedAverageCost averages the current cost over the on-hand quantity with the receipt.
+// Without stock or a known cost, the receipt's unit cost is used as is. cost_price defaults
+// to 0, so a cost of 0 counts as unknown rather than as free stock.
+func weightedAverageCost(basis *model.CostBasis, qty, unitCost float64) float64 {
+	if basis.CostPrice == nil || *basis.CostPrice <= 0 || basis.OnHand <= 0 {
+		return unitCost
+	}
+	avg := (basis.OnHand**basis.CostPrice + qty*unitCost) / (basis.OnHand + qty)
+	return math.Round(avg*100) / 100 // cost_price is DECIMAL(15,2)
+}
@@ -0,0 +1,34 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/fekuna/omnipos-product-service/internal/model"
+)
+
+func cost(v float64) *float64 { return &v }
+
+func TestWeightedAverageCost(t *testing.T) {
+	tests := []struct {
+		name     string
+		basis    model.CostBasis
+		qty      float64
+		unitCost float64
+		want     float64
+	}{
+		{"averaged with stock on hand", model.CostBasis{CostPrice: cost(4), OnHand: 10}, 10, 5, 4.5},
+		{"no stock on hand", model.CostBasis{CostPrice: cost(4), OnHand: 0}, 10, 5, 5},
+		{"negative stock on hand", model.CostBasis{CostPrice: cost(4), OnHand: -3}, 10, 5, 5},
+		{"no cost yet", model.CostBasis{OnHand: 10}, 10, 5, 5},
+		{"zero cost is unknown", model.CostBasis{CostPrice: cost(0), OnHand: 10}, 10, 5, 5},
+		{"rounded down to cents", model.CostBasis{CostPrice: cost(1), OnHand: 2}, 1, 1.01, 1},
+		{"rounded up to cents", model.CostBasis{CostPrice: cost(1), OnHand: 2}, 1, 1.02, 1.01},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := weightedAverageCost(&tt.basis, tt.qty, tt.unitCost); got != tt.want {
+				t.Errorf("weightedAverageCost = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/fekuna/omnipos-pkg/logger"
+	"github.com/fekuna/omnipos-product-service/internal/database"
+	"github.com/fekuna/omnipos-product-service/internal/purchase"
+	"go.uber.org/zap"
+)
+
+// ReorderJob periodically refreshes reorder suggestions for all merchants, so low stock
+// shows up for review without anyone having to ask for it.
+type ReorderJob struct {
+	uc       purchase.UseCase
+	interval time.Duration
+	logger   logger.ZapLogger
+}
+
+func NewReorderJob(uc purchase.UseCase, interval time.Duration, logger logger.ZapLogger) *ReorderJob {
+	return &ReorderJob{
+		uc:       uc,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+func (j *ReorderJob) Start(ctx context.Context) {
+	j.logger.Info("Starting Reorder Job", zap.Duration("interval", j.interval))
+	ctx = database.AsSystem(ctx) // runs across merchants
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			j.logger.Info("Stopping Reorder Job")
+			return
+		case <-ticker.C:
+			j.run(ctx)
+		}
+	}
+}
+
+func (j *ReorderJob) run(ctx context.Context) {
+	res, err := j.uc.GenerateReorderSuggestions(ctx, "")
+	if err != nil {
+		if ctx.Err() == nil {
+			j.logger.Error("Failed to generate reorder suggestions", zap.Error(err))
+		}
+		return
+	}
+	if res.Suggested > 0 || res.Obsolete > 0 {
+		j.logger.Info("Refreshed reorder suggestions",
+			zap.Int("suggested", res.Suggested),
+			zap.Int("obsolete", res.Obsolete),
+		)
+	}
+}
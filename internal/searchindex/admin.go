@@ -0,0 +1,28 @@
+package searchindex
+
+import "context"
+
+// Document is one search document to index.
+type Document struct {
+	ID     string
+	Source interface{}
+}
+
+// IndexAdmin manages search indexes and aliases, the part of Elasticsearch the reindex needs.
+type IndexAdmin interface {
+	CreateIndex(ctx context.Context, name, mapping string) error
+	DeleteIndex(ctx context.Context, name string) error
+	IndexExists(ctx context.Context, name string) (bool, error)
+
+	// AliasTargets returns the indexes behind an alias, none when there is no such alias.
+	AliasTargets(ctx context.Context, alias string) ([]string, error)
+	// SwapAlias points alias at index instead of remove in one atomic request. A concrete
+	// index named like the alias is deleted in the same request when dropIndex is set.
+	SwapAlias(ctx context.Context, alias, index string, remove []string, dropIndex bool) error
+
+	Bulk(ctx context.Context, index string, docs []Document) error
+	// CopyDocuments copies the documents of sources matching query into dest and returns how many.
+	CopyDocuments(ctx context.Context, sources []string, dest string, query map[string]interface{}) (int64, error)
+	DeleteDocuments(ctx context.Context, index string, ids []string) error
+	Refresh(ctx context.Context, index string) error
+}
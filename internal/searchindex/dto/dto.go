@@ -0,0 +1,26 @@
+package dto
+
+type ReindexInput struct {
+	MerchantID string // Empty rebuilds every merchant
+	BatchSize  int
+}
+
+// Reindex phases, in order
+const (
+	ReindexPhaseCreating = "creating" // New versioned index created
+	ReindexPhaseCopying  = "copying"  // Other merchants' documents copied from the live index
+	ReindexPhaseIndexing = "indexing" // Products loaded from Postgres
+	ReindexPhaseSwapping = "swapping" // Alias moved to the new index
+	ReindexPhaseCatchUp  = "catch_up" // Changes made during the rebuild applied
+	ReindexPhaseDone     = "done"
+)
+
+type ReindexProgress struct {
+	Index      string // The new versioned index
+	MerchantID string
+	Phase      string
+	Total      int   // Products to load from Postgres
+	Indexed    int   // Products loaded so far
+	Copied     int64 // Documents copied from the live index
+	CaughtUp   int   // Documents changed or deleted during the rebuild
+}
@@ -0,0 +1,235 @@
+package elastic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/fekuna/omnipos-product-service/internal/searchindex"
+)
+
+var _ searchindex.IndexAdmin = (*Client)(nil)
+
+// Client talks to the Elasticsearch REST API for the index administration the shared search
+// client doesn't cover. Requests go to the first address that answers.
+type Client struct {
+	addresses []string
+	username  string
+	password  string
+	http      *http.Client
+}
+
+func NewClient(addresses []string, username, password string) *Client {
+	return &Client{
+		addresses: addresses,
+		username:  username,
+		password:  password,
+		http:      &http.Client{Timeout: 10 * time.Minute}, // _reindex of a large index is slow
+	}
+}
+
+func (c *Client) CreateIndex(ctx context.Context, name, mapping string) error {
+	_, err := c.do(ctx, http.MethodPut, "/"+url.PathEscape(name), "application/json", []byte(mapping), nil)
+	return err
+}
+
+func (c *Client) DeleteIndex(ctx context.Context, name string) error {
+	_, err := c.do(ctx, http.MethodDelete, "/"+url.PathEscape(name), "", nil, nil, http.StatusNotFound)
+	return err
+}
+
+func (c *Client) IndexExists(ctx context.Context, name string) (bool, error) {
+	status, err := c.do(ctx, http.MethodHead, "/"+url.PathEscape(name), "", nil, nil, http.StatusNotFound)
+	if err != nil {
+		return false, err
+	}
+	return status == http.StatusOK, nil
+}
+
+func (c *Client) AliasTargets(ctx context.Context, alias string) ([]string, error) {
+	var resp map[string]interface{}
+	status, err := c.do(ctx, http.MethodGet, "/_alias/"+url.PathEscape(alias), "", nil, &resp, http.StatusNotFound)
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+
+	indexes := make([]string, 0, len(resp))
+	for index := range resp {
+		indexes = append(indexes, index)
+	}
+	return indexes, nil
+}
+
+func (c *Client) SwapAlias(ctx context.Context, alias, index string, remove []string, dropIndex bool) error {
+	actions := []map[string]interface{}{}
+	if dropIndex {
+		actions = append(actions, map[string]interface{}{"remove_index": map[string]string{"index": alias}})
+	}
+	for _, old := range remove {
+		actions = append(actions, map[string]interface{}{"remove": map[string]string{"index": old, "alias": alias}})
+	}
+	actions = append(actions, map[string]interface{}{"add": map[string]string{"index": index, "alias": alias}})
+
+	body, err := json.Marshal(map[string]interface{}{"actions": actions})
+	if err != nil {
+		return err
+	}
+	_, err = c.do(ctx, http.MethodPost, "/_aliases", "application/json", body, nil)
+	return err
+}
+
+func (c *Client) Bulk(ctx context.Context, index string, docs []searchindex.Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, doc := range docs {
+		action := map[string]interface{}{"index": map[string]string{"_index": index, "_id": doc.ID}}
+		if err := enc.Encode(action); err != nil {
+			return err
+		}
+		if err := enc.Encode(doc.Source); err != nil {
+			return fmt.Errorf("failed to encode document %s: %w", doc.ID, err)
+		}
+	}
+	return c.bulk(ctx, buf.Bytes())
+}
+
+func (c *Client) DeleteDocuments(ctx context.Context, index string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, id := range ids {
+		if err := enc.Encode(map[string]interface{}{"delete": map[string]string{"_index": index, "_id": id}}); err != nil {
+			return err
+		}
+	}
+	return c.bulk(ctx, buf.Bytes())
+}
+
+func (c *Client) bulk(ctx context.Context, body []byte) error {
+	var resp struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			ID     string          `json:"_id"`
+			Status int             `json:"status"`
+			Error  json.RawMessage `json:"error"`
+		} `json:"items"`
+	}
+	if _, err := c.do(ctx, http.MethodPost, "/_bulk", "application/x-ndjson", body, &resp); err != nil {
+		return err
+	}
+	if !resp.Errors {
+		return nil
+	}
+
+	for _, item := range resp.Items {
+		for op, result := range item {
+			// Deleting a document that is already gone is fine.
+			if op == "delete" && result.Status == http.StatusNotFound {
+				continue
+			}
+			if result.Status >= 300 {
+				return fmt.Errorf("bulk %s of %s failed: %s", op, result.ID, result.Error)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Client) CopyDocuments(ctx context.Context, sources []string, dest string, query map[string]interface{}) (int64, error) {
+	source := map[string]interface{}{"index": sources}
+	if query != nil {
+		source["query"] = query
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"source": source,
+		"dest":   map[string]string{"index": dest},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var resp struct {
+		Total    int64             `json:"total"`
+		Failures []json.RawMessage `json:"failures"`
+	}
+	if _, err := c.do(ctx, http.MethodPost, "/_reindex?wait_for_completion=true", "application/json", body, &resp); err != nil {
+		return 0, err
+	}
+	if len(resp.Failures) > 0 {
+		return 0, fmt.Errorf("copying documents to %s failed: %s", dest, resp.Failures[0])
+	}
+	return resp.Total, nil
+}
+
+func (c *Client) Refresh(ctx context.Context, index string) error {
+	_, err := c.do(ctx, http.MethodPost, "/"+url.PathEscape(index)+"/_refresh", "", nil, nil)
+	return err
+}
+
+// do sends a request and decodes the JSON response into out. Statuses listed in allowed are
+// returned without an error; any other status of 300 or more is an error.
+func (c *Client) do(ctx context.Context, method, path, contentType string, body []byte, out interface{}, allowed ...int) (int, error) {
+	if len(c.addresses) == 0 {
+		return 0, errors.New("no elasticsearch addresses configured")
+	}
+
+	var lastErr error
+	for _, address := range c.addresses {
+		req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(address, "/")+path, bytes.NewReader(body))
+		if err != nil {
+			return 0, err
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		if c.username != "" {
+			req.SetBasicAuth(c.username, c.password)
+		}
+
+		resp, err := c.http.Do(req)
+		if err != nil {
+			lastErr = err
+			continue // Try the next node
+		}
+		status, err := decodeResponse(resp, out, allowed)
+		return status, err
+	}
+	return 0, fmt.Errorf("elasticsearch unreachable: %w", lastErr)
+}
+
+func decodeResponse(resp *http.Response, out interface{}, allowed []int) (int, error) {
+	defer resp.Body.Close()
+
+	for _, status := range allowed {
+		if resp.StatusCode == status {
+			return resp.StatusCode, nil
+		}
+	}
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return resp.StatusCode, fmt.Errorf("elasticsearch %s: %s", resp.Status, msg)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, fmt.Errorf("failed to decode elasticsearch response: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
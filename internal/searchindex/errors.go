@@ -0,0 +1,9 @@
+package searchindex
+
+import "github.com/fekuna/omnipos-product-service/internal/apperror"
+
+var (
+	// ErrReindexRunning is returned when another rebuild of the same index holds the lock.
+	ErrReindexRunning  = apperror.New(apperror.FailedPrecondition, "REINDEX_RUNNING", "a rebuild of this index is already running")
+	ErrBatchSizeTooBig = apperror.Invalid("BATCH_SIZE_TOO_BIG", "batch_size", "batch size must be at most {{.Max}}")
+)
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/fekuna/omnipos-pkg/logger"
+	"github.com/fekuna/omnipos-product-service/internal/searchindex"
+	"github.com/fekuna/omnipos-product-service/internal/searchindex/dto"
+	productv1 "github.com/fekuna/omnipos-proto/gen/go/omnipos/product/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+var _ productv1.SearchIndexServiceServer = (*SearchIndexHandler)(nil)
+
+// SearchIndexHandler is an operator API; an empty merchant rebuilds every merchant.
+type SearchIndexHandler struct {
+	productv1.UnimplementedSearchIndexServiceServer
+	uc     searchindex.UseCase
+	logger logger.ZapLogger
+}
+
+func NewSearchIndexHandler(uc searchindex.UseCase, log logger.ZapLogger) *SearchIndexHandler {
+	return &SearchIndexHandler{
+		uc:     uc,
+		logger: log,
+	}
+}
+
+// ReindexProducts streams the progress of the rebuild. The rebuild carries on when the caller
+// goes away, since stopping halfway would only throw the new index away.
+func (h *SearchIndexHandler) ReindexProducts(req *productv1.ReindexProductsRequest, stream grpc.ServerStreamingServer[productv1.ReindexProductsProgress]) error {
+	ctx := context.WithoutCancel(stream.Context())
+
+	input := &dto.ReindexInput{
+		MerchantID: req.MerchantId,
+		BatchSize:  int(req.BatchSize),
+	}
+
+	var sendErr error
+	report := func(p dto.ReindexProgress) {
+		if sendErr == nil {
+			sendErr = stream.Send(mapProgressToProto(&p))
+		}
+	}
+
+	if _, err := h.uc.ReindexProducts(ctx, input, report); err != nil {
+		h.logger.Error("failed to rebuild product index", zap.String("merchant_id", req.MerchantId), zap.Error(err))
+		return err
+	}
+	if sendErr != nil {
+		h.logger.Warn("Product index rebuilt, but the caller stopped listening", zap.Error(sendErr))
+	}
+	return nil
+}
+
+func mapProgressToProto(p *dto.ReindexProgress) *productv1.ReindexProductsProgress {
+	return &productv1.ReindexProductsProgress{
+		Index:      p.Index,
+		MerchantId: p.MerchantID,
+		Phase:      p.Phase,
+		Total:      int32(p.Total),
+		Indexed:    int32(p.Indexed),
+		Copied:     p.Copied,
+		CaughtUp:   int32(p.CaughtUp),
+	}
+}
@@ -0,0 +1,12 @@
+package searchindex
+
+import (
+	"context"
+
+	"github.com/fekuna/omnipos-product-service/internal/searchindex/dto"
+)
+
+type UseCase interface {
+	// ReindexProducts rebuilds the product index, calling report after every step.
+	ReindexProducts(ctx context.Context, input *dto.ReindexInput, report func(dto.ReindexProgress)) (*dto.ReindexProgress, error)
+}
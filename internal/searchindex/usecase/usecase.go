@@ -0,0 +1,232 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fekuna/omnipos-pkg/cache"
+	"github.com/fekuna/omnipos-pkg/logger"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/fekuna/omnipos-product-service/internal/outbox"
+	"github.com/fekuna/omnipos-product-service/internal/product"
+	"github.com/fekuna/omnipos-product-service/internal/searchindex"
+	"github.com/fekuna/omnipos-product-service/internal/searchindex/dto"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultBatchSize = 500
+	maxBatchSize     = 5000
+
+	// reindexLockTTL bounds how long a crashed rebuild blocks the next one.
+	reindexLockTTL = 2 * time.Hour
+)
+
+type searchIndexUseCase struct {
+	products product.Repository
+	outbox   outbox.Repository
+	admin    searchindex.IndexAdmin
+	cache    *cache.RedisClient
+	logger   logger.ZapLogger
+}
+
+func NewSearchIndexUseCase(products product.Repository, outbox outbox.Repository, admin searchindex.IndexAdmin, cache *cache.RedisClient, log logger.ZapLogger) searchindex.UseCase {
+	return &searchIndexUseCase{
+		products: products,
+		outbox:   outbox,
+		admin:    admin,
+		cache:    cache,
+		logger:   log,
+	}
+}
+
+// ReindexProducts builds a new versioned index from Postgres and moves the products alias to
+// it in one atomic request, so searches never see a half-built index. For a single merchant
+// the other merchants' documents are copied over from the live index first. Writes made while
+// the index is built still go to the old index, so once the alias has moved, products changed
+// or deleted since the start are applied again from Postgres and the outbox.
+func (uc *searchIndexUseCase) ReindexProducts(ctx context.Context, input *dto.ReindexInput, report func(dto.ReindexProgress)) (*dto.ReindexProgress, error) {
+	batchSize := input.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	if batchSize > maxBatchSize {
+		return nil, searchindex.ErrBatchSizeTooBig.With("Max", maxBatchSize)
+	}
+
+	alias := product.SearchIndex
+
+	// Two rebuilds would both move the alias and delete each other's index.
+	lockKey := "searchindex:reindex:" + alias
+	lockValue := uuid.New().String()
+	acquired, err := uc.cache.AcquireLock(ctx, lockKey, lockValue, reindexLockTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock the %s index: %w", alias, err)
+	}
+	if !acquired {
+		return nil, searchindex.ErrReindexRunning
+	}
+	defer uc.cache.ReleaseLock(context.WithoutCancel(ctx), lockKey, lockValue)
+
+	started := time.Now()
+	progress := &dto.ReindexProgress{
+		Index:      fmt.Sprintf("%s_v%s", alias, started.UTC().Format("20060102150405")),
+		MerchantID: input.MerchantID,
+	}
+	step := func(phase string) {
+		progress.Phase = phase
+		if report != nil {
+			report(*progress)
+		}
+	}
+
+	total, err := uc.products.CountForIndexing(ctx, input.MerchantID, nil)
+	if err != nil {
+		return nil, err
+	}
+	progress.Total = total
+
+	// Find what the alias points at now. Before the first rebuild, products is a plain index.
+	live, err := uc.admin.AliasTargets(ctx, alias)
+	if err != nil {
+		return nil, err
+	}
+	dropPlainIndex := false
+	if len(live) == 0 {
+		exists, err := uc.admin.IndexExists(ctx, alias)
+		if err != nil {
+			return nil, err
+		}
+		dropPlainIndex = exists
+	}
+
+	if err := uc.admin.CreateIndex(ctx, progress.Index, product.SearchIndexMapping); err != nil {
+		return nil, fmt.Errorf("failed to create index %s: %w", progress.Index, err)
+	}
+	step(dto.ReindexPhaseCreating)
+
+	err = uc.build(ctx, input, progress, live, dropPlainIndex, batchSize, step)
+	if err == nil {
+		if err = uc.admin.SwapAlias(ctx, alias, progress.Index, live, dropPlainIndex); err != nil {
+			err = fmt.Errorf("failed to move alias %s: %w", alias, err)
+		}
+	}
+	if err != nil {
+		// The alias hasn't moved, the half-built index can go.
+		if delErr := uc.admin.DeleteIndex(context.WithoutCancel(ctx), progress.Index); delErr != nil {
+			uc.logger.Warn("Failed to delete abandoned index", zap.String("index", progress.Index), zap.Error(delErr))
+		}
+		return nil, err
+	}
+	step(dto.ReindexPhaseSwapping)
+
+	// From here on the new index is live: failures are reported, not rolled back.
+	for _, old := range live {
+		if err := uc.admin.DeleteIndex(ctx, old); err != nil {
+			uc.logger.Warn("Failed to delete old index", zap.String("index", old), zap.Error(err))
+		}
+	}
+
+	if err := uc.catchUp(ctx, progress, started, batchSize); err != nil {
+		return nil, fmt.Errorf("index %s is live but changes made during the rebuild were not applied: %w", progress.Index, err)
+	}
+	step(dto.ReindexPhaseCatchUp)
+
+	step(dto.ReindexPhaseDone)
+	uc.logger.Info("Product index rebuilt",
+		zap.String("index", progress.Index),
+		zap.String("merchant_id", input.MerchantID),
+		zap.Int("indexed", progress.Indexed),
+		zap.Int64("copied", progress.Copied),
+		zap.Int("caught_up", progress.CaughtUp),
+		zap.Duration("took", time.Since(started)),
+	)
+	return progress, nil
+}
+
+// build fills the new index: other merchants' documents from the live index, then products from Postgres.
+func (uc *searchIndexUseCase) build(ctx context.Context, input *dto.ReindexInput, progress *dto.ReindexProgress, live []string, dropPlainIndex bool, batchSize int, step func(string)) error {
+	sources := live
+	if dropPlainIndex {
+		sources = []string{product.SearchIndex}
+	}
+	if input.MerchantID != "" && len(sources) > 0 {
+		query := map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must_not": map[string]interface{}{
+					"term": map[string]interface{}{"merchant_id": input.MerchantID},
+				},
+			},
+		}
+		copied, err := uc.admin.CopyDocuments(ctx, sources, progress.Index, query)
+		if err != nil {
+			return err
+		}
+		progress.Copied = copied
+		step(dto.ReindexPhaseCopying)
+	}
+
+	err := uc.eachBatch(ctx, input.MerchantID, nil, batchSize, func(products []model.Product) error {
+		if err := uc.admin.Bulk(ctx, progress.Index, documents(products)); err != nil {
+			return err
+		}
+		progress.Indexed += len(products)
+		step(dto.ReindexPhaseIndexing)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return uc.admin.Refresh(ctx, progress.Index)
+}
+
+// catchUp applies the products changed and deleted since the rebuild started to the live index.
+// It covers every merchant: documents copied for a single-merchant rebuild can be stale too.
+func (uc *searchIndexUseCase) catchUp(ctx context.Context, progress *dto.ReindexProgress, since time.Time, batchSize int) error {
+	err := uc.eachBatch(ctx, "", &since, batchSize, func(products []model.Product) error {
+		progress.CaughtUp += len(products)
+		return uc.admin.Bulk(ctx, progress.Index, documents(products))
+	})
+	if err != nil {
+		return err
+	}
+
+	deleted, err := uc.outbox.FindSearchDeletes(ctx, product.SearchIndex, since)
+	if err != nil {
+		return err
+	}
+	progress.CaughtUp += len(deleted)
+	return uc.admin.DeleteDocuments(ctx, progress.Index, deleted)
+}
+
+func (uc *searchIndexUseCase) eachBatch(ctx context.Context, merchantID string, since *time.Time, batchSize int, fn func([]model.Product) error) error {
+	afterID := ""
+	for {
+		products, err := uc.products.FindForIndexing(ctx, merchantID, since, afterID, batchSize)
+		if err != nil {
+			return err
+		}
+		if len(products) == 0 {
+			return nil
+		}
+		if err := fn(products); err != nil {
+			return err
+		}
+		if len(products) < batchSize {
+			return nil
+		}
+		afterID = products[len(products)-1].ID
+	}
+}
+
+// documents indexes products the same way the outbox relay does, as the product model.
+func documents(products []model.Product) []searchindex.Document {
+	docs := make([]searchindex.Document, len(products))
+	for i := range products {
+		docs[i] = searchindex.Document{ID: products[i].ID, Source: &products[i]}
+	}
+	return docs
+}
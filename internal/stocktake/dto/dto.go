@@ -0,0 +1,27 @@
+package dto
+
+import "github.com/fekuna/omnipos-product-service/internal/model"
+
+type StocktakeFilters struct {
+	MerchantID string
+	StoreID    *string
+	Status     string
+	Page       int
+	PageSize   int
+}
+
+type VarianceLine struct {
+	ItemID           string
+	ProductID        string
+	VariantID        *string
+	ExpectedQuantity float64  // Snapshot plus movements up to the count
+	CountedQuantity  *float64 // Nil when no device counted the item
+	VarianceQuantity float64  // Counted minus expected, 0 for uncounted items
+}
+
+type VarianceReport struct {
+	Stocktake      *model.Stocktake
+	Lines          []VarianceLine
+	CountedItems   int
+	UncountedItems int
+}
@@ -0,0 +1,32 @@
+package dto
+
+type StartStocktakeInput struct {
+	MerchantID string
+	StoreID    *string
+	Scope      string  // 'full', 'category' or 'cycle'
+	CategoryID *string // Required for the category scope
+	SampleSize int     // Required for the cycle scope
+	Notes      string
+	UserID     string
+}
+
+type RecordCountsInput struct {
+	MerchantID  string
+	StocktakeID string
+	DeviceID    string
+	UserID      string
+	Counts      []CountInput
+}
+
+type CountInput struct {
+	ProductID string
+	VariantID *string
+	Quantity  float64 // Replaces the previous count of this device for the item
+}
+
+type ApproveStocktakeInput struct {
+	MerchantID    string
+	StocktakeID   string
+	UserID        string
+	ZeroUncounted bool // Book uncounted items as counted at zero instead of leaving them untouched
+}
@@ -0,0 +1,16 @@
+package stocktake
+
+import "github.com/fekuna/omnipos-product-service/internal/apperror"
+
+var (
+	ErrStocktakeNotFound  = apperror.New(apperror.NotFound, "STOCKTAKE_NOT_FOUND", "stocktake not found")
+	ErrWrongStatus        = apperror.New(apperror.FailedPrecondition, "STOCKTAKE_WRONG_STATUS", "stocktake is {{.Status}}")
+	ErrCategoryRequired   = apperror.Invalid("STOCKTAKE_CATEGORY_REQUIRED", "category_id", "category is required for a category stocktake")
+	ErrInvalidSampleSize  = apperror.Invalid("INVALID_SAMPLE_SIZE", "sample_size", "sample size must be between 1 and {{.Max}}")
+	ErrUnknownScope       = apperror.Invalid("UNKNOWN_STOCKTAKE_SCOPE", "scope", `unknown stocktake scope "{{.Scope}}"`)
+	ErrDeviceIDRequired   = apperror.Invalid("DEVICE_ID_REQUIRED", "device_id", "device id is required")
+	ErrNoCounts           = apperror.Invalid("NO_COUNTS", "counts", "no counts to record")
+	ErrNegativeCount      = apperror.Invalid("NEGATIVE_COUNT", "counts.quantity", "counted quantity cannot be negative")
+	ErrProductNotInScope  = apperror.Invalid("PRODUCT_NOT_IN_STOCKTAKE", "counts.product_id", "product {{.ProductID}} is not part of this stocktake")
+	ErrVarianceBelowHolds = apperror.New(apperror.FailedPrecondition, "VARIANCE_BELOW_RESERVED", "adjusting product {{.ProductID}} by {{.Variance}} would leave less stock than is reserved")
+)
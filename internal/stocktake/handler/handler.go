@@ -0,0 +1,242 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/fekuna/omnipos-pkg/logger"
+	"github.com/fekuna/omnipos-product-service/internal/auth"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/fekuna/omnipos-product-service/internal/stocktake"
+	"github.com/fekuna/omnipos-product-service/internal/stocktake/dto"
+	productv1 "github.com/fekuna/omnipos-proto/gen/go/omnipos/product/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var _ productv1.StocktakeServiceServer = (*StocktakeHandler)(nil)
+
+type StocktakeHandler struct {
+	productv1.UnimplementedStocktakeServiceServer
+	uc     stocktake.UseCase
+	logger logger.ZapLogger
+}
+
+func NewStocktakeHandler(uc stocktake.UseCase, log logger.ZapLogger) *StocktakeHandler {
+	return &StocktakeHandler{
+		uc:     uc,
+		logger: log,
+	}
+}
+
+func (h *StocktakeHandler) StartStocktake(ctx context.Context, req *productv1.StartStocktakeRequest) (*productv1.StocktakeResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+	if merchantID == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing merchant context")
+	}
+
+	input := &dto.StartStocktakeInput{
+		MerchantID: merchantID,
+		StoreID:    optionalString(req.StoreId),
+		Scope:      req.Scope,
+		CategoryID: optionalString(req.CategoryId),
+		SampleSize: int(req.SampleSize),
+		Notes:      req.Notes,
+		UserID:     auth.GetUserID(ctx),
+	}
+
+	st, err := h.uc.StartStocktake(ctx, input)
+	if err != nil {
+		h.logger.Error("failed to start stocktake", zap.Error(err))
+		return nil, err
+	}
+
+	return &productv1.StocktakeResponse{Stocktake: mapStocktakeToProto(st)}, nil
+}
+
+func (h *StocktakeHandler) GetStocktake(ctx context.Context, req *productv1.GetStocktakeRequest) (*productv1.StocktakeResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+
+	st, err := h.uc.GetStocktake(ctx, merchantID, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &productv1.StocktakeResponse{Stocktake: mapStocktakeToProto(st)}, nil
+}
+
+func (h *StocktakeHandler) ListStocktakes(ctx context.Context, req *productv1.ListStocktakesRequest) (*productv1.ListStocktakesResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+
+	filters := &dto.StocktakeFilters{
+		MerchantID: merchantID,
+		StoreID:    optionalString(req.StoreId),
+		Status:     req.Status,
+		Page:       int(req.Page),
+		PageSize:   int(req.PageSize),
+	}
+
+	stocktakes, count, err := h.uc.ListStocktakes(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	protos := make([]*productv1.Stocktake, len(stocktakes))
+	for i, st := range stocktakes {
+		protos[i] = mapStocktakeToProto(&st)
+	}
+
+	return &productv1.ListStocktakesResponse{
+		Stocktakes: protos,
+		Total:      int32(count),
+	}, nil
+}
+
+func (h *StocktakeHandler) RecordStocktakeCounts(ctx context.Context, req *productv1.RecordStocktakeCountsRequest) (*productv1.RecordStocktakeCountsResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+
+	counts := make([]dto.CountInput, len(req.Counts))
+	for i, c := range req.Counts {
+		counts[i] = dto.CountInput{
+			ProductID: c.ProductId,
+			VariantID: optionalString(c.VariantId),
+			Quantity:  c.Quantity,
+		}
+	}
+
+	input := &dto.RecordCountsInput{
+		MerchantID:  merchantID,
+		StocktakeID: req.Id,
+		DeviceID:    req.DeviceId,
+		UserID:      auth.GetUserID(ctx),
+		Counts:      counts,
+	}
+
+	if err := h.uc.RecordCounts(ctx, input); err != nil {
+		return nil, err
+	}
+
+	return &productv1.RecordStocktakeCountsResponse{Success: true}, nil
+}
+
+func (h *StocktakeHandler) GetStocktakeVariances(ctx context.Context, req *productv1.GetStocktakeVariancesRequest) (*productv1.StocktakeVarianceResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+
+	report, err := h.uc.GetVariances(ctx, merchantID, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapReportToProto(report), nil
+}
+
+func (h *StocktakeHandler) ApproveStocktake(ctx context.Context, req *productv1.ApproveStocktakeRequest) (*productv1.StocktakeVarianceResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+
+	input := &dto.ApproveStocktakeInput{
+		MerchantID:    merchantID,
+		StocktakeID:   req.Id,
+		UserID:        auth.GetUserID(ctx),
+		ZeroUncounted: req.ZeroUncounted,
+	}
+
+	report, err := h.uc.ApproveStocktake(ctx, input)
+	if err != nil {
+		h.logger.Error("failed to approve stocktake", zap.String("stocktake_id", req.Id), zap.Error(err))
+		return nil, err
+	}
+
+	return mapReportToProto(report), nil
+}
+
+func (h *StocktakeHandler) CancelStocktake(ctx context.Context, req *productv1.CancelStocktakeRequest) (*productv1.StocktakeResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+
+	st, err := h.uc.CancelStocktake(ctx, merchantID, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &productv1.StocktakeResponse{Stocktake: mapStocktakeToProto(st)}, nil
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func optionalTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func mapStocktakeToProto(m *model.Stocktake) *productv1.Stocktake {
+	if m == nil {
+		return nil
+	}
+
+	items := make([]*productv1.StocktakeItem, len(m.Items))
+	for i, item := range m.Items {
+		items[i] = &productv1.StocktakeItem{
+			Id:               item.ID,
+			InventoryId:      item.InventoryID,
+			ProductId:        item.ProductID,
+			VariantId:        stringValue(item.VariantID),
+			ExpectedQuantity: item.ExpectedQuantity,
+			CountedQuantity:  item.CountedQuantity,
+			VarianceQuantity: item.VarianceQuantity,
+		}
+	}
+
+	return &productv1.Stocktake{
+		Id:         m.ID,
+		MerchantId: m.MerchantID,
+		StoreId:    stringValue(m.StoreID),
+		Scope:      m.Scope,
+		CategoryId: stringValue(m.CategoryID),
+		SampleSize: int32(m.SampleSize),
+		Status:     m.Status,
+		Notes:      m.Notes,
+		CreatedBy:  stringValue(m.CreatedBy),
+		ApprovedBy: stringValue(m.ApprovedBy),
+		StartedAt:  timestamppb.New(m.StartedAt),
+		ApprovedAt: optionalTimestamp(m.ApprovedAt),
+		CreatedAt:  timestamppb.New(m.CreatedAt),
+		UpdatedAt:  timestamppb.New(m.UpdatedAt),
+		Items:      items,
+	}
+}
+
+func mapReportToProto(r *dto.VarianceReport) *productv1.StocktakeVarianceResponse {
+	lines := make([]*productv1.StocktakeVarianceLine, len(r.Lines))
+	for i, l := range r.Lines {
+		lines[i] = &productv1.StocktakeVarianceLine{
+			ItemId:           l.ItemID,
+			ProductId:        l.ProductID,
+			VariantId:        stringValue(l.VariantID),
+			ExpectedQuantity: l.ExpectedQuantity,
+			CountedQuantity:  l.CountedQuantity,
+			VarianceQuantity: l.VarianceQuantity,
+		}
+	}
+
+	return &productv1.StocktakeVarianceResponse{
+		Stocktake:      mapStocktakeToProto(r.Stocktake),
+		Lines:          lines,
+		CountedItems:   int32(r.CountedItems),
+		UncountedItems: int32(r.UncountedItems),
+	}
+}
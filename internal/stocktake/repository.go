@@ -0,0 +1,27 @@
+package stocktake
+
+import (
+	"context"
+	"time"
+
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/fekuna/omnipos-product-service/internal/stocktake/dto"
+)
+
+type Repository interface {
+	// Create stores the stocktake and snapshots the inventory rows in its scope.
+	Create(ctx context.Context, stocktake *model.Stocktake) error
+	FindByID(ctx context.Context, merchantID, id string) (*model.Stocktake, error)
+	FindAll(ctx context.Context, filters *dto.StocktakeFilters) ([]model.Stocktake, int, error)
+
+	UpsertCount(ctx context.Context, count *model.StocktakeCount) error
+	// FindLines sums the counts of each item and the movements between the snapshot and the
+	// item's last count, those whose transaction the item's snapshot did not see. Uncounted
+	// items replay movements up to asOf.
+	FindLines(ctx context.Context, stocktakeID string, asOf time.Time) ([]model.StocktakeLine, error)
+
+	// Used inside a transaction on approval
+	FindByIDForUpdate(ctx context.Context, merchantID, id string) (*model.Stocktake, error)
+	UpdateStatus(ctx context.Context, stocktake *model.Stocktake) error
+	UpdateItemResult(ctx context.Context, item *model.StocktakeItem) error
+}
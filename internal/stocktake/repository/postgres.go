@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fekuna/omnipos-product-service/internal/database"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/fekuna/omnipos-product-service/internal/stocktake/dto"
+	"github.com/jmoiron/sqlx"
+)
+
+type PGRepository struct {
+	DB *sqlx.DB
+}
+
+func NewPGRepository(db *sqlx.DB) *PGRepository {
+	return &PGRepository{DB: db}
+}
+
+// conn returns the transaction started by the TxManager if ctx carries one.
+func (r *PGRepository) conn(ctx context.Context) database.DBTX {
+	return database.Conn(ctx, r.DB)
+}
+
+func (r *PGRepository) Create(ctx context.Context, st *model.Stocktake) error {
+	return database.RunInTx(ctx, r.DB, func(ctx context.Context) error {
+		query := `
+            INSERT INTO stocktakes (
+                id, merchant_id, store_id, scope, category_id, sample_size, status, notes,
+                started_at, approved_at, created_by, approved_by, created_at, updated_at
+            )
+            VALUES (
+                :id, :merchant_id, :store_id, :scope, :category_id, :sample_size, :status, :notes,
+                :started_at, :approved_at, :created_by, :approved_by, :created_at, :updated_at
+            )
+        `
+		if _, err := r.conn(ctx).NamedExecContext(ctx, query, st); err != nil {
+			return fmt.Errorf("failed to create stocktake: %w", err)
+		}
+
+		// Snapshot the quantity on the books of every tracked location in scope, with the
+		// database snapshot it was read in to tell later movements from the ones it includes.
+		snapshot := `
+            INSERT INTO stocktake_items (id, stocktake_id, inventory_id, product_id, variant_id, expected_quantity, book_snapshot)
+            SELECT gen_random_uuid(), $1, i.id, i.product_id, i.variant_id, i.quantity, pg_current_snapshot()
+            FROM inventory i
+            JOIN products p ON p.id = i.product_id
+            WHERE i.merchant_id = $2 AND i.store_id IS NOT DISTINCT FROM $3
+                AND p.track_inventory = TRUE
+        `
+		args := []interface{}{st.ID, st.MerchantID, st.StoreID}
+
+		switch st.Scope {
+		case model.StocktakeScopeCategory:
+			snapshot = `
+            WITH RECURSIVE scope_categories AS (
+                SELECT id FROM categories WHERE id = $4 AND merchant_id = $2
+                UNION ALL
+                SELECT c.id FROM categories c JOIN scope_categories sc ON c.parent_id = sc.id
+            )` + snapshot + ` AND p.category_id IN (SELECT id FROM scope_categories)`
+			args = append(args, st.CategoryID)
+		case model.StocktakeScopeCycle:
+			snapshot += ` ORDER BY random() LIMIT $4`
+			args = append(args, st.SampleSize)
+		}
+
+		if _, err := r.conn(ctx).ExecContext(ctx, snapshot, args...); err != nil {
+			return fmt.Errorf("failed to snapshot inventory: %w", err)
+		}
+
+		items := []model.StocktakeItem{}
+		itemQuery := `SELECT * FROM stocktake_items WHERE stocktake_id = $1 ORDER BY product_id, variant_id NULLS FIRST`
+		if err := r.conn(ctx).SelectContext(ctx, &items, itemQuery, st.ID); err != nil {
+			return err
+		}
+		st.Items = items
+		return nil
+	})
+}
+
+func (r *PGRepository) FindByID(ctx context.Context, merchantID, id string) (*model.Stocktake, error) {
+	return r.findByID(ctx, merchantID, id, "")
+}
+
+func (r *PGRepository) FindByIDForUpdate(ctx context.Context, merchantID, id string) (*model.Stocktake, error) {
+	return r.findByID(ctx, merchantID, id, " FOR UPDATE")
+}
+
+func (r *PGRepository) findByID(ctx context.Context, merchantID, id, lockClause string) (*model.Stocktake, error) {
+	var st model.Stocktake
+	query := `SELECT * FROM stocktakes WHERE id = $1 AND merchant_id = $2` + lockClause
+	err := r.conn(ctx).GetContext(ctx, &st, query, id, merchantID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	items := []model.StocktakeItem{}
+	itemQuery := `SELECT * FROM stocktake_items WHERE stocktake_id = $1 ORDER BY product_id, variant_id NULLS FIRST`
+	if err := r.conn(ctx).SelectContext(ctx, &items, itemQuery, st.ID); err != nil {
+		return nil, err
+	}
+	st.Items = items
+
+	return &st, nil
+}
+
+func (r *PGRepository) FindAll(ctx context.Context, f *dto.StocktakeFilters) ([]model.Stocktake, int, error) {
+	var stocktakes []model.Stocktake
+	var count int
+
+	conditions := []string{"merchant_id = :merchant_id"}
+	args := map[string]interface{}{"merchant_id": f.MerchantID}
+	if f.StoreID != nil {
+		if *f.StoreID == "" {
+			conditions = append(conditions, "store_id IS NULL")
+		} else {
+			conditions = append(conditions, "store_id = :store_id")
+			args["store_id"] = *f.StoreID
+		}
+	}
+	if f.Status != "" {
+		conditions = append(conditions, "status = :status")
+		args["status"] = f.Status
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	countQuery := "SELECT count(*) FROM stocktakes" + whereClause
+	if err := database.NamedGet(ctx, r.conn(ctx), &count, countQuery, args); err != nil {
+		return nil, 0, err
+	}
+
+	query := "SELECT * FROM stocktakes" + whereClause + " ORDER BY created_at DESC"
+	if f.PageSize > 0 {
+		offset := (f.Page - 1) * f.PageSize
+		query += fmt.Sprintf(" LIMIT %d OFFSET %d", f.PageSize, offset)
+	}
+
+	err := database.NamedSelect(ctx, r.conn(ctx), &stocktakes, query, args)
+	return stocktakes, count, err
+}
+
+func (r *PGRepository) UpsertCount(ctx context.Context, c *model.StocktakeCount) error {
+	query := `
+        INSERT INTO stocktake_counts (id, stocktake_item_id, device_id, quantity, counted_by, counted_at)
+        VALUES (:id, :stocktake_item_id, :device_id, :quantity, :counted_by, :counted_at)
+        ON CONFLICT (stocktake_item_id, device_id)
+        DO UPDATE SET
+            quantity = EXCLUDED.quantity,
+            counted_by = EXCLUDED.counted_by,
+            counted_at = EXCLUDED.counted_at
+    `
+	_, err := r.conn(ctx).NamedExecContext(ctx, query, c)
+	return err
+}
+
+func (r *PGRepository) FindLines(ctx context.Context, stocktakeID string, asOf time.Time) ([]model.StocktakeLine, error) {
+	query := `
+        SELECT si.id AS item_id, si.inventory_id, si.product_id, si.variant_id, si.expected_quantity,
+            c.counted_quantity, c.last_counted_at,
+            COALESCE(m.change, 0) AS movement_quantity
+        FROM stocktake_items si
+        JOIN stocktakes s ON s.id = si.stocktake_id
+        LEFT JOIN LATERAL (
+            SELECT SUM(quantity) AS counted_quantity, MAX(counted_at) AS last_counted_at
+            FROM stocktake_counts
+            WHERE stocktake_item_id = si.id
+        ) c ON TRUE
+        LEFT JOIN LATERAL (
+            SELECT SUM(im.quantity_change) AS change
+            FROM inventory_movements im
+            WHERE im.merchant_id = s.merchant_id
+                AND im.store_id IS NOT DISTINCT FROM s.store_id
+                AND im.product_id = si.product_id
+                AND im.variant_id IS NOT DISTINCT FROM si.variant_id
+                AND im.movement_type <> 'transit_loss' -- logged without changing on-hand
+                AND CASE WHEN si.book_snapshot IS NULL THEN im.created_at > s.started_at
+                    ELSE NOT pg_visible_in_snapshot(im.change_xid, si.book_snapshot) END
+                AND im.created_at <= COALESCE(c.last_counted_at, $2)
+        ) m ON TRUE
+        WHERE si.stocktake_id = $1
+        ORDER BY si.product_id, si.variant_id NULLS FIRST
+    `
+	lines := []model.StocktakeLine{}
+	err := r.conn(ctx).SelectContext(ctx, &lines, query, stocktakeID, asOf)
+	return lines, err
+}
+
+func (r *PGRepository) UpdateStatus(ctx context.Context, st *model.Stocktake) error {
+	query := `
+        UPDATE stocktakes
+        SET status = :status,
+            approved_at = :approved_at,
+            approved_by = :approved_by,
+            updated_at = :updated_at
+        WHERE id = :id AND merchant_id = :merchant_id
+    `
+	_, err := r.conn(ctx).NamedExecContext(ctx, query, st)
+	return err
+}
+
+func (r *PGRepository) UpdateItemResult(ctx context.Context, item *model.StocktakeItem) error {
+	query := `
+        UPDATE stocktake_items
+        SET counted_quantity = :counted_quantity,
+            variance_quantity = :variance_quantity
+        WHERE id = :id AND stocktake_id = :stocktake_id
+    `
+	_, err := r.conn(ctx).NamedExecContext(ctx, query, item)
+	return err
+}
@@ -0,0 +1,18 @@
+package stocktake
+
+import (
+	"context"
+
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/fekuna/omnipos-product-service/internal/stocktake/dto"
+)
+
+type UseCase interface {
+	StartStocktake(ctx context.Context, input *dto.StartStocktakeInput) (*model.Stocktake, error)
+	GetStocktake(ctx context.Context, merchantID, id string) (*model.Stocktake, error)
+	ListStocktakes(ctx context.Context, filters *dto.StocktakeFilters) ([]model.Stocktake, int, error)
+	RecordCounts(ctx context.Context, input *dto.RecordCountsInput) error
+	GetVariances(ctx context.Context, merchantID, id string) (*dto.VarianceReport, error)
+	ApproveStocktake(ctx context.Context, input *dto.ApproveStocktakeInput) (*dto.VarianceReport, error)
+	CancelStocktake(ctx context.Context, merchantID, id string) (*model.Stocktake, error)
+}
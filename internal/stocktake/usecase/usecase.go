@@ -0,0 +1,354 @@
+package usecase
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/fekuna/omnipos-pkg/logger"
+	"github.com/fekuna/omnipos-product-service/internal/database"
+	"github.com/fekuna/omnipos-product-service/internal/inventory"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/fekuna/omnipos-product-service/internal/stocktake"
+	"github.com/fekuna/omnipos-product-service/internal/stocktake/dto"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const maxCycleSampleSize = 1000
+
+type stocktakeUseCase struct {
+	repo    stocktake.Repository
+	invRepo inventory.Repository
+	tx      database.TxManager
+	logger  logger.ZapLogger
+}
+
+func NewStocktakeUseCase(repo stocktake.Repository, invRepo inventory.Repository, tx database.TxManager, log logger.ZapLogger) stocktake.UseCase {
+	return &stocktakeUseCase{
+		repo:    repo,
+		invRepo: invRepo,
+		tx:      tx,
+		logger:  log,
+	}
+}
+
+// StartStocktake opens a count and freezes the expected quantity of every location in scope.
+func (uc *stocktakeUseCase) StartStocktake(ctx context.Context, input *dto.StartStocktakeInput) (*model.Stocktake, error) {
+	switch input.Scope {
+	case model.StocktakeScopeFull:
+	case model.StocktakeScopeCategory:
+		if input.CategoryID == nil || *input.CategoryID == "" {
+			return nil, stocktake.ErrCategoryRequired
+		}
+	case model.StocktakeScopeCycle:
+		if input.SampleSize <= 0 || input.SampleSize > maxCycleSampleSize {
+			return nil, stocktake.ErrInvalidSampleSize.With("Max", maxCycleSampleSize)
+		}
+	default:
+		return nil, stocktake.ErrUnknownScope.With("Scope", input.Scope)
+	}
+
+	now := time.Now()
+	var createdBy *string
+	if input.UserID != "" {
+		createdBy = &input.UserID
+	}
+
+	st := &model.Stocktake{
+		BaseModel:  model.BaseModel{ID: uuid.New().String(), CreatedAt: now, UpdatedAt: now},
+		MerchantID: input.MerchantID,
+		StoreID:    input.StoreID,
+		Scope:      input.Scope,
+		Status:     model.StocktakeStatusCounting,
+		Notes:      input.Notes,
+		StartedAt:  now,
+		CreatedBy:  createdBy,
+	}
+	if input.Scope == model.StocktakeScopeCategory {
+		st.CategoryID = input.CategoryID
+	}
+	if input.Scope == model.StocktakeScopeCycle {
+		st.SampleSize = input.SampleSize
+	}
+
+	if err := uc.repo.Create(ctx, st); err != nil {
+		return nil, err
+	}
+	if len(st.Items) == 0 {
+		uc.logger.Warn("Stocktake started with nothing in scope", zap.String("stocktake_id", st.ID))
+	}
+	return st, nil
+}
+
+func (uc *stocktakeUseCase) GetStocktake(ctx context.Context, merchantID, id string) (*model.Stocktake, error) {
+	st, err := uc.repo.FindByID(ctx, merchantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if st == nil {
+		return nil, stocktake.ErrStocktakeNotFound
+	}
+	return st, nil
+}
+
+func (uc *stocktakeUseCase) ListStocktakes(ctx context.Context, filters *dto.StocktakeFilters) ([]model.Stocktake, int, error) {
+	return uc.repo.FindAll(ctx, filters)
+}
+
+// RecordCounts stores the counts of one device. Each device keeps one count per item, so a
+// recount from the same device replaces its earlier number while other devices add up.
+func (uc *stocktakeUseCase) RecordCounts(ctx context.Context, input *dto.RecordCountsInput) error {
+	deviceID := strings.TrimSpace(input.DeviceID)
+	if deviceID == "" {
+		return stocktake.ErrDeviceIDRequired
+	}
+	if len(input.Counts) == 0 {
+		return stocktake.ErrNoCounts
+	}
+
+	return uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		st, err := uc.repo.FindByIDForUpdate(ctx, input.MerchantID, input.StocktakeID)
+		if err != nil {
+			return err
+		}
+		if st == nil {
+			return stocktake.ErrStocktakeNotFound
+		}
+		if st.Status != model.StocktakeStatusCounting {
+			return stocktake.ErrWrongStatus.With("Status", st.Status)
+		}
+
+		items := make(map[string]string, len(st.Items))
+		for _, item := range st.Items {
+			items[model.LocationKey(item.ProductID, item.VariantID)] = item.ID
+		}
+
+		var countedBy *string
+		if input.UserID != "" {
+			countedBy = &input.UserID
+		}
+		now := time.Now()
+
+		for _, c := range input.Counts {
+			if c.Quantity < 0 {
+				return stocktake.ErrNegativeCount
+			}
+			itemID, ok := items[model.LocationKey(c.ProductID, c.VariantID)]
+			if !ok {
+				return stocktake.ErrProductNotInScope.With("ProductID", c.ProductID)
+			}
+
+			count := &model.StocktakeCount{
+				ID:              uuid.New().String(),
+				StocktakeItemID: itemID,
+				DeviceID:        deviceID,
+				Quantity:        c.Quantity,
+				CountedBy:       countedBy,
+				CountedAt:       now,
+			}
+			if err := uc.repo.UpsertCount(ctx, count); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (uc *stocktakeUseCase) GetVariances(ctx context.Context, merchantID, id string) (*dto.VarianceReport, error) {
+	st, err := uc.GetStocktake(ctx, merchantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	lines, err := uc.repo.FindLines(ctx, st.ID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return buildReport(st, lines, false), nil
+}
+
+// ApproveStocktake books the variances as adjustment movements and stamps last_counted_at.
+// Variances are measured against the books at the time of each item's last count, and are
+// then applied to the current quantity, so sales made while counting are not double counted.
+func (uc *stocktakeUseCase) ApproveStocktake(ctx context.Context, input *dto.ApproveStocktakeInput) (*dto.VarianceReport, error) {
+	var report *dto.VarianceReport
+
+	err := uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		st, err := uc.repo.FindByIDForUpdate(ctx, input.MerchantID, input.StocktakeID)
+		if err != nil {
+			return err
+		}
+		if st == nil {
+			return stocktake.ErrStocktakeNotFound
+		}
+		if st.Status != model.StocktakeStatusCounting {
+			return stocktake.ErrWrongStatus.With("Status", st.Status)
+		}
+
+		now := time.Now()
+		lines, err := uc.repo.FindLines(ctx, st.ID, now)
+		if err != nil {
+			return err
+		}
+		report = buildReport(st, lines, input.ZeroUncounted)
+
+		for i, line := range report.Lines {
+			if line.CountedQuantity == nil {
+				continue
+			}
+
+			countedAt := now
+			if lines[i].LastCountedAt != nil {
+				countedAt = *lines[i].LastCountedAt
+			}
+			if err := uc.applyVariance(ctx, st, lines[i], line.VarianceQuantity, countedAt, input.UserID, now); err != nil {
+				return err
+			}
+
+			variance := line.VarianceQuantity
+			item := &model.StocktakeItem{
+				ID:               line.ItemID,
+				StocktakeID:      st.ID,
+				CountedQuantity:  line.CountedQuantity,
+				VarianceQuantity: &variance,
+			}
+			if err := uc.repo.UpdateItemResult(ctx, item); err != nil {
+				return err
+			}
+		}
+
+		st.Status = model.StocktakeStatusApproved
+		st.ApprovedAt = &now
+		if input.UserID != "" {
+			st.ApprovedBy = &input.UserID
+		}
+		st.UpdatedAt = now
+		return uc.repo.UpdateStatus(ctx, st)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	uc.logger.Info("Stocktake approved",
+		zap.String("stocktake_id", input.StocktakeID),
+		zap.Int("counted", report.CountedItems),
+		zap.Int("uncounted", report.UncountedItems),
+	)
+	return report, nil
+}
+
+func (uc *stocktakeUseCase) CancelStocktake(ctx context.Context, merchantID, id string) (*model.Stocktake, error) {
+	var result *model.Stocktake
+	err := uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		st, err := uc.repo.FindByIDForUpdate(ctx, merchantID, id)
+		if err != nil {
+			return err
+		}
+		if st == nil {
+			return stocktake.ErrStocktakeNotFound
+		}
+		if st.Status != model.StocktakeStatusCounting {
+			return stocktake.ErrWrongStatus.With("Status", st.Status)
+		}
+
+		st.Status = model.StocktakeStatusCancelled
+		st.UpdatedAt = time.Now()
+		if err := uc.repo.UpdateStatus(ctx, st); err != nil {
+			return err
+		}
+		result = st
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// applyVariance posts an adjustment for a non-zero variance and stamps last_counted_at.
+func (uc *stocktakeUseCase) applyVariance(ctx context.Context, st *model.Stocktake, line model.StocktakeLine, variance float64, countedAt time.Time, userID string, now time.Time) error {
+	inv, err := uc.invRepo.GetByLocationForUpdate(ctx, st.MerchantID, line.ProductID, line.VariantID, st.StoreID)
+	if err != nil {
+		return err
+	}
+	if inv == nil {
+		return inventory.ErrLocationGone.With("ProductID", line.ProductID)
+	}
+
+	if variance == 0 {
+		return uc.invRepo.MarkCounted(ctx, inv.ID, countedAt)
+	}
+
+	quantityBefore := inv.Quantity
+	inv.Quantity = roundQuantity(inv.Quantity + variance)
+	if inv.Quantity < inv.ReservedQuantity {
+		return stocktake.ErrVarianceBelowHolds.With("ProductID", line.ProductID).With("Variance", variance)
+	}
+	inv.AvailableQuantity = inv.Quantity - inv.ReservedQuantity
+	inv.LastCountedAt = &countedAt
+	inv.UpdatedAt = now
+
+	refType := "stocktake"
+	refID := st.ID
+	var createdBy *string
+	if userID != "" {
+		createdBy = &userID
+	}
+
+	movement := &model.InventoryMovement{
+		ID:             uuid.New().String(),
+		MerchantID:     st.MerchantID,
+		StoreID:        st.StoreID,
+		ProductID:      line.ProductID,
+		VariantID:      line.VariantID,
+		MovementType:   model.MovementAdjustment,
+		QuantityChange: variance,
+		QuantityBefore: quantityBefore,
+		QuantityAfter:  inv.Quantity,
+		ReferenceType:  &refType,
+		ReferenceID:    &refID,
+		Notes:          "Stocktake variance",
+		CreatedBy:      createdBy,
+		CreatedAt:      now,
+	}
+
+	return uc.invRepo.AdjustStockWithMovement(ctx, inv, movement)
+}
+
+// buildReport computes the variance of every line. With zeroUncounted, items no device
+// counted are treated as counted at zero.
+func buildReport(st *model.Stocktake, lines []model.StocktakeLine, zeroUncounted bool) *dto.VarianceReport {
+	report := &dto.VarianceReport{Stocktake: st, Lines: make([]dto.VarianceLine, len(lines))}
+	for i, l := range lines {
+		counted := l.CountedQuantity
+		if counted == nil && zeroUncounted {
+			zero := 0.0
+			counted = &zero
+		}
+
+		line := dto.VarianceLine{
+			ItemID:           l.ItemID,
+			ProductID:        l.ProductID,
+			VariantID:        l.VariantID,
+			ExpectedQuantity: roundQuantity(l.ExpectedAtCount()),
+			CountedQuantity:  counted,
+		}
+		if l.CountedQuantity == nil {
+			report.UncountedItems++
+		} else {
+			report.CountedItems++
+		}
+		if counted != nil {
+			line.VarianceQuantity = roundQuantity(*counted - line.ExpectedQuantity)
+		}
+		report.Lines[i] = line
+	}
+	return report
+}
+
+// roundQuantity drops float noise below the DECIMAL(15,3) precision of quantities.
+func roundQuantity(q float64) float64 {
+	return math.Round(q*1000) / 1000
+}
@@ -0,0 +1,9 @@
+package dto
+
+type TransferFilters struct {
+	MerchantID string
+	StoreID    *string // Matches either side of the transfer
+	Status     string
+	Page       int
+	PageSize   int
+}
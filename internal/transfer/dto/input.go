@@ -0,0 +1,28 @@
+package dto
+
+type CreateTransferInput struct {
+	MerchantID    string
+	SourceStoreID *string
+	TargetStoreID *string
+	Notes         string
+	UserID        string
+	Items         []TransferItemInput
+}
+
+type TransferItemInput struct {
+	ProductID string
+	VariantID *string
+	Quantity  float64
+}
+
+type ReceiveTransferInput struct {
+	MerchantID string
+	TransferID string
+	UserID     string
+	Items      []ReceiveItemInput
+}
+
+type ReceiveItemInput struct {
+	ItemID   string
+	Quantity float64 // Quantity arriving with this receipt, added to what was received before
+}
@@ -0,0 +1,15 @@
+package transfer
+
+import "github.com/fekuna/omnipos-product-service/internal/apperror"
+
+var (
+	ErrTransferNotFound = apperror.New(apperror.NotFound, "TRANSFER_NOT_FOUND", "transfer not found")
+	ErrNoItems          = apperror.Invalid("TRANSFER_WITHOUT_ITEMS", "items", "transfer has no items")
+	ErrInvalidItem      = apperror.Invalid("INVALID_TRANSFER_ITEM", "items", "each item needs a product and a positive quantity")
+	ErrProductNotFound  = apperror.New(apperror.NotFound, "PRODUCT_NOT_FOUND", "product {{.ProductID}} not found")
+	ErrUnknownItem      = apperror.Invalid("UNKNOWN_TRANSFER_ITEM", "items.item_id", "item {{.ItemID}} is not part of this transfer")
+	ErrNegativeReceipt  = apperror.Invalid("NEGATIVE_RECEIVED_QUANTITY", "items.quantity", "received quantity cannot be negative")
+	ErrEmptyReceipt     = apperror.Invalid("EMPTY_TRANSFER_RECEIPT", "items", "receipt has no received quantity")
+	ErrOverReceipt      = apperror.Invalid("TRANSFER_OVER_RECEIPT", "items.quantity", "received quantity for product {{.ProductID}} exceeds dispatched quantity {{.Dispatched}}")
+	ErrWrongStatus      = apperror.New(apperror.FailedPrecondition, "TRANSFER_WRONG_STATUS", "transfer is {{.Status}}")
+)
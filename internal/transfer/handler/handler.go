@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/fekuna/omnipos-pkg/logger"
+	"github.com/fekuna/omnipos-product-service/internal/auth"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/fekuna/omnipos-product-service/internal/transfer"
+	"github.com/fekuna/omnipos-product-service/internal/transfer/dto"
+	productv1 "github.com/fekuna/omnipos-proto/gen/go/omnipos/product/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var _ productv1.StockTransferServiceServer = (*TransferHandler)(nil)
+
+type TransferHandler struct {
+	productv1.UnimplementedStockTransferServiceServer
+	uc     transfer.UseCase
+	logger logger.ZapLogger
+}
+
+func NewTransferHandler(uc transfer.UseCase, log logger.ZapLogger) *TransferHandler {
+	return &TransferHandler{
+		uc:     uc,
+		logger: log,
+	}
+}
+
+func (h *TransferHandler) CreateTransfer(ctx context.Context, req *productv1.CreateTransferRequest) (*productv1.StockTransferResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+	if merchantID == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing merchant context")
+	}
+
+	items := make([]dto.TransferItemInput, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = dto.TransferItemInput{
+			ProductID: item.ProductId,
+			VariantID: optionalID(item.VariantId),
+			Quantity:  item.Quantity,
+		}
+	}
+
+	input := &dto.CreateTransferInput{
+		MerchantID:    merchantID,
+		SourceStoreID: optionalID(req.SourceStoreId),
+		TargetStoreID: optionalID(req.TargetStoreId),
+		Notes:         req.Notes,
+		UserID:        auth.GetUserID(ctx),
+		Items:         items,
+	}
+
+	t, err := h.uc.CreateTransfer(ctx, input)
+	if err != nil {
+		h.logger.Error("failed to create transfer", zap.Error(err))
+		return nil, err
+	}
+
+	return &productv1.StockTransferResponse{Transfer: mapTransferToProto(t)}, nil
+}
+
+func (h *TransferHandler) GetTransfer(ctx context.Context, req *productv1.GetTransferRequest) (*productv1.StockTransferResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+
+	t, err := h.uc.GetTransfer(ctx, merchantID, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &productv1.StockTransferResponse{Transfer: mapTransferToProto(t)}, nil
+}
+
+func (h *TransferHandler) ListTransfers(ctx context.Context, req *productv1.ListTransfersRequest) (*productv1.ListTransfersResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+
+	filters := &dto.TransferFilters{
+		MerchantID: merchantID,
+		StoreID:    optionalID(req.StoreId),
+		Status:     req.Status,
+		Page:       int(req.Page),
+		PageSize:   int(req.PageSize),
+	}
+
+	transfers, count, err := h.uc.ListTransfers(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	protos := make([]*productv1.StockTransfer, len(transfers))
+	for i, t := range transfers {
+		protos[i] = mapTransferToProto(&t)
+	}
+
+	return &productv1.ListTransfersResponse{
+		Transfers: protos,
+		Total:     int32(count),
+	}, nil
+}
+
+func (h *TransferHandler) DispatchTransfer(ctx context.Context, req *productv1.DispatchTransferRequest) (*productv1.StockTransferResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+
+	t, err := h.uc.DispatchTransfer(ctx, merchantID, req.Id, auth.GetUserID(ctx))
+	if err != nil {
+		h.logger.Error("failed to dispatch transfer", zap.String("transfer_id", req.Id), zap.Error(err))
+		return nil, err
+	}
+
+	return &productv1.StockTransferResponse{Transfer: mapTransferToProto(t)}, nil
+}
+
+func (h *TransferHandler) MarkTransferInTransit(ctx context.Context, req *productv1.MarkTransferInTransitRequest) (*productv1.StockTransferResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+
+	t, err := h.uc.MarkInTransit(ctx, merchantID, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &productv1.StockTransferResponse{Transfer: mapTransferToProto(t)}, nil
+}
+
+func (h *TransferHandler) ReceiveTransfer(ctx context.Context, req *productv1.ReceiveTransferRequest) (*productv1.StockTransferResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+
+	items := make([]dto.ReceiveItemInput, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = dto.ReceiveItemInput{
+			ItemID:   item.ItemId,
+			Quantity: item.Quantity,
+		}
+	}
+
+	input := &dto.ReceiveTransferInput{
+		MerchantID: merchantID,
+		TransferID: req.Id,
+		UserID:     auth.GetUserID(ctx),
+		Items:      items,
+	}
+
+	t, err := h.uc.ReceiveTransfer(ctx, input)
+	if err != nil {
+		h.logger.Error("failed to receive transfer", zap.String("transfer_id", req.Id), zap.Error(err))
+		return nil, err
+	}
+
+	return &productv1.StockTransferResponse{Transfer: mapTransferToProto(t)}, nil
+}
+
+func (h *TransferHandler) CloseTransfer(ctx context.Context, req *productv1.CloseTransferRequest) (*productv1.StockTransferResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+
+	t, err := h.uc.CloseTransfer(ctx, merchantID, req.Id, auth.GetUserID(ctx))
+	if err != nil {
+		h.logger.Error("failed to close transfer", zap.String("transfer_id", req.Id), zap.Error(err))
+		return nil, err
+	}
+
+	return &productv1.StockTransferResponse{Transfer: mapTransferToProto(t)}, nil
+}
+
+func (h *TransferHandler) CancelTransfer(ctx context.Context, req *productv1.CancelTransferRequest) (*productv1.StockTransferResponse, error) {
+	merchantID := auth.GetMerchantID(ctx)
+
+	t, err := h.uc.CancelTransfer(ctx, merchantID, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &productv1.StockTransferResponse{Transfer: mapTransferToProto(t)}, nil
+}
+
+func optionalID(id string) *string {
+	if id == "" {
+		return nil
+	}
+	return &id
+}
+
+func mapTransferToProto(m *model.StockTransfer) *productv1.StockTransfer {
+	if m == nil {
+		return nil
+	}
+
+	items := make([]*productv1.StockTransferItem, len(m.Items))
+	for i, item := range m.Items {
+		variantID := ""
+		if item.VariantID != nil {
+			variantID = *item.VariantID
+		}
+		items[i] = &productv1.StockTransferItem{
+			Id:                item.ID,
+			ProductId:         item.ProductID,
+			VariantId:         variantID,
+			Quantity:          item.Quantity,
+			QuantityReceived:  item.QuantityReceived,
+			QuantityShrinkage: item.QuantityShrinkage,
+		}
+	}
+
+	sourceStoreID := ""
+	if m.SourceStoreID != nil {
+		sourceStoreID = *m.SourceStoreID
+	}
+	targetStoreID := ""
+	if m.TargetStoreID != nil {
+		targetStoreID = *m.TargetStoreID
+	}
+	createdBy := ""
+	if m.CreatedBy != nil {
+		createdBy = *m.CreatedBy
+	}
+
+	return &productv1.StockTransfer{
+		Id:            m.ID,
+		MerchantId:    m.MerchantID,
+		SourceStoreId: sourceStoreID,
+		TargetStoreId: targetStoreID,
+		Status:        m.Status,
+		Notes:         m.Notes,
+		CreatedBy:     createdBy,
+		DispatchedAt:  optionalTimestamp(m.DispatchedAt),
+		ReceivedAt:    optionalTimestamp(m.ReceivedAt),
+		ClosedAt:      optionalTimestamp(m.ClosedAt),
+		CreatedAt:     timestamppb.New(m.CreatedAt),
+		UpdatedAt:     timestamppb.New(m.UpdatedAt),
+		Items:         items,
+	}
+}
+
+func optionalTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
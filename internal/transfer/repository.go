@@ -0,0 +1,19 @@
+package transfer
+
+import (
+	"context"
+
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/fekuna/omnipos-product-service/internal/transfer/dto"
+)
+
+type Repository interface {
+	Create(ctx context.Context, transfer *model.StockTransfer) error
+	FindByID(ctx context.Context, merchantID, id string) (*model.StockTransfer, error)
+	FindAll(ctx context.Context, filters *dto.TransferFilters) ([]model.StockTransfer, int, error)
+
+	// Used inside a transaction while stock is moved
+	FindByIDForUpdate(ctx context.Context, merchantID, id string) (*model.StockTransfer, error)
+	UpdateStatus(ctx context.Context, transfer *model.StockTransfer) error
+	UpdateItem(ctx context.Context, item *model.StockTransferItem) error
+}
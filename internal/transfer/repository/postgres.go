@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fekuna/omnipos-product-service/internal/database"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/fekuna/omnipos-product-service/internal/transfer/dto"
+	"github.com/jmoiron/sqlx"
+)
+
+type PGRepository struct {
+	DB *sqlx.DB
+}
+
+func NewPGRepository(db *sqlx.DB) *PGRepository {
+	return &PGRepository{DB: db}
+}
+
+// conn returns the transaction started by the TxManager if ctx carries one.
+func (r *PGRepository) conn(ctx context.Context) database.DBTX {
+	return database.Conn(ctx, r.DB)
+}
+
+func (r *PGRepository) Create(ctx context.Context, t *model.StockTransfer) error {
+	return database.RunInTx(ctx, r.DB, func(ctx context.Context) error {
+		query := `
+            INSERT INTO stock_transfers (
+                id, merchant_id, source_store_id, target_store_id, status, notes,
+                created_by, dispatched_at, received_at, closed_at, created_at, updated_at
+            )
+            VALUES (
+                :id, :merchant_id, :source_store_id, :target_store_id, :status, :notes,
+                :created_by, :dispatched_at, :received_at, :closed_at, :created_at, :updated_at
+            )
+        `
+		if _, err := r.conn(ctx).NamedExecContext(ctx, query, t); err != nil {
+			return fmt.Errorf("failed to create transfer: %w", err)
+		}
+
+		itemQuery := `
+            INSERT INTO stock_transfer_items (
+                id, transfer_id, product_id, variant_id, quantity, quantity_received, quantity_shrinkage
+            )
+            VALUES (
+                :id, :transfer_id, :product_id, :variant_id, :quantity, :quantity_received, :quantity_shrinkage
+            )
+        `
+		for _, item := range t.Items {
+			if _, err := r.conn(ctx).NamedExecContext(ctx, itemQuery, item); err != nil {
+				return fmt.Errorf("failed to create transfer item: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *PGRepository) FindByID(ctx context.Context, merchantID, id string) (*model.StockTransfer, error) {
+	return r.findByID(ctx, merchantID, id, "")
+}
+
+func (r *PGRepository) FindByIDForUpdate(ctx context.Context, merchantID, id string) (*model.StockTransfer, error) {
+	return r.findByID(ctx, merchantID, id, " FOR UPDATE")
+}
+
+func (r *PGRepository) findByID(ctx context.Context, merchantID, id, lockClause string) (*model.StockTransfer, error) {
+	var t model.StockTransfer
+	query := `SELECT * FROM stock_transfers WHERE id = $1 AND merchant_id = $2` + lockClause
+	err := r.conn(ctx).GetContext(ctx, &t, query, id, merchantID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	items := []model.StockTransferItem{}
+	itemQuery := `SELECT * FROM stock_transfer_items WHERE transfer_id = $1 ORDER BY product_id, variant_id NULLS FIRST`
+	if err := r.conn(ctx).SelectContext(ctx, &items, itemQuery, t.ID); err != nil {
+		return nil, err
+	}
+	t.Items = items
+
+	return &t, nil
+}
+
+func (r *PGRepository) FindAll(ctx context.Context, f *dto.TransferFilters) ([]model.StockTransfer, int, error) {
+	var transfers []model.StockTransfer
+	var count int
+
+	conditions := []string{"merchant_id = :merchant_id"}
+	args := map[string]interface{}{"merchant_id": f.MerchantID}
+	if f.StoreID != nil {
+		if *f.StoreID == "" {
+			conditions = append(conditions, "(source_store_id IS NULL OR target_store_id IS NULL)")
+		} else {
+			conditions = append(conditions, "(source_store_id = :store_id OR target_store_id = :store_id)")
+			args["store_id"] = *f.StoreID
+		}
+	}
+	if f.Status != "" {
+		conditions = append(conditions, "status = :status")
+		args["status"] = f.Status
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	countQuery := "SELECT count(*) FROM stock_transfers" + whereClause
+	if err := database.NamedGet(ctx, r.conn(ctx), &count, countQuery, args); err != nil {
+		return nil, 0, err
+	}
+
+	query := "SELECT * FROM stock_transfers" + whereClause + " ORDER BY created_at DESC"
+	if f.PageSize > 0 {
+		offset := (f.Page - 1) * f.PageSize
+		query += fmt.Sprintf(" LIMIT %d OFFSET %d", f.PageSize, offset)
+	}
+
+	err := database.NamedSelect(ctx, r.conn(ctx), &transfers, query, args)
+	return transfers, count, err
+}
+
+func (r *PGRepository) UpdateStatus(ctx context.Context, t *model.StockTransfer) error {
+	query := `
+        UPDATE stock_transfers
+        SET status = :status,
+            dispatched_at = :dispatched_at,
+            received_at = :received_at,
+            closed_at = :closed_at,
+            updated_at = :updated_at
+        WHERE id = :id AND merchant_id = :merchant_id
+    `
+	_, err := r.conn(ctx).NamedExecContext(ctx, query, t)
+	return err
+}
+
+func (r *PGRepository) UpdateItem(ctx context.Context, item *model.StockTransferItem) error {
+	query := `
+        UPDATE stock_transfer_items
+        SET quantity_received = :quantity_received,
+            quantity_shrinkage = :quantity_shrinkage
+        WHERE id = :id AND transfer_id = :transfer_id
+    `
+	_, err := r.conn(ctx).NamedExecContext(ctx, query, item)
+	return err
+}
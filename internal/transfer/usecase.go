@@ -0,0 +1,21 @@
+package transfer
+
+import (
+	"context"
+
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/fekuna/omnipos-product-service/internal/transfer/dto"
+)
+
+type UseCase interface {
+	CreateTransfer(ctx context.Context, input *dto.CreateTransferInput) (*model.StockTransfer, error)
+	GetTransfer(ctx context.Context, merchantID, id string) (*model.StockTransfer, error)
+	ListTransfers(ctx context.Context, filters *dto.TransferFilters) ([]model.StockTransfer, int, error)
+
+	// State transitions: draft -> dispatched -> in_transit -> (partially_)received -> closed
+	DispatchTransfer(ctx context.Context, merchantID, id, userID string) (*model.StockTransfer, error)
+	MarkInTransit(ctx context.Context, merchantID, id string) (*model.StockTransfer, error)
+	ReceiveTransfer(ctx context.Context, input *dto.ReceiveTransferInput) (*model.StockTransfer, error)
+	CloseTransfer(ctx context.Context, merchantID, id, userID string) (*model.StockTransfer, error)
+	CancelTransfer(ctx context.Context, merchantID, id string) (*model.StockTransfer, error)
+}
@@ -0,0 +1,372 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/fekuna/omnipos-pkg/logger"
+	"github.com/fekuna/omnipos-product-service/internal/database"
+	"github.com/fekuna/omnipos-product-service/internal/inventory"
+	"github.com/fekuna/omnipos-product-service/internal/model"
+	"github.com/fekuna/omnipos-product-service/internal/transfer"
+	"github.com/fekuna/omnipos-product-service/internal/transfer/dto"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type transferUseCase struct {
+	repo    transfer.Repository
+	invRepo inventory.Repository
+	tx      database.TxManager
+	logger  logger.ZapLogger
+}
+
+func NewTransferUseCase(repo transfer.Repository, invRepo inventory.Repository, tx database.TxManager, log logger.ZapLogger) transfer.UseCase {
+	return &transferUseCase{
+		repo:    repo,
+		invRepo: invRepo,
+		tx:      tx,
+		logger:  log,
+	}
+}
+
+func (uc *transferUseCase) CreateTransfer(ctx context.Context, input *dto.CreateTransferInput) (*model.StockTransfer, error) {
+	if model.StoreKey(input.SourceStoreID) == model.StoreKey(input.TargetStoreID) {
+		return nil, inventory.ErrSameStore
+	}
+	if len(input.Items) == 0 {
+		return nil, transfer.ErrNoItems
+	}
+
+	id := uuid.New().String()
+	now := time.Now()
+
+	var createdBy *string
+	if input.UserID != "" {
+		createdBy = &input.UserID
+	}
+
+	t := &model.StockTransfer{
+		BaseModel:     model.BaseModel{ID: id, CreatedAt: now, UpdatedAt: now},
+		MerchantID:    input.MerchantID,
+		SourceStoreID: input.SourceStoreID,
+		TargetStoreID: input.TargetStoreID,
+		Status:        model.TransferStatusDraft,
+		Notes:         input.Notes,
+		CreatedBy:     createdBy,
+	}
+
+	// One line per product/variant, duplicates are summed.
+	index := make(map[string]int)
+	for _, in := range input.Items {
+		if in.ProductID == "" || in.Quantity <= 0 {
+			return nil, transfer.ErrInvalidItem
+		}
+		key := model.LocationKey(in.ProductID, in.VariantID)
+		if i, ok := index[key]; ok {
+			t.Items[i].Quantity += in.Quantity
+			continue
+		}
+		owned, err := uc.invRepo.IsProductOwned(ctx, input.MerchantID, in.ProductID, in.VariantID)
+		if err != nil {
+			return nil, err
+		}
+		if !owned {
+			return nil, transfer.ErrProductNotFound.With("ProductID", in.ProductID)
+		}
+		index[key] = len(t.Items)
+		t.Items = append(t.Items, model.StockTransferItem{
+			ID:         uuid.New().String(),
+			TransferID: id,
+			ProductID:  in.ProductID,
+			VariantID:  in.VariantID,
+			Quantity:   in.Quantity,
+		})
+	}
+
+	if err := uc.repo.Create(ctx, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (uc *transferUseCase) GetTransfer(ctx context.Context, merchantID, id string) (*model.StockTransfer, error) {
+	t, err := uc.repo.FindByID(ctx, merchantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, transfer.ErrTransferNotFound
+	}
+	return t, nil
+}
+
+func (uc *transferUseCase) ListTransfers(ctx context.Context, filters *dto.TransferFilters) ([]model.StockTransfer, int, error) {
+	return uc.repo.FindAll(ctx, filters)
+}
+
+// DispatchTransfer takes the stock out of the source store with one transfer_out per item.
+func (uc *transferUseCase) DispatchTransfer(ctx context.Context, merchantID, id, userID string) (*model.StockTransfer, error) {
+	return uc.transition(ctx, merchantID, id, []string{model.TransferStatusDraft}, func(ctx context.Context, t *model.StockTransfer, now time.Time) error {
+		for _, i := range lockOrder(t.Items) {
+			item := t.Items[i]
+			err := uc.postMovement(ctx, t, t.SourceStoreID, item, -item.Quantity, model.MovementTransferOut, "Transfer dispatched", userID, now)
+			if err != nil {
+				return err
+			}
+		}
+		t.Status = model.TransferStatusDispatched
+		t.DispatchedAt = &now
+		return nil
+	})
+}
+
+func (uc *transferUseCase) MarkInTransit(ctx context.Context, merchantID, id string) (*model.StockTransfer, error) {
+	return uc.transition(ctx, merchantID, id, []string{model.TransferStatusDispatched}, func(ctx context.Context, t *model.StockTransfer, now time.Time) error {
+		t.Status = model.TransferStatusInTransit
+		return nil
+	})
+}
+
+// ReceiveTransfer books what actually arrived into the target store with transfer_in movements.
+// It can be called several times for shipments that arrive in parts, each of which has to
+// receive something.
+func (uc *transferUseCase) ReceiveTransfer(ctx context.Context, input *dto.ReceiveTransferInput) (*model.StockTransfer, error) {
+	allowed := []string{model.TransferStatusDispatched, model.TransferStatusInTransit, model.TransferStatusPartiallyReceived}
+	return uc.transition(ctx, input.MerchantID, input.TransferID, allowed, func(ctx context.Context, t *model.StockTransfer, now time.Time) error {
+		known := make(map[string]bool, len(t.Items))
+		for _, item := range t.Items {
+			known[item.ID] = true
+		}
+
+		received := make(map[string]float64, len(input.Items))
+		for _, in := range input.Items {
+			if !known[in.ItemID] {
+				return transfer.ErrUnknownItem.With("ItemID", in.ItemID)
+			}
+			if in.Quantity < 0 {
+				return transfer.ErrNegativeReceipt
+			}
+			received[in.ItemID] += in.Quantity
+		}
+
+		complete, booked := true, false
+		for _, i := range lockOrder(t.Items) {
+			item := &t.Items[i]
+			if qty := received[item.ID]; qty > 0 {
+				if item.QuantityReceived+qty > item.Quantity {
+					return transfer.ErrOverReceipt.With("ProductID", item.ProductID).With("Dispatched", item.Quantity)
+				}
+				if err := uc.postMovement(ctx, t, t.TargetStoreID, *item, qty, model.MovementTransferIn, "Transfer received", input.UserID, now); err != nil {
+					return err
+				}
+				item.QuantityReceived += qty
+				if err := uc.repo.UpdateItem(ctx, item); err != nil {
+					return err
+				}
+				booked = true
+			}
+
+			if item.QuantityReceived < item.Quantity {
+				complete = false
+			}
+		}
+		if !booked {
+			return transfer.ErrEmptyReceipt
+		}
+
+		t.Status = model.TransferStatusPartiallyReceived
+		if complete {
+			t.Status = model.TransferStatusReceived
+		}
+		t.ReceivedAt = &now
+		return nil
+	})
+}
+
+// CloseTransfer finalises a received transfer. Quantities that never arrived are recorded as
+// the item's shrinkage and logged as a transit_loss at the source, next to the transfer_out
+// that took them out. No store's stock changes: the target never had them.
+func (uc *transferUseCase) CloseTransfer(ctx context.Context, merchantID, id, userID string) (*model.StockTransfer, error) {
+	allowed := []string{model.TransferStatusReceived, model.TransferStatusPartiallyReceived}
+	return uc.transition(ctx, merchantID, id, allowed, func(ctx context.Context, t *model.StockTransfer, now time.Time) error {
+		for _, i := range lockOrder(t.Items) {
+			item := &t.Items[i]
+			missing := item.Quantity - item.QuantityReceived
+			if missing <= 0 {
+				continue
+			}
+
+			if err := uc.logTransitLoss(ctx, t, *item, missing, userID, now); err != nil {
+				return err
+			}
+
+			item.QuantityShrinkage = missing
+			if err := uc.repo.UpdateItem(ctx, item); err != nil {
+				return err
+			}
+			uc.logger.Warn("Transfer closed with shrinkage",
+				zap.String("transfer_id", t.ID),
+				zap.String("product_id", item.ProductID),
+				zap.Float64("shrinkage", missing),
+			)
+		}
+
+		t.Status = model.TransferStatusClosed
+		t.ClosedAt = &now
+		return nil
+	})
+}
+
+func (uc *transferUseCase) CancelTransfer(ctx context.Context, merchantID, id string) (*model.StockTransfer, error) {
+	return uc.transition(ctx, merchantID, id, []string{model.TransferStatusDraft}, func(ctx context.Context, t *model.StockTransfer, now time.Time) error {
+		t.Status = model.TransferStatusCancelled
+		return nil
+	})
+}
+
+// transition locks the transfer, checks it is in one of the allowed states and runs apply
+// and the status update in one transaction.
+func (uc *transferUseCase) transition(ctx context.Context, merchantID, id string, allowed []string, apply func(ctx context.Context, t *model.StockTransfer, now time.Time) error) (*model.StockTransfer, error) {
+	var result *model.StockTransfer
+	err := uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		t, err := uc.repo.FindByIDForUpdate(ctx, merchantID, id)
+		if err != nil {
+			return err
+		}
+		if t == nil {
+			return transfer.ErrTransferNotFound
+		}
+
+		permitted := false
+		for _, s := range allowed {
+			if t.Status == s {
+				permitted = true
+				break
+			}
+		}
+		if !permitted {
+			return transfer.ErrWrongStatus.With("Status", t.Status)
+		}
+
+		now := time.Now()
+		if err := apply(ctx, t, now); err != nil {
+			return err
+		}
+		t.UpdatedAt = now
+		if err := uc.repo.UpdateStatus(ctx, t); err != nil {
+			return err
+		}
+
+		result = t
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// lockOrder returns the indexes of items sorted by product and variant, the order every
+// stock write locks inventory rows in.
+func lockOrder(items []model.StockTransferItem) []int {
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		a, b = order[a], order[b]
+		return model.LocationKey(items[a].ProductID, items[a].VariantID) < model.LocationKey(items[b].ProductID, items[b].VariantID)
+	})
+	return order
+}
+
+// postMovement changes the stock of item at storeID by change and logs it against the transfer.
+func (uc *transferUseCase) postMovement(ctx context.Context, t *model.StockTransfer, storeID *string, item model.StockTransferItem, change float64, movementType model.MovementType, notes, userID string, now time.Time) error {
+	inv, err := uc.invRepo.GetByLocationForUpdate(ctx, t.MerchantID, item.ProductID, item.VariantID, storeID)
+	if err != nil {
+		return err
+	}
+	if inv == nil {
+		inv = &model.Inventory{
+			ID:         uuid.New().String(),
+			MerchantID: t.MerchantID,
+			StoreID:    storeID,
+			ProductID:  item.ProductID,
+			VariantID:  item.VariantID,
+		}
+	}
+	if change < 0 && inv.AvailableQuantity < -change {
+		return inventory.ErrInsufficientInventory.With("ProductID", item.ProductID)
+	}
+
+	quantityBefore := inv.Quantity
+	inv.Quantity += change
+	inv.AvailableQuantity += change
+	inv.UpdatedAt = now
+
+	refType := "transfer"
+	refID := t.ID
+	var createdBy *string
+	if userID != "" {
+		createdBy = &userID
+	}
+
+	movement := &model.InventoryMovement{
+		ID:             uuid.New().String(),
+		MerchantID:     t.MerchantID,
+		StoreID:        storeID,
+		ProductID:      item.ProductID,
+		VariantID:      item.VariantID,
+		MovementType:   movementType,
+		QuantityChange: change,
+		QuantityBefore: quantityBefore,
+		QuantityAfter:  inv.Quantity,
+		ReferenceType:  &refType,
+		ReferenceID:    &refID,
+		Notes:          notes,
+		CreatedBy:      createdBy,
+		CreatedAt:      now,
+	}
+
+	return uc.invRepo.AdjustStockWithMovement(ctx, inv, movement)
+}
+
+// logTransitLoss logs missing units of item as lost in transit at the source store. The
+// source's stock already dropped when the transfer was dispatched, so the movement reports
+// the row's current quantity as both before and after.
+func (uc *transferUseCase) logTransitLoss(ctx context.Context, t *model.StockTransfer, item model.StockTransferItem, missing float64, userID string, now time.Time) error {
+	inv, err := uc.invRepo.GetByLocationForUpdate(ctx, t.MerchantID, item.ProductID, item.VariantID, t.SourceStoreID)
+	if err != nil {
+		return err
+	}
+	var onHand float64
+	if inv != nil {
+		onHand = inv.Quantity
+	}
+
+	refType := "transfer"
+	refID := t.ID
+	var createdBy *string
+	if userID != "" {
+		createdBy = &userID
+	}
+
+	return uc.invRepo.LogMovement(ctx, &model.InventoryMovement{
+		ID:             uuid.New().String(),
+		MerchantID:     t.MerchantID,
+		StoreID:        t.SourceStoreID,
+		ProductID:      item.ProductID,
+		VariantID:      item.VariantID,
+		MovementType:   model.MovementTransitLoss,
+		QuantityChange: -missing,
+		QuantityBefore: onHand,
+		QuantityAfter:  onHand,
+		ReferenceType:  &refType,
+		ReferenceID:    &refID,
+		Notes:          "Lost in transit",
+		CreatedBy:      createdBy,
+		CreatedAt:      now,
+	})
+}
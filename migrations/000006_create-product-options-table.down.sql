@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_product_variants_combination;
+DROP INDEX IF EXISTS idx_product_options_product_id;
+
+ALTER TABLE product_variants DROP COLUMN IF EXISTS combination_key;
+ALTER TABLE product_variants DROP COLUMN IF EXISTS option_values;
+
+DROP TABLE IF EXISTS product_options CASCADE;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS product_options (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL, -- e.g., "Size", "Colour"
+    option_values JSONB NOT NULL DEFAULT '[]', -- e.g., ["S", "M", "L"]
+    position INT NOT NULL DEFAULT 0, -- axis order used for SKU/name generation
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT unique_product_option_name UNIQUE(product_id, name)
+);
+
+-- Generated variants remember the option combination they were built from
+ALTER TABLE product_variants ADD COLUMN IF NOT EXISTS option_values JSONB; -- e.g., {"Size": "L", "Colour": "Red"}
+ALTER TABLE product_variants ADD COLUMN IF NOT EXISTS combination_key VARCHAR(255); -- canonical form of option_values
+
+-- Indexes for performance
+CREATE INDEX IF NOT EXISTS idx_product_options_product_id ON product_options(product_id, position);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_variants_combination ON product_variants(product_id, combination_key)
+    WHERE combination_key IS NOT NULL;
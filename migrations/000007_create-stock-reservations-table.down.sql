@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_stock_reservation_items_inventory_id;
+DROP INDEX IF EXISTS idx_stock_reservation_items_reservation_id;
+DROP INDEX IF EXISTS idx_stock_reservations_expiry;
+
+DROP TABLE IF EXISTS stock_reservation_items CASCADE;
+DROP TABLE IF EXISTS stock_reservations CASCADE;
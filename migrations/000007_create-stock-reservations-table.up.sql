@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    merchant_id UUID NOT NULL,
+    order_id UUID NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'active', -- 'active', 'committed', 'released', 'expired'
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT unique_merchant_order_reservation UNIQUE(merchant_id, order_id),
+    CONSTRAINT valid_reservation_status CHECK (status IN ('active', 'committed', 'released', 'expired'))
+);
+
+CREATE TABLE IF NOT EXISTS stock_reservation_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    reservation_id UUID NOT NULL REFERENCES stock_reservations(id) ON DELETE CASCADE,
+    inventory_id UUID NOT NULL REFERENCES inventory(id) ON DELETE CASCADE, -- row holding the reserved quantity
+    store_id UUID,
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    variant_id UUID REFERENCES product_variants(id) ON DELETE CASCADE,
+    quantity DECIMAL(15,3) NOT NULL,
+    CONSTRAINT positive_reservation_quantity CHECK (quantity > 0)
+);
+
+-- Indexes for performance
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_expiry ON stock_reservations(expires_at) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_stock_reservation_items_reservation_id ON stock_reservation_items(reservation_id);
+CREATE INDEX IF NOT EXISTS idx_stock_reservation_items_inventory_id ON stock_reservation_items(inventory_id);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_stock_transfer_items_product_id;
+DROP INDEX IF EXISTS idx_stock_transfer_items_transfer_id;
+DROP INDEX IF EXISTS idx_stock_transfers_merchant_status;
+
+DROP TABLE IF EXISTS stock_transfer_items CASCADE;
+DROP TABLE IF EXISTS stock_transfers CASCADE;
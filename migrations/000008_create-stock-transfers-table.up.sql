@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS stock_transfers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    merchant_id UUID NOT NULL,
+    source_store_id UUID, -- NULL = central/warehouse inventory
+    target_store_id UUID, -- NULL = central/warehouse inventory
+    status VARCHAR(30) NOT NULL DEFAULT 'draft', -- 'draft', 'dispatched', 'in_transit', 'partially_received', 'received', 'closed', 'cancelled'
+    notes TEXT NOT NULL DEFAULT '',
+    created_by UUID, -- user_id who created the transfer
+    dispatched_at TIMESTAMPTZ,
+    received_at TIMESTAMPTZ, -- last receipt
+    closed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT valid_transfer_status CHECK (status IN ('draft', 'dispatched', 'in_transit', 'partially_received', 'received', 'closed', 'cancelled')),
+    CONSTRAINT different_transfer_stores CHECK (source_store_id IS DISTINCT FROM target_store_id)
+);
+
+CREATE TABLE IF NOT EXISTS stock_transfer_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transfer_id UUID NOT NULL REFERENCES stock_transfers(id) ON DELETE CASCADE,
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    variant_id UUID REFERENCES product_variants(id) ON DELETE CASCADE,
+    quantity DECIMAL(15,3) NOT NULL, -- quantity dispatched from the source
+    quantity_received DECIMAL(15,3) NOT NULL DEFAULT 0,
+    quantity_shrinkage DECIMAL(15,3) NOT NULL DEFAULT 0, -- written off when the transfer is closed short
+    CONSTRAINT positive_transfer_quantity CHECK (quantity > 0),
+    CONSTRAINT received_not_exceed_dispatched CHECK (quantity_received >= 0 AND quantity_received <= quantity)
+);
+
+-- Indexes for performance
+CREATE INDEX IF NOT EXISTS idx_stock_transfers_merchant_status ON stock_transfers(merchant_id, status, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_stock_transfer_items_transfer_id ON stock_transfer_items(transfer_id);
+CREATE INDEX IF NOT EXISTS idx_stock_transfer_items_product_id ON stock_transfer_items(product_id);
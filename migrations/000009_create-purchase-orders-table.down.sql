@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS idx_purchase_receipt_items_receipt_id;
+DROP INDEX IF EXISTS idx_purchase_receipts_purchase_order_id;
+DROP INDEX IF EXISTS idx_purchase_order_items_product_id;
+DROP INDEX IF EXISTS idx_purchase_order_items_purchase_order_id;
+DROP INDEX IF EXISTS idx_purchase_orders_supplier_id;
+DROP INDEX IF EXISTS idx_purchase_orders_merchant_status;
+DROP INDEX IF EXISTS idx_suppliers_merchant_id;
+
+DROP TABLE IF EXISTS merchant_inventory_settings CASCADE;
+DROP TABLE IF EXISTS purchase_receipt_items CASCADE;
+DROP TABLE IF EXISTS purchase_receipts CASCADE;
+DROP TABLE IF EXISTS purchase_order_items CASCADE;
+DROP TABLE IF EXISTS purchase_orders CASCADE;
+DROP TABLE IF EXISTS suppliers CASCADE;
@@ -0,0 +1,80 @@
+CREATE TABLE IF NOT EXISTS suppliers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    merchant_id UUID NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    contact_name VARCHAR(255),
+    email VARCHAR(255),
+    phone VARCHAR(50),
+    address TEXT,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT unique_merchant_supplier_name UNIQUE(merchant_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS purchase_orders (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    merchant_id UUID NOT NULL,
+    supplier_id UUID NOT NULL REFERENCES suppliers(id),
+    store_id UUID, -- receiving location, NULL = central/warehouse inventory
+    po_number VARCHAR(50) NOT NULL,
+    status VARCHAR(30) NOT NULL DEFAULT 'draft', -- 'draft', 'ordered', 'partially_received', 'received', 'closed', 'cancelled'
+    notes TEXT NOT NULL DEFAULT '',
+    expected_at TIMESTAMPTZ,
+    ordered_at TIMESTAMPTZ,
+    received_at TIMESTAMPTZ, -- last receipt
+    closed_at TIMESTAMPTZ,
+    created_by UUID, -- user_id who created the purchase order
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT unique_merchant_po_number UNIQUE(merchant_id, po_number),
+    CONSTRAINT valid_purchase_order_status CHECK (status IN ('draft', 'ordered', 'partially_received', 'received', 'closed', 'cancelled'))
+);
+
+CREATE TABLE IF NOT EXISTS purchase_order_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    purchase_order_id UUID NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    variant_id UUID REFERENCES product_variants(id) ON DELETE CASCADE,
+    quantity_ordered DECIMAL(15,3) NOT NULL,
+    quantity_received DECIMAL(15,3) NOT NULL DEFAULT 0,
+    unit_cost DECIMAL(15,2) NOT NULL DEFAULT 0,
+    CONSTRAINT positive_quantity_ordered CHECK (quantity_ordered > 0),
+    CONSTRAINT positive_unit_cost CHECK (unit_cost >= 0),
+    CONSTRAINT received_not_exceed_ordered CHECK (quantity_received >= 0 AND quantity_received <= quantity_ordered)
+);
+
+CREATE TABLE IF NOT EXISTS purchase_receipts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    merchant_id UUID NOT NULL,
+    purchase_order_id UUID NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+    notes TEXT NOT NULL DEFAULT '',
+    received_by UUID, -- user_id who booked the receipt
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS purchase_receipt_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    receipt_id UUID NOT NULL REFERENCES purchase_receipts(id) ON DELETE CASCADE,
+    purchase_order_item_id UUID NOT NULL REFERENCES purchase_order_items(id) ON DELETE CASCADE,
+    quantity DECIMAL(15,3) NOT NULL,
+    unit_cost DECIMAL(15,2) NOT NULL, -- invoiced cost, may differ from the ordered cost
+    CONSTRAINT positive_receipt_quantity CHECK (quantity > 0)
+);
+
+-- Per-merchant inventory settings
+CREATE TABLE IF NOT EXISTS merchant_inventory_settings (
+    merchant_id UUID PRIMARY KEY,
+    costing_method VARCHAR(30) NOT NULL DEFAULT 'weighted_average', -- 'manual', 'last_cost', 'weighted_average'
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT valid_costing_method CHECK (costing_method IN ('manual', 'last_cost', 'weighted_average'))
+);
+
+-- Indexes for performance
+CREATE INDEX IF NOT EXISTS idx_suppliers_merchant_id ON suppliers(merchant_id);
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_merchant_status ON purchase_orders(merchant_id, status, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_supplier_id ON purchase_orders(supplier_id);
+CREATE INDEX IF NOT EXISTS idx_purchase_order_items_purchase_order_id ON purchase_order_items(purchase_order_id);
+CREATE INDEX IF NOT EXISTS idx_purchase_order_items_product_id ON purchase_order_items(product_id);
+CREATE INDEX IF NOT EXISTS idx_purchase_receipts_purchase_order_id ON purchase_receipts(purchase_order_id);
+CREATE INDEX IF NOT EXISTS idx_purchase_receipt_items_receipt_id ON purchase_receipt_items(receipt_id);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_reorder_suggestions_merchant_status;
+DROP INDEX IF EXISTS idx_reorder_suggestions_open_inventory;
+
+DROP TABLE IF EXISTS reorder_suggestions CASCADE;
+
+ALTER TABLE inventory DROP COLUMN IF EXISTS max_stock_level;
@@ -0,0 +1,27 @@
+-- Optional stock ceiling: reorder up to this level instead of a fixed reorder_quantity
+ALTER TABLE inventory ADD COLUMN IF NOT EXISTS max_stock_level DECIMAL(15,3);
+
+CREATE TABLE IF NOT EXISTS reorder_suggestions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    merchant_id UUID NOT NULL,
+    inventory_id UUID NOT NULL REFERENCES inventory(id) ON DELETE CASCADE,
+    store_id UUID, -- NULL = central/warehouse inventory
+    supplier_id UUID REFERENCES suppliers(id) ON DELETE SET NULL, -- last supplier the product was ordered from
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    variant_id UUID REFERENCES product_variants(id) ON DELETE CASCADE,
+    available_quantity DECIMAL(15,3) NOT NULL,
+    incoming_quantity DECIMAL(15,3) NOT NULL DEFAULT 0, -- open purchase orders and inbound transfers
+    reorder_point DECIMAL(15,3) NOT NULL,
+    suggested_quantity DECIMAL(15,3) NOT NULL,
+    unit_cost DECIMAL(15,2) NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'open', -- 'open', 'converted', 'dismissed', 'obsolete'
+    purchase_order_id UUID REFERENCES purchase_orders(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT positive_suggested_quantity CHECK (suggested_quantity > 0),
+    CONSTRAINT valid_reorder_suggestion_status CHECK (status IN ('open', 'converted', 'dismissed', 'obsolete'))
+);
+
+-- At most one open suggestion per inventory location
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reorder_suggestions_open_inventory ON reorder_suggestions(inventory_id) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS idx_reorder_suggestions_merchant_status ON reorder_suggestions(merchant_id, status);
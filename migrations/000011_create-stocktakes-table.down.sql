@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_stocktake_counts_item_id;
+DROP INDEX IF EXISTS idx_stocktake_items_stocktake_id;
+DROP INDEX IF EXISTS idx_stocktakes_merchant_status;
+
+DROP TABLE IF EXISTS stocktake_counts CASCADE;
+DROP TABLE IF EXISTS stocktake_items CASCADE;
+DROP TABLE IF EXISTS stocktakes CASCADE;
@@ -0,0 +1,47 @@
+CREATE TABLE IF NOT EXISTS stocktakes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    merchant_id UUID NOT NULL,
+    store_id UUID, -- NULL = central/warehouse inventory
+    scope VARCHAR(20) NOT NULL, -- 'full', 'category', 'cycle'
+    category_id UUID REFERENCES categories(id) ON DELETE SET NULL, -- for 'category' scope, includes subcategories
+    sample_size INT NOT NULL DEFAULT 0, -- for 'cycle' scope
+    status VARCHAR(20) NOT NULL DEFAULT 'counting', -- 'counting', 'approved', 'cancelled'
+    notes TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMPTZ NOT NULL, -- snapshot time, movements after it are replayed
+    approved_at TIMESTAMPTZ,
+    created_by UUID,
+    approved_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT valid_stocktake_scope CHECK (scope IN ('full', 'category', 'cycle')),
+    CONSTRAINT valid_stocktake_status CHECK (status IN ('counting', 'approved', 'cancelled'))
+);
+
+CREATE TABLE IF NOT EXISTS stocktake_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    stocktake_id UUID NOT NULL REFERENCES stocktakes(id) ON DELETE CASCADE,
+    inventory_id UUID NOT NULL REFERENCES inventory(id) ON DELETE CASCADE,
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    variant_id UUID REFERENCES product_variants(id) ON DELETE CASCADE,
+    expected_quantity DECIMAL(15,3) NOT NULL, -- frozen at started_at
+    counted_quantity DECIMAL(15,3), -- set on approval
+    variance_quantity DECIMAL(15,3), -- set on approval
+    CONSTRAINT unique_stocktake_inventory UNIQUE(stocktake_id, inventory_id)
+);
+
+-- One running count per device and item; a new scan from the same device replaces it
+CREATE TABLE IF NOT EXISTS stocktake_counts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    stocktake_item_id UUID NOT NULL REFERENCES stocktake_items(id) ON DELETE CASCADE,
+    device_id VARCHAR(100) NOT NULL,
+    quantity DECIMAL(15,3) NOT NULL,
+    counted_by UUID,
+    counted_at TIMESTAMPTZ NOT NULL,
+    CONSTRAINT unique_stocktake_item_device UNIQUE(stocktake_item_id, device_id),
+    CONSTRAINT positive_counted_quantity CHECK (quantity >= 0)
+);
+
+-- Indexes for performance
+CREATE INDEX IF NOT EXISTS idx_stocktakes_merchant_status ON stocktakes(merchant_id, status, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_stocktake_items_stocktake_id ON stocktake_items(stocktake_id);
+CREATE INDEX IF NOT EXISTS idx_stocktake_counts_item_id ON stocktake_counts(stocktake_item_id);
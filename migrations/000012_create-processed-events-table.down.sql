@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_processed_events_processed_at;
+
+DROP TABLE IF EXISTS processed_order_lines CASCADE;
+DROP TABLE IF EXISTS processed_events CASCADE;
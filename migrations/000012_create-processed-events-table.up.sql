@@ -0,0 +1,21 @@
+-- Consumed broker events, so redelivered or replayed messages are skipped
+CREATE TABLE IF NOT EXISTS processed_events (
+    event_id VARCHAR(100) PRIMARY KEY,
+    event_type VARCHAR(50) NOT NULL,
+    merchant_id UUID NOT NULL,
+    order_id UUID,
+    processed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Order lines whose stock movement has been posted, written in the same transaction as the movement
+CREATE TABLE IF NOT EXISTS processed_order_lines (
+    merchant_id UUID NOT NULL,
+    order_id UUID NOT NULL,
+    line_no INT NOT NULL, -- position of the item in the order payload
+    event_id VARCHAR(100), -- event that applied the line
+    movement_id UUID, -- inventory_movements.id of the posted movement
+    processed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (merchant_id, order_id, line_no)
+);
+
+CREATE INDEX IF NOT EXISTS idx_processed_events_processed_at ON processed_events(processed_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_dead_letter_events_status;
+
+DROP TABLE IF EXISTS dead_letter_events CASCADE;
@@ -0,0 +1,19 @@
+-- Broker messages that could not be processed after retrying, kept for inspection and replay
+CREATE TABLE IF NOT EXISTS dead_letter_events (
+    id UUID PRIMARY KEY,
+    topic VARCHAR(255) NOT NULL, -- topic the message was consumed from
+    partition INT NOT NULL DEFAULT 0,
+    "offset" BIGINT NOT NULL DEFAULT 0,
+    message_key BYTEA,
+    payload BYTEA NOT NULL, -- original message value, stored as received
+    error TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 1,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, replayed
+    replay_count INT NOT NULL DEFAULT 0,
+    last_replayed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_dead_letter_events_status CHECK (status IN ('pending', 'replayed'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_dead_letter_events_status ON dead_letter_events(status, created_at);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_inventory_movements_reverses;
+
+-- NOT VALID keeps existing write_off rows while rejecting new ones
+ALTER TABLE inventory_movements DROP CONSTRAINT IF EXISTS valid_movement_type;
+ALTER TABLE inventory_movements ADD CONSTRAINT valid_movement_type
+    CHECK (movement_type IN ('purchase', 'sale', 'adjustment', 'transfer_in', 'transfer_out', 'return')) NOT VALID;
+
+ALTER TABLE inventory_movements DROP COLUMN IF EXISTS reverses_movement_id;
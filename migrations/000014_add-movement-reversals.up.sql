@@ -0,0 +1,9 @@
+-- Returns and cancellations point at the sale movement they undo
+ALTER TABLE inventory_movements ADD COLUMN IF NOT EXISTS reverses_movement_id UUID REFERENCES inventory_movements(id);
+
+-- Damaged returns are written off instead of going back to sellable stock
+ALTER TABLE inventory_movements DROP CONSTRAINT IF EXISTS valid_movement_type;
+ALTER TABLE inventory_movements ADD CONSTRAINT valid_movement_type
+    CHECK (movement_type IN ('purchase', 'sale', 'adjustment', 'transfer_in', 'transfer_out', 'return', 'write_off'));
+
+CREATE INDEX IF NOT EXISTS idx_inventory_movements_reverses ON inventory_movements(reverses_movement_id) WHERE reverses_movement_id IS NOT NULL;
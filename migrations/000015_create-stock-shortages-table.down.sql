@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_stock_shortages_merchant_status;
+
+DROP TABLE IF EXISTS stock_shortages CASCADE;
+
+ALTER TABLE inventory DROP CONSTRAINT IF EXISTS positive_quantity;
+ALTER TABLE inventory DROP CONSTRAINT IF EXISTS reserved_not_exceed_quantity;
+ALTER TABLE inventory DROP COLUMN IF EXISTS negative_allowed;
+
+-- NOT VALID keeps locations that are still short
+ALTER TABLE inventory ADD CONSTRAINT positive_quantity CHECK (quantity >= 0) NOT VALID;
+ALTER TABLE inventory ADD CONSTRAINT reserved_not_exceed_quantity CHECK (reserved_quantity <= quantity) NOT VALID;
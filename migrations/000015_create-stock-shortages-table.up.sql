@@ -0,0 +1,31 @@
+-- Orders may be allowed to sell more than is available, leaving the location short until it is restocked or recounted.
+-- Only locations an order oversold may go negative or below their holds: the flag is set by the sale and
+-- cleared once the location is no longer short.
+ALTER TABLE inventory ADD COLUMN IF NOT EXISTS negative_allowed BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE inventory DROP CONSTRAINT IF EXISTS positive_quantity;
+ALTER TABLE inventory DROP CONSTRAINT IF EXISTS reserved_not_exceed_quantity;
+ALTER TABLE inventory ADD CONSTRAINT positive_quantity CHECK (negative_allowed OR quantity >= 0);
+ALTER TABLE inventory ADD CONSTRAINT reserved_not_exceed_quantity CHECK (negative_allowed OR reserved_quantity <= quantity);
+
+-- Sales that went through without enough stock, waiting for review
+CREATE TABLE IF NOT EXISTS stock_shortages (
+    id UUID PRIMARY KEY,
+    merchant_id UUID NOT NULL,
+    inventory_id UUID NOT NULL REFERENCES inventory(id) ON DELETE CASCADE,
+    store_id UUID,
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    variant_id UUID REFERENCES product_variants(id) ON DELETE CASCADE,
+    order_id UUID NOT NULL,
+    movement_id UUID, -- sale movement that overdrew the location
+    quantity_short DECIMAL(15,3) NOT NULL, -- sold beyond what was available
+    status VARCHAR(20) NOT NULL DEFAULT 'open', -- open, resolved
+    notes TEXT,
+    resolved_by UUID,
+    resolved_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT valid_stock_shortage_status CHECK (status IN ('open', 'resolved')),
+    CONSTRAINT positive_quantity_short CHECK (quantity_short > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_shortages_merchant_status ON stock_shortages(merchant_id, status, created_at DESC);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_outbox_delivered;
+DROP INDEX IF EXISTS idx_outbox_pending_aggregate;
+DROP INDEX IF EXISTS idx_outbox_pending;
+
+DROP TABLE IF EXISTS outbox CASCADE;
@@ -0,0 +1,23 @@
+-- Side effects of a change (events, search indexing, cache invalidation), written in the same
+-- transaction as the change and delivered afterwards by the outbox relay
+CREATE TABLE IF NOT EXISTS outbox (
+    id UUID PRIMARY KEY,
+    seq BIGSERIAL NOT NULL UNIQUE, -- delivery order within an aggregate
+    destination VARCHAR(20) NOT NULL, -- kafka, search, cache
+    aggregate_type VARCHAR(50) NOT NULL,
+    aggregate_id VARCHAR(255) NOT NULL,
+    merchant_id UUID,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, delivered
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    available_at TIMESTAMPTZ NOT NULL DEFAULT NOW(), -- not retried before this
+    delivered_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_outbox_destination CHECK (destination IN ('kafka', 'search', 'cache')),
+    CONSTRAINT chk_outbox_status CHECK (status IN ('pending', 'delivered'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox(seq) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_outbox_pending_aggregate ON outbox(destination, aggregate_type, aggregate_id, seq) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_outbox_delivered ON outbox(delivered_at) WHERE status = 'delivered';
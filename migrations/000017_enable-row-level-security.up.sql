@@ -0,0 +1,40 @@
+-- Row-level security: a transaction only sees the rows of the merchant in its
+-- app.merchant_id setting, unless app.bypass_rls is on (background jobs, operators).
+-- Both settings are set per transaction by the service.
+CREATE OR REPLACE FUNCTION app_tenant_visible(row_merchant UUID) RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+    SELECT current_setting('app.bypass_rls', true) = 'on'
+        OR row_merchant = NULLIF(current_setting('app.merchant_id', true), '')::uuid
+$$;
+
+ALTER TABLE categories ENABLE ROW LEVEL SECURITY;
+ALTER TABLE categories FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON categories
+    USING (app_tenant_visible(merchant_id))
+    WITH CHECK (app_tenant_visible(merchant_id));
+
+ALTER TABLE products ENABLE ROW LEVEL SECURITY;
+ALTER TABLE products FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON products
+    USING (app_tenant_visible(merchant_id))
+    WITH CHECK (app_tenant_visible(merchant_id));
+
+-- Variants have no merchant of their own: a variant is visible when its product is, as the
+-- products policy also applies inside the subquery
+ALTER TABLE product_variants ENABLE ROW LEVEL SECURITY;
+ALTER TABLE product_variants FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON product_variants
+    USING (EXISTS (SELECT 1 FROM products p WHERE p.id = product_variants.product_id))
+    WITH CHECK (EXISTS (SELECT 1 FROM products p WHERE p.id = product_variants.product_id));
+
+ALTER TABLE inventory ENABLE ROW LEVEL SECURITY;
+ALTER TABLE inventory FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON inventory
+    USING (app_tenant_visible(merchant_id))
+    WITH CHECK (app_tenant_visible(merchant_id));
+
+ALTER TABLE inventory_movements ENABLE ROW LEVEL SECURITY;
+ALTER TABLE inventory_movements FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON inventory_movements
+    USING (app_tenant_visible(merchant_id))
+    WITH CHECK (app_tenant_visible(merchant_id));
@@ -0,0 +1,6 @@
+COMMENT ON COLUMN inventory_movements.reference_type IS NULL;
+COMMENT ON COLUMN inventory_movements.movement_type IS NULL;
+
+ALTER TABLE inventory_movements DROP CONSTRAINT IF EXISTS valid_movement_type;
+ALTER TABLE inventory_movements ADD CONSTRAINT valid_movement_type
+    CHECK (movement_type IN ('purchase', 'sale', 'adjustment', 'transfer_in', 'transfer_out', 'return', 'write_off')) NOT VALID;
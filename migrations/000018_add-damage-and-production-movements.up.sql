@@ -0,0 +1,10 @@
+-- Damaged stock and stock made in-house get movement types of their own, so reports by
+-- type (idx_inventory_movements_type) no longer have to guess from adjustments
+ALTER TABLE inventory_movements DROP CONSTRAINT IF EXISTS valid_movement_type;
+ALTER TABLE inventory_movements ADD CONSTRAINT valid_movement_type
+    CHECK (movement_type IN ('purchase', 'sale', 'adjustment', 'transfer_in', 'transfer_out', 'return', 'write_off', 'damage', 'production'));
+
+COMMENT ON COLUMN inventory_movements.movement_type IS
+    'purchase, sale, adjustment, transfer_in, transfer_out, return, write_off, damage, production';
+COMMENT ON COLUMN inventory_movements.reference_type IS
+    'order, adjustment, transfer, purchase_order, stocktake';
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_inventory_product_variant;
+
+ALTER TABLE inventory DROP CONSTRAINT IF EXISTS unique_inventory_location;
+ALTER TABLE inventory ADD CONSTRAINT unique_inventory_location
+    UNIQUE (merchant_id, store_id, product_id, variant_id);
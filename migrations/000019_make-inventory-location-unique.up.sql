@@ -0,0 +1,9 @@
+-- A location without a store (warehouse) or variant (the product itself) has NULLs in the
+-- unique key, and plain UNIQUE lets NULLs repeat, so concurrent first adjustments could
+-- create two rows for one location. Requires PostgreSQL 15.
+ALTER TABLE inventory DROP CONSTRAINT IF EXISTS unique_inventory_location;
+ALTER TABLE inventory ADD CONSTRAINT unique_inventory_location
+    UNIQUE NULLS NOT DISTINCT (merchant_id, store_id, product_id, variant_id);
+
+-- Variant lookups and per-product roll-ups
+CREATE INDEX IF NOT EXISTS idx_inventory_product_variant ON inventory(merchant_id, product_id, variant_id);
@@ -0,0 +1,9 @@
+DELETE FROM outbox WHERE destination = 'notify';
+ALTER TABLE outbox DROP CONSTRAINT IF EXISTS chk_outbox_destination;
+ALTER TABLE outbox ADD CONSTRAINT chk_outbox_destination
+    CHECK (destination IN ('kafka', 'search', 'cache'));
+
+DROP INDEX IF EXISTS idx_inventory_store_change;
+DROP TRIGGER IF EXISTS trg_inventory_change_xid ON inventory;
+DROP FUNCTION IF EXISTS set_inventory_change_xid();
+ALTER TABLE inventory DROP COLUMN IF EXISTS change_xid;
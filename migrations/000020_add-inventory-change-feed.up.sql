@@ -0,0 +1,23 @@
+-- The transaction that last changed a location. A stock watch remembers the oldest
+-- transaction still running when it last read (its resume token) and rereads every
+-- location changed by that transaction or a later one, so no commit is missed.
+ALTER TABLE inventory ADD COLUMN IF NOT EXISTS change_xid xid8 NOT NULL DEFAULT pg_current_xact_id();
+
+CREATE OR REPLACE FUNCTION set_inventory_change_xid() RETURNS trigger AS $$
+BEGIN
+    NEW.change_xid := pg_current_xact_id();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_inventory_change_xid ON inventory;
+CREATE TRIGGER trg_inventory_change_xid
+    BEFORE UPDATE ON inventory
+    FOR EACH ROW EXECUTE FUNCTION set_inventory_change_xid();
+
+CREATE INDEX IF NOT EXISTS idx_inventory_store_change ON inventory(merchant_id, store_id, change_xid);
+
+-- Wakeups for stock watchers, published to Redis once the change commits
+ALTER TABLE outbox DROP CONSTRAINT IF EXISTS chk_outbox_destination;
+ALTER TABLE outbox ADD CONSTRAINT chk_outbox_destination
+    CHECK (destination IN ('kafka', 'search', 'cache', 'notify'));
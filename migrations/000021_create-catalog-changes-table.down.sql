@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS trg_product_variants_catalog_change ON product_variants;
+DROP TRIGGER IF EXISTS trg_products_catalog_change ON products;
+DROP TRIGGER IF EXISTS trg_categories_catalog_change ON categories;
+DROP FUNCTION IF EXISTS record_catalog_change();
+DROP TABLE IF EXISTS catalog_changes;
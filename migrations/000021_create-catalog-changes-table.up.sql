@@ -0,0 +1,89 @@
+-- The last change of every category, product and variant, kept after the row is deleted
+-- as its tombstone. Catalog sync rereads the entries changed by the transaction in its
+-- token or a later one. Filled by triggers, so every write path is covered.
+CREATE TABLE IF NOT EXISTS catalog_changes (
+    entity_type VARCHAR(20) NOT NULL, -- category, product, variant
+    entity_id UUID NOT NULL,
+    merchant_id UUID NOT NULL,
+    product_id UUID, -- product of a variant
+    deleted BOOLEAN NOT NULL DEFAULT FALSE,
+    change_xid xid8 NOT NULL DEFAULT pg_current_xact_id(),
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (entity_type, entity_id),
+    CONSTRAINT chk_catalog_changes_entity_type CHECK (entity_type IN ('category', 'product', 'variant'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_catalog_changes_sync ON catalog_changes(merchant_id, entity_type, change_xid);
+
+CREATE OR REPLACE FUNCTION record_catalog_change() RETURNS trigger AS $$
+DECLARE
+    entity RECORD;
+    merchant UUID;
+    parent UUID;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        entity := OLD;
+    ELSE
+        entity := NEW;
+    END IF;
+
+    IF TG_ARGV[0] = 'variant' THEN
+        parent := entity.product_id;
+        SELECT p.merchant_id INTO merchant FROM products p WHERE p.id = parent;
+        IF merchant IS NULL THEN
+            -- Deleted along with its product, whose tombstone covers it
+            RETURN NULL;
+        END IF;
+    ELSE
+        merchant := entity.merchant_id;
+    END IF;
+
+    INSERT INTO catalog_changes (entity_type, entity_id, merchant_id, product_id, deleted, change_xid, changed_at)
+    VALUES (TG_ARGV[0], entity.id, merchant, parent, TG_OP = 'DELETE', pg_current_xact_id(), NOW())
+    ON CONFLICT (entity_type, entity_id) DO UPDATE SET
+        merchant_id = EXCLUDED.merchant_id,
+        product_id = EXCLUDED.product_id,
+        deleted = EXCLUDED.deleted,
+        change_xid = EXCLUDED.change_xid,
+        changed_at = EXCLUDED.changed_at;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_categories_catalog_change ON categories;
+CREATE TRIGGER trg_categories_catalog_change
+    AFTER INSERT OR UPDATE OR DELETE ON categories
+    FOR EACH ROW EXECUTE FUNCTION record_catalog_change('category');
+
+DROP TRIGGER IF EXISTS trg_products_catalog_change ON products;
+CREATE TRIGGER trg_products_catalog_change
+    AFTER INSERT OR UPDATE OR DELETE ON products
+    FOR EACH ROW EXECUTE FUNCTION record_catalog_change('product');
+
+DROP TRIGGER IF EXISTS trg_product_variants_catalog_change ON product_variants;
+CREATE TRIGGER trg_product_variants_catalog_change
+    AFTER INSERT OR UPDATE OR DELETE ON product_variants
+    FOR EACH ROW EXECUTE FUNCTION record_catalog_change('variant');
+
+-- Existing rows, read past row-level security
+SELECT set_config('app.bypass_rls', 'on', true);
+
+INSERT INTO catalog_changes (entity_type, entity_id, merchant_id)
+SELECT 'category', id, merchant_id FROM categories
+ON CONFLICT DO NOTHING;
+
+INSERT INTO catalog_changes (entity_type, entity_id, merchant_id)
+SELECT 'product', id, merchant_id FROM products
+ON CONFLICT DO NOTHING;
+
+INSERT INTO catalog_changes (entity_type, entity_id, merchant_id, product_id)
+SELECT 'variant', v.id, p.merchant_id, v.product_id
+FROM product_variants v
+JOIN products p ON p.id = v.product_id
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE catalog_changes ENABLE ROW LEVEL SECURITY;
+ALTER TABLE catalog_changes FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON catalog_changes
+    USING (app_tenant_visible(merchant_id))
+    WITH CHECK (app_tenant_visible(merchant_id));
@@ -0,0 +1,71 @@
+DROP POLICY IF EXISTS tenant_isolation ON stocktake_counts;
+ALTER TABLE stocktake_counts NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE stocktake_counts DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON stocktake_items;
+ALTER TABLE stocktake_items NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE stocktake_items DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON purchase_receipt_items;
+ALTER TABLE purchase_receipt_items NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE purchase_receipt_items DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON purchase_order_items;
+ALTER TABLE purchase_order_items NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE purchase_order_items DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON stock_transfer_items;
+ALTER TABLE stock_transfer_items NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE stock_transfer_items DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON stock_reservation_items;
+ALTER TABLE stock_reservation_items NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE stock_reservation_items DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON product_options;
+ALTER TABLE product_options NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE product_options DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON processed_order_lines;
+ALTER TABLE processed_order_lines NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE processed_order_lines DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON processed_events;
+ALTER TABLE processed_events NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE processed_events DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON stock_shortages;
+ALTER TABLE stock_shortages NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE stock_shortages DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON stocktakes;
+ALTER TABLE stocktakes NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE stocktakes DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON reorder_suggestions;
+ALTER TABLE reorder_suggestions NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE reorder_suggestions DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON merchant_inventory_settings;
+ALTER TABLE merchant_inventory_settings NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE merchant_inventory_settings DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON purchase_receipts;
+ALTER TABLE purchase_receipts NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE purchase_receipts DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON purchase_orders;
+ALTER TABLE purchase_orders NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE purchase_orders DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON suppliers;
+ALTER TABLE suppliers NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE suppliers DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON stock_transfers;
+ALTER TABLE stock_transfers NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE stock_transfers DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON stock_reservations;
+ALTER TABLE stock_reservations NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE stock_reservations DISABLE ROW LEVEL SECURITY;
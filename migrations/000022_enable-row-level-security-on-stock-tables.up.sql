@@ -0,0 +1,113 @@
+-- Row-level security for the merchant tables 000017 left out, with the same tenant_isolation
+-- policy. The outbox and dead letters are read across merchants by the relay and operators
+-- and stay unrestricted.
+
+ALTER TABLE stock_reservations ENABLE ROW LEVEL SECURITY;
+ALTER TABLE stock_reservations FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON stock_reservations
+    USING (app_tenant_visible(merchant_id))
+    WITH CHECK (app_tenant_visible(merchant_id));
+
+ALTER TABLE stock_transfers ENABLE ROW LEVEL SECURITY;
+ALTER TABLE stock_transfers FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON stock_transfers
+    USING (app_tenant_visible(merchant_id))
+    WITH CHECK (app_tenant_visible(merchant_id));
+
+ALTER TABLE suppliers ENABLE ROW LEVEL SECURITY;
+ALTER TABLE suppliers FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON suppliers
+    USING (app_tenant_visible(merchant_id))
+    WITH CHECK (app_tenant_visible(merchant_id));
+
+ALTER TABLE purchase_orders ENABLE ROW LEVEL SECURITY;
+ALTER TABLE purchase_orders FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON purchase_orders
+    USING (app_tenant_visible(merchant_id))
+    WITH CHECK (app_tenant_visible(merchant_id));
+
+ALTER TABLE purchase_receipts ENABLE ROW LEVEL SECURITY;
+ALTER TABLE purchase_receipts FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON purchase_receipts
+    USING (app_tenant_visible(merchant_id))
+    WITH CHECK (app_tenant_visible(merchant_id));
+
+ALTER TABLE merchant_inventory_settings ENABLE ROW LEVEL SECURITY;
+ALTER TABLE merchant_inventory_settings FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON merchant_inventory_settings
+    USING (app_tenant_visible(merchant_id))
+    WITH CHECK (app_tenant_visible(merchant_id));
+
+ALTER TABLE reorder_suggestions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE reorder_suggestions FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON reorder_suggestions
+    USING (app_tenant_visible(merchant_id))
+    WITH CHECK (app_tenant_visible(merchant_id));
+
+ALTER TABLE stocktakes ENABLE ROW LEVEL SECURITY;
+ALTER TABLE stocktakes FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON stocktakes
+    USING (app_tenant_visible(merchant_id))
+    WITH CHECK (app_tenant_visible(merchant_id));
+
+ALTER TABLE stock_shortages ENABLE ROW LEVEL SECURITY;
+ALTER TABLE stock_shortages FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON stock_shortages
+    USING (app_tenant_visible(merchant_id))
+    WITH CHECK (app_tenant_visible(merchant_id));
+
+ALTER TABLE processed_events ENABLE ROW LEVEL SECURITY;
+ALTER TABLE processed_events FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON processed_events
+    USING (app_tenant_visible(merchant_id))
+    WITH CHECK (app_tenant_visible(merchant_id));
+
+ALTER TABLE processed_order_lines ENABLE ROW LEVEL SECURITY;
+ALTER TABLE processed_order_lines FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON processed_order_lines
+    USING (app_tenant_visible(merchant_id))
+    WITH CHECK (app_tenant_visible(merchant_id));
+
+-- Child rows have no merchant of their own: they are visible when their parent is, as the
+-- parent's policy also applies inside the subquery
+ALTER TABLE product_options ENABLE ROW LEVEL SECURITY;
+ALTER TABLE product_options FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON product_options
+    USING (EXISTS (SELECT 1 FROM products p WHERE p.id = product_options.product_id))
+    WITH CHECK (EXISTS (SELECT 1 FROM products p WHERE p.id = product_options.product_id));
+
+ALTER TABLE stock_reservation_items ENABLE ROW LEVEL SECURITY;
+ALTER TABLE stock_reservation_items FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON stock_reservation_items
+    USING (EXISTS (SELECT 1 FROM stock_reservations p WHERE p.id = stock_reservation_items.reservation_id))
+    WITH CHECK (EXISTS (SELECT 1 FROM stock_reservations p WHERE p.id = stock_reservation_items.reservation_id));
+
+ALTER TABLE stock_transfer_items ENABLE ROW LEVEL SECURITY;
+ALTER TABLE stock_transfer_items FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON stock_transfer_items
+    USING (EXISTS (SELECT 1 FROM stock_transfers p WHERE p.id = stock_transfer_items.transfer_id))
+    WITH CHECK (EXISTS (SELECT 1 FROM stock_transfers p WHERE p.id = stock_transfer_items.transfer_id));
+
+ALTER TABLE purchase_order_items ENABLE ROW LEVEL SECURITY;
+ALTER TABLE purchase_order_items FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON purchase_order_items
+    USING (EXISTS (SELECT 1 FROM purchase_orders p WHERE p.id = purchase_order_items.purchase_order_id))
+    WITH CHECK (EXISTS (SELECT 1 FROM purchase_orders p WHERE p.id = purchase_order_items.purchase_order_id));
+
+ALTER TABLE purchase_receipt_items ENABLE ROW LEVEL SECURITY;
+ALTER TABLE purchase_receipt_items FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON purchase_receipt_items
+    USING (EXISTS (SELECT 1 FROM purchase_receipts p WHERE p.id = purchase_receipt_items.receipt_id))
+    WITH CHECK (EXISTS (SELECT 1 FROM purchase_receipts p WHERE p.id = purchase_receipt_items.receipt_id));
+
+ALTER TABLE stocktake_items ENABLE ROW LEVEL SECURITY;
+ALTER TABLE stocktake_items FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON stocktake_items
+    USING (EXISTS (SELECT 1 FROM stocktakes p WHERE p.id = stocktake_items.stocktake_id))
+    WITH CHECK (EXISTS (SELECT 1 FROM stocktakes p WHERE p.id = stocktake_items.stocktake_id));
+
+ALTER TABLE stocktake_counts ENABLE ROW LEVEL SECURITY;
+ALTER TABLE stocktake_counts FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON stocktake_counts
+    USING (EXISTS (SELECT 1 FROM stocktake_items p WHERE p.id = stocktake_counts.stocktake_item_id))
+    WITH CHECK (EXISTS (SELECT 1 FROM stocktake_items p WHERE p.id = stocktake_counts.stocktake_item_id));
@@ -0,0 +1,10 @@
+ALTER DEFAULT PRIVILEGES IN SCHEMA public REVOKE EXECUTE ON FUNCTIONS FROM omnipos_app;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public REVOKE USAGE, SELECT ON SEQUENCES FROM omnipos_app;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public REVOKE SELECT, INSERT, UPDATE, DELETE ON TABLES FROM omnipos_app;
+
+REVOKE EXECUTE ON ALL FUNCTIONS IN SCHEMA public FROM omnipos_app;
+REVOKE USAGE, SELECT ON ALL SEQUENCES IN SCHEMA public FROM omnipos_app;
+REVOKE SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public FROM omnipos_app;
+REVOKE USAGE ON SCHEMA public FROM omnipos_app;
+
+-- The role is shared by every database of the cluster, so it is left in place
@@ -0,0 +1,20 @@
+-- The role the service connects as. Row-level security does not apply to superusers,
+-- roles with BYPASSRLS or, unless forced, the table owner, so the service must not run as
+-- the role that owns the schema and runs these migrations. It is created without LOGIN or a
+-- password: deploy tooling grants both, and `make dev_app_role` does for local development.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'omnipos_app') THEN
+        CREATE ROLE omnipos_app NOLOGIN NOSUPERUSER NOBYPASSRLS NOCREATEDB NOCREATEROLE;
+    END IF;
+END $$;
+
+GRANT USAGE ON SCHEMA public TO omnipos_app;
+GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO omnipos_app;
+GRANT USAGE, SELECT ON ALL SEQUENCES IN SCHEMA public TO omnipos_app;
+GRANT EXECUTE ON ALL FUNCTIONS IN SCHEMA public TO omnipos_app;
+
+-- Tables created by later migrations are granted as well
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT SELECT, INSERT, UPDATE, DELETE ON TABLES TO omnipos_app;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT USAGE, SELECT ON SEQUENCES TO omnipos_app;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT EXECUTE ON FUNCTIONS TO omnipos_app;
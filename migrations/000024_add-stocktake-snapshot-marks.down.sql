@@ -0,0 +1,2 @@
+ALTER TABLE stocktake_items DROP COLUMN IF EXISTS book_snapshot;
+ALTER TABLE inventory_movements DROP COLUMN IF EXISTS change_xid;
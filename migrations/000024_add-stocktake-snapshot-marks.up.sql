@@ -0,0 +1,10 @@
+-- A stocktake replays the movements its frozen expected_quantity does not include. Their
+-- created_at cannot tell which those are: it is taken before the movement's transaction
+-- commits, so a movement committing after the snapshot can be older than started_at and
+-- be missed. Each movement now records its transaction, and each stocktake item the
+-- snapshot its expected_quantity was read in; a movement is replayed when its transaction
+-- was not visible in that snapshot.
+ALTER TABLE inventory_movements ADD COLUMN IF NOT EXISTS change_xid xid8;
+ALTER TABLE inventory_movements ALTER COLUMN change_xid SET DEFAULT pg_current_xact_id(); -- NULL for older movements
+
+ALTER TABLE stocktake_items ADD COLUMN IF NOT EXISTS book_snapshot pg_snapshot; -- NULL for items snapshotted before, replayed from started_at
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_outbox_failed;
+
+UPDATE outbox SET status = 'pending' WHERE status = 'failed';
+ALTER TABLE outbox DROP CONSTRAINT IF EXISTS chk_outbox_status;
+ALTER TABLE outbox ADD CONSTRAINT chk_outbox_status
+    CHECK (status IN ('pending', 'delivered'));
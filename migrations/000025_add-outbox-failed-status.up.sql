@@ -0,0 +1,8 @@
+-- Entries the relay gave up on after OUTBOX_MAX_ATTEMPTS are parked as failed, so they stop
+-- being retried and no longer hold back the later entries of their aggregate. Setting one
+-- back to pending retries it.
+ALTER TABLE outbox DROP CONSTRAINT IF EXISTS chk_outbox_status;
+ALTER TABLE outbox ADD CONSTRAINT chk_outbox_status
+    CHECK (status IN ('pending', 'delivered', 'failed'));
+
+CREATE INDEX IF NOT EXISTS idx_outbox_failed ON outbox(created_at) WHERE status = 'failed';
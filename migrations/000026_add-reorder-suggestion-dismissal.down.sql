@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_reorder_suggestions_closed_inventory;
+
+ALTER TABLE reorder_suggestions DROP COLUMN IF EXISTS dismissed_until;
+ALTER TABLE reorder_suggestions DROP COLUMN IF EXISTS max_stock_level;
+ALTER TABLE reorder_suggestions DROP COLUMN IF EXISTS reorder_quantity;
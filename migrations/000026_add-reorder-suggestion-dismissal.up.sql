@@ -0,0 +1,9 @@
+-- A dismissed suggestion keeps its location off the list until the stock or reorder settings
+-- it was raised under change, or until dismissed_until passes (NULL holds until a change).
+ALTER TABLE reorder_suggestions ADD COLUMN IF NOT EXISTS reorder_quantity DECIMAL(15,3) NOT NULL DEFAULT 0;
+ALTER TABLE reorder_suggestions ADD COLUMN IF NOT EXISTS max_stock_level DECIMAL(15,3);
+ALTER TABLE reorder_suggestions ADD COLUMN IF NOT EXISTS dismissed_until TIMESTAMPTZ;
+
+-- Latest closed suggestion of a location, checked by every generation run
+CREATE INDEX IF NOT EXISTS idx_reorder_suggestions_closed_inventory
+    ON reorder_suggestions(inventory_id, updated_at DESC) WHERE status <> 'open';
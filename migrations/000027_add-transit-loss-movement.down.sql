@@ -0,0 +1,6 @@
+COMMENT ON COLUMN inventory_movements.movement_type IS
+    'purchase, sale, adjustment, transfer_in, transfer_out, return, write_off, damage, production';
+
+ALTER TABLE inventory_movements DROP CONSTRAINT IF EXISTS valid_movement_type;
+ALTER TABLE inventory_movements ADD CONSTRAINT valid_movement_type
+    CHECK (movement_type IN ('purchase', 'sale', 'adjustment', 'transfer_in', 'transfer_out', 'return', 'write_off', 'damage', 'production')) NOT VALID;
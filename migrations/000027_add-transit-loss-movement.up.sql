@@ -0,0 +1,9 @@
+-- Stock that left a store on a transfer and never arrived gets a movement type of its own.
+-- It is logged against the source store without changing its on-hand quantity, which
+-- already dropped with the transfer_out, so quantity_before equals quantity_after.
+ALTER TABLE inventory_movements DROP CONSTRAINT IF EXISTS valid_movement_type;
+ALTER TABLE inventory_movements ADD CONSTRAINT valid_movement_type
+    CHECK (movement_type IN ('purchase', 'sale', 'adjustment', 'transfer_in', 'transfer_out', 'return', 'write_off', 'damage', 'production', 'transit_loss'));
+
+COMMENT ON COLUMN inventory_movements.movement_type IS
+    'purchase, sale, adjustment, transfer_in, transfer_out, return, write_off, damage, production, transit_loss';
@@ -0,0 +1,3 @@
+-- Lets the service log in as omnipos_app (migration 000023) with the password of .env.docker.
+-- Local development and the isolation tests only, never run it against a shared database.
+ALTER ROLE omnipos_app LOGIN PASSWORD 'omnipos_app';